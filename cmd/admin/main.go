@@ -51,6 +51,10 @@ func main() {
 	rootCmd.AddCommand(admin.HealthCheckCmd)
 	rootCmd.AddCommand(admin.VersionCmd)
 	rootCmd.AddCommand(admin.MigrateCmd)
+	rootCmd.AddCommand(admin.TokenCmd)
+	rootCmd.AddCommand(admin.CertCmd)
+	rootCmd.AddCommand(admin.CACmd)
+	rootCmd.AddCommand(admin.AgentCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		logger.Errorf("failed to start admin server. errmsg:%s", err.Error())
@@ -0,0 +1,136 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"os-artificer/saber/pkg/inventory"
+
+	"github.com/spf13/cobra"
+)
+
+// commandTimeout bounds each command's round trips to etcd.
+const commandTimeout = 30 * time.Second
+
+// StoreFunc opens the agent inventory; the returned func releases it.
+type StoreFunc func() (*inventory.Store, func(), error)
+
+// NewAgentCmd returns the "agent" command that queries the agent inventory.
+func NewAgentCmd(getStore StoreFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Query the agent inventory",
+	}
+
+	var state string
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List agents",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if state != "" && state != string(inventory.StateOnline) && state != string(inventory.StateOffline) {
+				return fmt.Errorf("--state must be %s or %s", inventory.StateOnline, inventory.StateOffline)
+			}
+			return withStore(getStore, func(ctx context.Context, store *inventory.Store) error {
+				agents, err := store.List(ctx)
+				if err != nil {
+					return err
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "CLIENT ID\tHOSTNAME\tSTATE\tVERSION\tLAST SEEN")
+				for _, a := range agents {
+					if state != "" && string(a.State) != state {
+						continue
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+						a.ClientID, a.Hostname, a.State, a.AgentVersion, formatTime(a.LastSeen))
+				}
+				return w.Flush()
+			})
+		},
+	}
+	list.Flags().StringVar(&state, "state", "", "only list online or offline agents")
+
+	get := &cobra.Command{
+		Use:   "get <clientID>",
+		Short: "Show an agent",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStore(getStore, func(ctx context.Context, store *inventory.Store) error {
+				a, err := store.Get(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				out := cmd.OutOrStdout()
+				fmt.Fprintf(out, "clientID:   %s\n", a.ClientID)
+				fmt.Fprintf(out, "hostname:   %s\n", a.Hostname)
+				fmt.Fprintf(out, "ips:        %s\n", strings.Join(a.IPs, ", "))
+				fmt.Fprintf(out, "os:         %s/%s\n", a.OS, a.Arch)
+				fmt.Fprintf(out, "agent:      %s %s\n", a.AgentName, a.AgentVersion)
+				fmt.Fprintf(out, "plugins:    %s\n", strings.Join(a.Plugins, ", "))
+				fmt.Fprintf(out, "state:      %s\n", a.State)
+				fmt.Fprintf(out, "controller: %s\n", a.Controller)
+				fmt.Fprintf(out, "remoteAddr: %s\n", a.RemoteAddr)
+				fmt.Fprintf(out, "firstSeen:  %s\n", formatTime(a.FirstSeen))
+				fmt.Fprintf(out, "lastSeen:   %s\n", formatTime(a.LastSeen))
+				return nil
+			})
+		},
+	}
+
+	remove := &cobra.Command{
+		Use:   "delete <clientID>",
+		Short: "Remove a decommissioned agent from the inventory",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStore(getStore, func(ctx context.Context, store *inventory.Store) error {
+				if err := store.Delete(ctx, args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "agent %s deleted\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	cmd.AddCommand(list, get, remove)
+	return cmd
+}
+
+func withStore(getStore StoreFunc, fn func(ctx context.Context, store *inventory.Store) error) error {
+	store, release, err := getStore()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	return fn(ctx, store)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format(time.RFC3339)
+}
@@ -17,6 +17,8 @@
 package admin
 
 import (
+	"os-artificer/saber/internal/admin/agents"
+	"os-artificer/saber/internal/admin/enroll"
 	"os-artificer/saber/internal/admin/migration"
 	"os-artificer/saber/pkg/version"
 
@@ -68,3 +70,15 @@ var HealthCheckCmd = &cobra.Command{
 
 // MigrateCmd creates database and runs migrations (uses service.storage when type=mysql).
 var MigrateCmd = migration.NewMigrateCmd(GetDBConfigForMigrate)
+
+// TokenCmd mints, lists and revokes agent bootstrap tokens.
+var TokenCmd = enroll.NewTokenCmd(GetEnrollmentStore)
+
+// CertCmd lists and revokes enrolled agent certificates.
+var CertCmd = enroll.NewCertCmd(GetEnrollmentStore)
+
+// AgentCmd lists, shows and deletes the agents in the inventory.
+var AgentCmd = agents.NewAgentCmd(GetInventoryStore)
+
+// CACmd creates the internal CA that controllers sign agent certificates with.
+var CACmd = enroll.NewCACmd()
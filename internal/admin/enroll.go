@@ -0,0 +1,87 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"os-artificer/saber/internal/admin/config"
+	"os-artificer/saber/pkg/discovery"
+	"os-artificer/saber/pkg/enrollment"
+	"os-artificer/saber/pkg/inventory"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// GetEnrollmentStore loads admin config and opens the enrollment store in the discovery
+// etcd, under the same registryRootKeyPrefix as the controllers.
+func GetEnrollmentStore() (*enrollment.Store, func(), error) {
+	cli, err := openDiscoveryEtcd()
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() { _ = cli.Close() }
+	return enrollment.NewStore(cli, config.Cfg.Discovery.RegistryRootKeyPrefix), release, nil
+}
+
+// GetInventoryStore loads admin config and opens the agent inventory in the discovery
+// etcd, under the same registryRootKeyPrefix as the controllers.
+func GetInventoryStore() (*inventory.Store, func(), error) {
+	cli, err := openDiscoveryEtcd()
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() { _ = cli.Close() }
+	return inventory.NewStore(cli, config.Cfg.Discovery.RegistryRootKeyPrefix), release, nil
+}
+
+// openDiscoveryEtcd loads admin config and connects to the discovery etcd.
+func openDiscoveryEtcd() (*clientv3.Client, error) {
+	loadAdminConfig()
+	cfg := &config.Cfg.Discovery
+
+	var endpoints []string
+	for _, ep := range strings.Split(cfg.EtcdEndpoint, ",") {
+		if ep = strings.TrimSpace(ep); ep != "" {
+			endpoints = append(endpoints, ep)
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("discovery.etcdEndpoint is required in config file (e.g. -c admin.yaml)")
+	}
+
+	tlsCfg, err := buildDiscoveryTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []discovery.Option{
+		discovery.OptionEndpoints(endpoints),
+		discovery.OptionUser(cfg.EtcdUser),
+		discovery.OptionPassword(cfg.EtcdPassword),
+		discovery.OptionDialTimeout(cfg.DialTimeout),
+	}
+	if tlsCfg != nil {
+		opts = append(opts, discovery.OptionTLS(tlsCfg))
+	}
+	cli, err := discovery.NewClientWithOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return cli.OriginClient()
+}
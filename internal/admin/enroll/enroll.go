@@ -0,0 +1,243 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package enroll
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"os-artificer/saber/pkg/enrollment"
+
+	"github.com/spf13/cobra"
+)
+
+// commandTimeout bounds each command's round trips to etcd.
+const commandTimeout = 30 * time.Second
+
+// StoreFunc opens the enrollment store; the returned func releases it.
+type StoreFunc func() (*enrollment.Store, func(), error)
+
+// NewTokenCmd returns the "token" command that mints, lists and revokes bootstrap tokens.
+func NewTokenCmd(getStore StoreFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage agent bootstrap tokens",
+	}
+
+	var (
+		ttl     time.Duration
+		oneTime bool
+		comment string
+	)
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Mint a bootstrap token (printed once)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStore(getStore, func(ctx context.Context, store *enrollment.Store) error {
+				token, info, err := store.CreateToken(ctx, ttl, oneTime, comment)
+				if err != nil {
+					return err
+				}
+				out := cmd.OutOrStdout()
+				fmt.Fprintf(out, "token:   %s\n", token)
+				fmt.Fprintf(out, "id:      %s\n", info.ID)
+				fmt.Fprintf(out, "oneTime: %t\n", info.OneTime)
+				fmt.Fprintf(out, "expires: %s\n", formatTime(info.ExpiresAt))
+				return nil
+			})
+		},
+	}
+	create.Flags().DurationVar(&ttl, "ttl", 24*time.Hour, "token lifetime, 0 for no expiry")
+	create.Flags().BoolVar(&oneTime, "one-time", false, "consume the token on its first enrollment")
+	create.Flags().StringVar(&comment, "comment", "", "free-form note shown by token list")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List usable bootstrap tokens",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStore(getStore, func(ctx context.Context, store *enrollment.Store) error {
+				tokens, err := store.ListTokens(ctx)
+				if err != nil {
+					return err
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tONE-TIME\tCREATED\tEXPIRES\tCOMMENT")
+				for _, t := range tokens {
+					fmt.Fprintf(w, "%s\t%t\t%s\t%s\t%s\n",
+						t.ID, t.OneTime, formatTime(t.CreatedAt), formatTime(t.ExpiresAt), t.Comment)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	revoke := &cobra.Command{
+		Use:   "revoke <id>",
+		Short: "Revoke a bootstrap token",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStore(getStore, func(ctx context.Context, store *enrollment.Store) error {
+				if err := store.RevokeToken(ctx, args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "token %s revoked\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	cmd.AddCommand(create, list, revoke)
+	return cmd
+}
+
+// NewCertCmd returns the "cert" command that lists and revokes agent certificates.
+func NewCertCmd(getStore StoreFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cert",
+		Short: "Manage enrolled agent certificates",
+	}
+
+	list := &cobra.Command{
+		Use:   "list [clientID]",
+		Short: "List the unexpired certificates issued to agents",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientID := ""
+			if len(args) > 0 {
+				clientID = args[0]
+			}
+			return withStore(getStore, func(ctx context.Context, store *enrollment.Store) error {
+				certs, err := store.ListCertificates(ctx, clientID)
+				if err != nil {
+					return err
+				}
+				revoked, err := store.Revocations(ctx)
+				if err != nil {
+					return err
+				}
+				isRevoked := make(map[string]bool, len(revoked))
+				for _, c := range revoked {
+					isRevoked[c.Serial] = true
+				}
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "CLIENT ID\tSERIAL\tISSUED\tEXPIRES\tREVOKED")
+				for _, c := range certs {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", c.ClientID, c.Serial,
+						formatTime(c.IssuedAt), formatTime(c.NotAfter), isRevoked[c.Serial])
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	var serial string
+	revoke := &cobra.Command{
+		Use:   "revoke <clientID>",
+		Short: "Revoke the certificates of an agent",
+		Long: "Revoke the certificates issued to an agent, or only the one with --serial.\n" +
+			"Controllers disconnect the agent and refuse it until it enrolls again.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStore(getStore, func(ctx context.Context, store *enrollment.Store) error {
+				revoked, err := store.RevokeCertificates(ctx, args[0], serial)
+				if err != nil {
+					return err
+				}
+				for _, c := range revoked {
+					fmt.Fprintf(cmd.OutOrStdout(), "certificate %s of %s revoked\n", c.Serial, c.ClientID)
+				}
+				return nil
+			})
+		},
+	}
+	revoke.Flags().StringVar(&serial, "serial", "", "revoke only the certificate with this serial (hex)")
+
+	cmd.AddCommand(list, revoke)
+	return cmd
+}
+
+// NewCACmd returns the "ca" command that creates the internal CA controllers sign with.
+func NewCACmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ca",
+		Short: "Manage the internal agent CA",
+	}
+
+	var (
+		certFile string
+		keyFile  string
+		cn       string
+		validity time.Duration
+	)
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a self-signed CA for agent enrollment",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, f := range []string{certFile, keyFile} {
+				if _, err := os.Stat(f); err == nil {
+					return fmt.Errorf("%s already exists", f)
+				}
+			}
+
+			certPEM, keyPEM, err := enrollment.GenerateCA(cn, validity)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+				return err
+			}
+			if err := os.WriteFile(certFile, certPEM, 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "CA written to %s (key %s)\n", certFile, keyFile)
+			return nil
+		},
+	}
+	initCmd.Flags().StringVar(&certFile, "cert", "./etc/ca.pem", "CA certificate output file")
+	initCmd.Flags().StringVar(&keyFile, "key", "./etc/ca-key.pem", "CA private key output file")
+	initCmd.Flags().StringVar(&cn, "cn", "saber agent CA", "CA common name")
+	initCmd.Flags().DurationVar(&validity, "validity", 10*365*24*time.Hour, "CA lifetime")
+
+	cmd.AddCommand(initCmd)
+	return cmd
+}
+
+func withStore(getStore StoreFunc, fn func(ctx context.Context, store *enrollment.Store) error) error {
+	store, release, err := getStore()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	return fn(ctx, store)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format(time.RFC3339)
+}
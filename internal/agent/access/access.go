@@ -0,0 +1,199 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+// Package access resolves the controller and databus instances the agent connects to,
+// from the access servers configured in agent.yaml.
+package access
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/pkg/discovery"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbnet"
+)
+
+// Names under which the controller and databus register themselves.
+const (
+	ServiceController = "controller"
+	ServiceDatabus    = "databus"
+)
+
+const (
+	// TypeEtcd discovers instances registered in etcd by pkg/discovery.
+	TypeEtcd = "etcd"
+
+	defaultSyncInterval = 30 * time.Second
+	defaultDialTimeout  = 5 * time.Second
+)
+
+// Resolver watches the instances of services registered with the access servers.
+type Resolver struct {
+	cfg      config.AccessServerConfig
+	opts     []discovery.Option
+	mu       sync.Mutex
+	watchers map[string]*ServiceWatcher
+}
+
+// NewResolver returns a Resolver for cfg, or nil when no etcd access servers are
+// configured, in which case the agent uses static endpoints only.
+func NewResolver(cfg *config.AccessServerConfig) (*Resolver, error) {
+	if cfg == nil || !strings.EqualFold(cfg.Type, TypeEtcd) {
+		return nil, nil
+	}
+
+	endpoints := etcdEndpoints(cfg.Endpoints)
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+
+	c := *cfg
+	if c.SyncMetaInterval <= 0 {
+		c.SyncMetaInterval = defaultSyncInterval
+	}
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = defaultDialTimeout
+	}
+
+	tlsCfg, err := buildAccessTLS(&c, endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []discovery.Option{
+		discovery.OptionEndpoints(endpoints),
+		discovery.OptionUser(c.User),
+		discovery.OptionPassword(c.Password),
+		discovery.OptionDialTimeout(c.DialTimeout),
+		discovery.OptionLogger(logger.GetOriginLogger()),
+	}
+	if c.RegistryRootKeyPrefix != "" {
+		opts = append(opts, discovery.OptionRegistryRootKeyPrefix(c.RegistryRootKeyPrefix))
+	}
+	if tlsCfg != nil {
+		opts = append(opts, discovery.OptionTLS(tlsCfg))
+	}
+
+	return &Resolver{cfg: c, opts: opts, watchers: make(map[string]*ServiceWatcher)}, nil
+}
+
+// Watch returns the watcher of service, creating it on first use. Call it before Run.
+func (r *Resolver) Watch(service string) (*ServiceWatcher, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if w, ok := r.watchers[service]; ok {
+		return w, nil
+	}
+
+	opts := append([]discovery.Option{discovery.OptionServiceName(service)}, r.opts...)
+	cli, err := discovery.NewClientWithOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	w := newServiceWatcher(service, cli.GetSelfPrefix()+"/", r.cfg.SyncMetaInterval, r.cfg.DialTimeout)
+	w.newDiscovery = cli.CreateDiscovery
+	r.watchers[service] = w
+	return w, nil
+}
+
+// Run watches all services until ctx is done.
+func (r *Resolver) Run(ctx context.Context) error {
+	r.mu.Lock()
+	watchers := make([]*ServiceWatcher, 0, len(r.watchers))
+	for _, w := range r.watchers {
+		watchers = append(watchers, w)
+	}
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, w := range watchers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.run(ctx)
+		}()
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+// etcdEndpoints splits a comma-separated endpoint list into etcd client endpoints;
+// tcp:// endpoints become host:port.
+func etcdEndpoints(s string) []string {
+	var out []string
+	for _, ep := range strings.Split(s, ",") {
+		ep = strings.TrimSpace(ep)
+		if ep == "" {
+			continue
+		}
+		if strings.HasPrefix(ep, "tcp://") {
+			if e, err := sbnet.NewEndpointFromString(ep); err == nil {
+				ep = e.HostPort()
+			}
+		}
+		out = append(out, ep)
+	}
+	return out
+}
+
+// buildAccessTLS builds *tls.Config from access server config for etcd https endpoints.
+// Returns (nil, nil) when TLS is not needed (no UseTLS, no cert paths, no InsecureSkipVerify, no https endpoint).
+func buildAccessTLS(cfg *config.AccessServerConfig, endpoints []string) (*tls.Config, error) {
+	needTLS := cfg.UseTLS || cfg.InsecureSkipVerify || cfg.EtcdCACert != "" || cfg.EtcdCert != "" || cfg.EtcdKey != ""
+	if !needTLS {
+		for _, ep := range endpoints {
+			if strings.HasPrefix(ep, "https://") {
+				needTLS = true
+				break
+			}
+		}
+	}
+	if !needTLS {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.EtcdCACert != "" {
+		b, err := os.ReadFile(cfg.EtcdCACert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no valid CA certs in %s", cfg.EtcdCACert)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.EtcdCert != "" && cfg.EtcdKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.EtcdCert, cfg.EtcdKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
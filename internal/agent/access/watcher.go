@@ -0,0 +1,222 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package access
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/pkg/discovery"
+	"os-artificer/saber/pkg/logger"
+)
+
+var _ config.EndpointResolver = (*ServiceWatcher)(nil)
+
+// maxRetryInterval caps the backoff between failed watches.
+const maxRetryInterval = time.Minute
+
+var errWatchClosed = errors.New("watch channel closed")
+
+// ServiceWatcher keeps the live endpoints of one service. Instances register their
+// listen address under prefix; the watcher follows puts and deletes and resyncs the
+// full list periodically in case an event was missed.
+type ServiceWatcher struct {
+	service  string
+	prefix   string
+	interval time.Duration
+	timeout  time.Duration
+
+	newDiscovery func() (*discovery.Discovery, error)
+
+	mu          sync.Mutex
+	instances   map[string]string
+	endpoints   []string
+	subscribers []func([]string)
+}
+
+func newServiceWatcher(service, prefix string, interval, timeout time.Duration) *ServiceWatcher {
+	return &ServiceWatcher{
+		service:   service,
+		prefix:    prefix,
+		interval:  interval,
+		timeout:   timeout,
+		instances: make(map[string]string),
+	}
+}
+
+// Endpoints implements config.EndpointResolver.
+func (w *ServiceWatcher) Endpoints() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.endpoints)
+}
+
+// Subscribe implements config.EndpointResolver.
+func (w *ServiceWatcher) Subscribe(fn func(endpoints []string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// run watches the service until ctx is done, starting over after failures.
+func (w *ServiceWatcher) run(ctx context.Context) {
+	retry := w.interval / 4
+	for {
+		err := w.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger.Warnf("access: watch of %s instances failed, retrying in %v: %v", w.service, retry, err)
+		select {
+		case <-time.After(retry):
+		case <-ctx.Done():
+			return
+		}
+		retry = min(2*retry, maxRetryInterval)
+	}
+}
+
+// watch follows the service's instances until the watch fails or ctx is done.
+func (w *ServiceWatcher) watch(ctx context.Context) error {
+	disc, err := w.newDiscovery()
+	if err != nil {
+		return err
+	}
+	defer disc.Close()
+
+	// Watch first so that no change between the initial list and the watch is lost.
+	events, err := disc.WatchWithPrefix(ctx, w.prefix)
+	if err != nil {
+		return err
+	}
+	if err := w.sync(ctx, disc); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case ev, ok := <-events:
+			if !ok {
+				return errWatchClosed
+			}
+			w.apply(ev)
+
+		case <-ticker.C:
+			if err := w.sync(ctx, disc); err != nil {
+				logger.Warnf("access: resync of %s instances failed: %v", w.service, err)
+			}
+		}
+	}
+}
+
+// sync replaces the known instances with those currently registered.
+func (w *ServiceWatcher) sync(ctx context.Context, disc *discovery.Discovery) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	kvs, err := disc.GetWithPrefix(ctx, w.prefix)
+	if err != nil {
+		return err
+	}
+
+	instances := make(map[string]string, len(kvs))
+	for key, value := range kvs {
+		if ep, ok := w.parse(key, value); ok {
+			instances[key] = ep
+		}
+	}
+
+	w.mu.Lock()
+	w.instances = instances
+	w.mu.Unlock()
+	w.update()
+	return nil
+}
+
+// apply applies one watch event.
+func (w *ServiceWatcher) apply(ev *discovery.WatchEvent) {
+	w.mu.Lock()
+	switch ev.EventType {
+	case discovery.WatchedEventPut:
+		if ep, ok := w.parse(ev.Key, ev.Value); ok {
+			w.instances[ev.Key] = ep
+		} else {
+			delete(w.instances, ev.Key)
+		}
+	case discovery.WatchedEventDelete:
+		delete(w.instances, ev.Key)
+	}
+	w.mu.Unlock()
+	w.update()
+}
+
+// update recomputes the endpoint list and notifies subscribers when it changed.
+func (w *ServiceWatcher) update() {
+	w.mu.Lock()
+	seen := make(map[string]struct{}, len(w.instances))
+	endpoints := make([]string, 0, len(w.instances))
+	for _, ep := range w.instances {
+		if _, ok := seen[ep]; ok {
+			continue
+		}
+		seen[ep] = struct{}{}
+		endpoints = append(endpoints, ep)
+	}
+	sort.Strings(endpoints)
+
+	if slices.Equal(endpoints, w.endpoints) {
+		w.mu.Unlock()
+		return
+	}
+	w.endpoints = endpoints
+	subscribers := slices.Clone(w.subscribers)
+	w.mu.Unlock()
+
+	logger.Infof("access: %s instances changed: %v", w.service, endpoints)
+	for _, fn := range subscribers {
+		fn(slices.Clone(endpoints))
+	}
+}
+
+// parse returns the endpoint registered by an instance. Instances registered with an
+// unspecified host cannot be dialled and are skipped, as are empty values, which
+// Registry writes before SetService.
+func (w *ServiceWatcher) parse(key string, value []byte) (string, bool) {
+	if len(strings.TrimSpace(string(value))) == 0 {
+		return "", false
+	}
+
+	ep, err := discovery.ParseServiceEndpoint(value)
+	if err != nil {
+		logger.Warnf("access: ignoring %s instance %s: %v", w.service, key, err)
+		return "", false
+	}
+	return ep.String(), true
+}
@@ -0,0 +1,131 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package access
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/pkg/discovery"
+	"os-artificer/saber/pkg/sbnet"
+)
+
+func TestServiceWatcherFollowsInstances(t *testing.T) {
+	w := newServiceWatcher("databus", "/saber/databus/self/", time.Minute, time.Second)
+
+	var notified [][]string
+	w.Subscribe(func(endpoints []string) { notified = append(notified, endpoints) })
+
+	put := func(key, value string) {
+		w.apply(&discovery.WatchEvent{EventType: discovery.WatchedEventPut, Key: key, Value: []byte(value)})
+	}
+
+	put("/saber/databus/self/a", "tcp://10.0.0.2:26689")
+	put("/saber/databus/self/b", "10.0.0.1:26689")
+	put("/saber/databus/self/c", `{"listen_address":{"host":"10.0.0.3","port":26689}}`)
+	put("/saber/databus/self/d", "tcp://0.0.0.0:26689")
+	put("/saber/databus/self/e", "")
+
+	want := []string{"tcp://10.0.0.1:26689", "tcp://10.0.0.2:26689", "tcp://10.0.0.3:26689"}
+	if got := w.Endpoints(); !slices.Equal(got, want) {
+		t.Fatalf("Endpoints() = %v, want %v", got, want)
+	}
+
+	w.apply(&discovery.WatchEvent{EventType: discovery.WatchedEventDelete, Key: "/saber/databus/self/a"})
+	want = []string{"tcp://10.0.0.1:26689", "tcp://10.0.0.3:26689"}
+	if got := w.Endpoints(); !slices.Equal(got, want) {
+		t.Fatalf("Endpoints() after delete = %v, want %v", got, want)
+	}
+
+	// Re-registering the same address does not notify again.
+	n := len(notified)
+	put("/saber/databus/self/b", "tcp://10.0.0.1:26689")
+	if len(notified) != n {
+		t.Fatalf("subscriber notified without a change: %v", notified[n:])
+	}
+	if last := notified[len(notified)-1]; !slices.Equal(last, want) {
+		t.Fatalf("last notification = %v, want %v", last, want)
+	}
+}
+
+// Servers listening on a wildcard address register the address they are reached at.
+func TestServiceWatcherResolvesWildcardListener(t *testing.T) {
+	t.Setenv(sbnet.PodIPEnv, "10.0.0.4")
+	w := newServiceWatcher("controller", "/saber/controller/self/", time.Minute, time.Second)
+
+	listen, err := sbnet.NewEndpointFromString("tcp://0.0.0.0:26689")
+	if err != nil {
+		t.Fatal(err)
+	}
+	advertised, err := sbnet.AdvertisedEndpoint(*listen, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.apply(&discovery.WatchEvent{
+		EventType: discovery.WatchedEventPut,
+		Key:       "/saber/controller/self/a",
+		Value:     []byte(advertised.String()),
+	})
+
+	want := []string{"tcp://10.0.0.4:26689"}
+	if got := w.Endpoints(); !slices.Equal(got, want) {
+		t.Fatalf("Endpoints() = %v, want %v", got, want)
+	}
+}
+
+func TestNewResolverNeedsEtcd(t *testing.T) {
+	for _, cfg := range []struct{ typ, endpoints string }{
+		{"", "tcp://127.0.0.1:2379"},
+		{"static", "tcp://127.0.0.1:2379"},
+		{"etcd", ""},
+	} {
+		r, err := NewResolver(newAccessConfig(cfg.typ, cfg.endpoints))
+		if err != nil || r != nil {
+			t.Fatalf("NewResolver(%q, %q) = %v, %v; want nil, nil", cfg.typ, cfg.endpoints, r, err)
+		}
+	}
+
+	r, err := NewResolver(newAccessConfig("etcd", "tcp://127.0.0.1:2379, https://etcd:2379"))
+	if err != nil || r == nil {
+		t.Fatalf("NewResolver(etcd) = %v, %v", r, err)
+	}
+	w, err := r.Watch(ServiceController)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.prefix != "/os-artificer/saber/controller/self/" {
+		t.Fatalf("watch prefix = %q", w.prefix)
+	}
+}
+
+func TestEtcdEndpoints(t *testing.T) {
+	got := etcdEndpoints("tcp://127.0.0.1:2379, https://etcd:2379,,")
+	want := []string{"127.0.0.1:2379", "https://etcd:2379"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("etcdEndpoints() = %v, want %v", got, want)
+	}
+}
+
+func newAccessConfig(typ, endpoints string) *config.AccessServerConfig {
+	return &config.AccessServerConfig{
+		Type:                  typ,
+		Endpoints:             endpoints,
+		RegistryRootKeyPrefix: "/os-artificer/saber",
+	}
+}
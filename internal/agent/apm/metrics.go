@@ -0,0 +1,76 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package apm
+
+import (
+	pkgapm "os-artificer/saber/pkg/apm"
+)
+
+// Business metrics for the agent, registered to the default APM registry.
+// Update them from reporter/harvester code.
+var (
+	// SpoolDepth is the number of messages waiting in a reporter's disk spool.
+	// Labels: reporter.
+	SpoolDepth = pkgapm.NewGauge(
+		"agent",
+		"spool_depth",
+		"Number of messages waiting in the reporter disk spool",
+		[]string{"reporter"},
+	)
+
+	// SpoolBytes is the size of a reporter's disk spool.
+	// Labels: reporter.
+	SpoolBytes = pkgapm.NewGauge(
+		"agent",
+		"spool_bytes",
+		"Size in bytes of the reporter disk spool",
+		[]string{"reporter"},
+	)
+
+	// SpoolDroppedTotal is the total number of spooled messages dropped before replay.
+	// Labels: reporter, reason (e.g. "max_bytes", "max_age", "corrupt").
+	SpoolDroppedTotal = pkgapm.NewCounter(
+		"agent",
+		"spool_dropped_total",
+		"Total number of spooled messages dropped before replay",
+		[]string{"reporter", "reason"},
+	)
+
+	// InflightBatches is the number of batches sent and waiting for an ack.
+	// Labels: reporter.
+	InflightBatches = pkgapm.NewGauge(
+		"agent",
+		"inflight_batches",
+		"Number of batches sent and waiting for an acknowledgement",
+		[]string{"reporter"},
+	)
+
+	// RetransmitsTotal is the total number of batches sent again for lack of an ack.
+	// Labels: reporter, reason (e.g. "timeout", "disconnect", "nack").
+	RetransmitsTotal = pkgapm.NewCounter(
+		"agent",
+		"retransmits_total",
+		"Total number of batches retransmitted for lack of an acknowledgement",
+		[]string{"reporter", "reason"},
+	)
+)
+
+func init() {
+	SpoolDepth.WithLabelValues("databus").Set(0)
+	SpoolBytes.WithLabelValues("databus").Set(0)
+	InflightBatches.WithLabelValues("databus").Set(0)
+}
@@ -20,6 +20,9 @@ import (
 	"time"
 
 	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbnet"
+
+	"google.golang.org/grpc/credentials"
 )
 
 var Cfg = Configuration{
@@ -27,14 +30,39 @@ var Cfg = Configuration{
 	Version: "v1.0.0",
 
 	AccessServers: AccessServerConfig{
-		Type:             "etcd",
-		Endpoints:        "tcp://127.0.0.1:2379",
-		SyncMetaInterval: 30 * time.Second,
+		Type:                  "etcd",
+		Endpoints:             "tcp://127.0.0.1:2379",
+		SyncMetaInterval:      30 * time.Second,
+		DialTimeout:           5 * time.Second,
+		RegistryRootKeyPrefix: "/os-artificer/saber",
 	},
 
 	Controller: ControllerConfig{
-		Endpoints:        "tcp://127.0.0.1:26688",
-		SyncMetaInterval: 30 * time.Second,
+		Endpoints:         "tcp://127.0.0.1:26688",
+		SyncMetaInterval:  30 * time.Second,
+		HeartbeatInterval: 30 * time.Second,
+	},
+
+	Enrollment: EnrollmentConfig{
+		Dir: "./data/tls",
+	},
+
+	Tasks: TasksConfig{
+		Enabled:        false,
+		MaxConcurrent:  4,
+		MaxOutputBytes: 1 << 20,
+		MaxFileBytes:   16 << 20,
+		DefaultTimeout: 60 * time.Second,
+		MaxTimeout:     10 * time.Minute,
+	},
+
+	APM: APMConfig{
+		Enabled: true,
+		Endpoint: sbnet.Endpoint{
+			Protocol: "tcp",
+			Host:     "127.0.0.1",
+			Port:     8203,
+		},
 	},
 
 	Reporters: []ReporterEntry{
@@ -67,23 +95,78 @@ var Cfg = Configuration{
 	},
 }
 
-// DiscoveryConfig discovery configuration
+// AccessServerConfig is where the agent discovers controller and databus instances.
+// With type etcd, it watches the instances registered under RegistryRootKeyPrefix and
+// resyncs them every SyncMetaInterval; static endpoints are used while none is known.
 type AccessServerConfig struct {
-	Type             string        `yaml:"type"`
-	Endpoints        string        `yaml:"endpoints"`
-	SyncMetaInterval time.Duration `yaml:"syncMetaInterval"`
+	Type                  string        `yaml:"type"`
+	Endpoints             string        `yaml:"endpoints"`
+	SyncMetaInterval      time.Duration `yaml:"syncMetaInterval"`
+	User                  string        `yaml:"user"`
+	Password              string        `yaml:"password"`
+	DialTimeout           time.Duration `yaml:"dialTimeout"`
+	RegistryRootKeyPrefix string        `yaml:"registryRootKeyPrefix"`
+	// TLS: when etcd uses https://, set UseTLS true. InsecureSkipVerify is for dev/test only.
+	UseTLS             bool   `yaml:"useTLS"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	EtcdCACert         string `yaml:"etcdCACert"` // path to CA cert (optional)
+	EtcdCert           string `yaml:"etcdCert"`   // path to client cert (optional)
+	EtcdKey            string `yaml:"etcdKey"`    // path to client key (optional)
 }
 
-// ControllerConfig controller service configuration
+// ControllerConfig controller service configuration. The agent sends a heartbeat every
+// HeartbeatInterval; keep it well below the controller's inventory offlineAfter.
 type ControllerConfig struct {
-	Endpoints        string        `yaml:"endpoints"`
-	SyncMetaInterval time.Duration `yaml:"syncMetaInterval"`
+	Endpoints         string        `yaml:"endpoints"`
+	SyncMetaInterval  time.Duration `yaml:"syncMetaInterval"`
+	HeartbeatInterval time.Duration `yaml:"heartbeatInterval"`
+}
+
+// EnrollmentConfig lets the agent obtain its client certificate from the controller with a
+// bootstrap token. The certificate is stored in Dir and used for all later connections;
+// delete it to enroll again. It is skipped when tls.certFile is set.
+type EnrollmentConfig struct {
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"tokenFile"`
+	Dir       string `yaml:"dir"`
+}
+
+// TasksConfig lets the controller run tasks on the agent. Commands run only if listed in
+// Commands (absolute paths, matched by path or base name), files are collected and listed
+// only under Paths, and processes are killed only with AllowKill. Command output is capped
+// at MaxOutputBytes and collected files at MaxFileBytes.
+type TasksConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Commands       []string      `yaml:"commands"`
+	Paths          []string      `yaml:"paths"`
+	AllowKill      bool          `yaml:"allowKill"`
+	MaxConcurrent  int           `yaml:"maxConcurrent"`
+	MaxOutputBytes int64         `yaml:"maxOutputBytes"`
+	MaxFileBytes   int64         `yaml:"maxFileBytes"`
+	DefaultTimeout time.Duration `yaml:"defaultTimeout"`
+	MaxTimeout     time.Duration `yaml:"maxTimeout"`
+}
+
+// APMConfig APM config
+type APMConfig struct {
+	Enabled  bool           `yaml:"enabled"`
+	Endpoint sbnet.Endpoint `yaml:"endpoint"`
 }
 
 // ReporterEntry config for one reporter (e.g. type + config in reporters list).
+// A reporter without routes receives every event.
 type ReporterEntry struct {
-	Type   string         `yaml:"type"`
-	Config map[string]any `yaml:"config"`
+	Name    string          `yaml:"name"`
+	Type    string          `yaml:"type"`
+	Enabled *bool           `yaml:"enabled"`
+	Routes  []ReporterRoute `yaml:"routes"`
+	Config  map[string]any  `yaml:"config"`
+}
+
+// ReporterRoute selects events by plugin and event name. An empty list matches any name.
+type ReporterRoute struct {
+	Plugins []string `yaml:"plugins"`
+	Events  []string `yaml:"events"`
 }
 
 // ReporterOpts is passed to reporter.CreateReporter when creating a reporter from an entry.
@@ -91,6 +174,24 @@ type ReporterOpts struct {
 	Config       map[string]any
 	AgentName    string
 	AgentVersion string
+
+	// ClientID identifies the agent to databus and Kafka; the machine ID is used when empty.
+	ClientID string
+
+	// Databus, when set, resolves the live databus instances.
+	Databus EndpointResolver
+
+	// Credentials secures the databus connections; plaintext when nil.
+	Credentials credentials.TransportCredentials
+}
+
+// EndpointResolver provides the live endpoints of a service, e.g. from access servers.
+type EndpointResolver interface {
+	// Endpoints returns the known endpoints, sorted.
+	Endpoints() []string
+
+	// Subscribe registers fn to be called with the new endpoints whenever they change.
+	Subscribe(fn func(endpoints []string))
 }
 
 // HarvesterPluginEntry config for one harvester plugin
@@ -113,12 +214,17 @@ type LogConfig struct {
 	MaxBackupAge   int          `yaml:"maxBackupAge"`
 }
 
-// Configuration agent's configuration
+// Configuration agent's configuration. TLS applies to the controller and databus
+// channels; with a client certificate, its identity is the agent's clientID.
 type Configuration struct {
 	Name          string             `yaml:"name"`
 	Version       string             `yaml:"version"`
 	AccessServers AccessServerConfig `yaml:"accessServers"`
 	Controller    ControllerConfig   `yaml:"controller"`
+	TLS           sbnet.TLSConfig    `yaml:"tls"`
+	Enrollment    EnrollmentConfig   `yaml:"enrollment"`
+	Tasks         TasksConfig        `yaml:"tasks"`
+	APM           APMConfig          `yaml:"apm"`
 	Reporters     []ReporterEntry    `yaml:"reporters"`
 	Harvester     HarvesterConfig    `yaml:"harvester"`
 	Log           LogConfig          `yaml:"log"`
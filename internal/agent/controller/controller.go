@@ -19,17 +19,21 @@ package controller
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"sync"
 	"time"
 
+	"os-artificer/saber/internal/agent/config"
 	"os-artificer/saber/pkg/constant"
 	"os-artificer/saber/pkg/logger"
 	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
 	"os-artificer/saber/pkg/sbnet"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 )
@@ -41,18 +45,26 @@ type ResponseHandler func(*proto.AgentResponse)
 // messaging and automatic reconnect on connection failure.
 type ControllerClient struct {
 	endpoint             string
+	staticEndpoint       string
 	clientID             string
 	conn                 *grpc.ClientConn
 	stream               grpc.BidiStreamingClient[proto.AgentRequest, proto.AgentResponse]
 	ctx                  context.Context
 	cancel               context.CancelFunc
 	mu                   sync.RWMutex
+	sendMu               sync.Mutex // gRPC streams do not allow concurrent sends
 	closed               bool
 	reconnecting         bool
 	reconnectAttempts    int
 	reconnectInterval    time.Duration
 	maxReconnectAttempts int
 	onResponse           ResponseHandler
+	creds                credentials.TransportCredentials
+	handlers             map[sbmsg.Kind]MessageHandler
+	pending              *sbmsg.Pending
+	onConnect            []func(ctx context.Context)
+	heartbeatInterval    time.Duration
+	started              time.Time
 }
 
 // NewControllerClient creates a new controller client. Call Run() to establish the connection and
@@ -61,11 +73,16 @@ func NewControllerClient(ctx context.Context, endpoint string, clientID string)
 	ctxCancel, cancel := context.WithCancel(ctx)
 	return &ControllerClient{
 		endpoint:             endpoint,
+		staticEndpoint:       endpoint,
 		clientID:             clientID,
 		ctx:                  ctxCancel,
 		cancel:               cancel,
 		reconnectInterval:    constant.DefaultClientReconnectInterval,
 		maxReconnectAttempts: constant.DefaultClientMaxReconnectAttempts,
+		creds:                insecure.NewCredentials(),
+		handlers:             make(map[sbmsg.Kind]MessageHandler),
+		pending:              sbmsg.NewPending(),
+		started:              time.Now(),
 	}
 }
 
@@ -76,10 +93,90 @@ func (c *ControllerClient) OnResponse(h ResponseHandler) {
 	c.onResponse = h
 }
 
+// OnConnect registers fn to be called each time a stream to the controller is established,
+// e.g. to announce the agent. fn runs on its own goroutine with a context that is done
+// when the stream ends.
+func (c *ControllerClient) OnConnect(fn func(ctx context.Context)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onConnect = append(c.onConnect, fn)
+}
+
+// UseHeartbeat makes the client send a heartbeat every interval while connected, so the
+// controller can tell a silent agent from a lost one. Call it before Run.
+func (c *ControllerClient) UseHeartbeat(interval time.Duration) {
+	c.heartbeatInterval = interval
+}
+
+// UseResolver makes the client follow the controller instances known to r, connecting
+// to the one picked for its client ID. The static endpoint is used while r knows none.
+// Call it before Run.
+func (c *ControllerClient) UseResolver(r config.EndpointResolver) {
+	r.Subscribe(c.setEndpoints)
+	if eps := r.Endpoints(); len(eps) > 0 {
+		c.setEndpoints(eps)
+	}
+}
+
+// UseCredentials secures the controller connection with creds. Call it before Run.
+func (c *ControllerClient) UseCredentials(creds credentials.TransportCredentials) {
+	c.creds = creds
+}
+
+// setEndpoints picks the endpoint for this client and reconnects when it changed.
+// Rendezvous hashing keeps each agent on the same instance while that instance is
+// alive, and moves only a share of the agents when instances are added.
+func (c *ControllerClient) setEndpoints(endpoints []string) {
+	endpoint := pickEndpoint(c.clientID, endpoints)
+	if endpoint == "" {
+		endpoint = c.staticEndpoint
+	}
+
+	c.mu.Lock()
+	if endpoint == "" || endpoint == c.endpoint {
+		c.mu.Unlock()
+		return
+	}
+	c.endpoint = endpoint
+	conn := c.conn
+	c.mu.Unlock()
+
+	logger.Infof("controller client: moving to %s", endpoint)
+	if conn != nil {
+		// The recv loop fails and Run reconnects to the new endpoint.
+		_ = conn.Close()
+	}
+}
+
+// pickEndpoint returns the endpoint with the highest hash of clientID and endpoint.
+func pickEndpoint(clientID string, endpoints []string) string {
+	var (
+		best      string
+		bestScore uint64
+	)
+	for _, ep := range endpoints {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(clientID))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(ep))
+		if score := h.Sum64(); best == "" || score > bestScore {
+			best, bestScore = ep, score
+		}
+	}
+	return best
+}
+
 func (c *ControllerClient) newConn() (*grpc.ClientConn, error) {
-	ep, err := sbnet.NewEndpointFromString(c.endpoint)
+	c.mu.RLock()
+	endpoint := c.endpoint
+	c.mu.RUnlock()
+	if endpoint == "" {
+		return nil, fmt.Errorf("no controller endpoint known")
+	}
+
+	ep, err := sbnet.NewEndpointFromString(endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("parse endpoint %q: %w", c.endpoint, err)
+		return nil, fmt.Errorf("parse endpoint %q: %w", endpoint, err)
 	}
 	dialAddr := ep.HostPort()
 
@@ -91,7 +188,7 @@ func (c *ControllerClient) newConn() (*grpc.ClientConn, error) {
 
 	return grpc.NewClient(
 		dialAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(c.creds),
 		grpc.WithKeepaliveParams(kacp),
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(constant.DefaultMaxReceiveMessageSize),
@@ -129,7 +226,10 @@ func (c *ControllerClient) connect() error {
 		Headers:  nil,
 		Payload:  nil,
 	}
-	if err := stream.Send(first); err != nil {
+	c.sendMu.Lock()
+	err = stream.Send(first)
+	c.sendMu.Unlock()
+	if err != nil {
 		_ = conn.Close()
 		return fmt.Errorf("controller send first message: %w", err)
 	}
@@ -138,10 +238,20 @@ func (c *ControllerClient) connect() error {
 	c.conn = conn
 	c.stream = stream
 	c.reconnectAttempts = 0
+	onConnect := c.onConnect
 	c.mu.Unlock()
 
 	go c.monitorConnection()
 
+	sessionCtx, sessionCancel := context.WithCancel(c.ctx)
+	defer sessionCancel()
+	if c.heartbeatInterval > 0 {
+		go c.heartbeat(sessionCtx)
+	}
+	for _, fn := range onConnect {
+		go fn(sessionCtx)
+	}
+
 	// Recv loop: server can push AgentResponse at any time.
 	for {
 		msg, err := stream.Recv()
@@ -159,6 +269,28 @@ func (c *ControllerClient) connect() error {
 		if cb != nil {
 			cb(msg)
 		}
+		if env := msg.GetEnvelope(); env != nil {
+			c.dispatch(env)
+		}
+	}
+}
+
+// heartbeat sends a heartbeat every heartbeatInterval until ctx is done.
+func (c *ControllerClient) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(c.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			env, _ := sbmsg.New(&proto.Heartbeat{Uptime: int64(time.Since(c.started) / time.Second)})
+			if err := c.SendMessage(ctx, env); err != nil {
+				logger.Debugf("controller client: heartbeat: %v", err)
+			}
+		}
 	}
 }
 
@@ -216,7 +348,10 @@ func (c *ControllerClient) handleDisconnect() {
 			return
 		}
 
-		logger.Infof("controller client: reconnecting to %s", c.endpoint)
+		c.mu.RLock()
+		endpoint := c.endpoint
+		c.mu.RUnlock()
+		logger.Infof("controller client: reconnecting to %s", endpoint)
 		if err := c.connect(); err != nil {
 			logger.Warnf("controller client: reconnect failed: %v", err)
 			continue
@@ -238,6 +373,9 @@ func (c *ControllerClient) Send(ctx context.Context, req *proto.AgentRequest) er
 	if stream == nil {
 		return fmt.Errorf("controller client not connected")
 	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
 	return stream.Send(req)
 }
 
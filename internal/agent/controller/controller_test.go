@@ -0,0 +1,52 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPickEndpointIsStable(t *testing.T) {
+	endpoints := []string{"tcp://a:1", "tcp://b:1", "tcp://c:1"}
+
+	picked := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		id := fmt.Sprintf("agent-%d", i)
+		ep := pickEndpoint(id, endpoints)
+		picked[id] = ep
+		counts[ep]++
+	}
+	for _, ep := range endpoints {
+		if counts[ep] == 0 {
+			t.Fatalf("no agent picked %s: %v", ep, counts)
+		}
+	}
+
+	// Adding an instance only moves agents to the new instance.
+	more := append(endpoints, "tcp://d:1")
+	for id, before := range picked {
+		if after := pickEndpoint(id, more); after != before && after != "tcp://d:1" {
+			t.Fatalf("%s moved from %s to %s", id, before, after)
+		}
+	}
+
+	if ep := pickEndpoint("agent-1", nil); ep != "" {
+		t.Fatalf("pickEndpoint with no endpoints = %q", ep)
+	}
+}
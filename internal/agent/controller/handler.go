@@ -0,0 +1,96 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package controller
+
+import (
+	"context"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MessageHandler handles an envelope received from the controller. Handlers run on the
+// recv loop, so long-running work belongs in its own goroutine. A returned error is
+// reported to the controller in an Ack.
+type MessageHandler func(ctx context.Context, env *proto.Envelope) error
+
+// Handle registers h for the envelopes of the given kind, replacing any previous handler.
+func (c *ControllerClient) Handle(kind sbmsg.Kind, h MessageHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[kind] = h
+}
+
+// SendMessage sends env to the controller.
+func (c *ControllerClient) SendMessage(ctx context.Context, env *proto.Envelope) error {
+	return c.Send(ctx, &proto.AgentRequest{ClientID: c.clientID, Envelope: env})
+}
+
+// Reply sends body to the controller as the reply to env.
+func (c *ControllerClient) Reply(ctx context.Context, env *proto.Envelope, body protoreflect.ProtoMessage) error {
+	resp, err := sbmsg.Reply(env, body)
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(ctx, resp)
+}
+
+// Request sends body to the controller and waits for the reply, or for ctx to be done.
+// An Ack with a non-zero code is returned as an error.
+func (c *ControllerClient) Request(ctx context.Context, body protoreflect.ProtoMessage) (*proto.Envelope, error) {
+	req, err := sbmsg.New(body)
+	if err != nil {
+		return nil, err
+	}
+	// The agent talks to a single controller: every request has the same peer.
+	return c.pending.Call(ctx, "", req, func(env *proto.Envelope) error {
+		return c.SendMessage(ctx, env)
+	})
+}
+
+// dispatch hands env to the request waiting for it, or to the handler of its kind.
+func (c *ControllerClient) dispatch(env *proto.Envelope) {
+	if c.pending.Resolve("", env) {
+		return
+	}
+
+	kind := sbmsg.KindOf(env)
+	c.mu.RLock()
+	h := c.handlers[kind]
+	c.mu.RUnlock()
+
+	var err error
+	if h == nil {
+		err = gerrors.Newf(gerrors.Unimplemented, "no handler for %q messages", kind)
+	} else {
+		err = h(c.ctx, env)
+	}
+	if err == nil {
+		return
+	}
+
+	logger.Debugf("controller client: message %s (%s): %v", env.GetId(), kind, err)
+	if sbmsg.NeedsAck(env) {
+		if err := c.SendMessage(c.ctx, sbmsg.NewAck(env, err)); err != nil {
+			logger.Warnf("controller client: ack message %s: %v", env.GetId(), err)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/pkg/enrollment"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbnet"
+	"os-artificer/saber/pkg/tools"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	enrollTimeout      = 30 * time.Second
+	enrolledCertFile   = "agent.pem"
+	enrolledKeyFile    = "agent-key.pem"
+	enrolledCACertFile = "ca.pem"
+
+	// enrolledCurrent is the symlink in the enrollment dir to the directory holding the
+	// files above; enrolledGenPattern names those directories.
+	enrolledCurrent    = "current"
+	enrolledGenPattern = "tls-"
+
+	// renewRetryInterval is how long a failed renewal waits before it is tried again.
+	renewRetryInterval = 5 * time.Minute
+)
+
+// enroller obtains the client certificate of an agent holding a bootstrap token and
+// renews it before it expires. The certificate is kept in dir.
+type enroller struct {
+	tls       sbnet.TLSConfig
+	endpoints string
+	token     string
+	tokenFile string
+	dir       string
+}
+
+// enroll makes sure the agent has a client certificate when a bootstrap token is
+// configured: it reuses the one stored by an earlier enrollment unless it is unusable or
+// has expired, or asks the controller to sign a new one. cfg.TLS is pointed at the
+// certificate. The returned enroller renews it; it is nil when the agent does not enroll.
+func enroll(ctx context.Context, cfg *config.Configuration) (*enroller, error) {
+	e := &cfg.Enrollment
+	if cfg.TLS.CertFile != "" || (e.Token == "" && e.TokenFile == "") {
+		return nil, nil
+	}
+	if !cfg.TLS.Enabled {
+		return nil, errors.New("enrollment requires tls.enabled")
+	}
+
+	en := &enroller{
+		tls:       cfg.TLS,
+		endpoints: cfg.Controller.Endpoints,
+		token:     e.Token,
+		tokenFile: e.TokenFile,
+		dir:       e.Dir,
+	}
+	if _, err := en.stored(); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger.Warnf("stored agent certificate is not usable, enrolling again: %v", err)
+		}
+		if err := en.enrollWithToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.TLS.CertFile, cfg.TLS.KeyFile = en.certFile(), en.keyFile()
+	return en, nil
+}
+
+func (en *enroller) certFile() string {
+	return filepath.Join(en.dir, enrolledCurrent, enrolledCertFile)
+}
+
+func (en *enroller) keyFile() string {
+	return filepath.Join(en.dir, enrolledCurrent, enrolledKeyFile)
+}
+
+// stored returns the stored certificate if it matches the stored key and has not expired.
+func (en *enroller) stored() (*x509.Certificate, error) {
+	pair, err := tls.LoadX509KeyPair(en.certFile(), en.keyFile())
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(leaf.NotAfter) {
+		return nil, fmt.Errorf("certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+	return leaf, nil
+}
+
+// renewTime returns when cert is renewed: after two thirds of its lifetime.
+func renewTime(cert *x509.Certificate) time.Time {
+	return cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) * 2 / 3)
+}
+
+// run renews the certificate when it is due until ctx is done, and calls reload once a
+// new one is stored.
+func (en *enroller) run(ctx context.Context, reload func() error) {
+	failed := false
+	for {
+		wait := renewRetryInterval
+		if !failed {
+			wait = 0
+			if leaf, err := en.stored(); err == nil {
+				wait = time.Until(renewTime(leaf))
+			}
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+
+		if err := en.renew(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warnf("agent certificate renewal failed, retrying in %s: %v", renewRetryInterval, err)
+			failed = true
+			continue
+		}
+		failed = false
+		if err := reload(); err != nil {
+			logger.Warnf("renewed agent certificate not loaded: %v", err)
+		}
+	}
+}
+
+// renew replaces the stored certificate with a new one, which the controller signs in
+// exchange for the current certificate. A certificate that has expired, or that the
+// controller refuses to renew because it was revoked, is replaced by enrolling again
+// with the bootstrap token.
+func (en *enroller) renew(ctx context.Context) error {
+	leaf, err := en.stored()
+	if err != nil {
+		logger.Warnf("agent certificate not renewable, enrolling again: %v", err)
+		return en.enrollWithToken(ctx)
+	}
+
+	current := en.tls
+	current.CertFile, current.KeyFile = en.certFile(), en.keyFile()
+	certs, err := sbnet.NewCertReloader(current)
+	if err != nil {
+		return err
+	}
+
+	clientID := sbnet.CertIdentities(leaf)[0]
+	keyPEM, resp, err := en.request(ctx, &proto.EnrollRequest{ClientID: clientID}, certs)
+	if err != nil {
+		return err
+	}
+	if resp.GetCode() != int32(gerrors.Success) {
+		logger.Warnf("controller refused to renew the agent certificate, enrolling again: %s", resp.GetErrmsg())
+		return en.enrollWithToken(ctx)
+	}
+
+	if err := en.install(keyPEM, resp); err != nil {
+		return err
+	}
+	logger.Infof("agent certificate of %s renewed", clientID)
+	return nil
+}
+
+// enrollWithToken exchanges the bootstrap token for a certificate.
+func (en *enroller) enrollWithToken(ctx context.Context) error {
+	token := en.token
+	if token == "" {
+		b, err := os.ReadFile(en.tokenFile)
+		if err != nil {
+			return fmt.Errorf("read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+
+	clientID, err := tools.MachineID("saber-agent")
+	if err != nil {
+		return fmt.Errorf("enrollment requires machine-id: %w", err)
+	}
+
+	// The agent has no usable certificate: only the controller is authenticated.
+	bootstrap := en.tls
+	bootstrap.CertFile, bootstrap.KeyFile = "", ""
+	certs, err := sbnet.NewCertReloader(bootstrap)
+	if err != nil {
+		return err
+	}
+
+	keyPEM, resp, err := en.request(ctx, &proto.EnrollRequest{ClientID: clientID, Token: token}, certs)
+	if err != nil {
+		return err
+	}
+	if resp.GetCode() != int32(gerrors.Success) {
+		return gerrors.New(gerrors.Code(resp.GetCode()), resp.GetErrmsg())
+	}
+
+	if err := en.install(keyPEM, resp); err != nil {
+		return err
+	}
+	logger.Infof("agent enrolled as %s, certificate stored in %s", clientID, en.certFile())
+	return nil
+}
+
+// request signs req with a new key and sends it to the controllers in turn. It returns
+// the key and the response of the first controller that answered.
+func (en *enroller) request(ctx context.Context, req *proto.EnrollRequest,
+	certs *sbnet.CertReloader) ([]byte, *proto.EnrollResponse, error) {
+
+	keyPEM, csrPEM, err := enrollment.NewKeyAndCSR(req.GetClientID())
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Csr = csrPEM
+
+	var resp *proto.EnrollResponse
+	for _, endpoint := range strings.Split(en.endpoints, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint == "" {
+			continue
+		}
+		if resp, err = enrollAt(ctx, endpoint, certs, req); err == nil {
+			return keyPEM, resp, nil
+		}
+		logger.Warnf("enrollment at %s failed: %v", endpoint, err)
+	}
+	if err == nil {
+		err = errors.New("no controller endpoint configured")
+	}
+	return nil, nil, err
+}
+
+// install stores the key and the certificates of resp. They are written to a new
+// directory, which then replaces the current one through a single rename of the symlink
+// pointing at it: a crash never leaves a key and a certificate that do not match.
+func (en *enroller) install(keyPEM []byte, resp *proto.EnrollResponse) error {
+	if err := os.MkdirAll(en.dir, 0o700); err != nil {
+		return err
+	}
+	gen, err := os.MkdirTemp(en.dir, enrolledGenPattern)
+	if err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		data []byte
+		perm os.FileMode
+	}{
+		{enrolledKeyFile, keyPEM, 0o600},
+		{enrolledCACertFile, resp.GetCaCertificate(), 0o644},
+		{enrolledCertFile, resp.GetCertificate(), 0o644},
+	}
+	for _, f := range files {
+		if err := writeFileSync(filepath.Join(gen, f.name), f.data, f.perm); err != nil {
+			_ = os.RemoveAll(gen)
+			return err
+		}
+	}
+
+	current := filepath.Join(en.dir, enrolledCurrent)
+	link := current + ".tmp"
+	_ = os.Remove(link)
+	if err := os.Symlink(filepath.Base(gen), link); err != nil {
+		_ = os.RemoveAll(gen)
+		return err
+	}
+	if err := os.Rename(link, current); err != nil {
+		_ = os.Remove(link)
+		_ = os.RemoveAll(gen)
+		return err
+	}
+	if err := syncDir(en.dir); err != nil {
+		return err
+	}
+
+	// Earlier generations, including those of an install that was interrupted, are no
+	// longer referenced.
+	old, _ := filepath.Glob(filepath.Join(en.dir, enrolledGenPattern+"*"))
+	for _, dir := range old {
+		if dir != gen {
+			_ = os.RemoveAll(dir)
+		}
+	}
+	return nil
+}
+
+// enrollAt sends req to the controller at endpoint.
+func enrollAt(ctx context.Context, endpoint string, certs *sbnet.CertReloader,
+	req *proto.EnrollRequest) (*proto.EnrollResponse, error) {
+
+	ep, err := sbnet.NewEndpointFromString(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse endpoint %q: %w", endpoint, err)
+	}
+
+	conn, err := grpc.NewClient(ep.HostPort(), grpc.WithTransportCredentials(certs.ClientCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, enrollTimeout)
+	defer cancel()
+	return proto.NewControllerServiceClient(conn).Enroll(ctx, req)
+}
+
+// writeFileSync writes data to a new file at path and flushes it to disk.
+func writeFileSync(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes the entries of dir to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,362 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package agent
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/internal/controller/server"
+	"os-artificer/saber/pkg/discovery/etcdtest"
+	"os-artificer/saber/pkg/enrollment"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbnet"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// writeServerCert writes a self-signed certificate for 127.0.0.1 and its key to dir.
+func writeServerCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "controller"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certFile, keyFile
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startEnrollServer runs a controller issuing certificates valid for certTTL and returns
+// its address, the enrollment store and the CA file the controller presents.
+func startEnrollServer(t *testing.T, certTTL time.Duration) (string, *enrollment.Store, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	caPEM, caKeyPEM, err := enrollment.GenerateCA("saber test ca", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := enrollment.ParseCA(caPEM, caKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, caPEM)
+	serverCert, serverKey := writeServerCert(t, dir)
+
+	certs, err := sbnet.NewCertReloader(sbnet.TLSConfig{
+		Enabled:  true,
+		CertFile: serverCert,
+		KeyFile:  serverKey,
+		CAFile:   caFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	store := enrollment.NewStore(etcdtest.NewClient(t), "/saber-test")
+	revoked := enrollment.NewRevocationList(store)
+	if _, err := revoked.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = revoked.Run(ctx) }()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := "tcp://" + lis.Addr().String()
+	_ = lis.Close()
+	ep, err := sbnet.NewEndpointFromString(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := server.New(ctx, *ep, "")
+	s.UseCredentials(certs.ServerCredentials())
+	s.UseEnrollment(server.NewEnroller(ca, store, revoked, certTTL))
+	go func() { _ = s.Run() }()
+	t.Cleanup(func() { _ = s.Close() })
+
+	return addr, store, serverCert
+}
+
+func enrollConfig(addr, serverCA, dir string) *config.Configuration {
+	cfg := &config.Configuration{}
+	cfg.TLS = sbnet.TLSConfig{Enabled: true, CAFile: serverCA}
+	cfg.Controller.Endpoints = addr
+	cfg.Enrollment.Token = "unused"
+	cfg.Enrollment.Dir = dir
+	return cfg
+}
+
+// enrollWhenReady enrolls with cfg once the controller accepts connections.
+func enrollWhenReady(t *testing.T, cfg *config.Configuration) (*enroller, error) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		c := *cfg
+		en, err := enroll(context.Background(), &c)
+		if err == nil || gerrors.As(err, new(*gerrors.Error)) || time.Now().After(deadline) {
+			*cfg = c
+			return en, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func serial(t *testing.T, en *enroller) string {
+	t.Helper()
+
+	leaf, err := en.stored()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enrollment.SerialString(leaf)
+}
+
+func TestEnroll_renewal(t *testing.T) {
+	const certTTL = 3 * time.Second
+	addr, store, serverCA := startEnrollServer(t, certTTL)
+	ctx := context.Background()
+
+	token, _, err := store.CreateToken(ctx, time.Hour, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := enrollConfig(addr, serverCA, t.TempDir())
+	cfg.Enrollment.Token = token
+
+	en, err := enrollWhenReady(t, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TLS.CertFile != en.certFile() {
+		t.Fatalf("tls.certFile = %q, want the enrolled certificate", cfg.TLS.CertFile)
+	}
+	first := serial(t, en)
+
+	// The same clientID cannot enroll again while it holds a live certificate.
+	impostor := enrollConfig(addr, serverCA, t.TempDir())
+	impostor.Enrollment.Token = token
+	if _, err := enroll(ctx, impostor); !gerrors.Is(err, enrollment.ErrClientEnrolled) {
+		t.Fatalf("second enrollment: got %v, want ErrClientEnrolled", err)
+	}
+
+	// Renewal authenticates with the current certificate.
+	if err := en.renew(ctx); err != nil {
+		t.Fatal(err)
+	}
+	second := serial(t, en)
+	if second == first {
+		t.Fatal("renewal kept the certificate")
+	}
+
+	// A revoked agent falls back to its bootstrap token.
+	if _, err := store.RevokeCertificates(ctx, storedClientID(t, en), ""); err != nil {
+		t.Fatal(err)
+	}
+	waitRevoked(t, addr, cfg.TLS)
+	if err := en.renew(ctx); err != nil {
+		t.Fatalf("renewal after revocation: %v", err)
+	}
+	third := serial(t, en)
+	if third == second {
+		t.Fatal("revoked certificate kept")
+	}
+
+	// Once its certificate expired, the agent enrolls again on start.
+	leaf, err := en.stored()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Until(leaf.NotAfter) + 100*time.Millisecond)
+	if _, err := en.stored(); err == nil {
+		t.Fatal("expired certificate still usable")
+	}
+
+	restarted := enrollConfig(addr, serverCA, cfg.Enrollment.Dir)
+	restarted.Enrollment.Token = token
+	en, err = enroll(ctx, restarted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial(t, en) == third {
+		t.Fatal("expired certificate kept")
+	}
+}
+
+// storedClientID returns the clientID of the stored certificate.
+func storedClientID(t *testing.T, en *enroller) string {
+	t.Helper()
+
+	leaf, err := en.stored()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+// waitRevoked waits until the controller at addr refuses the client certificate of tlsCfg.
+func waitRevoked(t *testing.T, addr string, tlsCfg sbnet.TLSConfig) {
+	t.Helper()
+
+	certs, err := sbnet.NewCertReloader(tlsCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep, err := sbnet.NewEndpointFromString(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.NewClient(ep.HostPort(), grpc.WithTransportCredentials(certs.ClientCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		stream, err := proto.NewControllerServiceClient(conn).Connect(ctx)
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		cancel()
+		if status.Code(err) == codes.PermissionDenied {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("certificate not revoked at the controller: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestRenewTime(t *testing.T) {
+	now := time.Now()
+	cert := &x509.Certificate{NotBefore: now, NotAfter: now.Add(90 * time.Hour)}
+	if got := renewTime(cert); !got.Equal(now.Add(60 * time.Hour)) {
+		t.Fatalf("renewTime = %s, want two thirds into the lifetime", got)
+	}
+}
+
+func TestEnroller_install(t *testing.T) {
+	caPEM, caKeyPEM, err := enrollment.GenerateCA("saber test ca", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := enrollment.ParseCA(caPEM, caKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := func() ([]byte, *proto.EnrollResponse) {
+		keyPEM, csrPEM, err := enrollment.NewKeyAndCSR("agent-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		csr, err := enrollment.ParseCSR(csrPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, certPEM, err := ca.Sign(csr, "agent-1", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return keyPEM, &proto.EnrollResponse{Certificate: certPEM, CaCertificate: caPEM}
+	}
+
+	en := &enroller{dir: t.TempDir()}
+	if err := en.install(issue()); err != nil {
+		t.Fatal(err)
+	}
+	first := serial(t, en)
+
+	// An install interrupted after writing the new key leaves the stored pair usable.
+	keyPEM, _ := issue()
+	partial, err := os.MkdirTemp(en.dir, enrolledGenPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(partial, enrolledKeyFile), keyPEM)
+	if got := serial(t, en); got != first {
+		t.Fatalf("stored certificate %s after an interrupted install, want %s", got, first)
+	}
+
+	if err := en.install(issue()); err != nil {
+		t.Fatal(err)
+	}
+	if serial(t, en) == first {
+		t.Fatal("install kept the previous certificate")
+	}
+	gens, err := filepath.Glob(filepath.Join(en.dir, enrolledGenPattern+"*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 1 {
+		t.Fatalf("%d certificate directories left, want 1", len(gens))
+	}
+}
@@ -0,0 +1,247 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package accounts
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const accountsPluginVersion = "1.0.0"
+const pluginName = "accounts"
+
+func init() {
+	plugin.RegisterPlugin(pluginName, newAccountsPlugin)
+}
+
+// AccountsPlugin inventories local users, groups, sudo rules and SSH authorized keys.
+// It emits the full inventory at start and the differences between inventories after
+// that, so that new UID-0 accounts or new authorized keys become visible.
+type AccountsPlugin struct {
+	plugin.UnimplementedPlugin
+
+	wg        sync.WaitGroup
+	done      chan struct{}
+	opts      Options
+	collector *collector
+}
+
+func newAccountsPlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
+	accountsOptions, err := OptionsFromAny(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("accounts plugin options: %+v", accountsOptions)
+
+	p := &AccountsPlugin{opts: accountsOptions, done: make(chan struct{})}
+	p.collector = &collector{opts: &p.opts}
+	return p, nil
+}
+
+func (p *AccountsPlugin) Version() string {
+	return accountsPluginVersion
+}
+
+func (p *AccountsPlugin) Name() string {
+	return pluginName
+}
+
+func (p *AccountsPlugin) Run(ctx context.Context) (plugin.EventC, error) {
+	eventC := make(plugin.EventC)
+	done := p.done
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(eventC)
+
+		send := func(name string, data any) bool {
+			select {
+			case eventC <- &plugin.Event{PluginName: p.Name(), EventName: name, Data: data}:
+				return true
+			case <-done:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		ticker := time.NewTicker(p.opts.Interval.Duration())
+		defer ticker.Stop()
+
+		var prev *inventory
+		for {
+			cur, err := p.collector.collect()
+			if err != nil {
+				logger.Errorf("failed to collect accounts: %v", err)
+			} else {
+				if prev == nil {
+					if !send(sbmodels.AccountEventInventory, snapshot(cur)) {
+						return
+					}
+				} else {
+					for _, ev := range diff(prev, cur) {
+						if !send(ev.Action, ev) {
+							return
+						}
+					}
+				}
+				prev = cur
+			}
+
+			select {
+			case <-done:
+				logger.Infof("accounts plugin run exited: %s", p.Name())
+				return
+
+			case <-ctx.Done():
+				logger.Infof("accounts plugin run exited: %s", p.Name())
+				return
+
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+func (p *AccountsPlugin) Close() error {
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+
+	p.wg.Wait()
+	return nil
+}
+
+// snapshot flattens an inventory into its event model, sorted for stable output.
+func snapshot(inv *inventory) *sbmodels.AccountInventory {
+	out := &sbmodels.AccountInventory{
+		Users:          make([]sbmodels.UserAccount, 0, len(inv.users)),
+		Groups:         make([]sbmodels.GroupInfo, 0, len(inv.groups)),
+		SudoRules:      make([]sbmodels.SudoRule, 0, len(inv.sudoRules)),
+		AuthorizedKeys: make([]sbmodels.AuthorizedKey, 0, len(inv.keys)),
+		Timestamp:      time.Now(),
+	}
+
+	for _, u := range inv.users {
+		out.Users = append(out.Users, *u)
+	}
+	for _, g := range inv.groups {
+		out.Groups = append(out.Groups, *g)
+	}
+	for r := range inv.sudoRules {
+		out.SudoRules = append(out.SudoRules, r)
+	}
+	for _, k := range inv.keys {
+		out.AuthorizedKeys = append(out.AuthorizedKeys, *k)
+	}
+
+	sort.Slice(out.Users, func(i, j int) bool { return out.Users[i].UID < out.Users[j].UID })
+	sort.Slice(out.Groups, func(i, j int) bool { return out.Groups[i].GID < out.Groups[j].GID })
+	sort.Slice(out.SudoRules, func(i, j int) bool {
+		a, b := out.SudoRules[i], out.SudoRules[j]
+		return a.Source < b.Source || (a.Source == b.Source && a.Rule < b.Rule)
+	})
+	sort.Slice(out.AuthorizedKeys, func(i, j int) bool {
+		a, b := out.AuthorizedKeys[i], out.AuthorizedKeys[j]
+		if a.User != b.User {
+			return a.User < b.User
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+
+	return out
+}
+
+// diff returns the change events between two inventories: removals first, then
+// additions and modifications.
+func diff(prev, cur *inventory) []*sbmodels.AccountEvent {
+	now := time.Now()
+	var out []*sbmodels.AccountEvent
+	add := func(ev *sbmodels.AccountEvent) {
+		ev.Timestamp = now
+		out = append(out, ev)
+	}
+
+	for name, u := range prev.users {
+		if _, ok := cur.users[name]; !ok {
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventUserRemoved, User: u})
+		}
+	}
+	for name, g := range prev.groups {
+		if _, ok := cur.groups[name]; !ok {
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventGroupRemoved, Group: g})
+		}
+	}
+	for r := range prev.sudoRules {
+		if _, ok := cur.sudoRules[r]; !ok {
+			rule := r
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventSudoRuleRemoved, SudoRule: &rule})
+		}
+	}
+	for id, k := range prev.keys {
+		if _, ok := cur.keys[id]; !ok {
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventAuthorizedKeyRemoved, AuthorizedKey: k})
+		}
+	}
+
+	for name, u := range cur.users {
+		old, ok := prev.users[name]
+		switch {
+		case !ok:
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventUserAdded, User: u})
+		case !reflect.DeepEqual(old, u):
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventUserModified, User: u, PreviousUser: old})
+		}
+	}
+	for name, g := range cur.groups {
+		old, ok := prev.groups[name]
+		switch {
+		case !ok:
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventGroupAdded, Group: g})
+		case !reflect.DeepEqual(old, g):
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventGroupModified, Group: g, PreviousGroup: old})
+		}
+	}
+	for r := range cur.sudoRules {
+		if _, ok := prev.sudoRules[r]; !ok {
+			rule := r
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventSudoRuleAdded, SudoRule: &rule})
+		}
+	}
+	for id, k := range cur.keys {
+		if _, ok := prev.keys[id]; !ok {
+			add(&sbmodels.AccountEvent{Action: sbmodels.AccountEventAuthorizedKeyAdded, AuthorizedKey: k})
+		}
+	}
+
+	return out
+}
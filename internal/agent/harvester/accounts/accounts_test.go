@@ -0,0 +1,199 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package accounts
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", path, err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func newKey(t *testing.T, comment string) (line, fingerprint string) {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("ssh key: %v", err)
+	}
+	line = string(ssh.MarshalAuthorizedKey(sshPub))
+	return line[:len(line)-1] + " " + comment + "\n", ssh.FingerprintSHA256(sshPub)
+}
+
+func newTestRoot(t *testing.T) string {
+	root := t.TempDir()
+	writeFile(t, root, "/etc/passwd", "root:x:0:0:root:/root:/bin/bash\n"+
+		"alice:x:1000:1000:Alice:/home/alice:/bin/bash\n"+
+		"svc:x:999:999::/var/lib/svc:/usr/sbin/nologin\n")
+	writeFile(t, root, "/etc/shadow", "root:$6$salt$hash:19000:0:99999:7:::\n"+
+		"alice:!$6$salt$hash:19500::::::\n"+
+		"svc:!!:19000::::::\n")
+	writeFile(t, root, "/etc/group", "root:x:0:\nsudo:x:27:alice\nalice:x:1000:\nsvc:x:999:\n")
+	writeFile(t, root, "/etc/sudoers", "# comment\nDefaults env_reset\n\nroot ALL=(ALL:ALL) ALL\n%sudo ALL=(ALL:ALL) \\\n    ALL\n@includedir /etc/sudoers.d\n")
+	writeFile(t, root, "/etc/sudoers.d/ops", "alice ALL=(root) NOPASSWD: /usr/bin/systemctl\n")
+	writeFile(t, root, "/etc/sudoers.d/README.txt", "ignored ALL=(ALL) ALL\n")
+	return root
+}
+
+func TestCollect(t *testing.T) {
+	root := newTestRoot(t)
+	key, fp := newKey(t, "alice@laptop")
+	writeFile(t, root, "/home/alice/.ssh/authorized_keys", "# keys\n"+`from="10.0.0.0/8",no-pty `+key)
+
+	opts, err := OptionsFromAny(map[string]any{"root": root})
+	if err != nil {
+		t.Fatalf("OptionsFromAny: %v", err)
+	}
+	inv, err := (&collector{opts: &opts}).collect()
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	if len(inv.users) != 3 || len(inv.groups) != 4 {
+		t.Fatalf("users=%d groups=%d", len(inv.users), len(inv.groups))
+	}
+
+	rootUser, alice, svc := inv.users["root"], inv.users["alice"], inv.users["svc"]
+	if rootUser.PasswordState != sbmodels.PasswordStateSet || *rootUser.MaxDays != 99999 || rootUser.InactiveDays != nil {
+		t.Fatalf("root = %+v", rootUser)
+	}
+	if alice.PasswordState != sbmodels.PasswordStateLocked || alice.UID != 1000 || len(alice.Groups) != 1 || alice.Groups[0] != "sudo" {
+		t.Fatalf("alice = %+v", alice)
+	}
+	if !alice.LastChange.Equal(time.Unix(19500*86400, 0)) {
+		t.Fatalf("alice last change = %v", alice.LastChange)
+	}
+	if svc.PasswordState != sbmodels.PasswordStateDisabled {
+		t.Fatalf("svc password state = %s", svc.PasswordState)
+	}
+
+	wantRules := []sbmodels.SudoRule{
+		{Source: "/etc/sudoers", Rule: "Defaults env_reset"},
+		{Source: "/etc/sudoers", Rule: "root ALL=(ALL:ALL) ALL"},
+		{Source: "/etc/sudoers", Rule: "%sudo ALL=(ALL:ALL) ALL"},
+		{Source: "/etc/sudoers.d/ops", Rule: "alice ALL=(root) NOPASSWD: /usr/bin/systemctl"},
+	}
+	if len(inv.sudoRules) != len(wantRules) {
+		t.Fatalf("sudo rules = %v", inv.sudoRules)
+	}
+	for _, r := range wantRules {
+		if _, ok := inv.sudoRules[r]; !ok {
+			t.Fatalf("missing sudo rule %+v in %v", r, inv.sudoRules)
+		}
+	}
+
+	k := inv.keys[keyID{user: "alice", path: "/home/alice/.ssh/authorized_keys", fingerprint: fp}]
+	if len(inv.keys) != 1 || k == nil {
+		t.Fatalf("keys = %v", inv.keys)
+	}
+	if k.Type != ssh.KeyAlgoED25519 || k.Comment != "alice@laptop" || len(k.Options) != 2 {
+		t.Fatalf("key = %+v", k)
+	}
+}
+
+func TestPasswordState(t *testing.T) {
+	cases := map[string]string{
+		"":              sbmodels.PasswordStateEmpty,
+		"*":             sbmodels.PasswordStateDisabled,
+		"!":             sbmodels.PasswordStateDisabled,
+		"!!":            sbmodels.PasswordStateDisabled,
+		"!*":            sbmodels.PasswordStateDisabled,
+		"!$6$salt$hash": sbmodels.PasswordStateLocked,
+		"*LK*":          sbmodels.PasswordStateLocked,
+		"$y$j9T$hash":   sbmodels.PasswordStateSet,
+	}
+	for hash, want := range cases {
+		if got := passwordState(hash); got != want {
+			t.Fatalf("passwordState(%q) = %s, want %s", hash, got, want)
+		}
+	}
+}
+
+func TestAccountsPlugin_diff(t *testing.T) {
+	root := newTestRoot(t)
+
+	p, err := newAccountsPlugin(context.Background(), map[string]any{"root": root, "interval": "20ms"})
+	if err != nil {
+		t.Fatalf("newAccountsPlugin: %v", err)
+	}
+	defer p.Close()
+
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	first := <-eventC
+	inv, ok := first.Data.(*sbmodels.AccountInventory)
+	if first.EventName != sbmodels.AccountEventInventory || !ok || len(inv.Users) != 3 || inv.Users[0].Name != "root" {
+		t.Fatalf("first event = %+v", first)
+	}
+
+	key, fp := newKey(t, "backdoor")
+	writeFile(t, root, "/etc/passwd", "root:x:0:0:root:/root:/bin/bash\n"+
+		"alice:x:1000:1000:Alice:/home/alice:/bin/bash\n"+
+		"toor:x:0:0::/root:/bin/sh\n")
+	writeFile(t, root, "/root/.ssh/authorized_keys", key)
+
+	// toor shares root's home, so the new key is reported for both accounts.
+	got := make(map[string]*sbmodels.AccountEvent)
+	keyUsers := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(got) < 3 || len(keyUsers) < 2 {
+		select {
+		case ev := <-eventC:
+			data := ev.Data.(*sbmodels.AccountEvent)
+			got[ev.EventName] = data
+			if data.AuthorizedKey != nil && data.AuthorizedKey.Fingerprint == fp {
+				keyUsers[data.AuthorizedKey.User] = true
+			}
+		case <-timeout:
+			t.Fatalf("got %v", got)
+		}
+	}
+
+	if ev := got[sbmodels.AccountEventUserAdded]; ev == nil || ev.User.Name != "toor" || ev.User.UID != 0 {
+		t.Fatalf("user_added = %+v", ev)
+	}
+	if ev := got[sbmodels.AccountEventUserRemoved]; ev == nil || ev.User.Name != "svc" {
+		t.Fatalf("user_removed = %+v", ev)
+	}
+	if !keyUsers["root"] || !keyUsers["toor"] {
+		t.Fatalf("authorized_key_added for %v", keyUsers)
+	}
+}
@@ -0,0 +1,393 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package accounts
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// maxSudoersDepth bounds nested @include/@includedir directives.
+const maxSudoersDepth = 8
+
+// keyID identifies an authorized key entry across inventories.
+type keyID struct {
+	user        string
+	path        string
+	fingerprint string
+}
+
+// inventory is the parsed account state of the host.
+type inventory struct {
+	users     map[string]*sbmodels.UserAccount
+	groups    map[string]*sbmodels.GroupInfo
+	sudoRules map[sbmodels.SudoRule]struct{}
+	keys      map[keyID]*sbmodels.AuthorizedKey
+}
+
+// collector reads the account databases below opts.Root.
+type collector struct {
+	opts *Options
+}
+
+func (c *collector) hostPath(path string) string {
+	return filepath.Join(c.opts.Root, path)
+}
+
+// collect builds an inventory. passwd and group are required; shadow, sudoers and
+// authorized keys are best effort.
+func (c *collector) collect() (*inventory, error) {
+	inv := &inventory{
+		sudoRules: make(map[sbmodels.SudoRule]struct{}),
+		keys:      make(map[keyID]*sbmodels.AuthorizedKey),
+	}
+
+	data, err := os.ReadFile(c.hostPath("/etc/passwd"))
+	if err != nil {
+		return nil, fmt.Errorf("read passwd: %w", err)
+	}
+	inv.users = parsePasswd(data)
+
+	data, err = os.ReadFile(c.hostPath("/etc/group"))
+	if err != nil {
+		return nil, fmt.Errorf("read group: %w", err)
+	}
+	inv.groups = parseGroup(data)
+
+	for _, g := range inv.groups {
+		for _, member := range g.Members {
+			if u, ok := inv.users[member]; ok {
+				u.Groups = append(u.Groups, g.Name)
+			}
+		}
+	}
+	for _, u := range inv.users {
+		sort.Strings(u.Groups)
+	}
+
+	if data, err = os.ReadFile(c.hostPath("/etc/shadow")); err == nil {
+		applyShadow(inv.users, data)
+	} else {
+		logger.Debugf("accounts plugin: read shadow failed: %v", err)
+	}
+
+	c.readSudoers("/etc/sudoers", 0, inv.sudoRules)
+
+	for _, u := range inv.users {
+		for _, file := range c.opts.AuthorizedKeysFiles {
+			c.readAuthorizedKeys(u, file, inv.keys)
+		}
+	}
+
+	return inv, nil
+}
+
+// parsePasswd parses /etc/passwd. Malformed lines and NIS "+"/"-" entries are skipped;
+// the first entry of a duplicated name wins, as for getpwnam.
+func parsePasswd(data []byte) map[string]*sbmodels.UserAccount {
+	users := make(map[string]*sbmodels.UserAccount)
+
+	for _, fields := range colonRecords(data, 7) {
+		name := fields[0]
+		if name == "" || name[0] == '+' || name[0] == '-' {
+			continue
+		}
+		if _, dup := users[name]; dup {
+			continue
+		}
+
+		uid, err1 := strconv.ParseUint(fields[2], 10, 32)
+		gid, err2 := strconv.ParseUint(fields[3], 10, 32)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		users[name] = &sbmodels.UserAccount{
+			Name:          name,
+			UID:           uint32(uid),
+			GID:           uint32(gid),
+			Gecos:         fields[4],
+			Home:          fields[5],
+			Shell:         fields[6],
+			PasswordState: sbmodels.PasswordStateUnknown,
+		}
+	}
+
+	return users
+}
+
+// parseGroup parses /etc/group.
+func parseGroup(data []byte) map[string]*sbmodels.GroupInfo {
+	groups := make(map[string]*sbmodels.GroupInfo)
+
+	for _, fields := range colonRecords(data, 4) {
+		name := fields[0]
+		if name == "" || name[0] == '+' || name[0] == '-' {
+			continue
+		}
+		if _, dup := groups[name]; dup {
+			continue
+		}
+
+		gid, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+
+		g := &sbmodels.GroupInfo{Name: name, GID: uint32(gid)}
+		for _, m := range strings.Split(fields[3], ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				g.Members = append(g.Members, m)
+			}
+		}
+		sort.Strings(g.Members)
+		groups[name] = g
+	}
+
+	return groups
+}
+
+// applyShadow copies the password state and aging fields of /etc/shadow onto users.
+// The password hash is inspected for its lock markers only and never stored.
+func applyShadow(users map[string]*sbmodels.UserAccount, data []byte) {
+	for _, fields := range colonRecords(data, 9) {
+		u, ok := users[fields[0]]
+		if !ok {
+			continue
+		}
+
+		u.PasswordState = passwordState(fields[1])
+		u.LastChange = shadowDate(fields[2])
+		u.MinDays = shadowInt(fields[3])
+		u.MaxDays = shadowInt(fields[4])
+		u.WarnDays = shadowInt(fields[5])
+		u.InactiveDays = shadowInt(fields[6])
+		u.Expire = shadowDate(fields[7])
+	}
+}
+
+func passwordState(hash string) string {
+	if hash == "" {
+		return sbmodels.PasswordStateEmpty
+	}
+
+	rest := strings.TrimLeft(hash, "!")
+	switch {
+	case rest == "" || rest == "*":
+		return sbmodels.PasswordStateDisabled
+	case rest != hash || rest[0] == '*':
+		return sbmodels.PasswordStateLocked
+	default:
+		return sbmodels.PasswordStateSet
+	}
+}
+
+// shadowDate converts a day count since the epoch; empty fields are unset.
+func shadowDate(s string) *time.Time {
+	days := shadowInt(s)
+	if days == nil {
+		return nil
+	}
+
+	t := time.Unix(int64(*days)*86400, 0).UTC()
+	return &t
+}
+
+func shadowInt(s string) *int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// colonRecords splits colon-separated records with at least n fields, skipping blank
+// and comment lines.
+func colonRecords(data []byte, n int) [][]string {
+	var out [][]string
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) < n {
+			continue
+		}
+		out = append(out, fields)
+	}
+
+	return out
+}
+
+// readSudoers collects the effective lines of a sudoers file and the files it includes.
+// path is relative to the host root. Comments and blank lines are dropped, continuation
+// lines are joined and whitespace is collapsed so that reformatting is not a change.
+func (c *collector) readSudoers(path string, depth int, rules map[sbmodels.SudoRule]struct{}) {
+	if depth > maxSudoersDepth {
+		logger.Warnf("accounts plugin: sudoers include depth exceeded at %s", path)
+		return
+	}
+
+	data, err := os.ReadFile(c.hostPath(path))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Debugf("accounts plugin: read %s failed: %v", path, err)
+		}
+		return
+	}
+
+	for _, line := range sudoersLines(data) {
+		directive, arg, _ := strings.Cut(line, " ")
+		if !filepath.IsAbs(arg) {
+			// Relative includes are resolved against the including file's directory.
+			arg = filepath.Join(filepath.Dir(path), arg)
+		}
+
+		switch directive {
+		case "#include", "@include":
+			c.readSudoers(arg, depth+1, rules)
+
+		case "#includedir", "@includedir":
+			c.readSudoersDir(arg, depth+1, rules)
+
+		default:
+			rules[sbmodels.SudoRule{Source: path, Rule: line}] = struct{}{}
+		}
+	}
+}
+
+// readSudoersDir reads the files of an included directory in lexical order. As sudo does,
+// names ending in '~' or containing '.' are skipped.
+func (c *collector) readSudoersDir(dir string, depth int, rules map[sbmodels.SudoRule]struct{}) {
+	entries, err := os.ReadDir(c.hostPath(dir))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Debugf("accounts plugin: read %s failed: %v", dir, err)
+		}
+		return
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasSuffix(name, "~") || strings.Contains(name, ".") {
+			continue
+		}
+		c.readSudoers(filepath.Join(dir, name), depth, rules)
+	}
+}
+
+// sudoersLines returns the non-comment lines of a sudoers file with continuations joined.
+// "#include" and "#includedir" are directives, not comments.
+func sudoersLines(data []byte) []string {
+	var out []string
+	var cur strings.Builder
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		cont := strings.HasSuffix(line, "\\")
+		if cont {
+			line = strings.TrimSuffix(line, "\\")
+		}
+
+		cur.WriteString(line)
+		cur.WriteByte(' ')
+		if cont {
+			continue
+		}
+
+		joined := strings.Join(strings.Fields(cur.String()), " ")
+		cur.Reset()
+
+		if joined == "" {
+			continue
+		}
+		if joined[0] == '#' && !strings.HasPrefix(joined, "#include") {
+			continue
+		}
+		out = append(out, joined)
+	}
+
+	if joined := strings.Join(strings.Fields(cur.String()), " "); joined != "" && joined[0] != '#' {
+		out = append(out, joined)
+	}
+	return out
+}
+
+// readAuthorizedKeys parses one authorized keys file of u. Lines that are not valid keys
+// are ignored, as sshd does.
+func (c *collector) readAuthorizedKeys(u *sbmodels.UserAccount, file string, keys map[keyID]*sbmodels.AuthorizedKey) {
+	path := strings.ReplaceAll(file, "%u", u.Name)
+	if !filepath.IsAbs(path) {
+		if u.Home == "" {
+			return
+		}
+		path = filepath.Join(u.Home, path)
+	}
+
+	data, err := os.ReadFile(c.hostPath(path))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, os.ErrPermission) {
+			logger.Debugf("accounts plugin: read %s failed: %v", path, err)
+		}
+		return
+	}
+
+	for _, k := range parseAuthorizedKeys(data) {
+		k.User, k.Path = u.Name, path
+		keys[keyID{user: u.Name, path: path, fingerprint: k.Fingerprint}] = k
+	}
+}
+
+func parseAuthorizedKeys(data []byte) []*sbmodels.AuthorizedKey {
+	var out []*sbmodels.AuthorizedKey
+
+	for len(data) > 0 {
+		pub, comment, options, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			break
+		}
+		out = append(out, &sbmodels.AuthorizedKey{
+			Type:        pub.Type(),
+			Fingerprint: ssh.FingerprintSHA256(pub),
+			Comment:     comment,
+			Options:     options,
+		})
+		data = rest
+	}
+
+	return out
+}
@@ -0,0 +1,71 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package accounts
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+)
+
+const (
+	defaultInterval = 5 * time.Minute
+	defaultRoot     = "/"
+)
+
+// defaultAuthorizedKeysFiles matches the sshd_config AuthorizedKeysFile default.
+var defaultAuthorizedKeysFiles = []string{".ssh/authorized_keys", ".ssh/authorized_keys2"}
+
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
+
+// Options is the option for the accounts plugin.
+type Options struct {
+	// Interval between two inventories; change events are diffs between inventories.
+	Interval Duration `yaml:"interval" json:"interval"`
+	// AuthorizedKeysFiles are read for every user. Relative paths are taken from the
+	// user's home directory; %u in an absolute path is replaced with the user name.
+	AuthorizedKeysFiles []string `yaml:"authorizedKeysFiles" json:"authorizedKeysFiles"`
+	// Root is the host filesystem root, e.g. /host when running in a container.
+	Root string `yaml:"root" json:"root"`
+}
+
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
+func OptionsFromAny(opts any) (Options, error) {
+	var out Options
+	if o, ok := opts.(Options); ok {
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
+	}
+
+	out.Interval = Duration(out.Interval.OrDefault(defaultInterval))
+
+	if out.AuthorizedKeysFiles == nil {
+		out.AuthorizedKeysFiles = append([]string(nil), defaultAuthorizedKeysFiles...)
+	}
+	if out.Root == "" {
+		out.Root = defaultRoot
+	}
+	if !filepath.IsAbs(out.Root) {
+		return Options{}, fmt.Errorf("accounts options: root %q is not absolute", out.Root)
+	}
+
+	return out, nil
+}
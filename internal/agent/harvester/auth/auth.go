@@ -0,0 +1,293 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package auth
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const authPluginVersion = "1.0.0"
+const pluginName = "auth"
+
+func init() {
+	plugin.RegisterPlugin(pluginName, newAuthPlugin)
+}
+
+// sourceKind tells how chunks read from a followed file are decoded.
+type sourceKind int
+
+const (
+	sourceWtmp sourceKind = iota
+	sourceBtmp
+	sourceAuthLog
+)
+
+type source struct {
+	kind     sourceKind
+	follower *follower
+}
+
+// AuthPlugin reports logins, logouts, failed logins and sudo/su privilege changes read
+// from wtmp, btmp and the system auth log. Read positions are persisted so that a
+// restarted agent neither re-sends nor skips events.
+type AuthPlugin struct {
+	plugin.UnimplementedPlugin
+
+	wg      sync.WaitGroup
+	done    chan struct{}
+	opts    Options
+	sources []*source
+	state   map[string]position
+	dirty   bool
+
+	// lineUsers maps a terminal line to the user logged in on it, so that wtmp
+	// DEAD_PROCESS records, which carry no user name, can be attributed.
+	lineUsers map[string]string
+}
+
+func newAuthPlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
+	authOptions, err := OptionsFromAny(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("auth plugin options: %+v", authOptions)
+
+	state, err := loadState(authOptions.StatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &AuthPlugin{
+		opts:      authOptions,
+		state:     state,
+		lineUsers: make(map[string]string),
+		done:      make(chan struct{}),
+	}
+
+	if !disabled(authOptions.WtmpPath) {
+		p.sources = append(p.sources, &source{kind: sourceWtmp, follower: newFollower(authOptions.WtmpPath, utmpSize)})
+	}
+	if !disabled(authOptions.BtmpPath) {
+		p.sources = append(p.sources, &source{kind: sourceBtmp, follower: newFollower(authOptions.BtmpPath, utmpSize)})
+	}
+	for _, path := range authOptions.AuthLogPaths {
+		p.sources = append(p.sources, &source{kind: sourceAuthLog, follower: newFollower(path, 0)})
+	}
+
+	return p, nil
+}
+
+func (p *AuthPlugin) Version() string {
+	return authPluginVersion
+}
+
+func (p *AuthPlugin) Name() string {
+	return pluginName
+}
+
+func (p *AuthPlugin) Run(ctx context.Context) (plugin.EventC, error) {
+	eventC := make(plugin.EventC)
+	done := p.done
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(eventC)
+		defer p.shutdown()
+
+		send := func(ev *sbmodels.AuthEvent) bool {
+			select {
+			case eventC <- &plugin.Event{PluginName: p.Name(), EventName: ev.Action, Data: ev}:
+				return true
+			case <-done:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		fromEnd := p.opts.StartPosition == startPositionEnd
+		for _, s := range p.sources {
+			saved, ok := p.state[s.follower.path]
+			if err := s.follower.start(saved, ok, fromEnd); err != nil {
+				logger.Warnf("auth plugin: open %s failed: %v", s.follower.path, err)
+			}
+		}
+
+		ticker := time.NewTicker(p.opts.Interval.Duration())
+		defer ticker.Stop()
+
+		for {
+			if !p.poll(send) {
+				return
+			}
+			p.saveState()
+
+			select {
+			case <-done:
+				logger.Infof("auth plugin run exited: %s", p.Name())
+				return
+
+			case <-ctx.Done():
+				logger.Infof("auth plugin run exited: %s", p.Name())
+				return
+
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+func (p *AuthPlugin) Close() error {
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+
+	p.wg.Wait()
+	return nil
+}
+
+// poll reads every source and emits the decoded events. A position is committed only
+// after the events read before it have been sent. It returns false when the plugin is
+// shutting down.
+func (p *AuthPlugin) poll(send func(*sbmodels.AuthEvent) bool) bool {
+	now := time.Now()
+
+	for _, s := range p.sources {
+		chunks, err := s.follower.poll()
+		if err != nil {
+			logger.Warnf("auth plugin: %v", err)
+		}
+
+		for _, c := range chunks {
+			if ev := p.decode(s, c.data, now); ev != nil {
+				ev.Source = s.follower.path
+				if !send(ev) {
+					return false
+				}
+			}
+
+			p.state[s.follower.path] = position{Inode: c.inode, Offset: c.end}
+			p.dirty = true
+		}
+	}
+
+	return true
+}
+
+func (p *AuthPlugin) decode(s *source, data []byte, now time.Time) *sbmodels.AuthEvent {
+	if s.kind == sourceAuthLog {
+		return parseAuthLine(string(data), now)
+	}
+
+	r, err := decodeUtmp(data)
+	if err != nil {
+		logger.Debugf("auth plugin: %v", err)
+		return nil
+	}
+
+	if s.kind == sourceBtmp {
+		if r.User == "" {
+			return nil
+		}
+		ev := utmpEvent(r)
+		ev.Action = sbmodels.AuthEventLoginFailure
+		return ev
+	}
+
+	switch r.Type {
+	case utUserProcess:
+		p.lineUsers[r.Line] = r.User
+		ev := utmpEvent(r)
+		ev.Action, ev.Success = sbmodels.AuthEventLoginSuccess, true
+		return ev
+
+	case utDeadProcess:
+		user, ok := p.lineUsers[r.Line]
+		if !ok && r.User == "" {
+			return nil
+		}
+		delete(p.lineUsers, r.Line)
+
+		ev := utmpEvent(r)
+		ev.Action, ev.Success = sbmodels.AuthEventLogout, true
+		if ev.User == "" {
+			ev.User = user
+		}
+		return ev
+
+	case utBootTime:
+		// Every session ends at reboot; their DEAD_PROCESS records may never be written.
+		p.lineUsers = make(map[string]string)
+	}
+
+	return nil
+}
+
+// utmpEvent fills the fields common to all wtmp/btmp events.
+func utmpEvent(r utmpRecord) *sbmodels.AuthEvent {
+	ev := &sbmodels.AuthEvent{
+		User:      r.User,
+		TTY:       r.Line,
+		PID:       r.PID,
+		Service:   "login",
+		Timestamp: r.Time,
+	}
+
+	switch {
+	case r.Addr != nil && !r.Addr.IsUnspecified():
+		ev.SourceIP = r.Addr.String()
+		if r.Host != ev.SourceIP {
+			ev.Host = r.Host
+		}
+	case net.ParseIP(r.Host) != nil:
+		ev.SourceIP = r.Host
+	default:
+		ev.Host = r.Host
+	}
+	return ev
+}
+
+func (p *AuthPlugin) saveState() {
+	if !p.dirty {
+		return
+	}
+
+	if err := saveState(p.opts.StatePath, p.state); err != nil {
+		logger.Warnf("auth plugin: save state failed: %v", err)
+		return
+	}
+	p.dirty = false
+}
+
+func (p *AuthPlugin) shutdown() {
+	p.saveState()
+	for _, s := range p.sources {
+		s.follower.close()
+	}
+}
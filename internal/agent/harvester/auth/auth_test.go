@@ -0,0 +1,244 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package auth
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+func encodeUtmp(typ int16, pid int32, line, user, host string, addr []byte, sec int32) []byte {
+	b := make([]byte, utmpSize)
+	le := binary.LittleEndian
+	le.PutUint16(b[0:], uint16(typ))
+	le.PutUint32(b[4:], uint32(pid))
+	copy(b[8:40], line)
+	copy(b[44:76], user)
+	copy(b[76:332], host)
+	le.PutUint32(b[340:], uint32(sec))
+	copy(b[348:364], addr)
+	return b
+}
+
+func TestDecodeUtmp(t *testing.T) {
+	b := encodeUtmp(utUserProcess, 4242, "pts/0", "alice", "10.0.0.5", []byte{10, 0, 0, 5}, 1700000000)
+
+	r, err := decodeUtmp(b)
+	if err != nil {
+		t.Fatalf("decodeUtmp: %v", err)
+	}
+	if r.Type != utUserProcess || r.PID != 4242 || r.Line != "pts/0" || r.User != "alice" || r.Host != "10.0.0.5" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+	if r.Addr.String() != "10.0.0.5" {
+		t.Fatalf("addr = %v, want 10.0.0.5", r.Addr)
+	}
+	if r.Time.Unix() != 1700000000 {
+		t.Fatalf("time = %v", r.Time)
+	}
+
+	if _, err := decodeUtmp(b[:100]); err == nil {
+		t.Fatalf("expected error for short record")
+	}
+}
+
+func TestParseAuthLine(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		line       string
+		action     string
+		success    bool
+		user       string
+		targetUser string
+		sourceIP   string
+		method     string
+	}{
+		{"Mar 10 11:59:00 web1 sshd[100]: Accepted publickey for alice from 10.0.0.5 port 51234 ssh2: ED25519 SHA256:abc",
+			sbmodels.AuthEventLoginSuccess, true, "alice", "", "10.0.0.5", "publickey"},
+		{"Mar 10 11:59:01 web1 sshd[101]: Failed password for invalid user admin from 203.0.113.9 port 40000 ssh2",
+			sbmodels.AuthEventLoginFailure, false, "admin", "", "203.0.113.9", "password"},
+		{"Mar 10 11:59:02 web1 sshd[102]: Failed password for root from 203.0.113.9 port 40001 ssh2",
+			sbmodels.AuthEventLoginFailure, false, "root", "", "203.0.113.9", "password"},
+		{"Mar 10 11:59:03 web1 sshd[103]: Invalid user oracle from 203.0.113.9 port 40002",
+			sbmodels.AuthEventLoginFailure, false, "oracle", "", "203.0.113.9", "invalid_user"},
+		{"Mar 10 11:59:04 web1 sshd[100]: Disconnected from user alice 10.0.0.5 port 51234",
+			sbmodels.AuthEventLogout, true, "alice", "", "10.0.0.5", ""},
+		{"Mar 10 11:59:05 web1 sudo:    alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/usr/bin/id",
+			sbmodels.AuthEventPrivilegeEscalation, true, "alice", "root", "", "sudo"},
+		{"Mar 10 11:59:06 web1 sudo:      bob : 3 incorrect password attempts ; TTY=pts/1 ; PWD=/home/bob ; USER=root ; COMMAND=/bin/sh",
+			sbmodels.AuthEventPrivilegeEscalation, false, "bob", "root", "", "sudo"},
+		{"2025-03-10T11:59:07.123456+00:00 web1 su[200]: pam_unix(su-l:session): session opened for user root(uid=0) by alice(uid=1000)",
+			sbmodels.AuthEventPrivilegeEscalation, true, "alice", "root", "", "su"},
+		{"Mar 10 11:59:08 web1 su[201]: pam_unix(su:auth): authentication failure; logname=bob uid=1001 euid=0 tty=pts/1 ruser=bob rhost=  user=root",
+			sbmodels.AuthEventPrivilegeEscalation, false, "bob", "root", "", "su"},
+	}
+
+	for _, c := range cases {
+		ev := parseAuthLine(c.line, now)
+		if ev == nil {
+			t.Fatalf("no event for %q", c.line)
+		}
+		if ev.Action != c.action || ev.Success != c.success || ev.User != c.user ||
+			ev.TargetUser != c.targetUser || ev.SourceIP != c.sourceIP || ev.Method != c.method {
+			t.Fatalf("line %q: got %+v", c.line, ev)
+		}
+		if ev.Timestamp.Month() != time.March || ev.Timestamp.Day() != 10 || ev.Timestamp.Year() != 2025 {
+			t.Fatalf("line %q: timestamp %v", c.line, ev.Timestamp)
+		}
+	}
+
+	for _, line := range []string{
+		"Mar 10 11:59:09 web1 CRON[300]: pam_unix(cron:session): session opened for user root by (uid=0)",
+		"Mar 10 11:59:10 web1 sshd[400]: Received disconnect from 10.0.0.5 port 51234:11: disconnected by user",
+		"not a syslog line",
+	} {
+		if ev := parseAuthLine(line, now); ev != nil {
+			t.Fatalf("unexpected event for %q: %+v", line, ev)
+		}
+	}
+}
+
+func TestParseSyslogTime_yearRollover(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 5, 0, 0, time.UTC)
+	ts := parseSyslogTime("Dec 31 23:59:00", now)
+	if ts.Year() != 2024 {
+		t.Fatalf("year = %d, want 2024", ts.Year())
+	}
+}
+
+func collect(t *testing.T, eventC plugin.EventC, n int) []*sbmodels.AuthEvent {
+	t.Helper()
+	var out []*sbmodels.AuthEvent
+	timeout := time.After(5 * time.Second)
+	for len(out) < n {
+		select {
+		case ev := <-eventC:
+			out = append(out, ev.Data.(*sbmodels.AuthEvent))
+		case <-timeout:
+			t.Fatalf("got %d events, want %d", len(out), n)
+		}
+	}
+	return out
+}
+
+func appendFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestAuthPlugin_run(t *testing.T) {
+	dir := t.TempDir()
+	wtmp := filepath.Join(dir, "wtmp")
+	btmp := filepath.Join(dir, "btmp")
+	authLog := filepath.Join(dir, "auth.log")
+	state := filepath.Join(dir, "state.json")
+
+	// Existing content is skipped with startPosition "end".
+	appendFile(t, wtmp, encodeUtmp(utUserProcess, 1, "pts/9", "old", "", nil, 1))
+	appendFile(t, authLog, []byte("Mar 10 11:00:00 web1 sshd[1]: Accepted password for old from 10.0.0.1 port 1 ssh2\n"))
+
+	opts := map[string]any{
+		"wtmpPath":     wtmp,
+		"btmpPath":     btmp,
+		"authLogPaths": []any{authLog},
+		"interval":     "20ms",
+		"statePath":    state,
+	}
+
+	p, err := newAuthPlugin(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("newAuthPlugin: %v", err)
+	}
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	appendFile(t, wtmp, encodeUtmp(utUserProcess, 10, "pts/0", "alice", "10.0.0.5", []byte{10, 0, 0, 5}, 1700000000))
+	appendFile(t, wtmp, encodeUtmp(utDeadProcess, 10, "pts/0", "", "", nil, 1700000100))
+	appendFile(t, btmp, encodeUtmp(utLoginProcess, 11, "ssh:notty", "mallory", "203.0.113.9", []byte{203, 0, 113, 9}, 1700000200))
+	appendFile(t, authLog, []byte("Mar 10 11:59:05 web1 sudo:    alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/usr/bin/id\n"))
+
+	got := map[string]*sbmodels.AuthEvent{}
+	for _, ev := range collect(t, eventC, 4) {
+		got[ev.Action] = ev
+	}
+
+	if ev := got[sbmodels.AuthEventLoginSuccess]; ev == nil || ev.User != "alice" || ev.SourceIP != "10.0.0.5" || ev.Source != wtmp {
+		t.Fatalf("login_success = %+v", ev)
+	}
+	if ev := got[sbmodels.AuthEventLogout]; ev == nil || ev.User != "alice" || ev.TTY != "pts/0" {
+		t.Fatalf("logout = %+v", ev)
+	}
+	if ev := got[sbmodels.AuthEventLoginFailure]; ev == nil || ev.User != "mallory" || ev.Source != btmp {
+		t.Fatalf("login_failure = %+v", ev)
+	}
+	if ev := got[sbmodels.AuthEventPrivilegeEscalation]; ev == nil || ev.User != "alice" || ev.Command != "/usr/bin/id" {
+		t.Fatalf("privilege_escalation = %+v", ev)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// A restarted plugin resumes from the saved positions: only lines written since are reported,
+	// including a rotated log whose new file starts from the beginning.
+	appendFile(t, authLog, []byte("Mar 10 12:00:00 web1 sshd[2]: Failed password for root from 203.0.113.9 port 2 ssh2\n"))
+
+	p, err = newAuthPlugin(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("newAuthPlugin: %v", err)
+	}
+	defer p.Close()
+
+	eventC, err = p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	ev := collect(t, eventC, 1)[0]
+	if ev.Action != sbmodels.AuthEventLoginFailure || ev.User != "root" || ev.Source != authLog {
+		t.Fatalf("resumed event = %+v", ev)
+	}
+
+	if err := os.Rename(authLog, authLog+".1"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	appendFile(t, authLog+".1", []byte("Mar 10 12:00:01 web1 sshd[3]: Accepted password for bob from 10.0.0.7 port 3 ssh2\n"))
+	appendFile(t, authLog, []byte("Mar 10 12:00:02 web1 sshd[4]: Accepted password for carol from 10.0.0.8 port 4 ssh2\n"))
+
+	evs := collect(t, eventC, 2)
+	if evs[0].User != "bob" || evs[1].User != "carol" {
+		t.Fatalf("rotation events = %+v, %+v", evs[0], evs[1])
+	}
+}
@@ -0,0 +1,170 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package auth
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+var (
+	// "Mar  3 10:00:00 host sshd[123]: msg" or "2025-03-03T10:00:00.123+08:00 host sshd[123]: msg".
+	syslogLine = regexp.MustCompile(`^(\w{3}\s+\d{1,2} \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}T\S+) \S+ ([^\[:\s]+)(?:\[(\d+)\])?: (.*)$`)
+
+	sshdAccepted     = regexp.MustCompile(`^Accepted (\S+) for (\S+) from (\S+) port (\d+)`)
+	sshdFailed       = regexp.MustCompile(`^Failed (\S+) for (?:invalid user )?(\S+) from (\S+) port (\d+)`)
+	sshdInvalidUser  = regexp.MustCompile(`^Invalid user (\S*) from (\S+)(?: port (\d+))?`)
+	sshdDisconnected = regexp.MustCompile(`^Disconnected from user (\S+) (\S+) port (\d+)`)
+
+	// "alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/bin/bash"; a failure inserts
+	// its reason before TTY, e.g. "alice : 3 incorrect password attempts ; TTY=...".
+	sudoCommand = regexp.MustCompile(`^\s*(\S+) : (?:(.*?) ; )?TTY=(\S+) ; PWD=.*? ; USER=(\S+) ; (?:.*? ; )?COMMAND=(.*)$`)
+
+	suSessionOpened = regexp.MustCompile(`^pam_unix\(su(?:-l)?:session\): session opened for user ([^\s(]+)(?:\(uid=\d+\))? by ([^\s(]*)`)
+	suAuthFailure   = regexp.MustCompile(`^pam_unix\(su(?:-l)?:auth\): authentication failure;(.*)$`)
+)
+
+// parseAuthLine decodes one auth log line into an event, or returns nil when the line is
+// not a login, logout or privilege change. now resolves the year of traditional syslog
+// timestamps, which do not carry one.
+func parseAuthLine(line string, now time.Time) *sbmodels.AuthEvent {
+	m := syslogLine.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+
+	ev := &sbmodels.AuthEvent{
+		Service:   m[2],
+		Message:   line,
+		Timestamp: parseSyslogTime(m[1], now),
+	}
+	if pid, err := strconv.ParseInt(m[3], 10, 32); err == nil {
+		ev.PID = int32(pid)
+	}
+
+	msg := m[4]
+	switch ev.Service {
+	case "sshd":
+		return parseSSHD(ev, msg)
+	case "sudo":
+		return parseSudo(ev, msg)
+	case "su":
+		return parseSu(ev, msg)
+	}
+	return nil
+}
+
+func parseSSHD(ev *sbmodels.AuthEvent, msg string) *sbmodels.AuthEvent {
+	if m := sshdAccepted.FindStringSubmatch(msg); m != nil {
+		ev.Action, ev.Success = sbmodels.AuthEventLoginSuccess, true
+		ev.Method, ev.User, ev.SourceIP, ev.SourcePort = m[1], m[2], m[3], atoi(m[4])
+		return ev
+	}
+	if m := sshdFailed.FindStringSubmatch(msg); m != nil {
+		ev.Action = sbmodels.AuthEventLoginFailure
+		ev.Method, ev.User, ev.SourceIP, ev.SourcePort = m[1], m[2], m[3], atoi(m[4])
+		return ev
+	}
+	if m := sshdInvalidUser.FindStringSubmatch(msg); m != nil {
+		ev.Action = sbmodels.AuthEventLoginFailure
+		ev.Method, ev.User, ev.SourceIP, ev.SourcePort = "invalid_user", m[1], m[2], atoi(m[3])
+		return ev
+	}
+	if m := sshdDisconnected.FindStringSubmatch(msg); m != nil {
+		ev.Action, ev.Success = sbmodels.AuthEventLogout, true
+		ev.User, ev.SourceIP, ev.SourcePort = m[1], m[2], atoi(m[3])
+		return ev
+	}
+	return nil
+}
+
+func parseSudo(ev *sbmodels.AuthEvent, msg string) *sbmodels.AuthEvent {
+	m := sudoCommand.FindStringSubmatch(msg)
+	if m == nil {
+		return nil
+	}
+
+	ev.Action = sbmodels.AuthEventPrivilegeEscalation
+	ev.Method = "sudo"
+	ev.User, ev.TargetUser, ev.Command = m[1], m[4], m[5]
+	ev.Success = m[2] == ""
+	if m[3] != "unknown" {
+		ev.TTY = m[3]
+	}
+	return ev
+}
+
+func parseSu(ev *sbmodels.AuthEvent, msg string) *sbmodels.AuthEvent {
+	if m := suSessionOpened.FindStringSubmatch(msg); m != nil {
+		ev.Action, ev.Success = sbmodels.AuthEventPrivilegeEscalation, true
+		ev.Method, ev.TargetUser, ev.User = "su", m[1], m[2]
+		return ev
+	}
+	if m := suAuthFailure.FindStringSubmatch(msg); m != nil {
+		kv := parseKeyValues(m[1])
+		ev.Action = sbmodels.AuthEventPrivilegeEscalation
+		ev.Method, ev.TargetUser, ev.TTY = "su", kv["user"], kv["tty"]
+		ev.User = kv["ruser"]
+		if ev.User == "" {
+			ev.User = kv["logname"]
+		}
+		return ev
+	}
+	return nil
+}
+
+// parseKeyValues parses "logname=alice uid=1000 euid=0 tty=pts/0 ruser=alice rhost=  user=root".
+func parseKeyValues(s string) map[string]string {
+	out := make(map[string]string)
+	for _, field := range strings.Fields(s) {
+		if k, v, ok := strings.Cut(field, "="); ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// parseSyslogTime parses an RFC 3339 or traditional syslog timestamp. Traditional timestamps
+// are placed in the current year, or the previous one when that would be in the future.
+func parseSyslogTime(s string, now time.Time) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t
+	}
+
+	t, err := time.ParseInLocation("Jan _2 15:04:05", strings.Join(strings.Fields(s), " "), now.Location())
+	if err != nil {
+		t, err = time.ParseInLocation("Jan 2 15:04:05", strings.Join(strings.Fields(s), " "), now.Location())
+		if err != nil {
+			return now
+		}
+	}
+
+	t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
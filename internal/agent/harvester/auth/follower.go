@@ -0,0 +1,244 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	// maxReadBytes bounds how much of one file is consumed per poll.
+	maxReadBytes = 1 << 20
+	// maxLineBytes bounds a single auth log line; longer lines are dropped.
+	maxLineBytes = 64 * 1024
+)
+
+// chunk is one complete unit read from a followed file: a line without its newline,
+// or one fixed-size record.
+type chunk struct {
+	data  []byte
+	inode uint64
+	end   int64 // offset just past the chunk
+}
+
+// position is the persisted read position of one followed path.
+type position struct {
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+// follower reads what is appended to one path. It keeps the file open so that after
+// rotation the old file is drained before the new one is read, and restarts from the
+// beginning when the file is truncated. recordSize is 0 for line-oriented files.
+type follower struct {
+	path       string
+	recordSize int
+
+	f      *os.File
+	inode  uint64
+	offset int64
+}
+
+func newFollower(path string, recordSize int) *follower {
+	return &follower{path: path, recordSize: recordSize}
+}
+
+// start opens the file at the saved position, or at the beginning or end of the file
+// when there is no usable saved position. A missing file is not an error; it is picked
+// up by a later poll.
+func (fw *follower) start(saved position, hasSaved bool, fromEnd bool) error {
+	f, inode, size, err := openFile(fw.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fw.f, fw.inode = f, inode
+	switch {
+	case hasSaved && saved.Inode == inode && saved.Offset <= size:
+		fw.offset = saved.Offset
+	case hasSaved:
+		// Rotated or truncated while the agent was down.
+		fw.offset = 0
+	case fromEnd:
+		fw.offset = size
+	}
+
+	fw.offset = fw.align(fw.offset)
+	return nil
+}
+
+// poll returns the chunks appended since the previous poll. On rotation the remainder of
+// the old file is returned before the new file is opened.
+func (fw *follower) poll() ([]chunk, error) {
+	if fw.f == nil {
+		f, inode, _, err := openFile(fw.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		fw.f, fw.inode, fw.offset = f, inode, 0
+	}
+
+	fi, err := os.Stat(fw.path)
+	rotated := err == nil && inodeOf(fi) != fw.inode
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if cur, err := fw.f.Stat(); err == nil && cur.Size() < fw.offset {
+		fw.offset = 0
+	}
+
+	chunks, err := fw.read()
+	if err != nil {
+		return chunks, err
+	}
+
+	if rotated && len(chunks) == 0 {
+		_ = fw.f.Close()
+		fw.f = nil
+		return fw.poll()
+	}
+	return chunks, nil
+}
+
+// read returns the complete chunks between the current offset and EOF.
+func (fw *follower) read() ([]chunk, error) {
+	buf := make([]byte, maxReadBytes)
+	n, err := fw.f.ReadAt(buf, fw.offset)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read %s: %w", fw.path, err)
+	}
+	buf = buf[:n]
+
+	var chunks []chunk
+	if fw.recordSize > 0 {
+		for len(buf) >= fw.recordSize {
+			fw.offset += int64(fw.recordSize)
+			chunks = append(chunks, chunk{data: buf[:fw.recordSize], inode: fw.inode, end: fw.offset})
+			buf = buf[fw.recordSize:]
+		}
+		return chunks, nil
+	}
+
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		fw.offset += int64(i + 1)
+		if i <= maxLineBytes {
+			chunks = append(chunks, chunk{data: bytes.TrimSuffix(buf[:i], []byte{'\r'}), inode: fw.inode, end: fw.offset})
+		}
+		buf = buf[i+1:]
+	}
+
+	// A partial line that already fills the read buffer can never complete; skip it.
+	if len(buf) == maxReadBytes {
+		fw.offset += int64(len(buf))
+	}
+	return chunks, nil
+}
+
+// align rounds offset down to a record boundary for record-oriented files.
+func (fw *follower) align(offset int64) int64 {
+	if fw.recordSize > 0 {
+		return offset - offset%int64(fw.recordSize)
+	}
+	return offset
+}
+
+func (fw *follower) close() {
+	if fw.f != nil {
+		_ = fw.f.Close()
+		fw.f = nil
+	}
+}
+
+func openFile(path string) (*os.File, uint64, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, 0, err
+	}
+
+	return f, inodeOf(fi), fi.Size(), nil
+}
+
+func inodeOf(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
+
+// loadState reads the saved positions keyed by path. A missing file yields an empty map.
+func loadState(path string) (map[string]position, error) {
+	state := make(map[string]position)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read auth state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("decode auth state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// saveState writes the positions atomically (temporary file and rename).
+func saveState(path string, state map[string]position) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode auth state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create auth state dir: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write auth state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename auth state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+)
+
+const (
+	startPositionBeginning = "beginning"
+	startPositionEnd       = "end"
+
+	defaultWtmpPath  = "/var/log/wtmp"
+	defaultBtmpPath  = "/var/log/btmp"
+	defaultInterval  = 5 * time.Second
+	defaultStatePath = "./data/auth_state.json"
+)
+
+// defaultAuthLogPaths covers Debian/Ubuntu and RHEL-family layouts; missing files are skipped.
+var defaultAuthLogPaths = []string{"/var/log/auth.log", "/var/log/secure"}
+
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
+
+// Options is the option for the auth plugin.
+type Options struct {
+	// WtmpPath is the login accounting file of successful logins and logouts. "-" disables it.
+	WtmpPath string `yaml:"wtmpPath" json:"wtmpPath"`
+	// BtmpPath is the login accounting file of failed logins. "-" disables it.
+	BtmpPath string `yaml:"btmpPath" json:"btmpPath"`
+	// AuthLogPaths are syslog files carrying sshd, sudo and su messages.
+	AuthLogPaths []string `yaml:"authLogPaths" json:"authLogPaths"`
+	Interval     Duration `yaml:"interval" json:"interval"`
+	// StartPosition is where files without saved state start: "end" (default) or "beginning".
+	StartPosition string `yaml:"startPosition" json:"startPosition"`
+	StatePath     string `yaml:"statePath" json:"statePath"`
+}
+
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
+func OptionsFromAny(opts any) (Options, error) {
+	var out Options
+	if o, ok := opts.(Options); ok {
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
+	}
+
+	switch out.StartPosition {
+	case "":
+		out.StartPosition = startPositionEnd
+	case startPositionBeginning, startPositionEnd:
+	default:
+		return Options{}, fmt.Errorf("auth options: invalid startPosition %q", out.StartPosition)
+	}
+
+	if out.WtmpPath == "" {
+		out.WtmpPath = defaultWtmpPath
+	}
+	if out.BtmpPath == "" {
+		out.BtmpPath = defaultBtmpPath
+	}
+	if out.AuthLogPaths == nil {
+		out.AuthLogPaths = append([]string(nil), defaultAuthLogPaths...)
+	}
+
+	out.Interval = Duration(out.Interval.OrDefault(defaultInterval))
+	if out.StatePath == "" {
+		out.StatePath = defaultStatePath
+	}
+
+	return out, nil
+}
+
+// disabled reports whether a path option was turned off.
+func disabled(path string) bool {
+	return path == "-"
+}
@@ -0,0 +1,91 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package auth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// utmpSize is the size of struct utmp on Linux (glibc, all 64-bit ABIs with 32-bit time fields).
+const utmpSize = 384
+
+// ut_type values (utmp.h).
+const (
+	utBootTime     = 2
+	utUserProcess  = 7
+	utDeadProcess  = 8
+	utLoginProcess = 6
+)
+
+// utmpRecord is a decoded struct utmp.
+type utmpRecord struct {
+	Type int16
+	PID  int32
+	Line string
+	User string
+	Host string
+	Addr net.IP
+	Time time.Time
+}
+
+// decodeUtmp decodes one struct utmp:
+//
+//	short ut_type; (2 bytes padding) pid_t ut_pid; char ut_line[32]; char ut_id[4];
+//	char ut_user[32]; char ut_host[256]; struct exit_status ut_exit; int32 ut_session;
+//	struct { int32 tv_sec; int32 tv_usec; } ut_tv; int32 ut_addr_v6[4]; char reserved[20];
+func decodeUtmp(b []byte) (utmpRecord, error) {
+	if len(b) != utmpSize {
+		return utmpRecord{}, fmt.Errorf("utmp record is %d bytes, want %d", len(b), utmpSize)
+	}
+
+	le := binary.LittleEndian
+	r := utmpRecord{
+		Type: int16(le.Uint16(b[0:])),
+		PID:  int32(le.Uint32(b[4:])),
+		Line: cString(b[8:40]),
+		User: cString(b[44:76]),
+		Host: cString(b[76:332]),
+	}
+
+	sec := int64(int32(le.Uint32(b[340:])))
+	usec := int64(int32(le.Uint32(b[344:])))
+	r.Time = time.Unix(sec, usec*int64(time.Microsecond))
+
+	// ut_addr_v6 holds an IPv4 address in its first word when the other three are zero,
+	// always in network byte order.
+	addr := b[348:364]
+	switch {
+	case bytes.Equal(addr, make([]byte, 16)):
+	case bytes.Equal(addr[4:], make([]byte, 12)):
+		r.Addr = net.IP(append([]byte(nil), addr[:4]...))
+	default:
+		r.Addr = net.IP(append([]byte(nil), addr...))
+	}
+
+	return r, nil
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
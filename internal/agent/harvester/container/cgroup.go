@@ -0,0 +1,132 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package container
+
+import (
+	"regexp"
+	"strings"
+
+	"os-artificer/saber/internal/agent/harvester/procfs"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+var (
+	// containerIDPattern matches the 64-hex-digit IDs used by docker, containerd and CRI-O.
+	containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+	// podUIDPattern matches "pod<uid>" in cgroupfs ("pod1b2c-...") and systemd
+	// ("pod1b2c_...") kubepods layouts.
+	podUIDPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+)
+
+// runtimeMarkers map cgroup path fragments to the runtime that created the cgroup.
+var runtimeMarkers = []struct {
+	marker  string
+	runtime string
+}{
+	{"docker-", sbmodels.ContainerRuntimeDocker},
+	{"/docker/", sbmodels.ContainerRuntimeDocker},
+	{"cri-containerd-", sbmodels.ContainerRuntimeContainerd},
+	{"/containerd/", sbmodels.ContainerRuntimeContainerd},
+	{"crio-", sbmodels.ContainerRuntimeCRIO},
+	{"libpod-", sbmodels.ContainerRuntimePodman},
+}
+
+// selectCgroup returns the cgroup path that identifies the process: the first path that
+// carries a container ID or a pod UID, otherwise the unified hierarchy path, otherwise
+// the first path listed.
+func selectCgroup(entries []procfs.CgroupEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	for _, e := range entries {
+		if containerIDPattern.MatchString(e.Path) || podUIDPattern.MatchString(e.Path) {
+			return e.Path
+		}
+	}
+	for _, e := range entries {
+		if e.HierarchyID == 0 {
+			return e.Path
+		}
+	}
+	return entries[0].Path
+}
+
+// parseCgroupPath extracts the container ID, runtime and pod UID from a cgroup path such as
+//
+//	/kubepods/burstable/pod<uid>/<id>
+//	/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod<uid>.slice/cri-containerd-<id>.scope
+//	/system.slice/docker-<id>.scope
+//
+// It returns nil when the path belongs to neither a container nor a pod.
+func parseCgroupPath(path string) *sbmodels.ContainerInfo {
+	info := &sbmodels.ContainerInfo{CgroupPath: path}
+
+	if ids := containerIDPattern.FindAllString(path, -1); len(ids) > 0 {
+		info.ID = ids[len(ids)-1]
+	}
+	if m := podUIDPattern.FindStringSubmatch(path); m != nil {
+		info.PodUID = strings.ReplaceAll(m[1], "_", "-")
+	}
+	if info.ID == "" && info.PodUID == "" {
+		return nil
+	}
+
+	for _, rm := range runtimeMarkers {
+		if strings.Contains(path, rm.marker) {
+			info.Runtime = rm.runtime
+			break
+		}
+	}
+	return info
+}
+
+// parseMounts looks for the bind mounts a runtime sets up for /etc/hostname, /etc/hosts
+// and /etc/resolv.conf, whose source paths carry the container ID (docker) or pod UID
+// (kubelet). It is used for processes in a private cgroup namespace, whose cgroup path
+// reads "/".
+func parseMounts(mounts []procfs.MountInfo) *sbmodels.ContainerInfo {
+	info := &sbmodels.ContainerInfo{}
+
+	for _, m := range mounts {
+		switch m.MountPoint {
+		case "/etc/hostname", "/etc/hosts", "/etc/resolv.conf":
+		default:
+			continue
+		}
+
+		if strings.Contains(m.Root, "/containers/") {
+			if id := containerIDPattern.FindString(m.Root); id != "" && info.ID == "" {
+				info.ID = id
+				if strings.Contains(m.Root, "/docker/") {
+					info.Runtime = sbmodels.ContainerRuntimeDocker
+				}
+			}
+		}
+		if i := strings.Index(m.Root, "/pods/"); i >= 0 && info.PodUID == "" {
+			rest := m.Root[i+len("/pods/"):]
+			if uid, _, _ := strings.Cut(rest, "/"); len(uid) == 36 {
+				info.PodUID = uid
+			}
+		}
+	}
+
+	if info.ID == "" && info.PodUID == "" {
+		return nil
+	}
+	return info
+}
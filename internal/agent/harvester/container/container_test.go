@@ -0,0 +1,198 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"os-artificer/saber/internal/agent/harvester/procfs"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const (
+	dockerID = "3f4e1b2a9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f"
+	criID    = "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	podUID   = "6f1c3d2e-9a8b-4c7d-8e6f-5a4b3c2d1e0f"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestParseCgroupPath(t *testing.T) {
+	cases := []struct {
+		path    string
+		id      string
+		runtime string
+		podUID  string
+	}{
+		{"/system.slice/docker-" + dockerID + ".scope", dockerID, sbmodels.ContainerRuntimeDocker, ""},
+		{"/docker/" + dockerID, dockerID, sbmodels.ContainerRuntimeDocker, ""},
+		{"/kubepods/burstable/pod" + podUID + "/" + criID, criID, "", podUID},
+		{"/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + strings.ReplaceAll(podUID, "-", "_") +
+			".slice/cri-containerd-" + criID + ".scope", criID, sbmodels.ContainerRuntimeContainerd, podUID},
+		{"/kubepods.slice/kubepods-pod" + strings.ReplaceAll(podUID, "-", "_") + ".slice/crio-" + criID + ".scope",
+			criID, sbmodels.ContainerRuntimeCRIO, podUID},
+		{"/machine.slice/libpod-" + criID + ".scope/container", criID, sbmodels.ContainerRuntimePodman, ""},
+	}
+
+	for _, c := range cases {
+		info := parseCgroupPath(c.path)
+		if info == nil || info.ID != c.id || info.Runtime != c.runtime || info.PodUID != c.podUID || info.CgroupPath != c.path {
+			t.Fatalf("parseCgroupPath(%q) = %+v", c.path, info)
+		}
+	}
+
+	for _, path := range []string{"/", "/user.slice/user-1000.slice/session-2.scope", "/system.slice/sshd.service"} {
+		if info := parseCgroupPath(path); info != nil {
+			t.Fatalf("parseCgroupPath(%q) = %+v, want nil", path, info)
+		}
+	}
+}
+
+func TestSelectCgroup(t *testing.T) {
+	entries := []procfs.CgroupEntry{
+		{HierarchyID: 3, Controllers: []string{"cpu"}, Path: "/"},
+		{HierarchyID: 1, Controllers: []string{"name=systemd"}, Path: "/docker/" + dockerID},
+		{HierarchyID: 0, Path: "/"},
+	}
+	if got := selectCgroup(entries); got != "/docker/"+dockerID {
+		t.Fatalf("selectCgroup = %q", got)
+	}
+	if got := selectCgroup(entries[2:]); got != "/" {
+		t.Fatalf("selectCgroup(v2) = %q", got)
+	}
+}
+
+func newTestResolver(t *testing.T) (r *Resolver, procRoot, hostRoot string) {
+	root := t.TempDir()
+	procRoot, hostRoot = filepath.Join(root, "proc"), filepath.Join(root, "host")
+
+	opts, err := Options{HostRoot: hostRoot}.OrDefault()
+	if err != nil {
+		t.Fatalf("OrDefault: %v", err)
+	}
+	return NewResolver(opts, procRoot), procRoot, hostRoot
+}
+
+func TestResolver_docker(t *testing.T) {
+	r, procRoot, hostRoot := newTestResolver(t)
+
+	writeFile(t, filepath.Join(procRoot, "100/cgroup"), "0::/system.slice/docker-"+dockerID+".scope\n")
+	writeFile(t, filepath.Join(hostRoot, dockerContainersDir, dockerID, "config.v2.json"), `{
+		"Name": "/k8s_web_nginx-7d9_default_`+podUID+`_0",
+		"Config": {"Image": "nginx:1.25", "Labels": {
+			"io.kubernetes.pod.name": "nginx-7d9",
+			"io.kubernetes.pod.namespace": "default",
+			"io.kubernetes.pod.uid": "`+podUID+`",
+			"io.kubernetes.container.name": "web"}}}`)
+
+	info := r.Resolve(100)
+	if info == nil || info.ID != dockerID || info.Runtime != sbmodels.ContainerRuntimeDocker || info.Image != "nginx:1.25" ||
+		info.PodName != "nginx-7d9" || info.PodNamespace != "default" || info.PodUID != podUID || info.ContainerName != "web" {
+		t.Fatalf("Resolve = %+v", info)
+	}
+
+	// Served from the cache; callers get their own copy.
+	info.PodName = "changed"
+	if again := r.Resolve(100); again.PodName != "nginx-7d9" {
+		t.Fatalf("cached Resolve = %+v", again)
+	}
+}
+
+func TestResolver_containerdAndLogs(t *testing.T) {
+	r, procRoot, hostRoot := newTestResolver(t)
+
+	cgroup := "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + strings.ReplaceAll(podUID, "-", "_") +
+		".slice/cri-containerd-" + criID + ".scope"
+	writeFile(t, filepath.Join(procRoot, "200/cgroup"), "0::"+cgroup+"\n")
+	writeFile(t, filepath.Join(hostRoot, containerdTaskDir, "k8s.io", criID, "config.json"), `{"annotations": {
+		"io.kubernetes.cri.sandbox-name": "api-5f6",
+		"io.kubernetes.cri.sandbox-namespace": "prod",
+		"io.kubernetes.cri.container-name": "api",
+		"io.kubernetes.cri.image-name": "registry/api:2"}}`)
+
+	info := r.Resolve(200)
+	if info == nil || info.ID != criID || info.Runtime != sbmodels.ContainerRuntimeContainerd || info.PodName != "api-5f6" ||
+		info.PodNamespace != "prod" || info.ContainerName != "api" || info.Image != "registry/api:2" || info.CgroupPath != cgroup {
+		t.Fatalf("Resolve = %+v", info)
+	}
+
+	// Without runtime state, the kubelet log symlink names the pod.
+	r2, procRoot2, hostRoot2 := newTestResolver(t)
+	writeFile(t, filepath.Join(procRoot2, "300/cgroup"), "0::"+cgroup+"\n")
+	writeFile(t, filepath.Join(hostRoot2, kubeletContainerLogDir, "api-5f6_prod_api-"+criID+".log"), "")
+	info = r2.Resolve(300)
+	if info == nil || info.PodName != "api-5f6" || info.PodNamespace != "prod" || info.ContainerName != "api" {
+		t.Fatalf("Resolve from logs = %+v", info)
+	}
+
+	if info := r2.ResolvePath("/var/log/pods/prod_api-5f6_" + podUID + "/api/0.log"); info == nil ||
+		info.PodName != "api-5f6" || info.PodNamespace != "prod" || info.PodUID != podUID || info.ContainerName != "api" {
+		t.Fatalf("ResolvePath(pod log) = %+v", info)
+	}
+	if info := r2.ResolvePath("/var/log/containers/api-5f6_prod_api-" + criID + ".log"); info == nil || info.ID != criID || info.PodName != "api-5f6" {
+		t.Fatalf("ResolvePath(container log) = %+v", info)
+	}
+	if info := r2.ResolvePath("/var/log/syslog"); info != nil {
+		t.Fatalf("ResolvePath(host file) = %+v", info)
+	}
+}
+
+func TestResolver_hostProcessAndMountFallback(t *testing.T) {
+	r, procRoot, _ := newTestResolver(t)
+
+	for pid, ns := range map[string]string{"1": "mnt:[4026531841]", "10": "mnt:[4026531841]", "20": "mnt:[4026532600]"} {
+		writeFile(t, filepath.Join(procRoot, pid, "cgroup"), "0::/\n")
+		if err := os.MkdirAll(filepath.Join(procRoot, pid, "ns"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(ns, filepath.Join(procRoot, pid, "ns", "mnt")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(procRoot, "20/mountinfo"),
+		"22 1 0:50 / / rw - overlay overlay rw\n"+
+			"30 22 8:1 /var/lib/docker/containers/"+dockerID+"/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n")
+
+	if info := r.Resolve(10); info != nil {
+		t.Fatalf("Resolve(host process) = %+v", info)
+	}
+	if info := r.Resolve(20); info == nil || info.ID != dockerID || info.Runtime != sbmodels.ContainerRuntimeDocker {
+		t.Fatalf("Resolve(cgroupns process) = %+v", info)
+	}
+	if info := r.Resolve(999); info != nil {
+		t.Fatalf("Resolve(exited) = %+v", info)
+	}
+
+	var disabled *Resolver
+	if disabled.Resolve(20) != nil || disabled.ResolvePath("/var/log/pods/a_b_c/d/0.log") != nil {
+		t.Fatal("nil resolver should resolve nothing")
+	}
+	if NewResolver(Options{Enabled: new(bool)}, procRoot) != nil {
+		t.Fatal("disabled options should yield a nil resolver")
+	}
+}
@@ -0,0 +1,176 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package container
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// Runtime state locations, relative to the host root.
+const (
+	dockerContainersDir    = "/var/lib/docker/containers"
+	containerdTaskDir      = "/run/containerd/io.containerd.runtime.v2.task"
+	crioContainersDir      = "/run/containers/storage/overlay-containers"
+	kubeletContainerLogDir = "/var/log/containers"
+	kubeletPodLogDir       = "/var/log/pods"
+)
+
+// Kubernetes labels (docker, CRI-O) and annotations (containerd CRI plugin) naming the pod.
+var (
+	podNameKeys       = []string{"io.kubernetes.pod.name", "io.kubernetes.cri.sandbox-name"}
+	podNamespaceKeys  = []string{"io.kubernetes.pod.namespace", "io.kubernetes.cri.sandbox-namespace"}
+	podUIDKeys        = []string{"io.kubernetes.pod.uid", "io.kubernetes.cri.sandbox-uid"}
+	containerNameKeys = []string{"io.kubernetes.container.name", "io.kubernetes.cri.container-name"}
+	imageKeys         = []string{"io.kubernetes.cri.image-name"}
+)
+
+// dockerConfig is the subset of docker's config.v2.json read here.
+type dockerConfig struct {
+	Name   string `json:"Name"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+// ociConfig is the subset of an OCI runtime bundle config.json read here.
+type ociConfig struct {
+	Annotations map[string]string `json:"annotations"`
+}
+
+// describe fills the name, image and pod fields of info from the first runtime state
+// file found for its container ID, then from the kubelet log directories.
+func (r *Resolver) describe(info *sbmodels.ContainerInfo) {
+	if info.ID != "" {
+		switch {
+		case r.describeDocker(info):
+		case r.describeOCI(info):
+		}
+		if info.PodName == "" {
+			r.describeFromContainerLog(info)
+		}
+	}
+	if info.PodName == "" && info.PodUID != "" {
+		r.describeFromPodLog(info)
+	}
+}
+
+func (r *Resolver) describeDocker(info *sbmodels.ContainerInfo) bool {
+	data, err := os.ReadFile(r.hostPath(dockerContainersDir, info.ID, "config.v2.json"))
+	if err != nil {
+		return false
+	}
+
+	var cfg dockerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return false
+	}
+
+	info.Runtime = sbmodels.ContainerRuntimeDocker
+	info.Name = strings.TrimPrefix(cfg.Name, "/")
+	info.Image = cfg.Config.Image
+	applyLabels(info, cfg.Config.Labels)
+	return true
+}
+
+// describeOCI reads the bundle config of a containerd task (any containerd namespace)
+// or a CRI-O container.
+func (r *Resolver) describeOCI(info *sbmodels.ContainerInfo) bool {
+	candidates := []struct {
+		pattern string
+		runtime string
+	}{
+		{r.hostPath(containerdTaskDir, "*", info.ID, "config.json"), sbmodels.ContainerRuntimeContainerd},
+		{r.hostPath(crioContainersDir, info.ID, "userdata", "config.json"), sbmodels.ContainerRuntimeCRIO},
+	}
+
+	for _, c := range candidates {
+		paths, _ := filepath.Glob(c.pattern)
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+
+			var cfg ociConfig
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				continue
+			}
+
+			if info.Runtime == "" || info.Runtime == sbmodels.ContainerRuntimeContainerd {
+				info.Runtime = c.runtime
+			}
+			applyLabels(info, cfg.Annotations)
+			return true
+		}
+	}
+	return false
+}
+
+// describeFromContainerLog parses the kubelet's /var/log/containers symlink names,
+// <pod>_<namespace>_<container>-<id>.log.
+func (r *Resolver) describeFromContainerLog(info *sbmodels.ContainerInfo) {
+	paths, _ := filepath.Glob(r.hostPath(kubeletContainerLogDir, "*-"+info.ID+".log"))
+	if len(paths) == 0 {
+		return
+	}
+
+	name := strings.TrimSuffix(filepath.Base(paths[0]), "-"+info.ID+".log")
+	parts := strings.SplitN(name, "_", 3)
+	if len(parts) != 3 {
+		return
+	}
+	info.PodName, info.PodNamespace, info.ContainerName = parts[0], parts[1], parts[2]
+}
+
+// describeFromPodLog finds the kubelet's /var/log/pods/<namespace>_<pod>_<uid> directory.
+func (r *Resolver) describeFromPodLog(info *sbmodels.ContainerInfo) {
+	paths, _ := filepath.Glob(r.hostPath(kubeletPodLogDir, "*_"+info.PodUID))
+	if len(paths) == 0 {
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimSuffix(filepath.Base(paths[0]), "_"+info.PodUID), "_", 2)
+	if len(parts) != 2 {
+		return
+	}
+	info.PodNamespace, info.PodName = parts[0], parts[1]
+}
+
+func applyLabels(info *sbmodels.ContainerInfo, labels map[string]string) {
+	setFirst(&info.PodName, labels, podNameKeys)
+	setFirst(&info.PodNamespace, labels, podNamespaceKeys)
+	setFirst(&info.PodUID, labels, podUIDKeys)
+	setFirst(&info.ContainerName, labels, containerNameKeys)
+	if info.Image == "" {
+		setFirst(&info.Image, labels, imageKeys)
+	}
+}
+
+func setFirst(dst *string, labels map[string]string, keys []string) {
+	for _, k := range keys {
+		if v := labels[k]; v != "" {
+			*dst = v
+			return
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+// Package container attributes processes and files to the container and Kubernetes pod
+// they belong to, using /proc/<pid>/cgroup, /proc/<pid>/mountinfo and the local state
+// files of the container runtimes (docker, containerd, CRI-O) and the kubelet.
+package container
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+)
+
+const (
+	defaultHostRoot = "/"
+	defaultCacheTTL = 5 * time.Minute
+)
+
+// Options configures container enrichment. It is embedded as the "container" option of
+// the plugins whose events are enriched.
+type Options struct {
+	// Enabled turns enrichment on (default true).
+	Enabled *bool `yaml:"enabled" json:"enabled"`
+	// HostRoot is where the host filesystem is visible, e.g. /host when the agent runs in
+	// a container; runtime state files are read below it.
+	HostRoot string `yaml:"hostRoot" json:"hostRoot"`
+	// CacheTTL bounds how long a resolved container is reused before its state files are
+	// read again.
+	CacheTTL plugin.Duration `yaml:"cacheTTL" json:"cacheTTL"`
+}
+
+// OrDefault returns o with defaults applied.
+func (o Options) OrDefault() (Options, error) {
+	if o.Enabled == nil {
+		enabled := true
+		o.Enabled = &enabled
+	}
+	if o.HostRoot == "" {
+		o.HostRoot = defaultHostRoot
+	}
+	if !filepath.IsAbs(o.HostRoot) {
+		return Options{}, fmt.Errorf("container options: hostRoot %q is not absolute", o.HostRoot)
+	}
+	o.CacheTTL = plugin.Duration(o.CacheTTL.OrDefault(defaultCacheTTL))
+	return o, nil
+}
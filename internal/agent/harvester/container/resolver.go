@@ -0,0 +1,190 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package container
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/procfs"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// maxCacheEntries bounds the resolver cache; expired entries are dropped beyond it.
+const maxCacheEntries = 4096
+
+type cacheEntry struct {
+	info    *sbmodels.ContainerInfo // nil: not in a container
+	expires time.Time
+}
+
+// Resolver maps pids and file paths to containers. Results are cached per cgroup path
+// (or container ID) for CacheTTL. A nil *Resolver resolves nothing, so callers can hold
+// one unconditionally and leave it nil when enrichment is disabled.
+type Resolver struct {
+	opts     Options
+	procRoot string
+
+	mu        sync.Mutex
+	cache     map[string]cacheEntry
+	hostMntNS string
+}
+
+// NewResolver returns a resolver reading procfs at procRoot, or nil when opts disables
+// enrichment. opts must have defaults applied (Options.OrDefault). procRoot is only used
+// by Resolve and may be empty for path-only resolvers.
+func NewResolver(opts Options, procRoot string) *Resolver {
+	if opts.Enabled != nil && !*opts.Enabled {
+		return nil
+	}
+
+	return &Resolver{opts: opts, procRoot: procRoot, cache: make(map[string]cacheEntry)}
+}
+
+// Resolve returns the container pid runs in, or nil for host processes and processes
+// that exited.
+func (r *Resolver) Resolve(pid int32) *sbmodels.ContainerInfo {
+	if r == nil {
+		return nil
+	}
+
+	entries, err := procfs.ReadCgroup(r.procRoot, pid)
+	if err != nil {
+		return nil
+	}
+
+	path := selectCgroup(entries)
+	if info := parseCgroupPath(path); info != nil {
+		return r.lookup("cgroup:"+path, func() *sbmodels.ContainerInfo {
+			r.describe(info)
+			return info
+		})
+	}
+
+	// A process in a private cgroup namespace sees its own cgroup as "/"; fall back to
+	// the runtime bind mounts when it does not share the host mount namespace.
+	ns, err := procfs.MountNamespace(r.procRoot, pid)
+	if err != nil || ns == r.hostMountNamespace() {
+		return nil
+	}
+
+	return r.lookup("mntns:"+ns, func() *sbmodels.ContainerInfo {
+		mounts, err := procfs.ReadMountInfo(r.procRoot, pid)
+		if err != nil {
+			return nil
+		}
+		info := parseMounts(mounts)
+		if info != nil {
+			info.CgroupPath = path
+			r.describe(info)
+		}
+		return info
+	})
+}
+
+// ResolvePath returns the container a file belongs to when its path is one of the
+// runtime or kubelet container log locations, e.g.
+//
+//	/var/lib/docker/containers/<id>/<id>-json.log
+//	/var/log/containers/<pod>_<namespace>_<container>-<id>.log
+//	/var/log/pods/<namespace>_<pod>_<uid>/<container>/0.log
+func (r *Resolver) ResolvePath(path string) *sbmodels.ContainerInfo {
+	if r == nil {
+		return nil
+	}
+
+	if strings.Contains(path, "/containers/") {
+		if id := containerIDPattern.FindString(path); id != "" {
+			return r.lookup("id:"+id, func() *sbmodels.ContainerInfo {
+				info := &sbmodels.ContainerInfo{ID: id}
+				if strings.Contains(path, "/docker/containers/") {
+					info.Runtime = sbmodels.ContainerRuntimeDocker
+				}
+				r.describe(info)
+				return info
+			})
+		}
+	}
+
+	if i := strings.Index(path, kubeletPodLogDir+"/"); i >= 0 {
+		rest := strings.Split(path[i+len(kubeletPodLogDir)+1:], "/")
+		parts := strings.SplitN(rest[0], "_", 3)
+		if len(parts) == 3 {
+			info := &sbmodels.ContainerInfo{PodNamespace: parts[0], PodName: parts[1], PodUID: parts[2]}
+			if len(rest) > 2 {
+				info.ContainerName = rest[1]
+			}
+			return info
+		}
+	}
+
+	return nil
+}
+
+// lookup returns the cached result for key, computing and caching it when missing or expired.
+func (r *Resolver) lookup(key string, compute func() *sbmodels.ContainerInfo) *sbmodels.ContainerInfo {
+	now := time.Now()
+
+	r.mu.Lock()
+	e, ok := r.cache[key]
+	r.mu.Unlock()
+
+	if !ok || now.After(e.expires) {
+		e = cacheEntry{info: compute(), expires: now.Add(r.opts.CacheTTL.Duration())}
+
+		r.mu.Lock()
+		if len(r.cache) >= maxCacheEntries {
+			r.evict(now)
+		}
+		r.cache[key] = e
+		r.mu.Unlock()
+	}
+
+	if e.info == nil {
+		return nil
+	}
+	info := *e.info
+	return &info
+}
+
+// evict drops expired entries, or everything when none has expired. Called with mu held.
+func (r *Resolver) evict(now time.Time) {
+	for k, e := range r.cache {
+		if now.After(e.expires) {
+			delete(r.cache, k)
+		}
+	}
+	if len(r.cache) >= maxCacheEntries {
+		r.cache = make(map[string]cacheEntry)
+	}
+}
+
+func (r *Resolver) hostMountNamespace() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hostMntNS == "" {
+		r.hostMntNS, _ = procfs.MountNamespace(r.procRoot, 1)
+	}
+	return r.hostMntNS
+}
+
+func (r *Resolver) hostPath(elem ...string) string {
+	return filepath.Join(append([]string{r.opts.HostRoot}, elem...)...)
+}
@@ -18,30 +18,64 @@ package file
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
+	"os-artificer/saber/internal/agent/harvester/container"
 	"os-artificer/saber/internal/agent/harvester/plugin"
 	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
 )
 
-const filePluginVersion = "1.0.0"
+const filePluginVersion = "1.1.0"
+const pluginName = "file"
 
 func init() {
-	plugin.RegisterPlugin("file", newFilePlugin)
+	plugin.RegisterPlugin(pluginName, newFilePlugin)
 }
 
-// FilePlugin collects data from files.
+// FilePlugin tails log files matched by glob patterns and emits one event per line
+// (or per multiline record). Read offsets are persisted in a registry so restarts
+// resume where the previous run stopped; rotated and truncated files are followed.
 type FilePlugin struct {
 	plugin.UnimplementedPlugin
 
-	wg   sync.WaitGroup
-	done chan struct{}
-	opts any
+	wg       sync.WaitGroup
+	done     chan struct{}
+	opts     Options
+	registry *Registry
+	tailers  map[fileID]*tailer // owned by the Run goroutine
+	resolver *container.Resolver
+}
+
+// candidate is a regular file matched by a scan.
+type candidate struct {
+	path string
+	size int64
 }
 
 func newFilePlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
-	return &FilePlugin{opts: opts, done: make(chan struct{})}, nil
+	fileOptions, err := OptionsFromAny(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("file plugin options: %+v", fileOptions)
+
+	registry, err := LoadRegistry(fileOptions.RegistryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilePlugin{
+		opts:     fileOptions,
+		registry: registry,
+		tailers:  make(map[fileID]*tailer),
+		resolver: container.NewResolver(fileOptions.Container, ""),
+		done:     make(chan struct{}),
+	}, nil
 }
 
 func (p *FilePlugin) Version() string {
@@ -49,20 +83,36 @@ func (p *FilePlugin) Version() string {
 }
 
 func (p *FilePlugin) Name() string {
-	return "file"
+	return pluginName
 }
 
 func (p *FilePlugin) Run(ctx context.Context) (plugin.EventC, error) {
 	eventC := make(plugin.EventC)
+	done := p.done
 
 	p.wg.Add(1)
 	go func() {
 		defer p.wg.Done()
 		defer close(eventC)
+		defer p.shutdown()
+
+		scanTicker := time.NewTicker(p.opts.ScanInterval.Duration())
+		defer scanTicker.Stop()
+
+		readTicker := time.NewTicker(p.opts.ReadInterval.Duration())
+		defer readTicker.Stop()
+
+		flushTicker := time.NewTicker(p.opts.RegistryFlushInterval.Duration())
+		defer flushTicker.Stop()
+
+		p.scan(true)
+		if !p.read(ctx, done, eventC) {
+			return
+		}
 
 		for {
 			select {
-			case <-p.done:
+			case <-done:
 				logger.Infof("file plugin run exited: %s", p.Name())
 				return
 
@@ -70,12 +120,16 @@ func (p *FilePlugin) Run(ctx context.Context) (plugin.EventC, error) {
 				logger.Infof("file plugin run exited: %s", p.Name())
 				return
 
-			case <-time.After(1 * time.Second):
-				eventC <- &plugin.Event{
-					PluginName: p.Name(),
-					EventName:  "file",
-					Data:       nil,
+			case <-scanTicker.C:
+				p.scan(false)
+
+			case <-readTicker.C:
+				if !p.read(ctx, done, eventC) {
+					return
 				}
+
+			case <-flushTicker.C:
+				p.flushRegistry()
 			}
 		}
 	}()
@@ -92,3 +146,203 @@ func (p *FilePlugin) Close() error {
 	p.wg.Wait()
 	return nil
 }
+
+// scan resolves the configured globs and starts a tailer for every file not yet followed.
+// initial is true for the scan at startup, when StartPosition applies.
+func (p *FilePlugin) scan(initial bool) {
+	matched := p.match()
+
+	for _, t := range p.tailers {
+		t.seen = false
+	}
+
+	for id, c := range matched {
+		if t, ok := p.tailers[id]; ok {
+			if t.path != c.path {
+				logger.Infof("file plugin: %s renamed to %s", t.path, c.path)
+				t.path = c.path
+				t.container = p.resolver.ResolvePath(c.path)
+			}
+			t.seen = true
+			continue
+		}
+
+		offset := p.startOffset(id, c, initial)
+		t, err := openTailer(c.path, id, offset, &p.opts)
+		if err != nil {
+			logger.Warnf("file plugin: open %s failed: %v", c.path, err)
+			continue
+		}
+
+		logger.Infof("file plugin: tailing %s (inode=%d) from offset %d", c.path, id.Inode, offset)
+		t.container = p.resolver.ResolvePath(c.path)
+		p.tailers[id] = t
+	}
+
+	// Forget offsets of files that are neither matched nor still being drained.
+	p.registry.Retain(func(id fileID) bool {
+		if _, ok := matched[id]; ok {
+			return true
+		}
+		_, ok := p.tailers[id]
+		return ok
+	})
+}
+
+// match returns every regular file matched by Paths and not by Exclude, keyed by identity.
+func (p *FilePlugin) match() map[fileID]candidate {
+	out := make(map[fileID]candidate)
+
+	for _, pattern := range p.opts.Paths {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			logger.Warnf("file plugin: glob %q failed: %v", pattern, err)
+			continue
+		}
+
+		for _, path := range paths {
+			if p.excluded(path) {
+				continue
+			}
+
+			fi, err := os.Stat(path)
+			if err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+
+			id, ok := fileIDOf(fi)
+			if !ok {
+				continue
+			}
+
+			if _, dup := out[id]; !dup {
+				out[id] = candidate{path: path, size: fi.Size()}
+			}
+		}
+	}
+
+	return out
+}
+
+func (p *FilePlugin) excluded(path string) bool {
+	for _, pattern := range p.opts.Exclude {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// startOffset decides where a newly discovered file is read from: the registry offset
+// when still valid, otherwise the end (initial scan with StartPosition "end") or the beginning.
+func (p *FilePlugin) startOffset(id fileID, c candidate, initial bool) int64 {
+	if off, ok := p.registry.Offset(id); ok {
+		if off <= c.size {
+			return off
+		}
+		logger.Infof("file plugin: %s shrank below registry offset %d, reading from start", c.path, off)
+		return 0
+	}
+
+	if initial && p.opts.StartPosition == startPositionEnd {
+		return c.size
+	}
+	return 0
+}
+
+// read polls every tailer and emits the records they produce. Tailers whose file is no longer
+// matched are closed once drained. It returns false when the plugin is shutting down.
+func (p *FilePlugin) read(ctx context.Context, done <-chan struct{}, eventC plugin.EventC) bool {
+	now := time.Now()
+
+	for id, t := range p.tailers {
+		recs, eof, truncated, err := t.poll(now)
+		if truncated {
+			logger.Infof("file plugin: %s truncated, reading from start", t.path)
+		}
+
+		for i := range recs {
+			if !p.emit(ctx, done, eventC, t, &recs[i]) {
+				return false
+			}
+		}
+
+		if err != nil {
+			// Reopened from the committed offset by the next scan.
+			logger.Warnf("file plugin: read %s failed: %v", t.path, err)
+			p.registry.Set(id, t.path, t.committed)
+			_ = t.close()
+			delete(p.tailers, id)
+			continue
+		}
+
+		if !eof || t.seen {
+			p.registry.Set(id, t.path, t.committed)
+			continue
+		}
+
+		drained := t.drain(now)
+		for i := range drained {
+			if !p.emit(ctx, done, eventC, t, &drained[i]) {
+				return false
+			}
+		}
+
+		logger.Infof("file plugin: stopped tailing %s (inode=%d)", t.path, id.Inode)
+		_ = t.close()
+		delete(p.tailers, id)
+		p.registry.Remove(id)
+	}
+
+	return true
+}
+
+func (p *FilePlugin) emit(ctx context.Context, done <-chan struct{}, eventC plugin.EventC, t *tailer, r *record) bool {
+	event := &plugin.Event{
+		PluginName: p.Name(),
+		EventName:  "log",
+		Data: &sbmodels.LogRecord{
+			Path:      t.path,
+			Inode:     t.id.Inode,
+			Offset:    r.offset,
+			Lines:     r.lines,
+			Message:   string(r.text),
+			Truncated: r.truncated,
+			Timestamp: time.Now(),
+			Container: t.container,
+		},
+	}
+
+	select {
+	case eventC <- event:
+		t.committed = r.end
+		return true
+
+	case <-done:
+		return false
+
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (p *FilePlugin) flushRegistry() {
+	if err := p.registry.Flush(); err != nil {
+		logger.Warnf("file plugin: flush registry failed: %v", err)
+	}
+}
+
+// shutdown records the committed offsets, closes every tailer and writes the registry.
+// Records still held by a multiline aggregator are not committed and are read again on restart.
+func (p *FilePlugin) shutdown() {
+	for id, t := range p.tailers {
+		p.registry.Set(id, t.path, t.committed)
+		_ = t.close()
+	}
+
+	p.flushRegistry()
+	p.tailers = make(map[fileID]*tailer)
+}
@@ -0,0 +1,237 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+func newTestPlugin(t *testing.T, dir string, extra map[string]any) *FilePlugin {
+	t.Helper()
+	opts := map[string]any{
+		"paths":                 []any{filepath.Join(dir, "*.log")},
+		"startPosition":         "beginning",
+		"scanInterval":          "20ms",
+		"readInterval":          "10ms",
+		"registryFlushInterval": "10ms",
+		"registryPath":          filepath.Join(dir, "registry.json"),
+	}
+	for k, v := range extra {
+		opts[k] = v
+	}
+
+	p, err := newFilePlugin(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("newFilePlugin: %v", err)
+	}
+	return p.(*FilePlugin)
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func nextRecord(t *testing.T, eventC plugin.EventC) *sbmodels.LogRecord {
+	t.Helper()
+	select {
+	case ev := <-eventC:
+		rec, ok := ev.Data.(*sbmodels.LogRecord)
+		if !ok {
+			t.Fatalf("event data = %T, want *sbmodels.LogRecord", ev.Data)
+		}
+		return rec
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for record")
+		return nil
+	}
+}
+
+func expectMessages(t *testing.T, eventC plugin.EventC, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if got := nextRecord(t, eventC).Message; got != w {
+			t.Fatalf("message = %q, want %q", got, w)
+		}
+	}
+}
+
+func TestFilePlugin_tailsAppendedLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "auth.log")
+	appendFile(t, path, "one\ntwo\n")
+
+	p := newTestPlugin(t, dir, nil)
+	defer p.Close()
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	first := nextRecord(t, eventC)
+	if first.Message != "one" || first.Path != path || first.Offset != 0 || first.Inode == 0 {
+		t.Fatalf("first record = %+v", first)
+	}
+	second := nextRecord(t, eventC)
+	if second.Message != "two" || second.Offset != 4 {
+		t.Fatalf("second record = %+v", second)
+	}
+
+	// A partial line is held back until its newline arrives.
+	appendFile(t, path, "thr")
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, path, "ee\n")
+	expectMessages(t, eventC, "three")
+}
+
+func TestFilePlugin_followsRotationAndTruncation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "before\n")
+
+	p := newTestPlugin(t, dir, nil)
+	defer p.Close()
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	expectMessages(t, eventC, "before")
+
+	// Rotate: the old file is renamed out of the glob and a new one takes its path.
+	if err := os.Rename(path, filepath.Join(dir, "app.log.1")); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	appendFile(t, filepath.Join(dir, "app.log.1"), "late write\nno newline")
+	appendFile(t, path, "after\n")
+	got := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		got[nextRecord(t, eventC).Message] = true
+	}
+	// The trailing line of the rotated file is emitted once the file is dropped.
+	if !got["late write"] || !got["no newline"] || !got["after"] {
+		t.Fatalf("records after rotation = %v", got)
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	appendFile(t, path, "new\n")
+	expectMessages(t, eventC, "new")
+}
+
+func TestFilePlugin_multiline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "java.log")
+	appendFile(t, path, "2025-01-01 error\n\tat a\n\tat b\n2025-01-02 ok\n")
+
+	p := newTestPlugin(t, dir, map[string]any{
+		"multiline": map[string]any{
+			"startPattern": `^\d{4}-`,
+			"timeout":      "50ms",
+		},
+	})
+	defer p.Close()
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	rec := nextRecord(t, eventC)
+	if rec.Message != "2025-01-01 error\n\tat a\n\tat b" || rec.Lines != 3 {
+		t.Fatalf("first record = %+v", rec)
+	}
+	// The last record is flushed by the timeout since no further start line arrives.
+	expectMessages(t, eventC, "2025-01-02 ok")
+}
+
+func TestFilePlugin_resumesFromRegistry(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "syslog.log")
+	appendFile(t, path, "a\nb\n")
+
+	p := newTestPlugin(t, dir, nil)
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	expectMessages(t, eventC, "a", "b")
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	appendFile(t, path, "c\n")
+
+	p = newTestPlugin(t, dir, nil)
+	defer p.Close()
+	eventC, err = p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	expectMessages(t, eventC, "c")
+}
+
+func TestSplitLines_truncatesLongLines(t *testing.T) {
+	lines, consumed := splitLines([]byte("abcdef\r\nxy\npartial"), 100, 4)
+	if consumed != 11 {
+		t.Fatalf("consumed = %d, want 11", consumed)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("len(lines) = %d, want 2", len(lines))
+	}
+	if string(lines[0].text) != "abcd" || !lines[0].truncated || lines[0].offset != 100 || lines[0].end != 108 {
+		t.Fatalf("lines[0] = %+v", lines[0])
+	}
+	if string(lines[1].text) != "xy" || lines[1].truncated || lines[1].offset != 108 {
+		t.Fatalf("lines[1] = %+v", lines[1])
+	}
+}
+
+func TestOptionsFromAny(t *testing.T) {
+	if _, err := OptionsFromAny(nil); err == nil {
+		t.Fatal("OptionsFromAny(nil) should fail without paths")
+	}
+
+	o, err := OptionsFromAny(map[string]any{"paths": []any{"/var/log/auth.log"}})
+	if err != nil {
+		t.Fatalf("OptionsFromAny: %v", err)
+	}
+	if o.StartPosition != startPositionEnd || o.ScanInterval.Duration() != defaultScanInterval ||
+		o.RegistryPath != defaultRegistryPath || o.MaxLineBytes != defaultMaxLineBytes {
+		t.Fatalf("defaults not applied: %+v", o)
+	}
+
+	if _, err := OptionsFromAny(map[string]any{"paths": []any{"/x"}, "startPosition": "middle"}); err == nil {
+		t.Fatal("invalid startPosition should fail")
+	}
+	if _, err := OptionsFromAny(map[string]any{"paths": []any{"/x"}, "multiline": map[string]any{"startPattern": "("}}); err == nil {
+		t.Fatal("invalid multiline pattern should fail")
+	}
+}
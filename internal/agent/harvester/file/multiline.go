@@ -0,0 +1,140 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package file
+
+import (
+	"bytes"
+	"regexp"
+	"time"
+)
+
+// record is one emitted unit: a single line, or several lines joined by the multiline aggregator.
+type record struct {
+	text      []byte
+	offset    int64 // file offset of the first byte of the record
+	end       int64 // file offset just past the record (including its newline)
+	lines     int
+	truncated bool
+}
+
+// multiline joins continuation lines onto the record started by the last line
+// matching the start pattern.
+type multiline struct {
+	start    *regexp.Regexp
+	maxLines int
+	maxBytes int
+	timeout  time.Duration
+
+	current *record
+	updated time.Time
+}
+
+func newMultiline(opts *MultilineOptions, maxBytes int) *multiline {
+	return &multiline{
+		start:    regexp.MustCompile(opts.StartPattern),
+		maxLines: opts.MaxLines,
+		maxBytes: maxBytes,
+		timeout:  opts.Timeout.Duration(),
+	}
+}
+
+// add feeds one line and returns the record completed by it, if any.
+func (m *multiline) add(line record, now time.Time) *record {
+	var done *record
+	if m.current != nil && (m.start.Match(line.text) || m.current.lines >= m.maxLines) {
+		done = m.current
+		m.current = nil
+	}
+
+	m.updated = now
+	if m.current == nil {
+		m.current = &line
+		return done
+	}
+
+	m.current.end = line.end
+	m.current.lines++
+	if m.current.truncated {
+		return done
+	}
+
+	room := m.maxBytes - len(m.current.text) - 1
+	if room <= 0 {
+		m.current.truncated = true
+		return done
+	}
+
+	m.current.text = append(m.current.text, '\n')
+	if len(line.text) > room {
+		m.current.text = append(m.current.text, line.text[:room]...)
+		m.current.truncated = true
+		return done
+	}
+	m.current.text = append(m.current.text, line.text...)
+	m.current.truncated = m.current.truncated || line.truncated
+
+	return done
+}
+
+// expired returns the pending record if no line was added within the timeout.
+func (m *multiline) expired(now time.Time) *record {
+	if m.current == nil || now.Sub(m.updated) < m.timeout {
+		return nil
+	}
+	return m.flush()
+}
+
+// flush returns the pending record, if any, and resets the aggregator.
+func (m *multiline) flush() *record {
+	done := m.current
+	m.current = nil
+	return done
+}
+
+// splitLines cuts complete lines off the front of buf, which starts at file offset base.
+// It returns the lines and the number of bytes consumed; the remainder is a partial line.
+// Lines longer than maxBytes are cut and marked truncated; the rest of such a line is skipped.
+func splitLines(buf []byte, base int64, maxBytes int) ([]record, int) {
+	var lines []record
+	consumed := 0
+
+	for {
+		idx := bytes.IndexByte(buf[consumed:], '\n')
+		if idx < 0 {
+			break
+		}
+
+		text := buf[consumed : consumed+idx]
+		text = bytes.TrimSuffix(text, []byte{'\r'})
+
+		line := record{
+			offset: base + int64(consumed),
+			end:    base + int64(consumed+idx+1),
+			lines:  1,
+		}
+		if len(text) > maxBytes {
+			text = text[:maxBytes]
+			line.truncated = true
+		}
+		line.text = append([]byte(nil), text...)
+
+		lines = append(lines, line)
+		consumed += idx + 1
+	}
+
+	return lines, consumed
+}
@@ -0,0 +1,129 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package file
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/container"
+	"os-artificer/saber/internal/agent/harvester/plugin"
+)
+
+const (
+	startPositionBeginning = "beginning"
+	startPositionEnd       = "end"
+
+	defaultScanInterval          = 10 * time.Second
+	defaultReadInterval          = 1 * time.Second
+	defaultRegistryFlushInterval = 5 * time.Second
+	defaultRegistryPath          = "./data/file_registry.json"
+	defaultMaxLineBytes          = 64 * 1024
+	defaultMultilineMaxLines     = 500
+	defaultMultilineTimeout      = 5 * time.Second
+)
+
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
+
+// MultilineOptions groups consecutive lines into one record. A line matching StartPattern
+// begins a new record; any other line is appended to the record in progress.
+type MultilineOptions struct {
+	StartPattern string   `yaml:"startPattern" json:"startPattern"`
+	MaxLines     int      `yaml:"maxLines" json:"maxLines"`
+	Timeout      Duration `yaml:"timeout" json:"timeout"`
+}
+
+// Options is the option for the file plugin.
+type Options struct {
+	// Paths are glob patterns of files to tail, e.g. /var/log/nginx/*.log.
+	Paths []string `yaml:"paths" json:"paths"`
+	// Exclude are glob patterns matched against each candidate path; matches are skipped.
+	Exclude []string `yaml:"exclude" json:"exclude"`
+	// StartPosition is where files without a registry offset start when the agent starts:
+	// "end" (default) or "beginning". Files that appear later are always read from the beginning.
+	StartPosition         string            `yaml:"startPosition" json:"startPosition"`
+	ScanInterval          Duration          `yaml:"scanInterval" json:"scanInterval"`
+	ReadInterval          Duration          `yaml:"readInterval" json:"readInterval"`
+	RegistryPath          string            `yaml:"registryPath" json:"registryPath"`
+	RegistryFlushInterval Duration          `yaml:"registryFlushInterval" json:"registryFlushInterval"`
+	MaxLineBytes          int               `yaml:"maxLineBytes" json:"maxLineBytes"`
+	Multiline             *MultilineOptions `yaml:"multiline" json:"multiline"`
+	// Container configures attribution of container log files to containers and pods.
+	Container container.Options `yaml:"container" json:"container"`
+}
+
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
+func OptionsFromAny(opts any) (Options, error) {
+	var out Options
+	if o, ok := opts.(Options); ok {
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
+	}
+
+	if len(out.Paths) == 0 {
+		return Options{}, fmt.Errorf("file options: no paths configured")
+	}
+
+	for _, pattern := range append(append([]string{}, out.Paths...), out.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return Options{}, fmt.Errorf("file options: invalid glob %q: %w", pattern, err)
+		}
+	}
+
+	switch out.StartPosition {
+	case "":
+		out.StartPosition = startPositionEnd
+	case startPositionBeginning, startPositionEnd:
+	default:
+		return Options{}, fmt.Errorf("file options: invalid startPosition %q", out.StartPosition)
+	}
+
+	out.ScanInterval = Duration(out.ScanInterval.OrDefault(defaultScanInterval))
+	out.ReadInterval = Duration(out.ReadInterval.OrDefault(defaultReadInterval))
+	out.RegistryFlushInterval = Duration(out.RegistryFlushInterval.OrDefault(defaultRegistryFlushInterval))
+
+	if out.RegistryPath == "" {
+		out.RegistryPath = defaultRegistryPath
+	}
+	if out.MaxLineBytes <= 0 {
+		out.MaxLineBytes = defaultMaxLineBytes
+	}
+
+	if out.Multiline != nil {
+		if out.Multiline.StartPattern == "" {
+			return Options{}, fmt.Errorf("file options: multiline requires startPattern")
+		}
+		if _, err := regexp.Compile(out.Multiline.StartPattern); err != nil {
+			return Options{}, fmt.Errorf("file options: invalid multiline startPattern: %w", err)
+		}
+		if out.Multiline.MaxLines <= 0 {
+			out.Multiline.MaxLines = defaultMultilineMaxLines
+		}
+		out.Multiline.Timeout = Duration(out.Multiline.Timeout.OrDefault(defaultMultilineTimeout))
+	}
+
+	var err error
+	if out.Container, err = out.Container.OrDefault(); err != nil {
+		return Options{}, err
+	}
+
+	return out, nil
+}
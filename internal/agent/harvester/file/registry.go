@@ -0,0 +1,193 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// fileID identifies a file independently of its path so that renames (log rotation)
+// do not look like new files.
+type fileID struct {
+	Dev   uint64 `json:"dev"`
+	Inode uint64 `json:"inode"`
+}
+
+func (id fileID) String() string {
+	return fmt.Sprintf("%d:%d", id.Dev, id.Inode)
+}
+
+// fileIDOf returns the device and inode of fi. It reports false when the platform
+// does not expose them.
+func fileIDOf(fi os.FileInfo) (fileID, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+
+	return fileID{Dev: uint64(st.Dev), Inode: uint64(st.Ino)}, true
+}
+
+// registryEntry is the persisted read state of one file.
+type registryEntry struct {
+	fileID
+	Path      string    `json:"path"`
+	Offset    int64     `json:"offset"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Registry persists, per file identity, the offset up to which records have been emitted,
+// so that a restarted agent neither re-sends nor skips lines.
+type Registry struct {
+	path    string
+	mu      sync.Mutex
+	entries map[fileID]*registryEntry
+	dirty   bool
+}
+
+// LoadRegistry reads the registry stored at path. A missing file yields an empty registry.
+func LoadRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path, entries: make(map[fileID]*registryEntry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read file registry: %w", err)
+	}
+
+	var entries []*registryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("decode file registry %s: %w", path, err)
+	}
+
+	for _, e := range entries {
+		r.entries[e.fileID] = e
+	}
+
+	return r, nil
+}
+
+// Offset returns the stored offset for id.
+func (r *Registry) Offset(id fileID) (int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.entries[id]
+	if !ok {
+		return 0, false
+	}
+	return e.Offset, true
+}
+
+// Set records that everything before offset in the file id (currently at path) has been emitted.
+func (r *Registry) Set(id fileID, path string, offset int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.entries[id]
+	if !ok {
+		e = &registryEntry{fileID: id}
+		r.entries[id] = e
+	}
+
+	if e.Path == path && e.Offset == offset {
+		return
+	}
+
+	e.Path = path
+	e.Offset = offset
+	e.UpdatedAt = time.Now()
+	r.dirty = true
+}
+
+// Remove drops the entry for id.
+func (r *Registry) Remove(id fileID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[id]; ok {
+		delete(r.entries, id)
+		r.dirty = true
+	}
+}
+
+// Retain drops every entry whose id is not accepted by keep.
+func (r *Registry) Retain(keep func(id fileID) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id := range r.entries {
+		if !keep(id) {
+			delete(r.entries, id)
+			r.dirty = true
+		}
+	}
+}
+
+// Flush writes the registry to disk if it changed since the last flush. The file is
+// replaced atomically so a crash never leaves a partially written registry behind.
+func (r *Registry) Flush() error {
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return nil
+	}
+
+	entries := make([]registryEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, *e)
+	}
+	r.dirty = false
+	r.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("encode file registry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("create file registry dir: %w", err)
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		r.markDirty()
+		return fmt.Errorf("write file registry: %w", err)
+	}
+
+	if err := os.Rename(tmp, r.path); err != nil {
+		r.markDirty()
+		return fmt.Errorf("replace file registry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Registry) markDirty() {
+	r.mu.Lock()
+	r.dirty = true
+	r.mu.Unlock()
+}
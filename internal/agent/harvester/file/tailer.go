@@ -0,0 +1,221 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const (
+	readChunkSize  = 32 * 1024
+	maxReadPerPoll = 1024 * 1024
+)
+
+// tailer follows one file (identified by device and inode) and turns appended bytes into records.
+// It keeps reading its open descriptor after the path is rotated away, so lines written
+// just before rotation are not lost.
+type tailer struct {
+	id        fileID
+	path      string
+	file      *os.File
+	offset    int64 // next byte to read
+	committed int64 // every record ending at or before this offset has been emitted
+	partial   []byte
+	partialAt int64 // file offset of partial[0]
+	skipping  bool  // discarding the remainder of an over-long line
+	maxBytes  int
+	ml        *multiline
+	chunk     []byte
+	seen      bool // matched by the most recent scan
+	container *sbmodels.ContainerInfo
+}
+
+func openTailer(path string, id fileID, offset int64, opts *Options) (*tailer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	// The path may have been rotated between the scan and the open.
+	if got, ok := fileIDOf(fi); !ok || got != id {
+		_ = f.Close()
+		return nil, fmt.Errorf("file %s changed identity before open", path)
+	}
+
+	t := &tailer{
+		id:        id,
+		path:      path,
+		file:      f,
+		offset:    offset,
+		committed: offset,
+		partialAt: offset,
+		maxBytes:  opts.MaxLineBytes,
+		chunk:     make([]byte, readChunkSize),
+		seen:      true,
+	}
+	if opts.Multiline != nil {
+		t.ml = newMultiline(opts.Multiline, opts.MaxLineBytes)
+	}
+
+	return t, nil
+}
+
+// poll reads data appended since the last call and returns the records it completes.
+// eof reports whether the end of the file was reached. A file that shrank below the
+// current offset is treated as truncated and read again from the beginning.
+func (t *tailer) poll(now time.Time) (recs []record, eof bool, truncated bool, err error) {
+	fi, err := t.file.Stat()
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	if fi.Size() < t.offset {
+		t.reset()
+		truncated = true
+	}
+
+	for read := 0; read < maxReadPerPoll; {
+		n, rerr := t.file.ReadAt(t.chunk, t.offset)
+		if n > 0 {
+			base := t.offset
+			t.offset += int64(n)
+			read += n
+			recs = t.consume(recs, t.chunk[:n], base, now)
+		}
+
+		if errors.Is(rerr, io.EOF) || n == 0 {
+			eof = true
+			break
+		}
+		if rerr != nil {
+			return recs, false, truncated, rerr
+		}
+	}
+
+	if t.ml != nil {
+		if r := t.ml.expired(now); r != nil {
+			recs = append(recs, *r)
+		}
+	}
+
+	return recs, eof, truncated, nil
+}
+
+// consume appends data read at file offset base to the partial line buffer and returns
+// recs extended with every record completed by it.
+func (t *tailer) consume(recs []record, data []byte, base int64, now time.Time) []record {
+	if t.skipping {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			t.partialAt = base + int64(len(data))
+			return recs
+		}
+		data = data[idx+1:]
+		t.partialAt = base + int64(idx+1)
+		t.skipping = false
+	}
+
+	t.partial = append(t.partial, data...)
+	lines, consumed := splitLines(t.partial, t.partialAt, t.maxBytes)
+	t.partial = append(t.partial[:0], t.partial[consumed:]...)
+	t.partialAt += int64(consumed)
+
+	if len(t.partial) > t.maxBytes {
+		lines = append(lines, record{
+			text:      append([]byte(nil), t.partial[:t.maxBytes]...),
+			offset:    t.partialAt,
+			end:       t.partialAt + int64(len(t.partial)),
+			lines:     1,
+			truncated: true,
+		})
+		t.partialAt += int64(len(t.partial))
+		t.partial = t.partial[:0]
+		t.skipping = true
+	}
+
+	for _, line := range lines {
+		if t.ml == nil {
+			recs = append(recs, line)
+			continue
+		}
+		if r := t.ml.add(line, now); r != nil {
+			recs = append(recs, *r)
+		}
+	}
+
+	return recs
+}
+
+// drain returns the records still held back once the file is read for the last time:
+// the trailing line left without a newline, and the record of the multiline aggregator.
+func (t *tailer) drain(now time.Time) []record {
+	var lines []record
+	if len(t.partial) > 0 {
+		text := bytes.TrimSuffix(t.partial, []byte{'\r'})
+		lines = append(lines, record{
+			text:   append([]byte(nil), text...),
+			offset: t.partialAt,
+			end:    t.partialAt + int64(len(t.partial)),
+			lines:  1,
+		})
+		t.partialAt += int64(len(t.partial))
+		t.partial = t.partial[:0]
+	}
+
+	if t.ml == nil {
+		return lines
+	}
+
+	var recs []record
+	for _, line := range lines {
+		if r := t.ml.add(line, now); r != nil {
+			recs = append(recs, *r)
+		}
+	}
+	if r := t.ml.flush(); r != nil {
+		recs = append(recs, *r)
+	}
+	return recs
+}
+
+func (t *tailer) reset() {
+	t.offset = 0
+	t.committed = 0
+	t.partial = t.partial[:0]
+	t.partialAt = 0
+	t.skipping = false
+	if t.ml != nil {
+		t.ml.flush()
+	}
+}
+
+func (t *tailer) close() error {
+	return t.file.Close()
+}
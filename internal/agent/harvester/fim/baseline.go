@@ -0,0 +1,147 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package fim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// Baseline is the on-disk database of file states the plugin compares rescans against.
+type Baseline struct {
+	path    string
+	files   map[string]*sbmodels.FileState
+	existed bool // loaded from disk or filled by a scan, rather than created empty
+	dirty   bool
+}
+
+// LoadBaseline reads the baseline stored at path. A missing file yields an empty baseline.
+func LoadBaseline(path string) (*Baseline, error) {
+	b := &Baseline{path: path, files: make(map[string]*sbmodels.FileState)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read fim baseline: %w", err)
+	}
+
+	var files []*sbmodels.FileState
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("decode fim baseline %s: %w", path, err)
+	}
+
+	for _, f := range files {
+		b.files[f.Path] = f
+	}
+	b.existed = true
+
+	return b, nil
+}
+
+// Existed reports whether the baseline was loaded from disk or filled by Reset, even if
+// it could not be saved since.
+func (b *Baseline) Existed() bool {
+	return b.existed
+}
+
+// Get returns the recorded state of path.
+func (b *Baseline) Get(path string) (*sbmodels.FileState, bool) {
+	f, ok := b.files[path]
+	return f, ok
+}
+
+// Subtree returns the recorded states of root and everything below it.
+func (b *Baseline) Subtree(root string) map[string]*sbmodels.FileState {
+	out := make(map[string]*sbmodels.FileState)
+	prefix := strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator)
+	for p, f := range b.files {
+		if p == root || strings.HasPrefix(p, prefix) {
+			out[p] = f
+		}
+	}
+	return out
+}
+
+// Replace swaps the recorded states of old's paths for the states in cur.
+func (b *Baseline) Replace(old, cur map[string]*sbmodels.FileState) {
+	for p := range old {
+		delete(b.files, p)
+	}
+	for p, f := range cur {
+		b.files[p] = f
+	}
+	b.dirty = true
+}
+
+// Reset replaces every recorded state with files.
+func (b *Baseline) Reset(files map[string]*sbmodels.FileState) {
+	b.files = files
+	b.dirty = true
+	b.existed = true
+}
+
+// Len returns the number of recorded paths.
+func (b *Baseline) Len() int {
+	return len(b.files)
+}
+
+// Save writes the baseline to disk if it changed. The file is replaced atomically.
+func (b *Baseline) Save() error {
+	if !b.dirty {
+		return nil
+	}
+
+	paths := make([]string, 0, len(b.files))
+	for p := range b.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	files := make([]*sbmodels.FileState, 0, len(paths))
+	for _, p := range paths {
+		files = append(files, b.files[p])
+	}
+
+	data, err := json.Marshal(files)
+	if err != nil {
+		return fmt.Errorf("encode fim baseline: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return fmt.Errorf("create fim baseline dir: %w", err)
+	}
+
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write fim baseline: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("replace fim baseline: %w", err)
+	}
+
+	b.dirty = false
+	return nil
+}
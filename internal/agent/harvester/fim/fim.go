@@ -0,0 +1,285 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package fim
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/container"
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const fimPluginVersion = "1.0.0"
+const pluginName = "fim"
+
+func init() {
+	plugin.RegisterPlugin(pluginName, newFIMPlugin)
+}
+
+// FIMPlugin monitors file integrity: it keeps an on-disk baseline of size, mode, owner,
+// mtime and SHA-256 of the configured paths and reports differences found by rescans.
+// With inotify enabled, changed paths are rescanned as soon as they are reported.
+type FIMPlugin struct {
+	plugin.UnimplementedPlugin
+
+	wg       sync.WaitGroup
+	done     chan struct{}
+	opts     Options
+	baseline *Baseline
+	scanner  *scanner
+	resolver *container.Resolver
+}
+
+func newFIMPlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
+	fimOptions, err := OptionsFromAny(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("fim plugin options: %+v", fimOptions)
+
+	baseline, err := LoadBaseline(fimOptions.BaselinePath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &FIMPlugin{opts: fimOptions, baseline: baseline, done: make(chan struct{})}
+	p.scanner = &scanner{opts: &p.opts}
+	p.resolver = container.NewResolver(p.opts.Container, "")
+	return p, nil
+}
+
+func (p *FIMPlugin) Version() string {
+	return fimPluginVersion
+}
+
+func (p *FIMPlugin) Name() string {
+	return pluginName
+}
+
+func (p *FIMPlugin) Run(ctx context.Context) (plugin.EventC, error) {
+	eventC := make(plugin.EventC)
+	done := p.done
+
+	var w *watcher
+	if p.opts.Inotify {
+		var err error
+		if w, err = newWatcher(p.opts.MaxWatchedDirs); err != nil {
+			return nil, err
+		}
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(eventC)
+		if w != nil {
+			defer w.close()
+		}
+
+		send := func(events []*sbmodels.FIMEvent) bool {
+			for _, ev := range events {
+				ev.Container = p.resolver.ResolvePath(ev.Path)
+				select {
+				case eventC <- &plugin.Event{PluginName: p.Name(), EventName: ev.Action, Data: ev}:
+				case <-done:
+					return false
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		// Watch before the first scan so changes made while it runs are not missed.
+		var watchEvents chan fsnotify.Event
+		var watchErrors chan error
+		if w != nil {
+			for _, root := range p.opts.Paths {
+				w.addRoot(root, p.scanner)
+			}
+			watchEvents, watchErrors = w.events(), w.errors()
+		}
+
+		if !send(p.fullScan(w)) {
+			return
+		}
+
+		ticker := time.NewTicker(p.opts.Interval.Duration())
+		defer ticker.Stop()
+
+		debounce := time.NewTimer(p.opts.Debounce.Duration())
+		debounce.Stop()
+		dirty := make(map[string]struct{})
+
+		for {
+			select {
+			case <-done:
+				logger.Infof("fim plugin run exited: %s", p.Name())
+				return
+
+			case <-ctx.Done():
+				logger.Infof("fim plugin run exited: %s", p.Name())
+				return
+
+			case <-ticker.C:
+				if !send(p.fullScan(w)) {
+					return
+				}
+
+			case ev, ok := <-watchEvents:
+				if !ok {
+					watchEvents = nil
+					continue
+				}
+				if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+					w.forget(ev.Name)
+				}
+				if !p.scanner.covered(ev.Name) {
+					continue
+				}
+				if len(dirty) == 0 {
+					debounce.Reset(p.opts.Debounce.Duration())
+				}
+				dirty[ev.Name] = struct{}{}
+
+			case err, ok := <-watchErrors:
+				if !ok {
+					watchErrors = nil
+					continue
+				}
+				logger.Warnf("fim plugin: inotify error: %v", err)
+				if errors.Is(err, fsnotify.ErrEventOverflow) {
+					clear(dirty)
+					if !send(p.fullScan(w)) {
+						return
+					}
+				}
+
+			case <-debounce.C:
+				paths := topLevel(dirty)
+				clear(dirty)
+
+				var events []*sbmodels.FIMEvent
+				for _, path := range paths {
+					events = append(events, p.partialScan(path, w)...)
+				}
+				p.saveBaseline()
+
+				if !send(events) {
+					return
+				}
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+func (p *FIMPlugin) Close() error {
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+
+	p.wg.Wait()
+	return nil
+}
+
+// fullScan rescans every configured path and replaces the baseline. The very first scan
+// only creates the baseline and reports nothing. Directories that are not watched yet,
+// e.g. created while inotify events were lost, are added to the watch set.
+func (p *FIMPlugin) fullScan(w *watcher) []*sbmodels.FIMEvent {
+	cur := p.scanner.scanAll(p.baseline)
+
+	var events []*sbmodels.FIMEvent
+	if p.baseline.Existed() {
+		old := make(map[string]*sbmodels.FileState)
+		for _, root := range p.opts.Paths {
+			for path, f := range p.baseline.Subtree(root) {
+				old[path] = f
+			}
+		}
+		events = compare(old, cur, time.Now())
+	} else {
+		logger.Infof("fim plugin: baseline created with %d entries", len(cur))
+	}
+
+	p.baseline.Reset(cur)
+	p.saveBaseline()
+
+	if w != nil {
+		for dir, f := range cur {
+			if f.Type == sbmodels.FileTypeDir {
+				w.add(dir)
+			}
+		}
+	}
+	return events
+}
+
+// partialScan rescans one path reported by inotify and updates that part of the baseline.
+// Directories that appeared are added to the watch set.
+func (p *FIMPlugin) partialScan(path string, w *watcher) []*sbmodels.FIMEvent {
+	old := p.baseline.Subtree(path)
+	cur := p.scanner.scanPath(path, p.baseline)
+	p.baseline.Replace(old, cur)
+
+	if w != nil {
+		for dir, f := range cur {
+			if f.Type == sbmodels.FileTypeDir {
+				w.add(dir)
+			}
+		}
+	}
+
+	return compare(old, cur, time.Now())
+}
+
+func (p *FIMPlugin) saveBaseline() {
+	if err := p.baseline.Save(); err != nil {
+		logger.Warnf("fim plugin: save baseline failed: %v", err)
+	}
+}
+
+// topLevel returns the paths of set that are not below another path of set, sorted.
+func topLevel(set map[string]struct{}) []string {
+	paths := make([]string, 0, len(set))
+	for path := range set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	out := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if n := len(out); n > 0 && strings.HasPrefix(path, out[n-1]+string(filepath.Separator)) {
+			continue
+		}
+		out = append(out, path)
+	}
+	return out
+}
@@ -0,0 +1,221 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package fim
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+func newTestPlugin(t *testing.T, root, baseline string, inotify bool) *FIMPlugin {
+	t.Helper()
+	p, err := newFIMPlugin(context.Background(), map[string]any{
+		"paths":        []any{root},
+		"exclude":      []any{"*.swp"},
+		"baselinePath": baseline,
+		"interval":     "1h",
+		"inotify":      inotify,
+		"debounce":     "50ms",
+	})
+	if err != nil {
+		t.Fatalf("newFIMPlugin: %v", err)
+	}
+	return p.(*FIMPlugin)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func byPath(events []*sbmodels.FIMEvent) map[string][]string {
+	out := make(map[string][]string)
+	for _, ev := range events {
+		out[ev.Path] = append(out[ev.Path], ev.Action)
+	}
+	return out
+}
+
+func TestFIMPlugin_fullScanReportsChanges(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "etc")
+	if err := os.MkdirAll(filepath.Join(root, "ssh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "passwd"), "root:x:0:0\n")
+	writeFile(t, filepath.Join(root, "ssh", "sshd_config"), "PermitRootLogin no\n")
+	writeFile(t, filepath.Join(root, "hosts"), "127.0.0.1 localhost\n")
+	baseline := filepath.Join(dir, "baseline.json")
+
+	p := newTestPlugin(t, root, baseline, false)
+	if events := p.fullScan(nil); len(events) != 0 {
+		t.Fatalf("first scan reported %d events, want none", len(events))
+	}
+	if _, err := os.Stat(baseline); err != nil {
+		t.Fatalf("baseline not saved: %v", err)
+	}
+
+	writeFile(t, filepath.Join(root, "passwd"), "root:x:0:0\nevil:x:0:0\n")
+	if err := os.Chmod(filepath.Join(root, "ssh", "sshd_config"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "hosts")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "ssh", "authorized_keys"), "ssh-ed25519 AAAA\n")
+	writeFile(t, filepath.Join(root, "ignored.swp"), "x")
+
+	// A restarted plugin compares against the baseline saved on disk.
+	p = newTestPlugin(t, root, baseline, false)
+	got := byPath(p.fullScan(nil))
+	want := map[string][]string{
+		filepath.Join(root, "passwd"):                 {sbmodels.FIMEventModified},
+		filepath.Join(root, "ssh", "sshd_config"):     {sbmodels.FIMEventPermissionChanged},
+		filepath.Join(root, "hosts"):                  {sbmodels.FIMEventDeleted},
+		filepath.Join(root, "ssh", "authorized_keys"): {sbmodels.FIMEventCreated},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for path, actions := range want {
+		if len(got[path]) != 1 || got[path][0] != actions[0] {
+			t.Fatalf("events for %s = %v, want %v", path, got[path], actions)
+		}
+	}
+
+	if events := p.fullScan(nil); len(events) != 0 {
+		t.Fatalf("rescan without changes reported %v", byPath(events))
+	}
+}
+
+func TestFIMPlugin_fullScanWithoutSavedBaseline(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "etc")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "passwd"), "root:x:0:0\n")
+
+	// The baseline cannot be saved once its directory is taken by a regular file.
+	p := newTestPlugin(t, root, filepath.Join(dir, "state", "baseline.json"), false)
+	writeFile(t, filepath.Join(dir, "state"), "")
+	if events := p.fullScan(nil); len(events) != 0 {
+		t.Fatalf("first scan reported %d events, want none", len(events))
+	}
+
+	writeFile(t, filepath.Join(root, "passwd"), "root:x:0:0\nevil:x:0:0\n")
+	got := byPath(p.fullScan(nil))
+	if actions := got[filepath.Join(root, "passwd")]; len(actions) != 1 || actions[0] != sbmodels.FIMEventModified {
+		t.Fatalf("events = %v, want passwd modified", got)
+	}
+}
+
+func TestFIMPlugin_fullScanWatchesNewDirectories(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "etc")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newTestPlugin(t, root, filepath.Join(dir, "baseline.json"), true)
+	w, err := newWatcher(p.opts.MaxWatchedDirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+	w.addRoot(root, p.scanner)
+	p.fullScan(w)
+
+	// A directory created while inotify events were lost is found by the next full scan.
+	sub := filepath.Join(root, "cron.d")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	p.fullScan(w)
+	if _, ok := w.watched[sub]; !ok {
+		t.Fatalf("%s not watched after a full scan", sub)
+	}
+}
+
+func TestFIMPlugin_inotify(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "ls"), "ELF")
+
+	p := newTestPlugin(t, root, filepath.Join(dir, "baseline.json"), true)
+	defer p.Close()
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// Wait for the initial scan to finish before changing files.
+	time.Sleep(100 * time.Millisecond)
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "sub", "backdoor"), "ELF")
+
+	seen := make(map[string]bool)
+	deadline := time.After(3 * time.Second)
+	for !seen[filepath.Join(root, "sub", "backdoor")] {
+		select {
+		case ev := <-eventC:
+			seen[ev.Data.(*sbmodels.FIMEvent).Path] = true
+		case <-deadline:
+			t.Fatalf("timed out; events seen: %v", seen)
+		}
+	}
+
+	// Files in the new directory are seen too, since it is watched after being scanned.
+	writeFile(t, filepath.Join(root, "sub", "backdoor"), "ELF2")
+	expectAction(t, eventC, filepath.Join(root, "sub", "backdoor"), sbmodels.FIMEventModified)
+}
+
+func expectAction(t *testing.T, eventC plugin.EventC, path, action string) {
+	t.Helper()
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-eventC:
+			fe := ev.Data.(*sbmodels.FIMEvent)
+			if fe.Path == path && fe.Action == action {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for %s on %s", action, path)
+		}
+	}
+}
+
+func TestTopLevel(t *testing.T) {
+	got := topLevel(map[string]struct{}{"/etc/ssh/a": {}, "/etc/ssh": {}, "/etc/passwd": {}})
+	if len(got) != 2 || got[0] != "/etc/passwd" || got[1] != "/etc/ssh" {
+		t.Fatalf("topLevel = %v", got)
+	}
+}
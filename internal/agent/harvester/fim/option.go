@@ -0,0 +1,105 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package fim
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/container"
+	"os-artificer/saber/internal/agent/harvester/plugin"
+)
+
+const (
+	defaultInterval      = 1 * time.Hour
+	defaultDebounce      = 2 * time.Second
+	defaultBaselinePath  = "./data/fim_baseline.json"
+	defaultHashMaxBytes  = 100 * 1024 * 1024
+	defaultMaxWatchedDir = 8192
+)
+
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
+
+// Options is the option for the fim plugin.
+type Options struct {
+	// Paths are the files and directories to monitor; directories are walked recursively.
+	Paths []string `yaml:"paths" json:"paths"`
+	// Exclude are glob patterns matched against the full path and the base name.
+	Exclude []string `yaml:"exclude" json:"exclude"`
+	// Interval between two full rescans.
+	Interval Duration `yaml:"interval" json:"interval"`
+	// BaselinePath is where the baseline database is stored.
+	BaselinePath string `yaml:"baselinePath" json:"baselinePath"`
+	// HashMaxBytes skips hashing regular files larger than this size.
+	HashMaxBytes int64 `yaml:"hashMaxBytes" json:"hashMaxBytes"`
+	// Inotify rescans changed paths as soon as the kernel reports them instead of
+	// waiting for the next full rescan.
+	Inotify bool `yaml:"inotify" json:"inotify"`
+	// Debounce is how long inotify changes are collected before the affected paths are rescanned.
+	Debounce Duration `yaml:"debounce" json:"debounce"`
+	// MaxWatchedDirs caps the number of inotify watches; deeper trees rely on full rescans.
+	MaxWatchedDirs int `yaml:"maxWatchedDirs" json:"maxWatchedDirs"`
+	// Container configures attribution of paths in container state directories.
+	Container container.Options `yaml:"container" json:"container"`
+}
+
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
+func OptionsFromAny(opts any) (Options, error) {
+	var out Options
+	if o, ok := opts.(Options); ok {
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
+	}
+
+	if len(out.Paths) == 0 {
+		return Options{}, fmt.Errorf("fim options: no paths configured")
+	}
+	for i, p := range out.Paths {
+		if !filepath.IsAbs(p) {
+			return Options{}, fmt.Errorf("fim options: path %q is not absolute", p)
+		}
+		out.Paths[i] = filepath.Clean(p)
+	}
+	for _, pattern := range out.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return Options{}, fmt.Errorf("fim options: invalid glob %q: %w", pattern, err)
+		}
+	}
+
+	out.Interval = Duration(out.Interval.OrDefault(defaultInterval))
+	out.Debounce = Duration(out.Debounce.OrDefault(defaultDebounce))
+
+	if out.BaselinePath == "" {
+		out.BaselinePath = defaultBaselinePath
+	}
+	if out.HashMaxBytes <= 0 {
+		out.HashMaxBytes = defaultHashMaxBytes
+	}
+	if out.MaxWatchedDirs <= 0 {
+		out.MaxWatchedDirs = defaultMaxWatchedDir
+	}
+
+	var err error
+	if out.Container, err = out.Container.OrDefault(); err != nil {
+		return Options{}, err
+	}
+
+	return out, nil
+}
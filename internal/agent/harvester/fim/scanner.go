@@ -0,0 +1,275 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package fim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// scanner walks monitored paths and records their state, reusing baseline hashes for
+// files whose size, inode, mtime and ctime are unchanged.
+type scanner struct {
+	opts *Options
+}
+
+// covered reports whether path lies under a configured path and neither it nor any
+// directory between it and that configured path is excluded.
+func (s *scanner) covered(path string) bool {
+	for _, root := range s.opts.Paths {
+		if path != root && !strings.HasPrefix(path, root+string(filepath.Separator)) {
+			continue
+		}
+
+		excluded := false
+		for p := path; ; p = filepath.Dir(p) {
+			if s.excluded(p) {
+				excluded = true
+				break
+			}
+			if p == root {
+				break
+			}
+		}
+		if !excluded {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scanner) excluded(path string) bool {
+	for _, pattern := range s.opts.Exclude {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scanAll scans every configured path.
+func (s *scanner) scanAll(prev *Baseline) map[string]*sbmodels.FileState {
+	out := make(map[string]*sbmodels.FileState)
+	for _, root := range s.opts.Paths {
+		for p, f := range s.scanPath(root, prev) {
+			out[p] = f
+		}
+	}
+	return out
+}
+
+// scanPath returns the state of root and, when root is a directory, of everything below it.
+// Symlinks are recorded but not followed. A missing root yields an empty result.
+func (s *scanner) scanPath(root string, prev *Baseline) map[string]*sbmodels.FileState {
+	out := make(map[string]*sbmodels.FileState)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				logger.Debugf("fim plugin: walk %s: %v", path, err)
+			}
+			return nil
+		}
+
+		if path != root && s.excluded(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		old, _ := prev.Get(path)
+		state, err := s.stat(path, old)
+		if err != nil {
+			return nil
+		}
+		out[path] = state
+		return nil
+	})
+	if err != nil {
+		logger.Warnf("fim plugin: walk %s failed: %v", root, err)
+	}
+
+	return out
+}
+
+// stat records the state of path. The content hash of prev is reused when the file was
+// evidently not rewritten (same inode, size, mtime and ctime).
+func (s *scanner) stat(path string, prev *sbmodels.FileState) (*sbmodels.FileState, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &sbmodels.FileState{
+		Path:  path,
+		Type:  fileType(fi.Mode()),
+		Size:  fi.Size(),
+		Mode:  fi.Mode().String(),
+		MTime: fi.ModTime().UTC(),
+	}
+
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		state.UID = st.Uid
+		state.GID = st.Gid
+		state.Inode = uint64(st.Ino)
+		state.CTime = time.Unix(int64(st.Ctim.Sec), int64(st.Ctim.Nsec)).UTC()
+	}
+
+	switch state.Type {
+	case sbmodels.FileTypeSymlink:
+		state.LinkTarget, _ = os.Readlink(path)
+
+	case sbmodels.FileTypeRegular:
+		if state.Size > s.opts.HashMaxBytes {
+			break
+		}
+		if prev != nil && prev.SHA256 != "" && prev.Inode == state.Inode && prev.Size == state.Size &&
+			prev.MTime.Equal(state.MTime) && prev.CTime.Equal(state.CTime) {
+			state.SHA256 = prev.SHA256
+			break
+		}
+		state.SHA256 = hashFile(path)
+	}
+
+	return state, nil
+}
+
+func hashFile(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func fileType(m fs.FileMode) string {
+	switch {
+	case m.IsRegular():
+		return sbmodels.FileTypeRegular
+	case m.IsDir():
+		return sbmodels.FileTypeDir
+	case m&fs.ModeSymlink != 0:
+		return sbmodels.FileTypeSymlink
+	default:
+		return sbmodels.FileTypeOther
+	}
+}
+
+// compare returns the events that turn old into cur, ordered by path.
+func compare(old, cur map[string]*sbmodels.FileState, now time.Time) []*sbmodels.FIMEvent {
+	paths := make([]string, 0, len(old)+len(cur))
+	for p := range old {
+		paths = append(paths, p)
+	}
+	for p := range cur {
+		if _, ok := old[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var events []*sbmodels.FIMEvent
+	for _, p := range paths {
+		o, c := old[p], cur[p]
+		switch {
+		case o == nil:
+			events = append(events, &sbmodels.FIMEvent{Action: sbmodels.FIMEventCreated, Path: p, Current: c, Timestamp: now})
+
+		case c == nil:
+			events = append(events, &sbmodels.FIMEvent{Action: sbmodels.FIMEventDeleted, Path: p, Previous: o, Timestamp: now})
+
+		default:
+			if changes := contentChanges(o, c); len(changes) > 0 {
+				events = append(events, &sbmodels.FIMEvent{
+					Action: sbmodels.FIMEventModified, Path: p, Changes: changes, Previous: o, Current: c, Timestamp: now,
+				})
+			}
+			if changes := permissionChanges(o, c); len(changes) > 0 {
+				events = append(events, &sbmodels.FIMEvent{
+					Action: sbmodels.FIMEventPermissionChanged, Path: p, Changes: changes, Previous: o, Current: c, Timestamp: now,
+				})
+			}
+		}
+	}
+
+	return events
+}
+
+// contentChanges lists content-related differences. Directory size and mtime change with
+// every entry added or removed, which is already reported per entry, so they are ignored.
+func contentChanges(o, c *sbmodels.FileState) []string {
+	var changes []string
+	if o.Type != c.Type {
+		changes = append(changes, "type")
+	}
+	if o.Inode != c.Inode {
+		changes = append(changes, "inode")
+	}
+	if c.Type == sbmodels.FileTypeDir {
+		return changes
+	}
+	if o.Size != c.Size {
+		changes = append(changes, "size")
+	}
+	if o.SHA256 != c.SHA256 {
+		changes = append(changes, "sha256")
+	}
+	if o.LinkTarget != c.LinkTarget {
+		changes = append(changes, "link_target")
+	}
+	// Without a hash (file over the size limit) a new mtime is the only hint of a rewrite.
+	if !o.MTime.Equal(c.MTime) && (len(changes) > 0 || (c.Type == sbmodels.FileTypeRegular && c.SHA256 == "")) {
+		changes = append(changes, "mtime")
+	}
+	return changes
+}
+
+func permissionChanges(o, c *sbmodels.FileState) []string {
+	var changes []string
+	if o.Mode != c.Mode {
+		changes = append(changes, "mode")
+	}
+	if o.UID != c.UID {
+		changes = append(changes, "uid")
+	}
+	if o.GID != c.GID {
+		changes = append(changes, "gid")
+	}
+	return changes
+}
@@ -0,0 +1,110 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package fim
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"os-artificer/saber/pkg/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watcher adds inotify watches on monitored directories, and on the parent directory of
+// monitored files so that atomic replacement (write to temp + rename) is seen.
+type watcher struct {
+	fsw     *fsnotify.Watcher
+	watched map[string]struct{}
+	max     int
+	warned  bool
+}
+
+func newWatcher(max int) (*watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &watcher{fsw: fsw, watched: make(map[string]struct{}), max: max}, nil
+}
+
+// addRoot watches root: recursively when it is a directory, via its parent otherwise.
+func (w *watcher) addRoot(root string, s *scanner) {
+	fi, err := os.Lstat(root)
+	if err != nil || !fi.IsDir() {
+		w.add(filepath.Dir(root))
+		return
+	}
+	w.addTree(root, s)
+}
+
+// addTree watches dir and every non-excluded directory below it.
+func (w *watcher) addTree(dir string, s *scanner) {
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != dir && s.excluded(path) {
+			return filepath.SkipDir
+		}
+		if !w.add(path) {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+}
+
+// add watches one directory. It returns false once the watch limit is reached.
+func (w *watcher) add(dir string) bool {
+	if _, ok := w.watched[dir]; ok {
+		return true
+	}
+
+	if len(w.watched) >= w.max {
+		if !w.warned {
+			logger.Warnf("fim plugin: inotify watch limit (%d) reached, remaining directories rely on full rescans", w.max)
+			w.warned = true
+		}
+		return false
+	}
+
+	if err := w.fsw.Add(dir); err != nil {
+		logger.Debugf("fim plugin: watch %s failed: %v", dir, err)
+		return true
+	}
+
+	w.watched[dir] = struct{}{}
+	return true
+}
+
+// forget drops bookkeeping for a directory the kernel stopped watching (removed or renamed).
+func (w *watcher) forget(dir string) {
+	delete(w.watched, dir)
+}
+
+func (w *watcher) events() chan fsnotify.Event {
+	return w.fsw.Events
+}
+
+func (w *watcher) errors() chan error {
+	return w.fsw.Errors
+}
+
+func (w *watcher) close() error {
+	return w.fsw.Close()
+}
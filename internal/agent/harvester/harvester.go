@@ -80,7 +80,8 @@ func (h *Harvester) Run(ctx context.Context) error {
 						continue
 					}
 
-					if err := h.reporter.SendMessage(ctx, content); err != nil {
+					sendCtx := reporter.WithEvent(ctx, plugin.Name(), event.EventName)
+					if err := h.reporter.SendMessage(sendCtx, content); err != nil {
 						logger.Warnf("harvester send message failed: %s, err: %v", plugin.Name(), err)
 					}
 				}
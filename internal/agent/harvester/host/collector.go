@@ -0,0 +1,294 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// errCollectorBusy is returned when a collector is due while its previous run, abandoned
+// after a timeout, has not returned yet.
+var errCollectorBusy = errors.New("previous run still in progress")
+
+// collectorGracePeriod is how long a timed out collector may take to return partial results.
+const collectorGracePeriod = 100 * time.Millisecond
+
+// collector gathers one group of host metrics. collect writes only the fields of
+// sbmodels.Stats the collector owns, and merge copies those fields between two Stats.
+type collector struct {
+	name     string
+	interval time.Duration
+	timeout  time.Duration
+	collect  func(ctx context.Context, s *sbmodels.Stats) error
+	merge    func(dst, src *sbmodels.Stats)
+
+	running atomic.Bool
+}
+
+// run runs the collector once, bounded by its timeout. The collect call runs in its own
+// goroutine so that a call blocked in the kernel (e.g. statfs on a dead NFS mount) is
+// abandoned rather than awaited; later runs are skipped until it returns.
+func (c *collector) run(ctx context.Context) (*sbmodels.Stats, error) {
+	if !c.running.CompareAndSwap(false, true) {
+		return nil, errCollectorBusy
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	type result struct {
+		stats *sbmodels.Stats
+		err   error
+	}
+	resultC := make(chan result, 1)
+
+	go func() {
+		defer c.running.Store(false)
+
+		s := sbmodels.NewHostStats()
+		err := c.collect(ctx, s)
+		resultC <- result{stats: s, err: err}
+	}()
+
+	select {
+	case r := <-resultC:
+		return r.stats, r.err
+	case <-ctx.Done():
+	}
+
+	// Collectors that honour ctx (e.g. disk) hand back partial results right away.
+	select {
+	case r := <-resultC:
+		return r.stats, r.err
+	case <-time.After(collectorGracePeriod):
+		return nil, fmt.Errorf("timed out after %s: %w", c.timeout, ctx.Err())
+	}
+}
+
+// newCollectors returns the enabled collectors. A nil collectors map enables all of them
+// with the default interval and timeout.
+func newCollectors(collectors map[string]CollectorOptions) []*collector {
+	out := make([]*collector, 0, len(collectorNames))
+	for _, name := range collectorNames {
+		o, ok := collectors[name]
+		if ok && !o.IsEnabled() {
+			continue
+		}
+
+		c := &collector{
+			name:     name,
+			interval: o.Interval.OrDefault(defaultInterval),
+			timeout:  o.Timeout.OrDefault(defaultTimeout),
+		}
+
+		switch name {
+		case CollectorCPU:
+			cc := newCPUCollector()
+			c.collect = cc.collect
+			c.merge = func(dst, src *sbmodels.Stats) {
+				dst.CPU = src.CPU
+				dst.CPUTotal = src.CPUTotal
+				dst.CPUCores = src.CPUCores
+				dst.Load = src.Load
+			}
+
+		case CollectorMemory:
+			mc := newMemoryCollector()
+			c.collect = mc.collect
+			c.merge = func(dst, src *sbmodels.Stats) {
+				dst.Memory = src.Memory
+				dst.MemoryDetail = src.MemoryDetail
+			}
+
+		case CollectorDisk:
+			dc := newDiskCollector()
+			c.collect = func(ctx context.Context, s *sbmodels.Stats) (err error) {
+				s.Disk, err = dc.collect(ctx)
+				return err
+			}
+			c.merge = func(dst, src *sbmodels.Stats) { dst.Disk = src.Disk }
+
+		case CollectorNetwork:
+			nc := newNetworkCollector()
+			c.collect = func(ctx context.Context, s *sbmodels.Stats) (err error) {
+				s.Networks, err = nc.collect(ctx)
+				return err
+			}
+			c.merge = func(dst, src *sbmodels.Stats) { dst.Networks = src.Networks }
+
+		case CollectorIdentity:
+			c.collect = collectIdentity
+			c.merge = func(dst, src *sbmodels.Stats) {
+				dst.Uptime = src.Uptime
+				dst.Hostname = src.Hostname
+				dst.OS = src.OS
+				dst.Arch = src.Arch
+				dst.Kernel = src.Kernel
+			}
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// diskCollector reports usage per mount point and the I/O rates of the underlying block
+// devices. statfs cannot be interrupted, so each mount point is queried in its own
+// goroutine; mount points whose query outlived the collector timeout are skipped until
+// that query returns.
+type diskCollector struct {
+	partitions func(ctx context.Context) ([]disk.PartitionStat, error)
+	usage      func(path string) (*disk.UsageStat, error)
+	ioCounters func(ctx context.Context) (map[string]disk.IOCountersStat, error)
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+
+	prevIO   map[string]disk.IOCountersStat
+	prevTime time.Time
+}
+
+func newDiskCollector() *diskCollector {
+	return &diskCollector{
+		partitions: func(ctx context.Context) ([]disk.PartitionStat, error) {
+			return disk.PartitionsWithContext(ctx, false)
+		},
+		usage: disk.Usage,
+		ioCounters: func(ctx context.Context) (map[string]disk.IOCountersStat, error) {
+			return disk.IOCountersWithContext(ctx)
+		},
+		pending: make(map[string]struct{}),
+	}
+}
+
+func (d *diskCollector) collect(ctx context.Context) ([]sbmodels.DiskStats, error) {
+	partitions, err := d.partitions(ctx)
+	if err != nil {
+		return []sbmodels.DiskStats{}, err
+	}
+
+	io, err := d.ioCounters(ctx)
+	if err != nil {
+		logger.Warnf("host plugin: failed to read disk I/O counters: %v", err)
+	}
+	now := time.Now()
+	elapsed := now.Sub(d.prevTime)
+
+	type result struct {
+		index int
+		usage *disk.UsageStat
+	}
+	resultC := make(chan result, len(partitions))
+
+	started := 0
+	for i, p := range partitions {
+		if !d.start(p.Mountpoint) {
+			logger.Warnf("host plugin: skipping %s, previous usage query still blocked", p.Mountpoint)
+			continue
+		}
+		started++
+
+		go func(i int, mountpoint string) {
+			defer d.finish(mountpoint)
+
+			u, err := d.usage(mountpoint)
+			if err != nil {
+				u = nil
+			}
+			resultC <- result{index: i, usage: u}
+		}(i, p.Mountpoint)
+	}
+
+	usages := make([]*disk.UsageStat, len(partitions))
+wait:
+	for received := 0; received < started; received++ {
+		select {
+		case r := <-resultC:
+			usages[r.index] = r.usage
+		case <-ctx.Done():
+			logger.Warnf("host plugin: disk usage of %d mount points timed out", started-received)
+			break wait
+		}
+	}
+
+	diskStats := make([]sbmodels.DiskStats, 0, len(partitions))
+	for i, u := range usages {
+		if u == nil {
+			continue
+		}
+
+		p := partitions[i]
+		ds := sbmodels.DiskStats{
+			Mountpoint:        p.Mountpoint,
+			UsedPercent:       u.UsedPercent,
+			Device:            p.Device,
+			FSType:            p.Fstype,
+			TotalBytes:        u.Total,
+			UsedBytes:         u.Used,
+			FreeBytes:         u.Free,
+			InodesTotal:       u.InodesTotal,
+			InodesUsed:        u.InodesUsed,
+			InodesUsedPercent: u.InodesUsedPercent,
+		}
+
+		if name := blockDeviceName(p.Device); name != "" {
+			cur, ok := io[name]
+			prev, hadPrev := d.prevIO[name]
+			if ok && hadPrev {
+				ds.ReadIOPS = counterRate(cur.ReadCount, prev.ReadCount, elapsed)
+				ds.WriteIOPS = counterRate(cur.WriteCount, prev.WriteCount, elapsed)
+				ds.ReadBytesPerSec = counterRate(cur.ReadBytes, prev.ReadBytes, elapsed)
+				ds.WriteBytesPerSec = counterRate(cur.WriteBytes, prev.WriteBytes, elapsed)
+			}
+		}
+
+		diskStats = append(diskStats, ds)
+	}
+	if io != nil {
+		d.prevIO, d.prevTime = io, now
+	}
+
+	return diskStats, nil
+}
+
+func (d *diskCollector) start(mountpoint string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.pending[mountpoint]; ok {
+		return false
+	}
+	d.pending[mountpoint] = struct{}{}
+	return true
+}
+
+func (d *diskCollector) finish(mountpoint string) {
+	d.mu.Lock()
+	delete(d.pending, mountpoint)
+	d.mu.Unlock()
+}
@@ -33,8 +33,9 @@ func newHostPlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
 	logger.Infof("host plugin options: %+v", hostOptions)
 
 	return &HostPlugin{
-		stats: sbmodels.NewHostStats(),
-		opts:  hostOptions,
-		done:  make(chan struct{}),
+		stats:      sbmodels.NewHostStats(),
+		collectors: newCollectors(hostOptions.Collectors),
+		opts:       hostOptions,
+		done:       make(chan struct{}),
 	}, nil
 }
@@ -33,14 +33,17 @@ func init() {
 	plugin.RegisterPlugin(pluginName, newHostPlugin)
 }
 
-// HostPlugin collects host metrics/info.
+// HostPlugin collects host metrics/info. Each collector runs on its own interval and the
+// latest values of all collectors are reported on the plugin interval.
 type HostPlugin struct {
 	plugin.UnimplementedPlugin
 
-	stats *sbmodels.Stats
-	wg    sync.WaitGroup
-	done  chan struct{}
-	opts  Options
+	mu         sync.Mutex
+	stats      *sbmodels.Stats
+	collectors []*collector
+	wg         sync.WaitGroup
+	done       chan struct{}
+	opts       Options
 }
 
 func (p *HostPlugin) Version() string {
@@ -53,18 +56,27 @@ func (p *HostPlugin) Name() string {
 
 func (p *HostPlugin) Run(ctx context.Context) (plugin.EventC, error) {
 	eventC := make(plugin.EventC)
+	done := p.done
+
+	for _, c := range p.collectors {
+		p.wg.Add(1)
+		go func(c *collector) {
+			defer p.wg.Done()
+			p.runCollector(ctx, done, c)
+		}(c)
+	}
 
 	p.wg.Add(1)
 	go func() {
 		defer p.wg.Done()
 		defer close(eventC)
 
-		timer := time.NewTimer(5 * time.Second)
-		defer timer.Stop()
+		ticker := time.NewTicker(p.opts.Interval.Duration())
+		defer ticker.Stop()
 
 		for {
 			select {
-			case <-p.done:
+			case <-done:
 				logger.Infof("host plugin run exited: %s", p.Name())
 				return
 
@@ -72,19 +84,14 @@ func (p *HostPlugin) Run(ctx context.Context) (plugin.EventC, error) {
 				logger.Infof("host plugin run exited: %s", p.Name())
 				return
 
-			case <-timer.C:
-				if err := CollectStats(p.stats); err != nil {
-					logger.Errorf("failed to collect host stats: %v", err)
-					timer.Reset(5 * time.Second)
-					continue
-				}
-
-				eventC <- &plugin.Event{
-					PluginName: p.Name(),
-					Data:       p.stats,
+			case <-ticker.C:
+				select {
+				case eventC <- &plugin.Event{PluginName: p.Name(), Data: p.snapshot()}:
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
 				}
-
-				timer.Reset(5 * time.Second)
 			}
 		}
 	}()
@@ -92,6 +99,42 @@ func (p *HostPlugin) Run(ctx context.Context) (plugin.EventC, error) {
 	return eventC, nil
 }
 
+// runCollector runs c immediately and then on its interval until the plugin stops,
+// merging each successful result into the reported stats.
+func (p *HostPlugin) runCollector(ctx context.Context, done <-chan struct{}, c *collector) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		s, err := c.run(ctx)
+		if err != nil {
+			logger.Errorf("host plugin: %s collector: %v", c.name, err)
+		} else {
+			p.mu.Lock()
+			c.merge(p.stats, s)
+			p.mu.Unlock()
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// snapshot returns a copy of the latest stats. Collectors replace slices rather than
+// modify them, so a shallow copy is safe to hand out.
+func (p *HostPlugin) snapshot() *sbmodels.Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := *p.stats
+	return &s
+}
+
 func (p *HostPlugin) Close() error {
 	if p.done != nil {
 		close(p.done)
@@ -0,0 +1,296 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package host
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/sbmodels"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/load"
+	netutil "github.com/shirou/gopsutil/v4/net"
+)
+
+func TestOptionsFromAny(t *testing.T) {
+	opts, err := OptionsFromAny(map[string]any{
+		"interval": "10s",
+		"timeout":  "3s",
+		"collectors": map[string]any{
+			"disk":     map[string]any{"interval": "1m", "timeout": "20s"},
+			"identity": map[string]any{"enabled": false},
+		},
+	})
+	if err != nil {
+		t.Fatalf("OptionsFromAny: %v", err)
+	}
+
+	if opts.Interval.Duration() != 10*time.Second || opts.Timeout.Duration() != 3*time.Second {
+		t.Fatalf("interval/timeout = %s/%s", opts.Interval.Duration(), opts.Timeout.Duration())
+	}
+	if c := opts.Collectors[CollectorDisk]; c.Interval.Duration() != time.Minute || c.Timeout.Duration() != 20*time.Second {
+		t.Fatalf("disk collector = %+v", c)
+	}
+	if c := opts.Collectors[CollectorCPU]; !c.IsEnabled() || c.Interval.Duration() != 10*time.Second || c.Timeout.Duration() != 3*time.Second {
+		t.Fatalf("cpu collector = %+v", c)
+	}
+
+	var names []string
+	for _, c := range newCollectors(opts.Collectors) {
+		names = append(names, c.name)
+	}
+	if len(names) != 4 || names[3] != CollectorNetwork {
+		t.Fatalf("enabled collectors = %v", names)
+	}
+
+	defaults, err := OptionsFromAny(nil)
+	if err != nil || defaults.Interval.Duration() != defaultInterval || len(defaults.Collectors) != len(collectorNames) {
+		t.Fatalf("OptionsFromAny(nil) = %+v, %v", defaults, err)
+	}
+
+	if _, err := OptionsFromAny(map[string]any{"collectors": map[string]any{"gpu": map[string]any{}}}); err == nil {
+		t.Fatal("unknown collector should be rejected")
+	}
+}
+
+func TestCollectorRunTimeout(t *testing.T) {
+	release := make(chan struct{})
+	c := &collector{
+		name:    "stuck",
+		timeout: 50 * time.Millisecond,
+		collect: func(ctx context.Context, s *sbmodels.Stats) error {
+			<-release // ignores ctx, like statfs on a dead mount
+			s.CPU = 42
+			return nil
+		},
+	}
+
+	start := time.Now()
+	if _, err := c.run(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("run = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("run blocked for %s", elapsed)
+	}
+	if _, err := c.run(context.Background()); !errors.Is(err, errCollectorBusy) {
+		t.Fatalf("run while stuck = %v, want busy", err)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for c.running.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s, err := c.run(context.Background())
+	if err != nil || s.CPU != 42 {
+		t.Fatalf("run after release = %+v, %v", s, err)
+	}
+}
+
+func TestDiskCollectorSkipsHungMount(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var nfsCalls atomic.Int32
+	d := newDiskCollector()
+	d.partitions = func(ctx context.Context) ([]disk.PartitionStat, error) {
+		return []disk.PartitionStat{{Mountpoint: "/"}, {Mountpoint: "/mnt/nfs"}}, nil
+	}
+	d.usage = func(path string) (*disk.UsageStat, error) {
+		if path == "/mnt/nfs" {
+			nfsCalls.Add(1)
+			<-release
+		}
+		return &disk.UsageStat{Path: path, UsedPercent: 12.5}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		stats, err := d.collect(ctx)
+		cancel()
+		if err != nil || len(stats) != 1 || stats[0].Mountpoint != "/" || stats[0].UsedPercent != 12.5 {
+			t.Fatalf("collect #%d = %+v, %v", i, stats, err)
+		}
+	}
+	if n := nfsCalls.Load(); n != 1 {
+		t.Fatalf("hung mount queried %d times, want 1", n)
+	}
+}
+
+func TestHostPluginRun(t *testing.T) {
+	fast := &collector{
+		name:     CollectorCPU,
+		interval: 10 * time.Millisecond,
+		timeout:  time.Second,
+		collect:  func(ctx context.Context, s *sbmodels.Stats) error { s.CPU = 7; return nil },
+		merge:    func(dst, src *sbmodels.Stats) { dst.CPU = src.CPU },
+	}
+	stuck := &collector{
+		name:     CollectorDisk,
+		interval: 10 * time.Millisecond,
+		timeout:  10 * time.Millisecond,
+		collect: func(ctx context.Context, s *sbmodels.Stats) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		merge: func(dst, src *sbmodels.Stats) { dst.Disk = src.Disk },
+	}
+
+	p := &HostPlugin{
+		stats:      sbmodels.NewHostStats(),
+		collectors: []*collector{fast, stuck},
+		opts:       Options{Interval: Duration(30 * time.Millisecond)},
+		done:       make(chan struct{}),
+	}
+
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	select {
+	case ev := <-eventC:
+		s, ok := ev.Data.(*sbmodels.Stats)
+		if !ok || ev.PluginName != pluginName || s.CPU != 7 || len(s.Disk) != 0 {
+			t.Fatalf("event = %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no host stats reported")
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for range eventC {
+	}
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestCPUCollector(t *testing.T) {
+	reads := [][]cpu.TimesStat{
+		{{CPU: "cpu-total", User: 100, System: 50, Idle: 800, Iowait: 40, Steal: 10}},
+		{
+			{CPU: "cpu0", User: 50, System: 25, Idle: 400, Iowait: 20, Steal: 5},
+			{CPU: "cpu1", User: 50, System: 25, Idle: 400, Iowait: 20, Steal: 5},
+		},
+		// 100 ticks later: total 40 user, 10 system, 30 idle, 10 iowait, 10 steal.
+		{{CPU: "cpu-total", User: 140, System: 60, Idle: 830, Iowait: 50, Steal: 20}},
+		{
+			{CPU: "cpu0", User: 90, System: 35, Idle: 400, Iowait: 20, Steal: 5},  // fully busy
+			{CPU: "cpu1", User: 50, System: 25, Idle: 430, Iowait: 30, Steal: 15}, // idle, waiting, stolen
+		},
+	}
+
+	c := newCPUCollector()
+	c.times = func(ctx context.Context, percpu bool) ([]cpu.TimesStat, error) {
+		r := reads[0]
+		reads = reads[1:]
+		return r, nil
+	}
+	c.load = func(ctx context.Context) (*load.AvgStat, error) {
+		return &load.AvgStat{Load1: 1.5, Load5: 1, Load15: 0.5}, nil
+	}
+
+	s := sbmodels.NewHostStats()
+	if err := c.collect(context.Background(), s); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	total := s.CPUTotal
+	if total.Core != -1 || !approx(total.User, 40) || !approx(total.Idle, 30) || !approx(total.IOWait, 10) ||
+		!approx(total.Steal, 10) || !approx(total.UsedPercent, 60) || !approx(s.CPU, 60) {
+		t.Fatalf("cpu total = %+v", total)
+	}
+	if len(s.CPUCores) != 2 || s.CPUCores[0].Core != 0 || !approx(s.CPUCores[0].UsedPercent, 100) ||
+		!approx(s.CPUCores[1].Idle, 60) || !approx(s.CPUCores[1].IOWait, 20) || !approx(s.CPUCores[1].Steal, 20) {
+		t.Fatalf("cpu cores = %+v", s.CPUCores)
+	}
+	if s.Load.Load1 != 1.5 || s.Load.Load15 != 0.5 {
+		t.Fatalf("load = %+v", s.Load)
+	}
+}
+
+func TestCounterRate(t *testing.T) {
+	if r := counterRate(3000, 1000, 2*time.Second); r != 1000 {
+		t.Fatalf("rate = %v", r)
+	}
+	if r := counterRate(10, 1000, time.Second); r != 0 {
+		t.Fatalf("rate after reset = %v", r)
+	}
+	if r := counterRate(10, 0, 0); r != 0 {
+		t.Fatalf("rate without elapsed time = %v", r)
+	}
+}
+
+func TestDiskCollectorIORates(t *testing.T) {
+	d := newDiskCollector()
+	d.partitions = func(ctx context.Context) ([]disk.PartitionStat, error) {
+		return []disk.PartitionStat{{Device: "/dev/saber-test-sda1", Mountpoint: "/", Fstype: "ext4"}}, nil
+	}
+	d.usage = func(path string) (*disk.UsageStat, error) {
+		return &disk.UsageStat{Path: path, Total: 100, Used: 40, Free: 60, UsedPercent: 40,
+			InodesTotal: 10, InodesUsed: 5, InodesUsedPercent: 50}, nil
+	}
+	counters := disk.IOCountersStat{Name: "saber-test-sda1", ReadCount: 100, WriteCount: 50, ReadBytes: 4096, WriteBytes: 8192}
+	d.ioCounters = func(ctx context.Context) (map[string]disk.IOCountersStat, error) {
+		return map[string]disk.IOCountersStat{counters.Name: counters}, nil
+	}
+
+	first, err := d.collect(context.Background())
+	if err != nil || len(first) != 1 {
+		t.Fatalf("first collect = %+v, %v", first, err)
+	}
+	if ds := first[0]; ds.FSType != "ext4" || ds.TotalBytes != 100 || ds.InodesUsedPercent != 50 || ds.ReadIOPS != 0 {
+		t.Fatalf("first disk stats = %+v", ds)
+	}
+
+	// Pretend the previous read happened two seconds ago.
+	d.prevTime = time.Now().Add(-2 * time.Second)
+	counters.ReadCount += 200
+	counters.WriteBytes += 4 << 20
+
+	second, err := d.collect(context.Background())
+	if err != nil || len(second) != 1 {
+		t.Fatalf("second collect = %+v, %v", second, err)
+	}
+	if ds := second[0]; math.Abs(ds.ReadIOPS-100) > 5 || math.Abs(ds.WriteBytesPerSec-2<<20) > 2<<20/20 || ds.WriteIOPS != 0 {
+		t.Fatalf("second disk stats = %+v", ds)
+	}
+}
+
+func TestNetworkStatsRates(t *testing.T) {
+	prev := map[string]netutil.IOCountersStat{"eth0": {Name: "eth0", BytesRecv: 1000, BytesSent: 500, PacketsRecv: 10}}
+	cur := netutil.IOCountersStat{Name: "eth0", BytesRecv: 5000, BytesSent: 300, PacketsRecv: 30}
+
+	ns := networkStats("eth0", "aa:bb", []string{"10.0.0.1"}, cur, prev, 2*time.Second)
+	if ns.RxBytes != 5000 || ns.RxBytesPerSec != 2000 || ns.RxPacketsPerSec != 10 || ns.TxBytesPerSec != 0 {
+		t.Fatalf("network stats = %+v", ns)
+	}
+
+	if ns := networkStats("eth1", "", nil, cur, prev, 2*time.Second); ns.RxBytesPerSec != 0 {
+		t.Fatalf("new NIC should have no rate: %+v", ns)
+	}
+}
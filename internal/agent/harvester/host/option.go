@@ -17,76 +17,99 @@
 package host
 
 import (
-	"encoding/json"
 	"fmt"
 	"time"
 
-	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/internal/agent/harvester/plugin"
 )
 
-// Duration supports JSON unmarshaling from string (e.g. "1s", "10m") or number (nanoseconds).
-type Duration time.Duration
+const (
+	defaultInterval = 5 * time.Second
+	defaultTimeout  = 5 * time.Second
+)
 
-// UnmarshalJSON implements json.Unmarshaler.
-func (d *Duration) UnmarshalJSON(data []byte) error {
-	var v any
-	if err := json.Unmarshal(data, &v); err != nil {
-		return err
-	}
+// Collector names, usable as keys of Options.Collectors.
+const (
+	CollectorCPU      = "cpu"
+	CollectorMemory   = "memory"
+	CollectorDisk     = "disk"
+	CollectorNetwork  = "network"
+	CollectorIdentity = "identity"
+)
 
-	switch val := v.(type) {
-	case string:
-		parsed, err := time.ParseDuration(val)
-		if err != nil {
-			return err
-		}
-		*d = Duration(parsed)
-		return nil
+// collectorNames lists the collectors in the order they are started.
+var collectorNames = []string{CollectorCPU, CollectorMemory, CollectorDisk, CollectorNetwork, CollectorIdentity}
 
-	case float64:
-		*d = Duration(int64(val))
-		return nil
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
 
-	default:
-		return fmt.Errorf("invalid duration: %v", v)
-	}
+// CollectorOptions configures one collector. Zero values inherit from Options.
+type CollectorOptions struct {
+	// Enabled turns the collector on (default true).
+	Enabled *bool `yaml:"enabled" json:"enabled"`
+	// Interval between two runs of the collector; the latest result is reported on every
+	// plugin interval.
+	Interval Duration `yaml:"interval" json:"interval"`
+	// Timeout bounds one run; a run that exceeds it is abandoned and the previous values
+	// are kept.
+	Timeout Duration `yaml:"timeout" json:"timeout"`
 }
 
-// Duration returns the value as time.Duration.
-func (d Duration) Duration() time.Duration {
-	return time.Duration(d)
+// IsEnabled reports whether the collector is enabled.
+func (c CollectorOptions) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
 }
 
 // Options is the option for the host plugin.
 type Options struct {
+	// Interval between two host stats reports, and the default collector interval.
 	Interval Duration `yaml:"interval" json:"interval"`
-	Timeout  Duration `yaml:"timeout" json:"timeout"`
+	// Timeout is the default collector timeout.
+	Timeout Duration `yaml:"timeout" json:"timeout"`
+	// Collectors configures individual collectors by name (cpu, memory, disk, network,
+	// identity). Collectors not listed run with the plugin interval and timeout.
+	Collectors map[string]CollectorOptions `yaml:"collectors" json:"collectors"`
 }
 
-// OptionsFromAny converts opts (any) to Options. Supports nil, Options, and map[string]any (via JSON).
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
 func OptionsFromAny(opts any) (Options, error) {
-	if opts == nil {
-		return Options{}, nil
-	}
-
+	var out Options
 	if o, ok := opts.(Options); ok {
-		return o, nil
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
 	}
 
-	if m, ok := opts.(map[string]any); ok {
-		data, err := json.Marshal(m)
-		if err != nil {
-			return Options{}, fmt.Errorf("host options marshal: %w", err)
-		}
+	out.Interval = Duration(out.Interval.OrDefault(defaultInterval))
+	out.Timeout = Duration(out.Timeout.OrDefault(defaultTimeout))
 
-		var out Options
-		if err := json.Unmarshal(data, &out); err != nil {
-			logger.Errorf("host options unmarshal: %v, %v", err, string(data))
-			return Options{}, fmt.Errorf("host options unmarshal: %w", err)
+	collectors := make(map[string]CollectorOptions, len(collectorNames))
+	for name, c := range out.Collectors {
+		if !isCollectorName(name) {
+			return Options{}, fmt.Errorf("host options: unknown collector %q", name)
 		}
-
-		return out, nil
+		collectors[name] = c
+	}
+	for _, name := range collectorNames {
+		c := collectors[name]
+		if c.Enabled == nil {
+			enabled := true
+			c.Enabled = &enabled
+		}
+		c.Interval = Duration(c.Interval.OrDefault(out.Interval.Duration()))
+		c.Timeout = Duration(c.Timeout.OrDefault(out.Timeout.Duration()))
+		collectors[name] = c
 	}
+	out.Collectors = collectors
 
-	return Options{}, fmt.Errorf("unsupported options type: %T", opts)
+	return out, nil
+}
+
+func isCollectorName(name string) bool {
+	for _, n := range collectorNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
@@ -17,23 +17,33 @@
 package host
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"os-artificer/saber/pkg/sbmodels"
 
 	"github.com/shirou/gopsutil/v4/cpu"
-	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
 	"github.com/shirou/gopsutil/v4/mem"
 	netutil "github.com/shirou/gopsutil/v4/net"
 )
 
+// cpuSampleWindow is the window of the first CPU sample, when there is no previous
+// collection to compute the deltas from.
+const cpuSampleWindow = 100 * time.Millisecond
+
 // CollectCPU returns CPU usage percentage (0-100) using gopsutil.
 func CollectCPU() float64 {
-	percent, err := cpu.Percent(100*time.Millisecond, false)
+	percent, err := cpu.Percent(cpuSampleWindow, false)
 	if err != nil || len(percent) == 0 {
 		return 0
 	}
@@ -53,82 +63,13 @@ func CollectMemory() float64 {
 
 // CollectDisk returns disk usage for physical devices only (e.g. hard disks, CD-ROM, USB) using gopsutil; virtual/memory partitions (e.g. tmpfs, /dev/shm) are excluded.
 func CollectDisk() []sbmodels.DiskStats {
-	partitions, err := disk.Partitions(false)
-	if err != nil || len(partitions) == 0 {
-		return []sbmodels.DiskStats{}
-	}
-
-	diskStats := make([]sbmodels.DiskStats, 0)
-	for _, p := range partitions {
-		u, err := disk.Usage(p.Mountpoint)
-		if err != nil {
-			continue
-		}
-
-		diskStats = append(diskStats, sbmodels.DiskStats{
-			Mountpoint:  p.Mountpoint,
-			UsedPercent: u.UsedPercent,
-		})
-	}
-
+	diskStats, _ := newDiskCollector().collect(context.Background())
 	return diskStats
 }
 
 // CollectNetwork returns one NetworkStats per physical NIC (one 网卡 per record). Each record has that NIC's MAC, IPs, IfName, and traffic counters.
 func CollectNetwork() []sbmodels.NetworkStats {
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return []sbmodels.NetworkStats{}
-	}
-
-	counterMap := make(map[string]netutil.IOCountersStat)
-	if counters, err := netutil.IOCounters(true); err == nil {
-		for i := range counters {
-			counterMap[counters[i].Name] = counters[i]
-		}
-	}
-
-	out := make([]sbmodels.NetworkStats, 0)
-	for _, iface := range ifaces {
-		if iface.Flags&net.FlagUp == 0 {
-			continue
-		}
-		if !isPhysicalInterface(iface.Name) {
-			continue
-		}
-
-		mac := ""
-		if iface.HardwareAddr != nil {
-			mac = iface.HardwareAddr.String()
-		}
-		ips := make([]string, 0)
-		addrs, _ := iface.Addrs()
-		for _, addr := range addrs {
-			ipnet, ok := addr.(*net.IPNet)
-			if !ok || ipnet.IP.IsLoopback() {
-				continue
-			}
-			if ip := ipnet.IP.To4(); ip != nil {
-				ips = append(ips, ip.String())
-			}
-		}
-
-		c := counterMap[iface.Name]
-		out = append(out, sbmodels.NetworkStats{
-			MAC:       mac,
-			IPs:       ips,
-			IfName:    iface.Name,
-			RxBytes:   c.BytesRecv,
-			TxBytes:   c.BytesSent,
-			RxPackets: c.PacketsRecv,
-			TxPackets: c.PacketsSent,
-			RxErrors:  c.Errin,
-			TxErrors:  c.Errout,
-			RxFifo:    c.Fifoin,
-			TxFifo:    c.Fifoout,
-		})
-	}
-
+	out, _ := newNetworkCollector().collect(context.Background())
 	return out
 }
 
@@ -139,6 +80,10 @@ func CollectUptime() string {
 		return ""
 	}
 
+	return formatUptime(sec)
+}
+
+func formatUptime(sec uint64) string {
 	d := time.Duration(sec) * time.Second
 	days := int(d.Hours() / 24)
 	hours := int(d.Hours()) % 24
@@ -170,6 +115,11 @@ func CollectOS() string {
 	if err != nil {
 		return ""
 	}
+
+	return osName(info)
+}
+
+func osName(info *host.InfoStat) string {
 	if info.Platform != "" && info.PlatformVersion != "" {
 		return info.Platform + " " + info.PlatformVersion
 	}
@@ -200,24 +150,306 @@ func CollectKernel() string {
 	return version
 }
 
-// CollectStats fills s with collected host metrics/info.
+// collectIdentity fills the host identity fields of s (uptime, hostname, OS, arch and
+// kernel) from a single host info read.
+func collectIdentity(ctx context.Context, s *sbmodels.Stats) error {
+	info, err := host.InfoWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.Uptime = formatUptime(info.Uptime)
+	s.Hostname = info.Hostname
+	s.OS = osName(info)
+	s.Arch = info.KernelArch
+	s.Kernel = info.KernelVersion
+
+	return nil
+}
+
+// CollectStats fills s with collected host metrics/info. Every collector runs; the errors
+// of the failed ones are joined.
 func CollectStats(s *sbmodels.Stats) error {
-	s.CPU = CollectCPU()
-	s.Memory = CollectMemory()
+	var errs []error
+	for _, c := range newCollectors(nil) {
+		if err := c.collect(context.Background(), s); err != nil {
+			errs = append(errs, fmt.Errorf("%s collector: %w", c.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// counterRate returns the per-second rate of a cumulative counter between two reads, or 0
+// when the counter went backwards (reset or wrap) or no time elapsed.
+func counterRate(cur, prev uint64, elapsed time.Duration) float64 {
+	if cur < prev || elapsed <= 0 {
+		return 0
+	}
+
+	return float64(cur-prev) / elapsed.Seconds()
+}
+
+// cpuCollector reports CPU time shares from the deltas of the cumulative CPU times
+// between two collections, in total and per core, and the load averages.
+type cpuCollector struct {
+	times func(ctx context.Context, percpu bool) ([]cpu.TimesStat, error)
+	load  func(ctx context.Context) (*load.AvgStat, error)
+
+	prevTotal *cpu.TimesStat
+	prevCores map[string]cpu.TimesStat
+}
+
+func newCPUCollector() *cpuCollector {
+	return &cpuCollector{times: cpu.TimesWithContext, load: load.AvgWithContext}
+}
+
+func (c *cpuCollector) collect(ctx context.Context, s *sbmodels.Stats) error {
+	total, cores, err := c.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.prevTotal == nil {
+		first := total
+		c.prevTotal, c.prevCores = &first, cores
+
+		select {
+		case <-time.After(cpuSampleWindow):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if total, cores, err = c.read(ctx); err != nil {
+			return err
+		}
+	}
+
+	s.CPUTotal = cpuPercent(-1, *c.prevTotal, total)
+	s.CPU = s.CPUTotal.UsedPercent
+
+	s.CPUCores = make([]sbmodels.CPUStats, 0, len(cores))
+	for name, cur := range cores {
+		core, err := strconv.Atoi(strings.TrimPrefix(name, "cpu"))
+		if err != nil {
+			continue
+		}
+		if prev, ok := c.prevCores[name]; ok {
+			s.CPUCores = append(s.CPUCores, cpuPercent(core, prev, cur))
+		}
+	}
+	sort.Slice(s.CPUCores, func(i, j int) bool { return s.CPUCores[i].Core < s.CPUCores[j].Core })
+	c.prevTotal, c.prevCores = &total, cores
+
+	avg, err := c.load(ctx)
+	if err != nil {
+		return fmt.Errorf("load average: %w", err)
+	}
+	s.Load = sbmodels.LoadStats{Load1: avg.Load1, Load5: avg.Load5, Load15: avg.Load15}
+
+	return nil
+}
+
+func (c *cpuCollector) read(ctx context.Context) (cpu.TimesStat, map[string]cpu.TimesStat, error) {
+	total, err := c.times(ctx, false)
+	if err != nil {
+		return cpu.TimesStat{}, nil, err
+	}
+	if len(total) == 0 {
+		return cpu.TimesStat{}, nil, errors.New("no cpu times reported")
+	}
 
-	s.Disk = CollectDisk()
-	s.Networks = CollectNetwork()
+	perCPU, err := c.times(ctx, true)
+	if err != nil {
+		return cpu.TimesStat{}, nil, err
+	}
 
-	s.Uptime = CollectUptime()
-	s.Hostname = CollectHostname()
+	cores := make(map[string]cpu.TimesStat, len(perCPU))
+	for _, t := range perCPU {
+		cores[t.CPU] = t
+	}
 
-	s.OS = CollectOS()
-	s.Arch = CollectArch()
-	s.Kernel = CollectKernel()
+	return total[0], cores, nil
+}
+
+// cpuPercent returns the share of each CPU state between two reads of the cumulative CPU
+// times. Guest time is already accounted in user time on Linux and is left out.
+func cpuPercent(core int, prev, cur cpu.TimesStat) sbmodels.CPUStats {
+	sum := func(t cpu.TimesStat) float64 {
+		return t.User + t.System + t.Nice + t.Idle + t.Iowait + t.Irq + t.Softirq + t.Steal
+	}
+
+	out := sbmodels.CPUStats{Core: core}
+	elapsed := sum(cur) - sum(prev)
+	if elapsed <= 0 {
+		return out
+	}
+
+	share := func(c, p float64) float64 {
+		return math.Min(100, math.Max(0, (c-p)/elapsed*100))
+	}
+	out.User = share(cur.User, prev.User)
+	out.System = share(cur.System, prev.System)
+	out.Nice = share(cur.Nice, prev.Nice)
+	out.Idle = share(cur.Idle, prev.Idle)
+	out.IOWait = share(cur.Iowait, prev.Iowait)
+	out.IRQ = share(cur.Irq, prev.Irq)
+	out.SoftIRQ = share(cur.Softirq, prev.Softirq)
+	out.Steal = share(cur.Steal, prev.Steal)
+	out.UsedPercent = math.Max(0, 100-out.Idle-out.IOWait)
+
+	return out
+}
+
+// memoryCollector reports memory and swap usage, and swap in/out rates.
+type memoryCollector struct {
+	virtual func(ctx context.Context) (*mem.VirtualMemoryStat, error)
+	swap    func(ctx context.Context) (*mem.SwapMemoryStat, error)
+
+	prevSwap *mem.SwapMemoryStat
+	prevTime time.Time
+}
+
+func newMemoryCollector() *memoryCollector {
+	return &memoryCollector{virtual: mem.VirtualMemoryWithContext, swap: mem.SwapMemoryWithContext}
+}
+
+func (c *memoryCollector) collect(ctx context.Context, s *sbmodels.Stats) error {
+	v, err := c.virtual(ctx)
+	if err != nil {
+		return err
+	}
+	sw, err := c.swap(ctx)
+	if err != nil {
+		return fmt.Errorf("swap: %w", err)
+	}
+	now := time.Now()
+
+	s.Memory = v.UsedPercent
+	s.MemoryDetail = sbmodels.MemoryStats{
+		TotalBytes:      v.Total,
+		AvailableBytes:  v.Available,
+		UsedBytes:       v.Used,
+		SwapTotalBytes:  sw.Total,
+		SwapUsedBytes:   sw.Used,
+		SwapFreeBytes:   sw.Free,
+		SwapUsedPercent: sw.UsedPercent,
+	}
+	if c.prevSwap != nil {
+		elapsed := now.Sub(c.prevTime)
+		s.MemoryDetail.SwapInBytesPerSec = counterRate(sw.Sin, c.prevSwap.Sin, elapsed)
+		s.MemoryDetail.SwapOutBytesPerSec = counterRate(sw.Sout, c.prevSwap.Sout, elapsed)
+	}
+	c.prevSwap, c.prevTime = sw, now
 
 	return nil
 }
 
+// networkCollector reports physical NICs with their cumulative traffic counters and the
+// per-second rates since the previous collection.
+type networkCollector struct {
+	counters func(ctx context.Context) ([]netutil.IOCountersStat, error)
+
+	prev     map[string]netutil.IOCountersStat
+	prevTime time.Time
+}
+
+func newNetworkCollector() *networkCollector {
+	return &networkCollector{
+		counters: func(ctx context.Context) ([]netutil.IOCountersStat, error) {
+			return netutil.IOCountersWithContext(ctx, true)
+		},
+	}
+}
+
+func (c *networkCollector) collect(ctx context.Context) ([]sbmodels.NetworkStats, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return []sbmodels.NetworkStats{}, err
+	}
+
+	counterMap := make(map[string]netutil.IOCountersStat)
+	if counters, err := c.counters(ctx); err == nil {
+		for i := range counters {
+			counterMap[counters[i].Name] = counters[i]
+		}
+	}
+	now := time.Now()
+	elapsed := now.Sub(c.prevTime)
+
+	out := make([]sbmodels.NetworkStats, 0)
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if !isPhysicalInterface(iface.Name) {
+			continue
+		}
+
+		mac := ""
+		if iface.HardwareAddr != nil {
+			mac = iface.HardwareAddr.String()
+		}
+		ips := make([]string, 0)
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || ipnet.IP.IsLoopback() {
+				continue
+			}
+			if ip := ipnet.IP.To4(); ip != nil {
+				ips = append(ips, ip.String())
+			}
+		}
+
+		out = append(out, networkStats(iface.Name, mac, ips, counterMap[iface.Name], c.prev, elapsed))
+	}
+	c.prev, c.prevTime = counterMap, now
+
+	return out, nil
+}
+
+// networkStats builds the record of one NIC; rates are computed when prev holds an
+// earlier read of the same NIC.
+func networkStats(name, mac string, ips []string, cur netutil.IOCountersStat,
+	prev map[string]netutil.IOCountersStat, elapsed time.Duration) sbmodels.NetworkStats {
+	ns := sbmodels.NetworkStats{
+		MAC:       mac,
+		IPs:       ips,
+		IfName:    name,
+		RxBytes:   cur.BytesRecv,
+		TxBytes:   cur.BytesSent,
+		RxPackets: cur.PacketsRecv,
+		TxPackets: cur.PacketsSent,
+		RxErrors:  cur.Errin,
+		TxErrors:  cur.Errout,
+		RxFifo:    cur.Fifoin,
+		TxFifo:    cur.Fifoout,
+	}
+
+	if p, ok := prev[name]; ok {
+		ns.RxBytesPerSec = counterRate(cur.BytesRecv, p.BytesRecv, elapsed)
+		ns.TxBytesPerSec = counterRate(cur.BytesSent, p.BytesSent, elapsed)
+		ns.RxPacketsPerSec = counterRate(cur.PacketsRecv, p.PacketsRecv, elapsed)
+		ns.TxPacketsPerSec = counterRate(cur.PacketsSent, p.PacketsSent, elapsed)
+	}
+
+	return ns
+}
+
+// blockDeviceName returns the name under which device appears in /proc/diskstats, e.g.
+// sda1 for /dev/sda1 and dm-0 for /dev/mapper/vg-root.
+func blockDeviceName(device string) string {
+	if !strings.HasPrefix(device, "/dev/") {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(device); err == nil {
+		device = resolved
+	}
+
+	return filepath.Base(device)
+}
+
 // isPhysicalInterface returns true if the interface name looks like a real physical NIC.
 // Excludes loopback, bridges, veth, docker, virbr, tun/tap and other virtual interfaces.
 func isPhysicalInterface(name string) bool {
@@ -0,0 +1,319 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package kernel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/internal/agent/harvester/procfs"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const kernelPluginVersion = "1.0.0"
+const pluginName = "kernel"
+
+func init() {
+	plugin.RegisterPlugin(pluginName, newKernelPlugin)
+}
+
+// KernelPlugin reports loaded kernel modules, the kernel taint value, hardening sysctls
+// and the active Linux security modules, and emits change events when any of them flip.
+type KernelPlugin struct {
+	plugin.UnimplementedPlugin
+
+	wg   sync.WaitGroup
+	done chan struct{}
+	opts Options
+}
+
+// state is one read of the kernel state; modules are keyed by name.
+type state struct {
+	sbmodels.KernelState
+	modules map[string]sbmodels.KernelModule
+}
+
+func newKernelPlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
+	kernelOptions, err := OptionsFromAny(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("kernel plugin options: %+v", kernelOptions)
+
+	return &KernelPlugin{opts: kernelOptions, done: make(chan struct{})}, nil
+}
+
+func (p *KernelPlugin) Version() string {
+	return kernelPluginVersion
+}
+
+func (p *KernelPlugin) Name() string {
+	return pluginName
+}
+
+func (p *KernelPlugin) Run(ctx context.Context) (plugin.EventC, error) {
+	eventC := make(plugin.EventC)
+	done := p.done
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(eventC)
+
+		send := func(name string, data any) bool {
+			select {
+			case eventC <- &plugin.Event{PluginName: p.Name(), EventName: name, Data: data}:
+				return true
+			case <-done:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		prev, err := p.collect()
+		if err != nil {
+			logger.Errorf("failed to collect kernel state: %v", err)
+		}
+		if !send(sbmodels.KernelEventState, p.snapshot(prev)) {
+			return
+		}
+
+		ticker := time.NewTicker(p.opts.Interval.Duration())
+		defer ticker.Stop()
+
+		snapshotTicker := time.NewTicker(p.opts.SnapshotInterval.Duration())
+		defer snapshotTicker.Stop()
+
+		for {
+			select {
+			case <-done:
+				logger.Infof("kernel plugin run exited: %s", p.Name())
+				return
+
+			case <-ctx.Done():
+				logger.Infof("kernel plugin run exited: %s", p.Name())
+				return
+
+			case <-ticker.C:
+				cur, err := p.collect()
+				if err != nil {
+					logger.Errorf("failed to collect kernel state: %v", err)
+					continue
+				}
+
+				if prev == nil {
+					// The first read failed; report the full state instead of a diff.
+					if !send(sbmodels.KernelEventState, p.snapshot(cur)) {
+						return
+					}
+				} else {
+					for _, ev := range diff(prev, cur) {
+						if !send(ev.Action, ev) {
+							return
+						}
+					}
+				}
+				prev = cur
+
+			case <-snapshotTicker.C:
+				if !send(sbmodels.KernelEventState, p.snapshot(prev)) {
+					return
+				}
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+func (p *KernelPlugin) Close() error {
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+
+	p.wg.Wait()
+	return nil
+}
+
+// collect reads the kernel state. Failing to read the module list or the taint value is
+// an error, so that a transient failure is not reported as every module unloading;
+// sysctls and security modules absent on this kernel are omitted.
+func (p *KernelPlugin) collect() (*state, error) {
+	s := &state{modules: make(map[string]sbmodels.KernelModule)}
+	s.Sysctls = make(map[string]string)
+
+	s.Release, _ = procfs.ReadSysctl(p.opts.ProcRoot, "kernel.osrelease")
+	s.Version, _ = procfs.ReadSysctl(p.opts.ProcRoot, "kernel.version")
+
+	tainted, err := procfs.ReadTainted(p.opts.ProcRoot)
+	if err != nil {
+		return nil, fmt.Errorf("read kernel taint: %w", err)
+	}
+	s.Tainted, s.TaintFlags = tainted, procfs.TaintFlags(tainted)
+
+	modules, err := procfs.ReadModules(p.opts.ProcRoot)
+	if err != nil {
+		return nil, fmt.Errorf("read kernel modules: %w", err)
+	}
+	for _, m := range modules {
+		s.modules[m.Name] = sbmodels.KernelModule{
+			Name:     m.Name,
+			Size:     m.Size,
+			RefCount: m.RefCount,
+			UsedBy:   m.UsedBy,
+			State:    m.State,
+			Taint:    m.Taint,
+		}
+	}
+
+	for _, name := range p.opts.Sysctls {
+		value, err := procfs.ReadSysctl(p.opts.ProcRoot, name)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				logger.Debugf("kernel plugin: read sysctl %s failed: %v", name, err)
+			}
+			continue
+		}
+		s.Sysctls[name] = value
+	}
+
+	if data, err := os.ReadFile(filepath.Join(p.opts.SysRoot, "kernel/security/lsm")); err == nil {
+		for _, lsm := range strings.Split(strings.TrimSpace(string(data)), ",") {
+			if lsm != "" {
+				s.LSMs = append(s.LSMs, lsm)
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(p.opts.SysRoot, "kernel/security/lockdown")); err == nil {
+		s.Lockdown = selectedLockdown(string(data))
+	}
+
+	return s, nil
+}
+
+// selectedLockdown returns the bracketed mode of "none [integrity] confidentiality".
+func selectedLockdown(s string) string {
+	for _, f := range strings.Fields(s) {
+		if strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") {
+			return strings.Trim(f, "[]")
+		}
+	}
+	return ""
+}
+
+// snapshot returns the event model of s with modules sorted by name. A nil s (the first
+// read failed) yields an empty state.
+func (p *KernelPlugin) snapshot(s *state) *sbmodels.KernelState {
+	if s == nil {
+		return &sbmodels.KernelState{Modules: []sbmodels.KernelModule{}, Sysctls: map[string]string{}, Timestamp: time.Now()}
+	}
+
+	out := s.KernelState
+	out.Modules = make([]sbmodels.KernelModule, 0, len(s.modules))
+	for _, m := range s.modules {
+		out.Modules = append(out.Modules, m)
+	}
+	sort.Slice(out.Modules, func(i, j int) bool { return out.Modules[i].Name < out.Modules[j].Name })
+	out.Timestamp = time.Now()
+	return &out
+}
+
+// diff returns the events that turn prev into cur.
+func diff(prev, cur *state) []*sbmodels.KernelEvent {
+	now := time.Now()
+	var out []*sbmodels.KernelEvent
+	add := func(ev *sbmodels.KernelEvent) {
+		ev.Timestamp = now
+		out = append(out, ev)
+	}
+
+	// A module whose size or taint changed under the same name was replaced: it is
+	// reported as unloaded, then loaded again.
+	for _, name := range sortedKeys(prev.modules) {
+		m := prev.modules[name]
+		if c, ok := cur.modules[name]; !ok || !sameModule(m, c) {
+			add(&sbmodels.KernelEvent{Action: sbmodels.KernelEventModuleUnloaded, Module: &m})
+		}
+	}
+	for _, name := range sortedKeys(cur.modules) {
+		m := cur.modules[name]
+		if p, ok := prev.modules[name]; !ok || !sameModule(p, m) {
+			add(&sbmodels.KernelEvent{Action: sbmodels.KernelEventModuleLoaded, Module: &m})
+		}
+	}
+
+	if prev.Tainted != cur.Tainted {
+		add(&sbmodels.KernelEvent{
+			Action:     sbmodels.KernelEventTaintChanged,
+			Name:       "kernel.tainted",
+			Previous:   strconv.FormatUint(prev.Tainted, 10),
+			Current:    strconv.FormatUint(cur.Tainted, 10),
+			TaintFlags: cur.TaintFlags,
+		})
+	}
+
+	names := sortedKeys(prev.Sysctls)
+	for name := range cur.Sysctls {
+		if _, ok := prev.Sysctls[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		old, cur := prev.Sysctls[name], cur.Sysctls[name]
+		if old != cur {
+			add(&sbmodels.KernelEvent{Action: sbmodels.KernelEventSysctlChanged, Name: name, Previous: old, Current: cur})
+		}
+	}
+
+	if old, cur := strings.Join(prev.LSMs, ","), strings.Join(cur.LSMs, ","); old != cur {
+		add(&sbmodels.KernelEvent{Action: sbmodels.KernelEventLSMChanged, Name: "lsm", Previous: old, Current: cur})
+	}
+	if prev.Lockdown != cur.Lockdown {
+		add(&sbmodels.KernelEvent{Action: sbmodels.KernelEventLSMChanged, Name: "lockdown", Previous: prev.Lockdown, Current: cur.Lockdown})
+	}
+
+	return out
+}
+
+// sameModule reports whether a and b are the same module image. Reference counts, users
+// and state change while a module is in use and are not compared.
+func sameModule(a, b sbmodels.KernelModule) bool {
+	return a.Size == b.Size && a.Taint == b.Taint
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,169 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package kernel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func newTestTree(t *testing.T) (procRoot, sysRoot string) {
+	root := t.TempDir()
+	procRoot, sysRoot = filepath.Join(root, "proc"), filepath.Join(root, "sys")
+
+	writeFile(t, filepath.Join(procRoot, "sys/kernel/osrelease"), "6.1.0-18-amd64\n")
+	writeFile(t, filepath.Join(procRoot, "sys/kernel/version"), "#1 SMP PREEMPT_DYNAMIC Debian 6.1.76-1\n")
+	writeFile(t, filepath.Join(procRoot, "sys/kernel/tainted"), "0\n")
+	writeFile(t, filepath.Join(procRoot, "sys/kernel/randomize_va_space"), "2\n")
+	writeFile(t, filepath.Join(procRoot, "sys/kernel/yama/ptrace_scope"), "1\n")
+	writeFile(t, filepath.Join(procRoot, "sys/net/ipv4/ip_forward"), "0\n")
+	writeFile(t, filepath.Join(procRoot, "modules"),
+		"ext4 1007616 1 - Live 0x0000000000000000\n"+
+			"mbcache 16384 1 ext4, Live 0x0000000000000000\n")
+	writeFile(t, filepath.Join(sysRoot, "kernel/security/lsm"), "lockdown,capability,landlock,yama,apparmor\n")
+	writeFile(t, filepath.Join(sysRoot, "kernel/security/lockdown"), "[none] integrity confidentiality\n")
+	return procRoot, sysRoot
+}
+
+func TestKernelPlugin_collect(t *testing.T) {
+	procRoot, sysRoot := newTestTree(t)
+
+	p, err := newKernelPlugin(context.Background(), map[string]any{"procRoot": procRoot, "sysRoot": sysRoot})
+	if err != nil {
+		t.Fatalf("newKernelPlugin: %v", err)
+	}
+
+	s, err := p.(*KernelPlugin).collect()
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	if s.Release != "6.1.0-18-amd64" || s.Tainted != 0 || len(s.modules) != 2 {
+		t.Fatalf("state = %+v", s)
+	}
+	if len(s.Sysctls) != 3 || s.Sysctls["kernel.yama.ptrace_scope"] != "1" {
+		t.Fatalf("sysctls = %v", s.Sysctls)
+	}
+	if len(s.LSMs) != 5 || s.LSMs[4] != "apparmor" || s.Lockdown != "none" {
+		t.Fatalf("lsms = %v lockdown = %q", s.LSMs, s.Lockdown)
+	}
+	if m := s.modules["mbcache"]; len(m.UsedBy) != 1 || m.UsedBy[0] != "ext4" {
+		t.Fatalf("mbcache = %+v", m)
+	}
+
+	snap := p.(*KernelPlugin).snapshot(s)
+	if snap.Modules[0].Name != "ext4" || snap.Modules[1].Name != "mbcache" {
+		t.Fatalf("snapshot modules = %+v", snap.Modules)
+	}
+}
+
+func TestKernelPlugin_changes(t *testing.T) {
+	procRoot, sysRoot := newTestTree(t)
+
+	p, err := newKernelPlugin(context.Background(), map[string]any{
+		"procRoot": procRoot,
+		"sysRoot":  sysRoot,
+		"interval": "20ms",
+	})
+	if err != nil {
+		t.Fatalf("newKernelPlugin: %v", err)
+	}
+	defer p.Close()
+
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	first := <-eventC
+	if first.EventName != sbmodels.KernelEventState {
+		t.Fatalf("first event = %+v", first)
+	}
+
+	writeFile(t, filepath.Join(procRoot, "modules"),
+		"ext4 1007616 1 - Live 0x0000000000000000\n"+
+			"mbcache 16384 1 ext4, Live 0x0000000000000000\n"+
+			"diamorphine 16384 0 - Live 0x0000000000000000 (OE)\n")
+	writeFile(t, filepath.Join(procRoot, "sys/kernel/tainted"), "12288\n")
+	writeFile(t, filepath.Join(procRoot, "sys/net/ipv4/ip_forward"), "1\n")
+	writeFile(t, filepath.Join(sysRoot, "kernel/security/lsm"), "capability\n")
+
+	got := make(map[string]*sbmodels.KernelEvent)
+	timeout := time.After(5 * time.Second)
+	for len(got) < 4 {
+		select {
+		case ev := <-eventC:
+			got[ev.EventName] = ev.Data.(*sbmodels.KernelEvent)
+		case <-timeout:
+			t.Fatalf("got %v", got)
+		}
+	}
+
+	if ev := got[sbmodels.KernelEventModuleLoaded]; ev.Module.Name != "diamorphine" || ev.Module.Taint != "OE" {
+		t.Fatalf("module loaded = %+v", ev.Module)
+	}
+	if ev := got[sbmodels.KernelEventTaintChanged]; ev.Previous != "0" || ev.Current != "12288" ||
+		len(ev.TaintFlags) != 2 || ev.TaintFlags[0] != "O" || ev.TaintFlags[1] != "E" {
+		t.Fatalf("taint changed = %+v", ev)
+	}
+	if ev := got[sbmodels.KernelEventSysctlChanged]; ev.Name != "net.ipv4.ip_forward" || ev.Previous != "0" || ev.Current != "1" {
+		t.Fatalf("sysctl changed = %+v", ev)
+	}
+	if ev := got[sbmodels.KernelEventLSMChanged]; ev.Name != "lsm" || ev.Current != "capability" {
+		t.Fatalf("lsm changed = %+v", ev)
+	}
+}
+
+func TestDiff_replacedModule(t *testing.T) {
+	module := func(name string, size uint64, refCount int, taint string) sbmodels.KernelModule {
+		return sbmodels.KernelModule{Name: name, Size: size, RefCount: refCount, State: "Live", Taint: taint}
+	}
+	prev := &state{modules: map[string]sbmodels.KernelModule{
+		"ext4":    module("ext4", 1007616, 1, ""),
+		"mbcache": module("mbcache", 16384, 1, ""),
+	}}
+	cur := &state{modules: map[string]sbmodels.KernelModule{
+		"ext4":    module("ext4", 1007616, 3, ""),
+		"mbcache": module("mbcache", 20480, 1, "OE"),
+	}}
+
+	events := diff(prev, cur)
+	if len(events) != 2 {
+		t.Fatalf("events = %d, want mbcache unloaded and loaded again", len(events))
+	}
+	if ev := events[0]; ev.Action != sbmodels.KernelEventModuleUnloaded || ev.Module.Size != 16384 {
+		t.Fatalf("first event = %s %+v", ev.Action, ev.Module)
+	}
+	if ev := events[1]; ev.Action != sbmodels.KernelEventModuleLoaded || ev.Module.Size != 20480 || ev.Module.Taint != "OE" {
+		t.Fatalf("second event = %s %+v", ev.Action, ev.Module)
+	}
+}
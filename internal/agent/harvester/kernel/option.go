@@ -0,0 +1,103 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package kernel
+
+import (
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+)
+
+const (
+	defaultInterval         = 1 * time.Minute
+	defaultSnapshotInterval = 1 * time.Hour
+	defaultProcRoot         = "/proc"
+	defaultSysRoot          = "/sys"
+)
+
+// defaultSysctls are the hardening-relevant sysctls reported when Sysctls is not set.
+var defaultSysctls = []string{
+	"kernel.randomize_va_space",
+	"kernel.kptr_restrict",
+	"kernel.dmesg_restrict",
+	"kernel.yama.ptrace_scope",
+	"kernel.modules_disabled",
+	"kernel.kexec_load_disabled",
+	"kernel.unprivileged_bpf_disabled",
+	"kernel.unprivileged_userns_clone",
+	"kernel.perf_event_paranoid",
+	"kernel.sysrq",
+	"kernel.core_pattern",
+	"fs.suid_dumpable",
+	"fs.protected_symlinks",
+	"fs.protected_hardlinks",
+	"fs.protected_fifos",
+	"fs.protected_regular",
+	"user.max_user_namespaces",
+	"vm.mmap_min_addr",
+	"net.core.bpf_jit_harden",
+	"net.ipv4.ip_forward",
+	"net.ipv6.conf.all.forwarding",
+	"net.ipv4.tcp_syncookies",
+	"net.ipv4.conf.all.rp_filter",
+	"net.ipv4.conf.all.accept_redirects",
+	"net.ipv4.conf.all.send_redirects",
+	"net.ipv4.conf.all.accept_source_route",
+	"net.ipv4.conf.all.log_martians",
+}
+
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
+
+// Options is the option for the kernel plugin.
+type Options struct {
+	// Interval between two reads of the kernel state; change events are diffs between reads.
+	Interval Duration `yaml:"interval" json:"interval"`
+	// SnapshotInterval between two full kernel state reports.
+	SnapshotInterval Duration `yaml:"snapshotInterval" json:"snapshotInterval"`
+	// Sysctls are the sysctls reported, in dotted form. Empty uses the built-in hardening list.
+	Sysctls []string `yaml:"sysctls" json:"sysctls"`
+	// ProcRoot and SysRoot are the procfs and sysfs mount points, e.g. /host/proc and
+	// /host/sys when running in a container.
+	ProcRoot string `yaml:"procRoot" json:"procRoot"`
+	SysRoot  string `yaml:"sysRoot" json:"sysRoot"`
+}
+
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
+func OptionsFromAny(opts any) (Options, error) {
+	var out Options
+	if o, ok := opts.(Options); ok {
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
+	}
+
+	out.Interval = Duration(out.Interval.OrDefault(defaultInterval))
+	out.SnapshotInterval = Duration(out.SnapshotInterval.OrDefault(defaultSnapshotInterval))
+
+	if len(out.Sysctls) == 0 {
+		out.Sysctls = append([]string(nil), defaultSysctls...)
+	}
+	if out.ProcRoot == "" {
+		out.ProcRoot = defaultProcRoot
+	}
+	if out.SysRoot == "" {
+		out.SysRoot = defaultSysRoot
+	}
+
+	return out, nil
+}
@@ -17,6 +17,14 @@
 package harvester
 
 import (
+	_ "os-artificer/saber/internal/agent/harvester/accounts"
+	_ "os-artificer/saber/internal/agent/harvester/auth"
 	_ "os-artificer/saber/internal/agent/harvester/file"
+	_ "os-artificer/saber/internal/agent/harvester/fim"
 	_ "os-artificer/saber/internal/agent/harvester/host"
+	_ "os-artificer/saber/internal/agent/harvester/kernel"
+	_ "os-artificer/saber/internal/agent/harvester/netconn"
+	_ "os-artificer/saber/internal/agent/harvester/packages"
+	_ "os-artificer/saber/internal/agent/harvester/persistence"
+	_ "os-artificer/saber/internal/agent/harvester/process"
 )
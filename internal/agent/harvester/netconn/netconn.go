@@ -0,0 +1,183 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package netconn
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/container"
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const netconnPluginVersion = "1.0.0"
+const pluginName = "netconn"
+
+func init() {
+	plugin.RegisterPlugin(pluginName, newNetconnPlugin)
+}
+
+// NetconnPlugin scans the kernel socket tables and reports listening sockets and new
+// outbound connections as diffs between scans, joined to the owning process.
+type NetconnPlugin struct {
+	plugin.UnimplementedPlugin
+
+	wg      sync.WaitGroup
+	done    chan struct{}
+	opts    Options
+	scanner *scanner
+}
+
+func newNetconnPlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
+	netconnOptions, err := OptionsFromAny(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("netconn plugin options: %+v", netconnOptions)
+
+	p := &NetconnPlugin{opts: netconnOptions, done: make(chan struct{})}
+	p.scanner = &scanner{opts: &p.opts, resolver: container.NewResolver(p.opts.Container, p.opts.ProcRoot)}
+	return p, nil
+}
+
+func (p *NetconnPlugin) Version() string {
+	return netconnPluginVersion
+}
+
+func (p *NetconnPlugin) Name() string {
+	return pluginName
+}
+
+func (p *NetconnPlugin) Run(ctx context.Context) (plugin.EventC, error) {
+	eventC := make(plugin.EventC)
+	done := p.done
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(eventC)
+
+		send := func(name string, data any) bool {
+			select {
+			case eventC <- &plugin.Event{PluginName: p.Name(), EventName: name, Data: data}:
+				return true
+			case <-done:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		prev, err := p.scanner.scan(nil)
+		if err != nil {
+			logger.Errorf("failed to scan sockets: %v", err)
+			prev = newSockets()
+		}
+		if !send(sbmodels.SocketEventSnapshot, p.snapshot(prev)) {
+			return
+		}
+
+		ticker := time.NewTicker(p.opts.Interval.Duration())
+		defer ticker.Stop()
+
+		snapshotTicker := time.NewTicker(p.opts.SnapshotInterval.Duration())
+		defer snapshotTicker.Stop()
+
+		for {
+			select {
+			case <-done:
+				logger.Infof("netconn plugin run exited: %s", p.Name())
+				return
+
+			case <-ctx.Done():
+				logger.Infof("netconn plugin run exited: %s", p.Name())
+				return
+
+			case <-ticker.C:
+				cur, err := p.scanner.scan(prev)
+				if err != nil {
+					logger.Errorf("failed to scan sockets: %v", err)
+					continue
+				}
+
+				listened, unlistened := diff(prev.listening, cur.listening)
+				connected, _ := diff(prev.outbound, cur.outbound)
+				prev = cur
+
+				p.scanner.resolveOwners(append(append([]*sbmodels.SocketInfo{}, listened...), connected...))
+
+				now := time.Now()
+				for _, events := range []struct {
+					action string
+					infos  []*sbmodels.SocketInfo
+				}{
+					{sbmodels.SocketEventUnlisten, unlistened},
+					{sbmodels.SocketEventListen, listened},
+					{sbmodels.SocketEventConnect, connected},
+				} {
+					for _, info := range events.infos {
+						if !send(events.action, &sbmodels.SocketEvent{Action: events.action, Socket: *info, Timestamp: now}) {
+							return
+						}
+					}
+				}
+
+			case <-snapshotTicker.C:
+				if !send(sbmodels.SocketEventSnapshot, p.snapshot(prev)) {
+					return
+				}
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+func (p *NetconnPlugin) Close() error {
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+
+	p.wg.Wait()
+	return nil
+}
+
+// snapshot resolves the owners of every socket in s and lists them.
+func (p *NetconnPlugin) snapshot(s *sockets) *sbmodels.SocketSnapshot {
+	listening := values(s.listening)
+	outbound := values(s.outbound)
+	p.scanner.resolveOwners(append(append([]*sbmodels.SocketInfo{}, listening...), outbound...))
+
+	out := &sbmodels.SocketSnapshot{
+		Listening: make([]sbmodels.SocketInfo, 0, len(listening)),
+		Outbound:  make([]sbmodels.SocketInfo, 0, len(outbound)),
+		Timestamp: time.Now(),
+	}
+	for _, info := range listening {
+		out.Listening = append(out.Listening, *info)
+	}
+	for _, info := range outbound {
+		out.Outbound = append(out.Outbound, *info)
+	}
+
+	return out
+}
@@ -0,0 +1,133 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package netconn
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"os-artificer/saber/internal/agent/harvester/procfs"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+func TestScanner_classifiesListenerAndOutbound(t *testing.T) {
+	if _, err := os.Stat("/proc/net/tcp"); err != nil {
+		t.Skip("procfs not available")
+	}
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	port := uint16(ln.Addr().(*net.TCPAddr).Port)
+
+	opts, err := OptionsFromAny(map[string]any{"protocols": []any{"tcp"}})
+	if err != nil {
+		t.Fatalf("OptionsFromAny: %v", err)
+	}
+	s := &scanner{opts: &opts}
+
+	before, err := s.scan(nil)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	listener, ok := before.listening["tcp|127.0.0.1|"+strconv.Itoa(int(port))]
+	if !ok {
+		t.Fatalf("listener on port %d not found", port)
+	}
+	s.resolveOwners([]*sbmodels.SocketInfo{listener})
+	if listener.PID != int32(os.Getpid()) || listener.State != "LISTEN" {
+		t.Fatalf("listener = %+v, want owned by pid %d", listener, os.Getpid())
+	}
+
+	conn, err := net.Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	accepted, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer accepted.Close()
+
+	after, err := s.scan(before)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if after.listening["tcp|127.0.0.1|"+strconv.Itoa(int(port))] != listener {
+		t.Fatal("resolved listener was not carried over to the next scan")
+	}
+
+	connected, _ := diff(before.outbound, after.outbound)
+	localPort := uint16(conn.LocalAddr().(*net.TCPAddr).Port)
+	found := false
+	for _, info := range connected {
+		if info.LocalPort == port {
+			t.Fatalf("accepted connection reported as outbound: %+v", info)
+		}
+		if info.LocalPort == localPort && info.RemotePort == port {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("outbound connection %d->%d not reported", localPort, port)
+	}
+}
+
+func TestAcceptedBy(t *testing.T) {
+	listen := func(proto, ip string, port uint16) string {
+		return listenKey(proto, &procfs.InetSocket{LocalIP: net.ParseIP(ip), LocalPort: port})
+	}
+	listeners := map[string]struct{}{
+		listen(protoTCP, "0.0.0.0", 80):    {},
+		listen(protoTCP, "10.0.0.1", 8080): {},
+		listen(protoTCP6, "::", 443):       {},
+	}
+
+	tests := []struct {
+		name  string
+		proto string
+		ip    string
+		port  uint16
+		want  bool
+	}{
+		{"wildcard listener", protoTCP, "10.0.0.1", 80, true},
+		{"bound listener", protoTCP, "10.0.0.1", 8080, true},
+		{"listener on another address", protoTCP, "10.0.0.2", 8080, false},
+		{"listener of the other family", protoTCP6, "fe80::1", 80, false},
+		{"ipv6 wildcard listener", protoTCP6, "fe80::1", 443, true},
+		{"ipv4 port of an ipv6 listener", protoTCP, "10.0.0.1", 443, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sock := &procfs.InetSocket{LocalIP: net.ParseIP(tt.ip), LocalPort: tt.port}
+			if got := acceptedBy(listeners, tt.proto, sock); got != tt.want {
+				t.Fatalf("acceptedBy(%s %s:%d) = %t, want %t", tt.proto, tt.ip, tt.port, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionsFromAny_rejectsUnknownProtocol(t *testing.T) {
+	if _, err := OptionsFromAny(map[string]any{"protocols": []any{"sctp"}}); err == nil {
+		t.Fatal("unknown protocol should fail")
+	}
+}
@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package netconn
+
+import (
+	"fmt"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/container"
+	"os-artificer/saber/internal/agent/harvester/plugin"
+)
+
+const (
+	protoTCP  = "tcp"
+	protoTCP6 = "tcp6"
+	protoUDP  = "udp"
+	protoUDP6 = "udp6"
+	protoUnix = "unix"
+
+	defaultInterval         = 15 * time.Second
+	defaultSnapshotInterval = 10 * time.Minute
+	defaultProcRoot         = "/proc"
+)
+
+var allProtocols = []string{protoTCP, protoTCP6, protoUDP, protoUDP6, protoUnix}
+
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
+
+// Options is the option for the netconn plugin.
+type Options struct {
+	// Interval between two socket table scans; events are diffs between scans.
+	Interval Duration `yaml:"interval" json:"interval"`
+	// SnapshotInterval between two full listings of listeners and outbound connections.
+	SnapshotInterval Duration `yaml:"snapshotInterval" json:"snapshotInterval"`
+	// Protocols to scan: any of tcp, tcp6, udp, udp6, unix (default all).
+	Protocols []string `yaml:"protocols" json:"protocols"`
+	// ResolveProcesses joins sockets to their owning process via /proc/<pid>/fd (default true).
+	ResolveProcesses *bool `yaml:"resolveProcesses" json:"resolveProcesses"`
+	// ProcRoot is the procfs mount point, e.g. /host/proc when running in a container.
+	ProcRoot string `yaml:"procRoot" json:"procRoot"`
+	// Container configures attribution of socket owners to containers and pods.
+	Container container.Options `yaml:"container" json:"container"`
+}
+
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
+func OptionsFromAny(opts any) (Options, error) {
+	var out Options
+	if o, ok := opts.(Options); ok {
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
+	}
+
+	out.Interval = Duration(out.Interval.OrDefault(defaultInterval))
+	out.SnapshotInterval = Duration(out.SnapshotInterval.OrDefault(defaultSnapshotInterval))
+
+	if len(out.Protocols) == 0 {
+		out.Protocols = allProtocols
+	}
+	for _, p := range out.Protocols {
+		switch p {
+		case protoTCP, protoTCP6, protoUDP, protoUDP6, protoUnix:
+		default:
+			return Options{}, fmt.Errorf("netconn options: unknown protocol %q", p)
+		}
+	}
+
+	if out.ResolveProcesses == nil {
+		resolve := true
+		out.ResolveProcesses = &resolve
+	}
+	if out.ProcRoot == "" {
+		out.ProcRoot = defaultProcRoot
+	}
+
+	var err error
+	if out.Container, err = out.Container.OrDefault(); err != nil {
+		return Options{}, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,315 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package netconn
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"os-artificer/saber/internal/agent/harvester/container"
+	"os-artificer/saber/internal/agent/harvester/procfs"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// Default ephemeral port range, used when ip_local_port_range cannot be read.
+const (
+	defaultEphemeralLow  = 32768
+	defaultEphemeralHigh = 60999
+)
+
+// sockets is the classified socket table produced by one scan, keyed for diffing.
+type sockets struct {
+	listening map[string]*sbmodels.SocketInfo
+	outbound  map[string]*sbmodels.SocketInfo
+}
+
+func newSockets() *sockets {
+	return &sockets{
+		listening: make(map[string]*sbmodels.SocketInfo),
+		outbound:  make(map[string]*sbmodels.SocketInfo),
+	}
+}
+
+// inetTable is the content of one /proc/net/<proto> file.
+type inetTable struct {
+	proto string
+	socks []procfs.InetSocket
+}
+
+// scanner reads /proc/net and classifies sockets into listeners and outbound connections.
+type scanner struct {
+	opts     *Options
+	resolver *container.Resolver
+}
+
+// scan reads the socket tables of every configured protocol. Entries also present in prev
+// keep prev's object so owner information resolved earlier is not looked up again.
+func (s *scanner) scan(prev *sockets) (*sockets, error) {
+	cur := newSockets()
+	ephemeralLow, ephemeralHigh := s.ephemeralRange()
+
+	var inet []inetTable
+	listeners := make(map[string]struct{})
+
+	for _, proto := range s.opts.Protocols {
+		if proto == protoUnix {
+			if err := s.scanUnix(cur); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		socks, err := procfs.ReadInetSockets(s.opts.ProcRoot, proto)
+		if err != nil {
+			return nil, err
+		}
+		inet = append(inet, inetTable{proto: proto, socks: socks})
+
+		if isTCP(proto) {
+			for i := range socks {
+				if socks[i].State == procfs.TCPListen {
+					listeners[listenKey(proto, &socks[i])] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for _, table := range inet {
+		for i := range table.socks {
+			sock := &table.socks[i]
+			switch {
+			case isTCP(table.proto) && sock.State == procfs.TCPListen:
+				cur.listening[listenKey(table.proto, sock)] = inetInfo(table.proto, sock)
+
+			case isTCP(table.proto) && (sock.State == procfs.TCPEstablished || sock.State == procfs.TCPSynSent):
+				// Connections accepted by a local listener are inbound. SYN_SENT is kept so
+				// short-lived outbound attempts are seen too.
+				if acceptedBy(listeners, table.proto, sock) {
+					continue
+				}
+				cur.outbound[connKey(table.proto, sock)] = inetInfo(table.proto, sock)
+
+			case !isTCP(table.proto) && sock.State == procfs.TCPEstablished:
+				cur.outbound[connKey(table.proto, sock)] = inetInfo(table.proto, sock)
+
+			case !isTCP(table.proto) && sock.State == procfs.TCPClose && sock.RemotePort == 0:
+				// Unconnected UDP sockets on ephemeral ports are client sockets, not services.
+				if int(sock.LocalPort) >= ephemeralLow && int(sock.LocalPort) <= ephemeralHigh {
+					continue
+				}
+				cur.listening[listenKey(table.proto, sock)] = inetInfo(table.proto, sock)
+			}
+		}
+	}
+
+	if prev != nil {
+		keepResolved(cur.listening, prev.listening)
+		keepResolved(cur.outbound, prev.outbound)
+	}
+
+	return cur, nil
+}
+
+func (s *scanner) scanUnix(cur *sockets) error {
+	socks, err := procfs.ReadUnixSockets(s.opts.ProcRoot)
+	if err != nil {
+		return err
+	}
+
+	for i := range socks {
+		sock := &socks[i]
+		if !sock.Listening {
+			continue
+		}
+
+		key := protoUnix + "|" + sock.Path
+		if sock.Path == "" {
+			key += strconv.FormatUint(sock.Inode, 10)
+		}
+		cur.listening[key] = &sbmodels.SocketInfo{
+			Protocol: protoUnix,
+			State:    "LISTEN",
+			Path:     sock.Path,
+			Inode:    sock.Inode,
+		}
+	}
+
+	return nil
+}
+
+// ephemeralRange reads net.ipv4.ip_local_port_range.
+func (s *scanner) ephemeralRange() (int, int) {
+	data, err := os.ReadFile(filepath.Join(s.opts.ProcRoot, "sys", "net", "ipv4", "ip_local_port_range"))
+	if err != nil {
+		return defaultEphemeralLow, defaultEphemeralHigh
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return defaultEphemeralLow, defaultEphemeralHigh
+	}
+	low, err1 := strconv.Atoi(fields[0])
+	high, err2 := strconv.Atoi(fields[1])
+	if err1 != nil || err2 != nil {
+		return defaultEphemeralLow, defaultEphemeralHigh
+	}
+
+	return low, high
+}
+
+// resolveOwners fills PID, process name and exe of infos by searching every process's
+// file descriptors for the socket inodes.
+func (s *scanner) resolveOwners(infos []*sbmodels.SocketInfo) {
+	if !*s.opts.ResolveProcesses || len(infos) == 0 {
+		return
+	}
+
+	wanted := make(map[uint64][]*sbmodels.SocketInfo, len(infos))
+	for _, info := range infos {
+		if info.PID == 0 && info.Inode != 0 {
+			wanted[info.Inode] = append(wanted[info.Inode], info)
+		}
+	}
+	if len(wanted) == 0 {
+		return
+	}
+
+	pids, err := procfs.ListPIDs(s.opts.ProcRoot)
+	if err != nil {
+		logger.Warnf("netconn plugin: list pids failed: %v", err)
+		return
+	}
+
+	for _, pid := range pids {
+		inodes, err := procfs.SocketInodes(s.opts.ProcRoot, pid)
+		if err != nil {
+			continue
+		}
+
+		for _, inode := range inodes {
+			owned, ok := wanted[inode]
+			if !ok {
+				continue
+			}
+
+			name := ""
+			if st, err := procfs.ReadStat(s.opts.ProcRoot, pid); err == nil {
+				name = st.Comm
+			}
+			exe, _, _ := procfs.ReadExe(s.opts.ProcRoot, pid)
+			c := s.resolver.Resolve(pid)
+
+			for _, info := range owned {
+				info.PID = pid
+				info.ProcessName = name
+				info.Exe = exe
+				info.Container = c
+			}
+			delete(wanted, inode)
+		}
+
+		if len(wanted) == 0 {
+			return
+		}
+	}
+}
+
+// diff returns the entries present only in cur (added) and only in prev (removed), in key order.
+func diff(prev, cur map[string]*sbmodels.SocketInfo) (added, removed []*sbmodels.SocketInfo) {
+	for _, k := range sortedKeys(cur) {
+		if _, ok := prev[k]; !ok {
+			added = append(added, cur[k])
+		}
+	}
+	for _, k := range sortedKeys(prev) {
+		if _, ok := cur[k]; !ok {
+			removed = append(removed, prev[k])
+		}
+	}
+	return added, removed
+}
+
+func keepResolved(cur, prev map[string]*sbmodels.SocketInfo) {
+	for k := range cur {
+		if old, ok := prev[k]; ok && old.Inode == cur[k].Inode {
+			cur[k] = old
+		}
+	}
+}
+
+func values(m map[string]*sbmodels.SocketInfo) []*sbmodels.SocketInfo {
+	out := make([]*sbmodels.SocketInfo, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		out = append(out, m[k])
+	}
+	return out
+}
+
+func sortedKeys(m map[string]*sbmodels.SocketInfo) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isTCP(proto string) bool {
+	return proto == protoTCP || proto == protoTCP6
+}
+
+func listenKey(proto string, s *procfs.InetSocket) string {
+	return fmt.Sprintf("%s|%s|%d", proto, s.LocalIP, s.LocalPort)
+}
+
+// acceptedBy reports whether the TCP connection sock of proto's table was accepted by one
+// of listeners, keyed by listenKey: a listener of the same table bound to its local
+// address and port, or to the wildcard address and its port.
+func acceptedBy(listeners map[string]struct{}, proto string, sock *procfs.InetSocket) bool {
+	if _, ok := listeners[listenKey(proto, sock)]; ok {
+		return true
+	}
+	wildcard := net.IPv4zero
+	if proto == protoTCP6 {
+		wildcard = net.IPv6unspecified
+	}
+	_, ok := listeners[listenKey(proto, &procfs.InetSocket{LocalIP: wildcard, LocalPort: sock.LocalPort})]
+	return ok
+}
+
+func connKey(proto string, s *procfs.InetSocket) string {
+	return fmt.Sprintf("%s|%s|%d|%s|%d|%d", proto, s.LocalIP, s.LocalPort, s.RemoteIP, s.RemotePort, s.Inode)
+}
+
+func inetInfo(proto string, s *procfs.InetSocket) *sbmodels.SocketInfo {
+	return &sbmodels.SocketInfo{
+		Protocol:   proto,
+		State:      s.StateName(),
+		LocalAddr:  s.LocalIP.String(),
+		LocalPort:  s.LocalPort,
+		RemoteAddr: s.RemoteIP.String(),
+		RemotePort: s.RemotePort,
+		Inode:      s.Inode,
+		UID:        s.UID,
+	}
+}
@@ -0,0 +1,100 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// parseDpkgStatus parses a dpkg status database: RFC 822 style paragraphs separated by
+// blank lines. Only packages whose state is installed (or waiting on triggers) are returned.
+func parseDpkgStatus(data []byte) []sbmodels.PackageInfo {
+	var out []sbmodels.PackageInfo
+	fields := make(map[string]string)
+	var last string
+
+	flush := func() {
+		if pkg, ok := dpkgPackage(fields); ok {
+			out = append(out, pkg)
+		}
+		fields = make(map[string]string)
+		last = ""
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+
+		case line[0] == ' ' || line[0] == '\t':
+			// Continuation of a multi-line field such as Description or Conffiles.
+			if last != "" {
+				fields[last] += "\n" + strings.TrimSpace(line)
+			}
+
+		default:
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			last = key
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+	flush()
+
+	return out
+}
+
+func dpkgPackage(fields map[string]string) (sbmodels.PackageInfo, bool) {
+	name := fields["Package"]
+	if name == "" || fields["Version"] == "" {
+		return sbmodels.PackageInfo{}, false
+	}
+
+	// Status is "<want> <flag> <state>".
+	status := strings.Fields(fields["Status"])
+	if len(status) != 3 {
+		return sbmodels.PackageInfo{}, false
+	}
+	switch status[2] {
+	case "installed", "triggers-awaited", "triggers-pending":
+	default:
+		return sbmodels.PackageInfo{}, false
+	}
+
+	// Source is "name" or "name (version)" and defaults to the binary package name.
+	source := name
+	if s := strings.Fields(fields["Source"]); len(s) > 0 {
+		source = s[0]
+	}
+
+	return sbmodels.PackageInfo{
+		Name:    name,
+		Version: fields["Version"],
+		Arch:    fields["Architecture"],
+		Source:  source,
+		Manager: sbmodels.PackageManagerDpkg,
+	}, true
+}
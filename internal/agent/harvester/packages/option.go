@@ -0,0 +1,109 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package packages
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const (
+	defaultInterval         = 1 * time.Minute
+	defaultSnapshotInterval = 24 * time.Hour
+	defaultRoot             = "/"
+	defaultDpkgStatusPath   = "/var/lib/dpkg/status"
+	defaultRPMDBPath        = "/var/lib/rpm"
+	defaultRPMCommand       = "rpm"
+	defaultRPMTimeout       = 60 * time.Second
+)
+
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
+
+// Options is the option for the packages plugin.
+type Options struct {
+	// Interval between two checks of the package databases. The inventory is only re-read
+	// when a database file changed.
+	Interval Duration `yaml:"interval" json:"interval"`
+	// SnapshotInterval between two full inventories.
+	SnapshotInterval Duration `yaml:"snapshotInterval" json:"snapshotInterval"`
+	// Managers restricts the databases read ("dpkg", "rpm"). Empty reads every database present.
+	Managers []string `yaml:"managers" json:"managers"`
+	// Root is the host filesystem root, e.g. /host when running in a container.
+	Root string `yaml:"root" json:"root"`
+	// DpkgStatusPath and RPMDBPath are relative to Root.
+	DpkgStatusPath string `yaml:"dpkgStatusPath" json:"dpkgStatusPath"`
+	RPMDBPath      string `yaml:"rpmDBPath" json:"rpmDBPath"`
+	// RPMCommand is the rpm binary queried for the rpm database, run with a RPMTimeout deadline.
+	RPMCommand string   `yaml:"rpmCommand" json:"rpmCommand"`
+	RPMTimeout Duration `yaml:"rpmTimeout" json:"rpmTimeout"`
+}
+
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
+func OptionsFromAny(opts any) (Options, error) {
+	var out Options
+	if o, ok := opts.(Options); ok {
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
+	}
+
+	for _, m := range out.Managers {
+		if m != sbmodels.PackageManagerDpkg && m != sbmodels.PackageManagerRPM {
+			return Options{}, fmt.Errorf("packages options: unknown manager %q", m)
+		}
+	}
+
+	out.Interval = Duration(out.Interval.OrDefault(defaultInterval))
+	out.SnapshotInterval = Duration(out.SnapshotInterval.OrDefault(defaultSnapshotInterval))
+	out.RPMTimeout = Duration(out.RPMTimeout.OrDefault(defaultRPMTimeout))
+
+	if out.Root == "" {
+		out.Root = defaultRoot
+	}
+	if !filepath.IsAbs(out.Root) {
+		return Options{}, fmt.Errorf("packages options: root %q is not absolute", out.Root)
+	}
+	if out.DpkgStatusPath == "" {
+		out.DpkgStatusPath = defaultDpkgStatusPath
+	}
+	if out.RPMDBPath == "" {
+		out.RPMDBPath = defaultRPMDBPath
+	}
+	if out.RPMCommand == "" {
+		out.RPMCommand = defaultRPMCommand
+	}
+
+	return out, nil
+}
+
+// enabled reports whether the manager is selected by Managers.
+func (o *Options) enabled(manager string) bool {
+	if len(o.Managers) == 0 {
+		return true
+	}
+	for _, m := range o.Managers {
+		if m == manager {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,345 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+	"os-artificer/saber/pkg/sbproc"
+)
+
+const packagesPluginVersion = "1.0.0"
+const pluginName = "packages"
+
+func init() {
+	plugin.RegisterPlugin(pluginName, newPackagesPlugin)
+}
+
+// pkgKey identifies an installed package; multiarch systems may install one name per arch.
+// rpm may also install several versions of one name (kernel, gpg-pubkey), so its keys
+// carry the version too.
+type pkgKey struct {
+	manager string
+	name    string
+	arch    string
+	version string
+}
+
+func keyOf(pkg sbmodels.PackageInfo) pkgKey {
+	key := pkgKey{manager: pkg.Manager, name: pkg.Name, arch: pkg.Arch}
+	if pkg.Manager == sbmodels.PackageManagerRPM {
+		key.version = pkg.Version
+	}
+	return key
+}
+
+// identity returns k without its version.
+func (k pkgKey) identity() pkgKey {
+	k.version = ""
+	return k
+}
+
+// inventory is the set of installed packages keyed by identity.
+type inventory map[pkgKey]sbmodels.PackageInfo
+
+// PackagesPlugin inventories the packages installed through dpkg and rpm. It emits the
+// full inventory at start and periodically, and install, remove and upgrade events as
+// diffs between inventories. Databases are only re-read when their files changed.
+type PackagesPlugin struct {
+	plugin.UnimplementedPlugin
+
+	wg   sync.WaitGroup
+	done chan struct{}
+	opts Options
+
+	// runRPM runs the rpm command; replaced in tests.
+	runRPM func(ctx context.Context, cmd string, args ...string) ([]byte, error)
+}
+
+func newPackagesPlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
+	packagesOptions, err := OptionsFromAny(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("packages plugin options: %+v", packagesOptions)
+
+	return &PackagesPlugin{
+		opts:   packagesOptions,
+		done:   make(chan struct{}),
+		runRPM: sbproc.RunWithStdout,
+	}, nil
+}
+
+func (p *PackagesPlugin) Version() string {
+	return packagesPluginVersion
+}
+
+func (p *PackagesPlugin) Name() string {
+	return pluginName
+}
+
+func (p *PackagesPlugin) Run(ctx context.Context) (plugin.EventC, error) {
+	eventC := make(plugin.EventC)
+	done := p.done
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(eventC)
+
+		send := func(name string, data any) bool {
+			select {
+			case eventC <- &plugin.Event{PluginName: p.Name(), EventName: name, Data: data}:
+				return true
+			case <-done:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		ticker := time.NewTicker(p.opts.Interval.Duration())
+		defer ticker.Stop()
+
+		snapshotTicker := time.NewTicker(p.opts.SnapshotInterval.Duration())
+		defer snapshotTicker.Stop()
+
+		var prev inventory
+		var prevStamp string
+
+		// refresh re-reads the databases when they changed. The first successful read is
+		// reported as an inventory, later ones as diffs.
+		refresh := func() bool {
+			stamp := p.stamp()
+			if prev != nil && stamp == prevStamp {
+				return true
+			}
+
+			cur, err := p.collect(ctx)
+			if err != nil {
+				logger.Errorf("failed to collect packages: %v", err)
+				return true
+			}
+
+			if prev == nil {
+				if !send(sbmodels.PackageEventInventory, snapshot(cur)) {
+					return false
+				}
+			} else {
+				for _, ev := range diff(prev, cur) {
+					if !send(ev.Action, ev) {
+						return false
+					}
+				}
+			}
+
+			prev, prevStamp = cur, stamp
+			return true
+		}
+
+		if !refresh() {
+			return
+		}
+
+		for {
+			select {
+			case <-done:
+				logger.Infof("packages plugin run exited: %s", p.Name())
+				return
+
+			case <-ctx.Done():
+				logger.Infof("packages plugin run exited: %s", p.Name())
+				return
+
+			case <-ticker.C:
+				if !refresh() {
+					return
+				}
+
+			case <-snapshotTicker.C:
+				if prev != nil && !send(sbmodels.PackageEventInventory, snapshot(prev)) {
+					return
+				}
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+func (p *PackagesPlugin) Close() error {
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+
+	p.wg.Wait()
+	return nil
+}
+
+func (p *PackagesPlugin) hostPath(path string) string {
+	return filepath.Join(p.opts.Root, path)
+}
+
+// collect reads every enabled package database present on the host. A database that
+// exists but cannot be read is an error, so that a transient failure is not reported
+// as the removal of every package.
+func (p *PackagesPlugin) collect(ctx context.Context) (inventory, error) {
+	inv := make(inventory)
+	add := func(pkgs []sbmodels.PackageInfo) {
+		for _, pkg := range pkgs {
+			inv[keyOf(pkg)] = pkg
+		}
+	}
+
+	if p.opts.enabled(sbmodels.PackageManagerDpkg) {
+		data, err := os.ReadFile(p.hostPath(p.opts.DpkgStatusPath))
+		switch {
+		case err == nil:
+			add(parseDpkgStatus(data))
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, fmt.Errorf("read dpkg status: %w", err)
+		}
+	}
+
+	if p.opts.enabled(sbmodels.PackageManagerRPM) {
+		if _, err := os.Stat(p.hostPath(p.opts.RPMDBPath)); err == nil {
+			rpmCtx, cancel := context.WithTimeout(ctx, p.opts.RPMTimeout.Duration())
+			out, err := p.runRPM(rpmCtx, p.opts.RPMCommand, rpmArgs(p.opts.Root)...)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("query rpm database: %w", err)
+			}
+			add(parseRPMOutput(out))
+		}
+	}
+
+	return inv, nil
+}
+
+// stamp summarizes the size and modification time of the package database files; it
+// changes whenever a package manager commits a transaction.
+func (p *PackagesPlugin) stamp() string {
+	var b strings.Builder
+	add := func(path string) {
+		if fi, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", path, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+
+	if p.opts.enabled(sbmodels.PackageManagerDpkg) {
+		add(p.hostPath(p.opts.DpkgStatusPath))
+	}
+	if p.opts.enabled(sbmodels.PackageManagerRPM) {
+		dir := p.hostPath(p.opts.RPMDBPath)
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			add(filepath.Join(dir, e.Name()))
+		}
+	}
+
+	return b.String()
+}
+
+// snapshot flattens an inventory into its event model, sorted by name.
+func snapshot(inv inventory) *sbmodels.PackageInventory {
+	out := &sbmodels.PackageInventory{
+		Packages:  make([]sbmodels.PackageInfo, 0, len(inv)),
+		Timestamp: time.Now(),
+	}
+	for _, pkg := range inv {
+		out.Packages = append(out.Packages, pkg)
+	}
+
+	sort.Slice(out.Packages, func(i, j int) bool {
+		a, b := out.Packages[i], out.Packages[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Arch != b.Arch {
+			return a.Arch < b.Arch
+		}
+		if a.Manager != b.Manager {
+			return a.Manager < b.Manager
+		}
+		return a.Version < b.Version
+	})
+	return out
+}
+
+// diff returns the removed, installed and upgraded packages between two inventories. An
+// rpm package whose only version was replaced by another one is upgraded; a version
+// installed or removed next to others, as rpm does for kernels, is installed or removed.
+func diff(prev, cur inventory) []*sbmodels.PackageEvent {
+	now := time.Now()
+	var out []*sbmodels.PackageEvent
+
+	prevVersions, curVersions := versions(prev), versions(cur)
+	replaced := make(map[pkgKey]sbmodels.PackageInfo)
+	for key, pkg := range prev {
+		if _, ok := cur[key]; ok {
+			continue
+		}
+		if id := key.identity(); key.version != "" && prevVersions[id] == 1 && curVersions[id] == 1 {
+			replaced[id] = pkg
+			continue
+		}
+		out = append(out, &sbmodels.PackageEvent{Action: sbmodels.PackageEventRemoved, Package: pkg, Timestamp: now})
+	}
+
+	for key, pkg := range cur {
+		old, ok := prev[key]
+		if !ok {
+			old, ok = replaced[key.identity()]
+			delete(replaced, key.identity())
+		}
+		switch {
+		case !ok:
+			out = append(out, &sbmodels.PackageEvent{Action: sbmodels.PackageEventInstalled, Package: pkg, Timestamp: now})
+		case old.Version != pkg.Version:
+			out = append(out, &sbmodels.PackageEvent{
+				Action: sbmodels.PackageEventUpgraded, Package: pkg, PreviousVersion: old.Version, Timestamp: now,
+			})
+		}
+	}
+
+	for _, pkg := range replaced {
+		out = append(out, &sbmodels.PackageEvent{Action: sbmodels.PackageEventRemoved, Package: pkg, Timestamp: now})
+	}
+	return out
+}
+
+// versions counts the installed versions of every package identity of inv.
+func versions(inv inventory) map[pkgKey]int {
+	out := make(map[pkgKey]int, len(inv))
+	for key := range inv {
+		out[key.identity()]++
+	}
+	return out
+}
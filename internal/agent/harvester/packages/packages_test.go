@@ -0,0 +1,207 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package packages
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const dpkgStatus = `Package: bash
+Essential: yes
+Status: install ok installed
+Priority: required
+Architecture: amd64
+Version: 5.2.15-2+b7
+Description: GNU Bourne Again SHell
+ Bash is an sh-compatible command language interpreter.
+
+Package: libc6
+Status: install ok installed
+Architecture: i386
+Multi-Arch: same
+Source: glibc (2.36-9+deb12u4)
+Version: 2.36-9+deb12u4
+
+Package: oldpkg
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 1.0
+`
+
+func TestParseDpkgStatus(t *testing.T) {
+	pkgs := parseDpkgStatus([]byte(dpkgStatus))
+	if len(pkgs) != 2 {
+		t.Fatalf("got %d packages: %+v", len(pkgs), pkgs)
+	}
+
+	want := []sbmodels.PackageInfo{
+		{Name: "bash", Version: "5.2.15-2+b7", Arch: "amd64", Source: "bash", Manager: sbmodels.PackageManagerDpkg},
+		{Name: "libc6", Version: "2.36-9+deb12u4", Arch: "i386", Source: "glibc", Manager: sbmodels.PackageManagerDpkg},
+	}
+	for i := range want {
+		if pkgs[i] != want[i] {
+			t.Fatalf("package %d = %+v, want %+v", i, pkgs[i], want[i])
+		}
+	}
+}
+
+func TestParseRPMOutput(t *testing.T) {
+	out := "bash\t0\t5.1.8\t6.el9\tx86_64\tbash-5.1.8-6.el9.src.rpm\n" +
+		"vim-enhanced\t2\t8.2.2637\t20.el9\tx86_64\tvim-8.2.2637-20.el9.src.rpm\n" +
+		"gpg-pubkey\t0\tfd431d51\t4ae0493b\t(none)\t(none)\n"
+
+	pkgs := parseRPMOutput([]byte(out))
+	want := []sbmodels.PackageInfo{
+		{Name: "bash", Version: "5.1.8-6.el9", Arch: "x86_64", Source: "bash", Manager: sbmodels.PackageManagerRPM},
+		{Name: "vim-enhanced", Version: "2:8.2.2637-20.el9", Arch: "x86_64", Source: "vim", Manager: sbmodels.PackageManagerRPM},
+	}
+	if len(pkgs) != len(want) {
+		t.Fatalf("got %+v", pkgs)
+	}
+	for i := range want {
+		if pkgs[i] != want[i] {
+			t.Fatalf("package %d = %+v, want %+v", i, pkgs[i], want[i])
+		}
+	}
+}
+
+func TestRPMArgs(t *testing.T) {
+	if args := rpmArgs("/"); args[0] != "-qa" {
+		t.Fatalf("args = %v", args)
+	}
+	if args := rpmArgs("/host"); args[0] != "--root" || args[1] != "/host" {
+		t.Fatalf("args = %v", args)
+	}
+}
+
+func TestPackagesPlugin_diff(t *testing.T) {
+	root := t.TempDir()
+	status := filepath.Join(root, "var/lib/dpkg/status")
+	if err := os.MkdirAll(filepath.Dir(status), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(status, []byte(dpkgStatus), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	p, err := newPackagesPlugin(context.Background(), map[string]any{"root": root, "interval": "20ms"})
+	if err != nil {
+		t.Fatalf("newPackagesPlugin: %v", err)
+	}
+	defer p.Close()
+	p.(*PackagesPlugin).runRPM = func(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+		t.Errorf("rpm run without an rpm database")
+		return nil, nil
+	}
+
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	first := <-eventC
+	inv := first.Data.(*sbmodels.PackageInventory)
+	if first.EventName != sbmodels.PackageEventInventory || len(inv.Packages) != 2 || inv.Packages[0].Name != "bash" {
+		t.Fatalf("inventory = %+v", first)
+	}
+
+	updated := `Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.2.15-2+b8
+
+Package: curl
+Status: install ok installed
+Architecture: amd64
+Version: 7.88.1-10+deb12u5
+`
+	if err := os.WriteFile(status, []byte(updated), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	got := make(map[string]*sbmodels.PackageEvent)
+	timeout := time.After(5 * time.Second)
+	for len(got) < 3 {
+		select {
+		case ev := <-eventC:
+			got[ev.EventName] = ev.Data.(*sbmodels.PackageEvent)
+		case <-timeout:
+			t.Fatalf("got %v", got)
+		}
+	}
+
+	if ev := got[sbmodels.PackageEventUpgraded]; ev.Package.Name != "bash" || ev.PreviousVersion != "5.2.15-2+b7" || ev.Package.Version != "5.2.15-2+b8" {
+		t.Fatalf("upgraded = %+v", ev)
+	}
+	if ev := got[sbmodels.PackageEventInstalled]; ev.Package.Name != "curl" {
+		t.Fatalf("installed = %+v", ev)
+	}
+	if ev := got[sbmodels.PackageEventRemoved]; ev.Package.Name != "libc6" {
+		t.Fatalf("removed = %+v", ev)
+	}
+}
+
+func TestDiff_rpmVersions(t *testing.T) {
+	rpm := func(name, version string) sbmodels.PackageInfo {
+		return sbmodels.PackageInfo{Name: name, Version: version, Arch: "x86_64", Manager: sbmodels.PackageManagerRPM}
+	}
+	inv := func(pkgs ...sbmodels.PackageInfo) inventory {
+		out := make(inventory)
+		for _, pkg := range pkgs {
+			out[keyOf(pkg)] = pkg
+		}
+		return out
+	}
+
+	prev := inv(
+		rpm("kernel", "5.14.0-362.el9"),
+		rpm("kernel", "5.14.0-427.el9"),
+		rpm("bash", "5.1.8-6.el9"),
+	)
+	if len(prev) != 3 {
+		t.Fatalf("inventory holds %d packages, want both kernels", len(prev))
+	}
+	cur := inv(
+		rpm("kernel", "5.14.0-427.el9"),
+		rpm("kernel", "5.14.0-503.el9"),
+		rpm("bash", "5.1.8-9.el9"),
+	)
+
+	got := make(map[string][]string)
+	for _, ev := range diff(prev, cur) {
+		got[ev.Action] = append(got[ev.Action], ev.Package.Name+"-"+ev.Package.Version+" "+ev.PreviousVersion)
+	}
+	want := map[string][]string{
+		sbmodels.PackageEventInstalled: {"kernel-5.14.0-503.el9 "},
+		sbmodels.PackageEventRemoved:   {"kernel-5.14.0-362.el9 "},
+		sbmodels.PackageEventUpgraded:  {"bash-5.1.8-9.el9 5.1.8-6.el9"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for action, events := range want {
+		if len(got[action]) != 1 || got[action][0] != events[0] {
+			t.Fatalf("%s events = %v, want %v", action, got[action], events)
+		}
+	}
+}
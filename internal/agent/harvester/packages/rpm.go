@@ -0,0 +1,90 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// rpmQueryFormat prints one tab-separated line per installed package.
+const rpmQueryFormat = `%{NAME}\t%{EPOCHNUM}\t%{VERSION}\t%{RELEASE}\t%{ARCH}\t%{SOURCERPM}\n`
+
+// rpmArgs returns the rpm arguments listing every package of the database below root.
+func rpmArgs(root string) []string {
+	args := []string{"-qa", "--queryformat", rpmQueryFormat}
+	if root != "/" {
+		args = append([]string{"--root", root}, args...)
+	}
+	return args
+}
+
+// parseRPMOutput parses the output of rpm -qa with rpmQueryFormat. Imported signing
+// keys (gpg-pubkey pseudo packages) are skipped.
+func parseRPMOutput(data []byte) []sbmodels.PackageInfo {
+	var out []sbmodels.PackageInfo
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		f := strings.Split(sc.Text(), "\t")
+		if len(f) != 6 || f[0] == "" || f[0] == "gpg-pubkey" {
+			continue
+		}
+
+		version := f[2] + "-" + f[3]
+		if f[1] != "" && f[1] != "0" {
+			version = f[1] + ":" + version
+		}
+
+		arch := f[4]
+		if arch == "(none)" {
+			arch = ""
+		}
+
+		out = append(out, sbmodels.PackageInfo{
+			Name:    f[0],
+			Version: version,
+			Arch:    arch,
+			Source:  sourceRPMName(f[5]),
+			Manager: sbmodels.PackageManagerRPM,
+		})
+	}
+
+	return out
+}
+
+// sourceRPMName returns the name part of a source rpm file name,
+// e.g. "bash" for "bash-5.1.8-6.el9.src.rpm".
+func sourceRPMName(srpm string) string {
+	if srpm == "" || srpm == "(none)" {
+		return ""
+	}
+
+	name := strings.TrimSuffix(strings.TrimSuffix(srpm, ".rpm"), ".src")
+	name = strings.TrimSuffix(name, ".nosrc")
+	for range 2 {
+		i := strings.LastIndexByte(name, '-')
+		if i <= 0 {
+			return srpm
+		}
+		name = name[:i]
+	}
+	return name
+}
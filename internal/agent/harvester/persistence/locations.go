@@ -0,0 +1,135 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package persistence
+
+import (
+	"path/filepath"
+	"strings"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// parser selects how the directives of an entry are extracted.
+type parser int
+
+const (
+	parseNone    parser = iota // scripts: content hash only
+	parseLines                 // every non-comment line
+	parseSystemd               // Exec*=, On*= and Unit= settings of unit files
+)
+
+// location is a set of well-known persistence files. pattern is a glob relative to the
+// host root; when walk is set it names directories whose contents are scanned recursively.
+type location struct {
+	kind    string
+	pattern string
+	walk    bool
+	parse   parser
+	// userFromName marks per-user crontab spools, where the file name is the owner.
+	userFromName bool
+}
+
+// homes are the directories whose per-user files are scanned.
+var homes = []string{"/root", "/home/*"}
+
+var shellProfiles = []string{".profile", ".bashrc", ".bash_profile", ".bash_login", ".bash_logout", ".zshrc", ".zprofile"}
+
+// locations lists the standard persistence locations. When a path is reachable through
+// several locations (e.g. /lib -> /usr/lib), the first one listed wins.
+var locations = buildLocations()
+
+func buildLocations() []location {
+	locs := []location{
+		{kind: sbmodels.PersistenceKindCron, pattern: "/etc/crontab", parse: parseLines},
+		{kind: sbmodels.PersistenceKindCron, pattern: "/etc/anacrontab", parse: parseLines},
+		{kind: sbmodels.PersistenceKindCron, pattern: "/etc/cron.d/*", parse: parseLines},
+		{kind: sbmodels.PersistenceKindCron, pattern: "/etc/cron.hourly/*"},
+		{kind: sbmodels.PersistenceKindCron, pattern: "/etc/cron.daily/*"},
+		{kind: sbmodels.PersistenceKindCron, pattern: "/etc/cron.weekly/*"},
+		{kind: sbmodels.PersistenceKindCron, pattern: "/etc/cron.monthly/*"},
+		{kind: sbmodels.PersistenceKindCron, pattern: "/var/spool/cron/crontabs/*", parse: parseLines, userFromName: true},
+		{kind: sbmodels.PersistenceKindCron, pattern: "/var/spool/cron/*", parse: parseLines, userFromName: true},
+
+		{kind: sbmodels.PersistenceKindSystemdUnit, pattern: "/etc/systemd/system", walk: true, parse: parseSystemd},
+		{kind: sbmodels.PersistenceKindSystemdUnit, pattern: "/run/systemd/system", walk: true, parse: parseSystemd},
+		{kind: sbmodels.PersistenceKindSystemdUnit, pattern: "/usr/local/lib/systemd/system", walk: true, parse: parseSystemd},
+		{kind: sbmodels.PersistenceKindSystemdUnit, pattern: "/usr/lib/systemd/system", walk: true, parse: parseSystemd},
+		{kind: sbmodels.PersistenceKindSystemdUnit, pattern: "/lib/systemd/system", walk: true, parse: parseSystemd},
+		{kind: sbmodels.PersistenceKindSystemdUnit, pattern: "/etc/systemd/user", walk: true, parse: parseSystemd},
+		{kind: sbmodels.PersistenceKindSystemdUnit, pattern: "/usr/lib/systemd/user", walk: true, parse: parseSystemd},
+
+		{kind: sbmodels.PersistenceKindRCScript, pattern: "/etc/rc.local"},
+		{kind: sbmodels.PersistenceKindRCScript, pattern: "/etc/rc.d/rc.local"},
+		{kind: sbmodels.PersistenceKindRCScript, pattern: "/etc/init.d/*"},
+
+		{kind: sbmodels.PersistenceKindShellProfile, pattern: "/etc/profile"},
+		{kind: sbmodels.PersistenceKindShellProfile, pattern: "/etc/profile.d/*"},
+		{kind: sbmodels.PersistenceKindShellProfile, pattern: "/etc/bash.bashrc"},
+		{kind: sbmodels.PersistenceKindShellProfile, pattern: "/etc/bashrc"},
+		{kind: sbmodels.PersistenceKindShellProfile, pattern: "/etc/environment", parse: parseLines},
+		{kind: sbmodels.PersistenceKindShellProfile, pattern: "/etc/zshrc"},
+		{kind: sbmodels.PersistenceKindShellProfile, pattern: "/etc/zprofile"},
+		{kind: sbmodels.PersistenceKindShellProfile, pattern: "/etc/zsh/zshrc"},
+		{kind: sbmodels.PersistenceKindShellProfile, pattern: "/etc/zsh/zprofile"},
+
+		{kind: sbmodels.PersistenceKindLDPreload, pattern: "/etc/ld.so.preload", parse: parseLines},
+		{kind: sbmodels.PersistenceKindLDPreload, pattern: "/etc/ld.so.conf", parse: parseLines},
+		{kind: sbmodels.PersistenceKindLDPreload, pattern: "/etc/ld.so.conf.d/*", parse: parseLines},
+
+		{kind: sbmodels.PersistenceKindModuleAutoload, pattern: "/etc/modules", parse: parseLines},
+		{kind: sbmodels.PersistenceKindModuleAutoload, pattern: "/etc/modules-load.d/*", parse: parseLines},
+		{kind: sbmodels.PersistenceKindModuleAutoload, pattern: "/run/modules-load.d/*", parse: parseLines},
+		{kind: sbmodels.PersistenceKindModuleAutoload, pattern: "/usr/lib/modules-load.d/*", parse: parseLines},
+		{kind: sbmodels.PersistenceKindModuleAutoload, pattern: "/lib/modules-load.d/*", parse: parseLines},
+		// modprobe "install" directives run arbitrary commands when a module is requested.
+		{kind: sbmodels.PersistenceKindModuleAutoload, pattern: "/etc/modprobe.d/*", parse: parseLines},
+		{kind: sbmodels.PersistenceKindModuleAutoload, pattern: "/usr/lib/modprobe.d/*", parse: parseLines},
+		{kind: sbmodels.PersistenceKindModuleAutoload, pattern: "/lib/modprobe.d/*", parse: parseLines},
+	}
+
+	for _, home := range homes {
+		locs = append(locs, location{
+			kind: sbmodels.PersistenceKindSystemdUnit, pattern: filepath.Join(home, ".config/systemd/user"), walk: true, parse: parseSystemd,
+		})
+		for _, name := range shellProfiles {
+			locs = append(locs, location{kind: sbmodels.PersistenceKindShellProfile, pattern: filepath.Join(home, name)})
+		}
+	}
+
+	return locs
+}
+
+// unitSuffixes are the systemd file types that can start code, plus drop-in overrides.
+var unitSuffixes = []string{".service", ".socket", ".timer", ".path", ".mount", ".automount", ".target", ".conf"}
+
+// isUnitFile reports whether a file found below a systemd directory is a unit or drop-in.
+func isUnitFile(path string) bool {
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return suffix != ".conf" || strings.HasSuffix(filepath.Dir(path), ".d")
+		}
+	}
+	return false
+}
+
+// unitKind distinguishes timers from other units.
+func unitKind(path string) string {
+	if strings.HasSuffix(path, ".timer") {
+		return sbmodels.PersistenceKindSystemdTimer
+	}
+	return sbmodels.PersistenceKindSystemdUnit
+}
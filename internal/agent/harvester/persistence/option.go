@@ -0,0 +1,83 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package persistence
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+)
+
+const (
+	defaultInterval     = 10 * time.Minute
+	defaultRoot         = "/"
+	defaultHashMaxBytes = 10 * 1024 * 1024
+)
+
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
+
+// Options is the option for the persistence plugin.
+type Options struct {
+	// Interval between two rescans; change events are diffs between scans.
+	Interval Duration `yaml:"interval" json:"interval"`
+	// Root is the host filesystem root, e.g. /host when running in a container.
+	Root string `yaml:"root" json:"root"`
+	// HashMaxBytes skips hashing and parsing files larger than this size.
+	HashMaxBytes int64 `yaml:"hashMaxBytes" json:"hashMaxBytes"`
+	// ExtraPaths are additional absolute glob patterns reported with kind "custom".
+	ExtraPaths []string `yaml:"extraPaths" json:"extraPaths"`
+	// Exclude are glob patterns matched against each path and its base name; matches are skipped.
+	Exclude []string `yaml:"exclude" json:"exclude"`
+}
+
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
+func OptionsFromAny(opts any) (Options, error) {
+	var out Options
+	if o, ok := opts.(Options); ok {
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
+	}
+
+	out.Interval = Duration(out.Interval.OrDefault(defaultInterval))
+
+	if out.Root == "" {
+		out.Root = defaultRoot
+	}
+	if !filepath.IsAbs(out.Root) {
+		return Options{}, fmt.Errorf("persistence options: root %q is not absolute", out.Root)
+	}
+	if out.HashMaxBytes <= 0 {
+		out.HashMaxBytes = defaultHashMaxBytes
+	}
+
+	for _, pattern := range out.ExtraPaths {
+		if !filepath.IsAbs(pattern) {
+			return Options{}, fmt.Errorf("persistence options: extra path %q is not absolute", pattern)
+		}
+	}
+	for _, pattern := range append(append([]string{}, out.ExtraPaths...), out.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return Options{}, fmt.Errorf("persistence options: invalid glob %q: %w", pattern, err)
+		}
+	}
+
+	return out, nil
+}
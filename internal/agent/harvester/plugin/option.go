@@ -0,0 +1,93 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration supports JSON unmarshaling from string (e.g. "1s", "10m") or number (nanoseconds).
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch val := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+		return nil
+
+	case float64:
+		*d = Duration(int64(val))
+		return nil
+
+	default:
+		return fmt.Errorf("invalid duration: %v", v)
+	}
+}
+
+// MarshalJSON implements json.Marshaler so options round-trip as duration strings.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Duration returns the value as time.Duration.
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// OrDefault returns the value as time.Duration, or def when the value is not positive.
+func (d Duration) OrDefault(def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return time.Duration(d)
+}
+
+// DecodeOptions decodes plugin options (nil or map[string]any from config) into out via JSON.
+// out must be a pointer to the plugin's options struct; a nil opts leaves out untouched.
+func DecodeOptions(name string, opts any, out any) error {
+	if opts == nil {
+		return nil
+	}
+
+	m, ok := opts.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s options: unsupported type: %T", name, opts)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("%s options marshal: %w", name, err)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s options unmarshal: %w", name, err)
+	}
+
+	return nil
+}
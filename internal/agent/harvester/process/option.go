@@ -0,0 +1,83 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package process
+
+import (
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/container"
+	"os-artificer/saber/internal/agent/harvester/plugin"
+)
+
+const (
+	defaultInterval         = 5 * time.Second
+	defaultSnapshotInterval = 10 * time.Minute
+	defaultHashMaxBytes     = 100 * 1024 * 1024
+	defaultProcRoot         = "/proc"
+)
+
+// Duration is the plugin option duration type (see plugin.Duration).
+type Duration = plugin.Duration
+
+// Options is the option for the process plugin.
+type Options struct {
+	// Interval between two /proc scans; start and exit events are diffs between scans.
+	Interval Duration `yaml:"interval" json:"interval"`
+	// SnapshotInterval between two full process table snapshots.
+	SnapshotInterval Duration `yaml:"snapshotInterval" json:"snapshotInterval"`
+	// HashExe enables SHA-256 hashing of process executables (default true).
+	HashExe *bool `yaml:"hashExe" json:"hashExe"`
+	// HashMaxBytes skips hashing executables larger than this size.
+	HashMaxBytes int64 `yaml:"hashMaxBytes" json:"hashMaxBytes"`
+	// IncludeKernelThreads reports kernel threads (children of kthreadd) as well.
+	IncludeKernelThreads bool `yaml:"includeKernelThreads" json:"includeKernelThreads"`
+	// ProcRoot is the procfs mount point, e.g. /host/proc when running in a container.
+	ProcRoot string `yaml:"procRoot" json:"procRoot"`
+	// Container configures attribution of processes to containers and pods.
+	Container container.Options `yaml:"container" json:"container"`
+}
+
+// OptionsFromAny converts opts (any) to Options with defaults applied. Supports nil, Options, and map[string]any.
+func OptionsFromAny(opts any) (Options, error) {
+	var out Options
+	if o, ok := opts.(Options); ok {
+		out = o
+	} else if err := plugin.DecodeOptions(pluginName, opts, &out); err != nil {
+		return Options{}, err
+	}
+
+	out.Interval = Duration(out.Interval.OrDefault(defaultInterval))
+	out.SnapshotInterval = Duration(out.SnapshotInterval.OrDefault(defaultSnapshotInterval))
+
+	if out.HashExe == nil {
+		hash := true
+		out.HashExe = &hash
+	}
+	if out.HashMaxBytes <= 0 {
+		out.HashMaxBytes = defaultHashMaxBytes
+	}
+	if out.ProcRoot == "" {
+		out.ProcRoot = defaultProcRoot
+	}
+
+	var err error
+	if out.Container, err = out.Container.OrDefault(); err != nil {
+		return Options{}, err
+	}
+
+	return out, nil
+}
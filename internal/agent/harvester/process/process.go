@@ -0,0 +1,165 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package process
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/plugin"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+const processPluginVersion = "1.0.0"
+const pluginName = "process"
+
+func init() {
+	plugin.RegisterPlugin(pluginName, newProcessPlugin)
+}
+
+// ProcessPlugin scans /proc at an interval and reports process starts and exits as
+// diffs between scans, plus periodic full snapshots of the process table.
+type ProcessPlugin struct {
+	plugin.UnimplementedPlugin
+
+	wg      sync.WaitGroup
+	done    chan struct{}
+	opts    Options
+	scanner *scanner
+}
+
+func newProcessPlugin(ctx context.Context, opts any) (plugin.Plugin, error) {
+	processOptions, err := OptionsFromAny(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("process plugin options: %+v", processOptions)
+
+	p := &ProcessPlugin{opts: processOptions, done: make(chan struct{})}
+	if p.scanner, err = newScanner(&p.opts); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *ProcessPlugin) Version() string {
+	return processPluginVersion
+}
+
+func (p *ProcessPlugin) Name() string {
+	return pluginName
+}
+
+func (p *ProcessPlugin) Run(ctx context.Context) (plugin.EventC, error) {
+	eventC := make(plugin.EventC)
+	done := p.done
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(eventC)
+
+		send := func(name string, data any) bool {
+			select {
+			case eventC <- &plugin.Event{PluginName: p.Name(), EventName: name, Data: data}:
+				return true
+			case <-done:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		prev, err := p.scanner.scan()
+		if err != nil {
+			logger.Errorf("failed to scan processes: %v", err)
+			prev = table{}
+		}
+		if !send(sbmodels.ProcessEventSnapshot, p.snapshot(prev)) {
+			return
+		}
+
+		ticker := time.NewTicker(p.opts.Interval.Duration())
+		defer ticker.Stop()
+
+		snapshotTicker := time.NewTicker(p.opts.SnapshotInterval.Duration())
+		defer snapshotTicker.Stop()
+
+		for {
+			select {
+			case <-done:
+				logger.Infof("process plugin run exited: %s", p.Name())
+				return
+
+			case <-ctx.Done():
+				logger.Infof("process plugin run exited: %s", p.Name())
+				return
+
+			case <-ticker.C:
+				cur, err := p.scanner.scan()
+				if err != nil {
+					logger.Errorf("failed to scan processes: %v", err)
+					continue
+				}
+
+				now := time.Now()
+				started, exited := diff(prev, cur)
+				prev = cur
+
+				for _, info := range exited {
+					if !send(sbmodels.ProcessEventExit, &sbmodels.ProcessEvent{
+						Action: sbmodels.ProcessEventExit, Process: *info, Timestamp: now,
+					}) {
+						return
+					}
+				}
+				for _, info := range started {
+					if !send(sbmodels.ProcessEventStart, &sbmodels.ProcessEvent{
+						Action: sbmodels.ProcessEventStart, Process: *info, Timestamp: now,
+					}) {
+						return
+					}
+				}
+
+			case <-snapshotTicker.C:
+				if !send(sbmodels.ProcessEventSnapshot, p.snapshot(prev)) {
+					return
+				}
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+func (p *ProcessPlugin) Close() error {
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+
+	p.wg.Wait()
+	return nil
+}
+
+func (p *ProcessPlugin) snapshot(t table) *sbmodels.ProcessSnapshot {
+	return &sbmodels.ProcessSnapshot{Processes: t.list(), Timestamp: time.Now()}
+}
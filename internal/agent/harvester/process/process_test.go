@@ -0,0 +1,143 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+func TestScanner_seesCurrentProcess(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("procfs not available")
+	}
+
+	opts, err := OptionsFromAny(nil)
+	if err != nil {
+		t.Fatalf("OptionsFromAny: %v", err)
+	}
+	s, err := newScanner(&opts)
+	if err != nil {
+		t.Fatalf("newScanner: %v", err)
+	}
+
+	before, err := s.scan()
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	var self *sbmodels.ProcessInfo
+	for _, info := range before {
+		if info.PID == int32(os.Getpid()) {
+			self = info
+		}
+	}
+	if self == nil {
+		t.Fatal("current process not found in scan")
+	}
+	if self.Exe == "" || len(self.ExeSHA256) != 64 || len(self.Cmdline) == 0 || self.StartTime.IsZero() {
+		t.Fatalf("current process info incomplete: %+v", self)
+	}
+
+	cmd := exec.Command("sleep", "5")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("start sleep: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	after, err := s.scan()
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	started, _ := diff(before, after)
+	found := false
+	for _, info := range started {
+		if info.PID == int32(cmd.Process.Pid) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("sleep (pid %d) not reported as started", cmd.Process.Pid)
+	}
+}
+
+func TestScanner_skipsProcessWithoutIDs(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("stat", "btime 1700000000\n")
+	for _, pid := range []string{"42", "43", "44"} {
+		write(pid+"/stat", pid+" (sshd) S 1 1 1 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 100 0 0\n")
+	}
+	write("42/status", "Uid:\t1000\t1000\t1000\t1000\nGid:\t100\t100\t100\t100\n")
+	// 43 exited while its status was read; 44's status cannot be read.
+	write("43/status", "")
+	if err := os.Mkdir(filepath.Join(root, "44", "status"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := OptionsFromAny(map[string]any{"procRoot": root})
+	if err != nil {
+		t.Fatalf("OptionsFromAny: %v", err)
+	}
+	s, err := newScanner(&opts)
+	if err != nil {
+		t.Fatalf("newScanner: %v", err)
+	}
+
+	procs, err := s.scan()
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(procs) != 1 {
+		t.Fatalf("scan found %d processes, want only pid 42: %v", len(procs), procs)
+	}
+	for _, info := range procs {
+		if info.PID != 42 || info.UID != 1000 || info.GID != 100 {
+			t.Fatalf("process = %+v", info)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := &sbmodels.ProcessInfo{PID: 10}
+	b := &sbmodels.ProcessInfo{PID: 20}
+	reused := &sbmodels.ProcessInfo{PID: 20}
+
+	prev := table{{pid: 10, start: 1}: a, {pid: 20, start: 1}: b}
+	cur := table{{pid: 10, start: 1}: a, {pid: 20, start: 9}: reused}
+
+	started, exited := diff(prev, cur)
+	if len(started) != 1 || started[0] != reused {
+		t.Fatalf("started = %v, want the process that reused pid 20", started)
+	}
+	if len(exited) != 1 || exited[0] != b {
+		t.Fatalf("exited = %v, want the original pid 20", exited)
+	}
+}
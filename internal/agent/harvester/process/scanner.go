@@ -0,0 +1,231 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package process
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+	"syscall"
+	"time"
+
+	"os-artificer/saber/internal/agent/harvester/container"
+	"os-artificer/saber/internal/agent/harvester/procfs"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbmodels"
+)
+
+// kthreaddPID is the parent of every kernel thread.
+const kthreaddPID = 2
+
+// procKey identifies a process instance; the start time disambiguates reused pids.
+type procKey struct {
+	pid   int32
+	start uint64
+}
+
+// exeKey identifies executable content without reading it, for the hash cache.
+type exeKey struct {
+	dev, ino uint64
+	size     int64
+	mtime    int64
+}
+
+// table is the process table produced by one scan.
+type table map[procKey]*sbmodels.ProcessInfo
+
+// scanner reads the process table from procfs and hashes executables, caching hashes
+// across scans so unchanged binaries are read only once. Each process is attributed to
+// its container once, when first seen.
+type scanner struct {
+	opts       *Options
+	boot       time.Time
+	hashes     map[exeKey]string
+	resolver   *container.Resolver
+	containers map[procKey]*sbmodels.ContainerInfo
+}
+
+func newScanner(opts *Options) (*scanner, error) {
+	boot, err := procfs.BootTime(opts.ProcRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scanner{
+		opts:       opts,
+		boot:       boot,
+		hashes:     make(map[exeKey]string),
+		resolver:   container.NewResolver(opts.Container, opts.ProcRoot),
+		containers: make(map[procKey]*sbmodels.ContainerInfo),
+	}, nil
+}
+
+// scan returns every process currently visible under ProcRoot. Processes that exit while
+// being read are skipped.
+func (s *scanner) scan() (table, error) {
+	pids, err := procfs.ListPIDs(s.opts.ProcRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[exeKey]struct{})
+	out := make(table, len(pids))
+	containers := make(map[procKey]*sbmodels.ContainerInfo, len(pids))
+	for _, pid := range pids {
+		key, info, ok := s.read(pid, used)
+		if !ok {
+			continue
+		}
+
+		c, known := s.containers[key]
+		if !known {
+			c = s.resolver.Resolve(pid)
+		}
+		info.Container, containers[key] = c, c
+		out[key] = info
+	}
+	s.containers = containers
+
+	for k := range s.hashes {
+		if _, ok := used[k]; !ok {
+			delete(s.hashes, k)
+		}
+	}
+
+	return out, nil
+}
+
+func (s *scanner) read(pid int32, used map[exeKey]struct{}) (procKey, *sbmodels.ProcessInfo, bool) {
+	root := s.opts.ProcRoot
+
+	stat, err := procfs.ReadStat(root, pid)
+	if err != nil {
+		return procKey{}, nil, false
+	}
+
+	if !s.opts.IncludeKernelThreads && (pid == kthreaddPID || stat.PPID == kthreaddPID) {
+		return procKey{}, nil, false
+	}
+
+	info := &sbmodels.ProcessInfo{
+		PID:       pid,
+		PPID:      stat.PPID,
+		Name:      stat.Comm,
+		StartTime: procfs.StartTime(s.boot, stat.StartTicks),
+	}
+
+	// Without its ids, a process would be attributed to root: skip it. It has exited (ESRCH)
+	// or is hidden from the agent (EACCES under hidepid).
+	status, err := procfs.ReadStatus(root, pid)
+	if err != nil {
+		return procKey{}, nil, false
+	}
+	info.UID, info.EUID, info.GID, info.EGID = status.UID, status.EUID, status.GID, status.EGID
+
+	if info.Cmdline, err = procfs.ReadCmdline(root, pid); err != nil {
+		info.Cmdline = []string{}
+	}
+
+	// exe and cwd are unreadable for other users' processes unless running as root.
+	info.Exe, info.ExeDeleted, _ = procfs.ReadExe(root, pid)
+	info.Cwd, _ = procfs.ReadCwd(root, pid)
+
+	if *s.opts.HashExe && info.Exe != "" {
+		info.ExeSHA256 = s.hash(pid, used)
+	}
+
+	return procKey{pid: pid, start: stat.StartTicks}, info, true
+}
+
+// hash returns the SHA-256 of the executable of pid, read through /proc/<pid>/exe so that
+// deleted and namespaced binaries are hashed as the process sees them.
+func (s *scanner) hash(pid int32, used map[exeKey]struct{}) string {
+	path := procfs.PIDPath(s.opts.ProcRoot, pid, "exe")
+
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() > s.opts.HashMaxBytes {
+		return ""
+	}
+
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+
+	key := exeKey{dev: uint64(st.Dev), ino: uint64(st.Ino), size: fi.Size(), mtime: fi.ModTime().UnixNano()}
+	used[key] = struct{}{}
+	if sum, ok := s.hashes[key]; ok {
+		return sum
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		logger.Debugf("process plugin: hash exe of pid %d failed: %v", pid, err)
+		return ""
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	s.hashes[key] = sum
+	return sum
+}
+
+// diff returns the processes present only in cur (started) and only in prev (exited),
+// each ordered by pid.
+func diff(prev, cur table) (started, exited []*sbmodels.ProcessInfo) {
+	for k, info := range cur {
+		if _, ok := prev[k]; !ok {
+			started = append(started, info)
+		}
+	}
+
+	for k, info := range prev {
+		if _, ok := cur[k]; !ok {
+			exited = append(exited, info)
+		}
+	}
+
+	sortByPID(started)
+	sortByPID(exited)
+	return started, exited
+}
+
+// list returns the processes of t ordered by pid.
+func (t table) list() []sbmodels.ProcessInfo {
+	infos := make([]*sbmodels.ProcessInfo, 0, len(t))
+	for _, info := range t {
+		infos = append(infos, info)
+	}
+	sortByPID(infos)
+
+	out := make([]sbmodels.ProcessInfo, 0, len(infos))
+	for _, info := range infos {
+		out = append(out, *info)
+	}
+	return out
+}
+
+func sortByPID(infos []*sbmodels.ProcessInfo) {
+	sort.Slice(infos, func(i, j int) bool { return infos[i].PID < infos[j].PID })
+}
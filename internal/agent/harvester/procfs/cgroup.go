@@ -0,0 +1,159 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CgroupEntry is one line of /proc/<pid>/cgroup. On the unified (v2) hierarchy there is a
+// single entry with HierarchyID 0 and no controllers.
+type CgroupEntry struct {
+	HierarchyID int
+	Controllers []string
+	Path        string
+}
+
+// MountInfo holds the fields of one /proc/<pid>/mountinfo line used by the agent.
+type MountInfo struct {
+	MountID    int
+	ParentID   int
+	Root       string // path within the mounted filesystem that forms the mount's root
+	MountPoint string
+	FSType     string
+	Source     string
+}
+
+// ReadCgroup parses /proc/<pid>/cgroup.
+func ReadCgroup(root string, pid int32) ([]CgroupEntry, error) {
+	data, err := os.ReadFile(PIDPath(root, pid, "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseCgroup(data)
+}
+
+// ParseCgroup parses the "hierarchy-ID:controller-list:cgroup-path" lines of /proc/<pid>/cgroup.
+func ParseCgroup(data []byte) ([]CgroupEntry, error) {
+	var out []CgroupEntry
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("malformed cgroup line: %q", line)
+		}
+
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("malformed cgroup line: %q", line)
+		}
+
+		e := CgroupEntry{HierarchyID: id, Path: parts[2]}
+		if parts[1] != "" {
+			e.Controllers = strings.Split(parts[1], ",")
+		}
+		out = append(out, e)
+	}
+
+	return out, sc.Err()
+}
+
+// ReadMountInfo parses /proc/<pid>/mountinfo.
+func ReadMountInfo(root string, pid int32) ([]MountInfo, error) {
+	data, err := os.ReadFile(PIDPath(root, pid, "mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseMountInfo(data)
+}
+
+// ParseMountInfo parses mountinfo lines:
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// The optional fields end at the "-" separator. Octal escapes (\040 for space) in paths are decoded.
+func ParseMountInfo(data []byte) ([]MountInfo, error) {
+	var out []MountInfo
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if len(fields) < 6 || sep < 0 || sep+2 >= len(fields) {
+			return nil, fmt.Errorf("malformed mountinfo line: %q", sc.Text())
+		}
+
+		m := MountInfo{
+			Root:       unescapeOctal(fields[3]),
+			MountPoint: unescapeOctal(fields[4]),
+			FSType:     fields[sep+1],
+			Source:     unescapeOctal(fields[sep+2]),
+		}
+		m.MountID, _ = strconv.Atoi(fields[0])
+		m.ParentID, _ = strconv.Atoi(fields[1])
+		out = append(out, m)
+	}
+
+	return out, sc.Err()
+}
+
+// MountNamespace returns the mount namespace identifier of pid, e.g. "mnt:[4026531841]".
+func MountNamespace(root string, pid int32) (string, error) {
+	return os.Readlink(PIDPath(root, pid, "ns", "mnt"))
+}
+
+// unescapeOctal decodes the \ooo escapes the kernel uses for whitespace and backslashes.
+func unescapeOctal(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package procfs
+
+import "testing"
+
+func TestParseCgroup(t *testing.T) {
+	v1 := "12:pids:/docker/abc\n1:name=systemd:/docker/abc\n0::/\n"
+	entries, err := ParseCgroup([]byte(v1))
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("ParseCgroup = %+v, %v", entries, err)
+	}
+	if entries[0].HierarchyID != 12 || entries[0].Controllers[0] != "pids" || entries[0].Path != "/docker/abc" {
+		t.Fatalf("entry 0 = %+v", entries[0])
+	}
+	if entries[2].HierarchyID != 0 || entries[2].Controllers != nil || entries[2].Path != "/" {
+		t.Fatalf("entry 2 = %+v", entries[2])
+	}
+
+	if _, err := ParseCgroup([]byte("garbage\n")); err == nil {
+		t.Fatal("ParseCgroup(malformed) should fail")
+	}
+}
+
+func TestParseMountInfo(t *testing.T) {
+	data := "22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw\n" +
+		"30 22 8:1 /var/lib/docker/containers/abc/hostname /etc/hostname rw,relatime - ext4 /dev/sda1 rw\n" +
+		"31 22 0:44 / /mnt/my\\040disk rw - tmpfs tmpfs rw\n"
+
+	mounts, err := ParseMountInfo([]byte(data))
+	if err != nil || len(mounts) != 3 {
+		t.Fatalf("ParseMountInfo = %+v, %v", mounts, err)
+	}
+	if m := mounts[1]; m.MountID != 30 || m.ParentID != 22 || m.Root != "/var/lib/docker/containers/abc/hostname" ||
+		m.MountPoint != "/etc/hostname" || m.FSType != "ext4" || m.Source != "/dev/sda1" {
+		t.Fatalf("mount 1 = %+v", m)
+	}
+	if mounts[2].MountPoint != "/mnt/my disk" {
+		t.Fatalf("mount 2 point = %q", mounts[2].MountPoint)
+	}
+}
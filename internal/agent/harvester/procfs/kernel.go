@@ -0,0 +1,132 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package procfs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// taintFlags are the letters of the kernel taint bits, indexed by bit number
+// (Documentation/admin-guide/tainted-kernels.rst).
+var taintFlags = []byte("PFSRMBUDAWCIOELKXTN")
+
+// Module is one entry of /proc/modules.
+type Module struct {
+	Name     string
+	Size     uint64
+	RefCount int
+	UsedBy   []string
+	State    string // Live, Loading or Unloading
+	Taint    string // per-module taint letters, e.g. "OE"; empty when untainted
+}
+
+// ReadModules parses /proc/modules. A missing file (kernel without module support)
+// yields no modules.
+func ReadModules(root string) ([]Module, error) {
+	f, err := os.Open(filepath.Join(root, "modules"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Module
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		m, err := ParseModule(sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("parse modules: %w", err)
+		}
+		out = append(out, m)
+	}
+
+	return out, sc.Err()
+}
+
+// ParseModule parses one line of /proc/modules:
+//
+//	name size refcount used_by state address [(taint)]
+func ParseModule(line string) (Module, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return Module{}, fmt.Errorf("malformed module line: %q", line)
+	}
+
+	size, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return Module{}, fmt.Errorf("malformed module size: %q", line)
+	}
+
+	m := Module{Name: fields[0], Size: size, State: fields[4]}
+	m.RefCount, _ = strconv.Atoi(fields[2]) // "-" when the module cannot be unloaded
+
+	if fields[3] != "-" {
+		for _, dep := range strings.Split(fields[3], ",") {
+			if dep != "" {
+				m.UsedBy = append(m.UsedBy, dep)
+			}
+		}
+	}
+
+	if len(fields) > 6 {
+		m.Taint = strings.Trim(fields[6], "()")
+	}
+	return m, nil
+}
+
+// ReadTainted returns the kernel taint bitmask from sys/kernel/tainted.
+func ReadTainted(root string) (uint64, error) {
+	value, err := ReadSysctl(root, "kernel.tainted")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// TaintFlags returns the letters of the bits set in a kernel taint value, lowest bit
+// first. Bits without a known letter are reported as their number.
+func TaintFlags(tainted uint64) []string {
+	var out []string
+	for bit := 0; bit < 64; bit++ {
+		if tainted&(1<<bit) == 0 {
+			continue
+		}
+		if bit < len(taintFlags) {
+			out = append(out, string(taintFlags[bit]))
+		} else {
+			out = append(out, strconv.Itoa(bit))
+		}
+	}
+	return out
+}
+
+// ReadSysctl returns the value of a sysctl given in dotted form (e.g. kernel.kptr_restrict),
+// with surrounding whitespace trimmed and internal tabs collapsed to single spaces.
+func ReadSysctl(root string, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "sys", strings.ReplaceAll(name, ".", "/")))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(string(data)), " "), nil
+}
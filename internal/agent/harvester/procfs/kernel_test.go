@@ -0,0 +1,73 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package procfs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseModule(t *testing.T) {
+	m, err := ParseModule("nvidia_uvm 1437696 2 - Live 0xffffffffc1a00000 (POE)")
+	if err != nil {
+		t.Fatalf("ParseModule: %v", err)
+	}
+	if m.Name != "nvidia_uvm" || m.Size != 1437696 || m.RefCount != 2 || m.UsedBy != nil || m.State != "Live" || m.Taint != "POE" {
+		t.Fatalf("ParseModule = %+v", m)
+	}
+
+	m, err = ParseModule("libcrc32c 12288 3 nf_conntrack,nf_tables,btrfs, Live 0x0000000000000000")
+	if err != nil {
+		t.Fatalf("ParseModule: %v", err)
+	}
+	if !reflect.DeepEqual(m.UsedBy, []string{"nf_conntrack", "nf_tables", "btrfs"}) || m.Taint != "" {
+		t.Fatalf("ParseModule = %+v", m)
+	}
+
+	if _, err := ParseModule("broken"); err == nil {
+		t.Fatal("ParseModule(malformed) should fail")
+	}
+}
+
+func TestTaintFlags(t *testing.T) {
+	// P (bit 0), O (bit 12), E (bit 13), bit 40 has no letter.
+	got := TaintFlags(1 | 1<<12 | 1<<13 | 1<<40)
+	if !reflect.DeepEqual(got, []string{"P", "O", "E", "40"}) {
+		t.Fatalf("TaintFlags = %v", got)
+	}
+	if TaintFlags(0) != nil {
+		t.Fatal("TaintFlags(0) should be empty")
+	}
+}
+
+func TestReadSysctl(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "sys/kernel/yama/ptrace_scope")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := ReadSysctl(root, "kernel.yama.ptrace_scope")
+	if err != nil || v != "1" {
+		t.Fatalf("ReadSysctl = %q, %v", v, err)
+	}
+}
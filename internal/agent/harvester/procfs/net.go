@@ -0,0 +1,262 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package procfs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// TCP states as encoded in the st column of /proc/net/tcp (include/net/tcp_states.h).
+var tcpStates = map[uint8]string{
+	0x01: "ESTABLISHED",
+	0x02: "SYN_SENT",
+	0x03: "SYN_RECV",
+	0x04: "FIN_WAIT1",
+	0x05: "FIN_WAIT2",
+	0x06: "TIME_WAIT",
+	0x07: "CLOSE",
+	0x08: "CLOSE_WAIT",
+	0x09: "LAST_ACK",
+	0x0A: "LISTEN",
+	0x0B: "CLOSING",
+}
+
+const (
+	TCPEstablished = 0x01
+	TCPSynSent     = 0x02
+	TCPClose       = 0x07
+	TCPListen      = 0x0A
+)
+
+// unixAcceptCon is the __SO_ACCEPTCON flag of /proc/net/unix marking a listening socket.
+const unixAcceptCon = 0x10000
+
+// InetSocket is one entry of /proc/net/{tcp,tcp6,udp,udp6}.
+type InetSocket struct {
+	LocalIP    net.IP
+	LocalPort  uint16
+	RemoteIP   net.IP
+	RemotePort uint16
+	State      uint8
+	UID        uint32
+	Inode      uint64
+}
+
+// StateName returns the TCP state name of s (UDP sockets reuse the same encoding).
+func (s *InetSocket) StateName() string {
+	if name, ok := tcpStates[s.State]; ok {
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", s.State)
+}
+
+// UnixSocket is one entry of /proc/net/unix.
+type UnixSocket struct {
+	Type      uint16 // SOCK_STREAM=1, SOCK_DGRAM=2, SOCK_SEQPACKET=5
+	State     uint8
+	Listening bool
+	Inode     uint64
+	Path      string // empty for unnamed sockets; abstract names start with '@'
+}
+
+// ReadInetSockets parses /proc/net/<name>, where name is tcp, tcp6, udp or udp6.
+// A missing file (e.g. IPv6 disabled) yields no sockets.
+func ReadInetSockets(root string, name string) ([]InetSocket, error) {
+	f, err := os.Open(filepath.Join(root, "net", name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []InetSocket
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		s, err := ParseInetSocket(sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+		out = append(out, s)
+	}
+
+	return out, sc.Err()
+}
+
+// ParseInetSocket parses one data line of /proc/net/tcp and friends.
+func ParseInetSocket(line string) (InetSocket, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 10 {
+		return InetSocket{}, fmt.Errorf("malformed socket line: %q", line)
+	}
+
+	var s InetSocket
+	var err error
+	if s.LocalIP, s.LocalPort, err = parseInetAddr(fields[1]); err != nil {
+		return InetSocket{}, err
+	}
+	if s.RemoteIP, s.RemotePort, err = parseInetAddr(fields[2]); err != nil {
+		return InetSocket{}, err
+	}
+
+	state, err := strconv.ParseUint(fields[3], 16, 8)
+	if err != nil {
+		return InetSocket{}, fmt.Errorf("malformed socket state %q: %w", fields[3], err)
+	}
+	s.State = uint8(state)
+
+	uid, err := strconv.ParseUint(fields[7], 10, 32)
+	if err != nil {
+		return InetSocket{}, fmt.Errorf("malformed socket uid %q: %w", fields[7], err)
+	}
+	s.UID = uint32(uid)
+
+	if s.Inode, err = strconv.ParseUint(fields[9], 10, 64); err != nil {
+		return InetSocket{}, fmt.Errorf("malformed socket inode %q: %w", fields[9], err)
+	}
+
+	return s, nil
+}
+
+// parseInetAddr decodes "0100007F:0050". The address is a sequence of 32-bit words in
+// host byte order; the port is big-endian hex.
+func parseInetAddr(s string) (net.IP, uint16, error) {
+	addr, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("malformed socket address %q", s)
+	}
+
+	raw, err := hex.DecodeString(addr)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("malformed socket address %q", s)
+	}
+
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.NativeEndian.Uint32(raw[i:]))
+	}
+
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed socket port %q: %w", s, err)
+	}
+
+	return ip, uint16(port), nil
+}
+
+// ReadUnixSockets parses /proc/net/unix.
+func ReadUnixSockets(root string) ([]UnixSocket, error) {
+	f, err := os.Open(filepath.Join(root, "net", "unix"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []UnixSocket
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		s, err := ParseUnixSocket(sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("parse unix: %w", err)
+		}
+		out = append(out, s)
+	}
+
+	return out, sc.Err()
+}
+
+// ParseUnixSocket parses one data line of /proc/net/unix:
+// "Num RefCount Protocol Flags Type St Inode [Path]".
+func ParseUnixSocket(line string) (UnixSocket, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 7 {
+		return UnixSocket{}, fmt.Errorf("malformed unix socket line: %q", line)
+	}
+
+	flags, err := strconv.ParseUint(fields[3], 16, 32)
+	if err != nil {
+		return UnixSocket{}, fmt.Errorf("malformed unix socket flags %q: %w", fields[3], err)
+	}
+	typ, err := strconv.ParseUint(fields[4], 16, 16)
+	if err != nil {
+		return UnixSocket{}, fmt.Errorf("malformed unix socket type %q: %w", fields[4], err)
+	}
+	state, err := strconv.ParseUint(fields[5], 16, 8)
+	if err != nil {
+		return UnixSocket{}, fmt.Errorf("malformed unix socket state %q: %w", fields[5], err)
+	}
+	inode, err := strconv.ParseUint(fields[6], 10, 64)
+	if err != nil {
+		return UnixSocket{}, fmt.Errorf("malformed unix socket inode %q: %w", fields[6], err)
+	}
+
+	s := UnixSocket{
+		Type:      uint16(typ),
+		State:     uint8(state),
+		Listening: flags&unixAcceptCon != 0,
+		Inode:     inode,
+	}
+	if len(fields) > 7 {
+		s.Path = strings.Join(fields[7:], " ")
+	}
+
+	return s, nil
+}
+
+// SocketInodes returns the inodes of the sockets held open by pid, read from the
+// "socket:[inode]" targets of /proc/<pid>/fd.
+func SocketInodes(root string, pid int32) ([]uint64, error) {
+	dir := PIDPath(root, pid, "fd")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []uint64
+	for _, e := range entries {
+		target, err := os.Readlink(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+
+		v, ok := strings.CutPrefix(target, "socket:[")
+		if !ok {
+			continue
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(v, "]"), 10, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, inode)
+	}
+
+	return out, nil
+}
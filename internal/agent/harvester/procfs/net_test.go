@@ -0,0 +1,65 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package procfs
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseInetSocket(t *testing.T) {
+	line := "   0: 0100007F:0277 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 1 0000000000000000 100 0 0 10 0"
+	s, err := ParseInetSocket(line)
+	if err != nil {
+		t.Fatalf("ParseInetSocket: %v", err)
+	}
+	if !s.LocalIP.Equal(net.IPv4(127, 0, 0, 1)) || s.LocalPort != 631 || s.State != TCPListen ||
+		s.StateName() != "LISTEN" || s.Inode != 12345 || s.UID != 0 {
+		t.Fatalf("ParseInetSocket = %+v", s)
+	}
+
+	line6 := "   1: 00000000000000000000000001000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 777 1"
+	s, err = ParseInetSocket(line6)
+	if err != nil {
+		t.Fatalf("ParseInetSocket(v6): %v", err)
+	}
+	if !s.LocalIP.Equal(net.IPv6loopback) || s.LocalPort != 22 || s.UID != 1000 {
+		t.Fatalf("ParseInetSocket(v6) = %+v", s)
+	}
+
+	if _, err := ParseInetSocket("  0: zz:0016"); err == nil {
+		t.Fatal("ParseInetSocket(malformed) should fail")
+	}
+}
+
+func TestParseUnixSocket(t *testing.T) {
+	s, err := ParseUnixSocket("0000000000000000: 00000002 00000000 00010000 0001 01 20513 /run/docker.sock")
+	if err != nil {
+		t.Fatalf("ParseUnixSocket: %v", err)
+	}
+	if !s.Listening || s.Type != 1 || s.Inode != 20513 || s.Path != "/run/docker.sock" {
+		t.Fatalf("ParseUnixSocket = %+v", s)
+	}
+
+	s, err = ParseUnixSocket("0000000000000000: 00000003 00000000 00000000 0001 03 20600")
+	if err != nil {
+		t.Fatalf("ParseUnixSocket(unnamed): %v", err)
+	}
+	if s.Listening || s.Path != "" || s.State != 3 {
+		t.Fatalf("ParseUnixSocket(unnamed) = %+v", s)
+	}
+}
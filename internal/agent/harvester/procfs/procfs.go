@@ -0,0 +1,241 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+// Package procfs reads process and kernel state from a Linux procfs mount.
+// Every function takes the mount point so agents running in a container can read
+// the host's /proc (e.g. /host/proc), and tests can use a fake tree.
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userHZ is the clock tick rate used by /proc/<pid>/stat times (USER_HZ, 100 on all Linux ABIs).
+const userHZ = 100
+
+const deletedSuffix = " (deleted)"
+
+// Stat holds the fields of /proc/<pid>/stat used by the agent.
+type Stat struct {
+	PID   int32
+	Comm  string
+	State byte
+	PPID  int32
+	// StartTicks is the process start time in clock ticks since boot.
+	StartTicks uint64
+}
+
+// Status holds the credential fields of /proc/<pid>/status.
+type Status struct {
+	UID, EUID uint32
+	GID, EGID uint32
+}
+
+// ListPIDs returns the pids of all processes visible under root.
+func ListPIDs(root string) ([]int32, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	pids := make([]int32, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		pid, err := strconv.ParseInt(e.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		pids = append(pids, int32(pid))
+	}
+
+	return pids, nil
+}
+
+// PIDPath returns the path of name under the /proc/<pid> directory.
+func PIDPath(root string, pid int32, name ...string) string {
+	return filepath.Join(append([]string{root, strconv.Itoa(int(pid))}, name...)...)
+}
+
+// ReadStat parses /proc/<pid>/stat.
+func ReadStat(root string, pid int32) (Stat, error) {
+	data, err := os.ReadFile(PIDPath(root, pid, "stat"))
+	if err != nil {
+		return Stat{}, err
+	}
+	return ParseStat(data)
+}
+
+// ParseStat parses the content of a /proc/<pid>/stat file. The command name may contain
+// spaces and parentheses, so it is delimited by the first '(' and the last ')'.
+func ParseStat(data []byte) (Stat, error) {
+	open := bytes.IndexByte(data, '(')
+	closing := bytes.LastIndexByte(data, ')')
+	if open < 0 || closing < open {
+		return Stat{}, fmt.Errorf("malformed stat: %q", data)
+	}
+
+	pid, err := strconv.ParseInt(strings.TrimSpace(string(data[:open])), 10, 32)
+	if err != nil {
+		return Stat{}, fmt.Errorf("malformed stat pid: %w", err)
+	}
+
+	// Fields after the command name start with field 3 (state).
+	fields := strings.Fields(string(data[closing+1:]))
+	if len(fields) < 20 {
+		return Stat{}, fmt.Errorf("malformed stat: %d fields", len(fields))
+	}
+
+	ppid, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil {
+		return Stat{}, fmt.Errorf("malformed stat ppid: %w", err)
+	}
+
+	start, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return Stat{}, fmt.Errorf("malformed stat starttime: %w", err)
+	}
+
+	return Stat{
+		PID:        int32(pid),
+		Comm:       string(data[open+1 : closing]),
+		State:      fields[0][0],
+		PPID:       int32(ppid),
+		StartTicks: start,
+	}, nil
+}
+
+// ReadStatus parses the Uid and Gid lines of /proc/<pid>/status.
+func ReadStatus(root string, pid int32) (Status, error) {
+	f, err := os.Open(PIDPath(root, pid, "status"))
+	if err != nil {
+		return Status{}, err
+	}
+	defer f.Close()
+
+	var (
+		st             Status
+		hasUID, hasGID bool
+	)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "Uid":
+			st.UID, st.EUID = parseIDPair(value)
+			hasUID = true
+		case "Gid":
+			st.GID, st.EGID = parseIDPair(value)
+			hasGID = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Status{}, err
+	}
+
+	// A process that exits while its status is read yields no ids; zero would mean root.
+	if !hasUID || !hasGID {
+		return Status{}, fmt.Errorf("malformed status: no Uid or Gid line")
+	}
+	return st, nil
+}
+
+// parseIDPair returns the real and effective ids of a "real effective saved fs" status value.
+func parseIDPair(value string) (uint32, uint32) {
+	fields := strings.Fields(value)
+	ids := [2]uint32{}
+	for i := 0; i < len(fields) && i < 2; i++ {
+		n, _ := strconv.ParseUint(fields[i], 10, 32)
+		ids[i] = uint32(n)
+	}
+	return ids[0], ids[1]
+}
+
+// ReadCmdline returns the NUL-separated arguments of /proc/<pid>/cmdline.
+func ReadCmdline(root string, pid int32) ([]string, error) {
+	data, err := os.ReadFile(PIDPath(root, pid, "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return []string{}, nil
+	}
+	return strings.Split(string(data), "\x00"), nil
+}
+
+// ReadExe returns the executable path of pid and whether it was deleted from disk.
+func ReadExe(root string, pid int32) (string, bool, error) {
+	target, err := os.Readlink(PIDPath(root, pid, "exe"))
+	if err != nil {
+		return "", false, err
+	}
+
+	if strings.HasSuffix(target, deletedSuffix) {
+		return strings.TrimSuffix(target, deletedSuffix), true, nil
+	}
+	return target, false, nil
+}
+
+// ReadCwd returns the working directory of pid.
+func ReadCwd(root string, pid int32) (string, error) {
+	return os.Readlink(PIDPath(root, pid, "cwd"))
+}
+
+// BootTime returns the system boot time from the btime line of /proc/stat.
+func BootTime(root string) (time.Time, error) {
+	f, err := os.Open(filepath.Join(root, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		value, ok := strings.CutPrefix(sc.Text(), "btime ")
+		if !ok {
+			continue
+		}
+		sec, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("malformed btime: %w", err)
+		}
+		return time.Unix(sec, 0), nil
+	}
+
+	if err := sc.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("btime not found in %s", filepath.Join(root, "stat"))
+}
+
+// StartTime converts clock ticks since boot to wall-clock time.
+func StartTime(boot time.Time, ticks uint64) time.Time {
+	return boot.Add(time.Duration(ticks) * time.Second / userHZ)
+}
@@ -0,0 +1,92 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package procfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseStat(t *testing.T) {
+	data := []byte("1234 (tricky ) name) S 1 1234 1234 0 -1 4194560 100 0 0 0 1 2 0 0 20 0 1 0 5678 1000 100 18446744073709551615\n")
+	st, err := ParseStat(data)
+	if err != nil {
+		t.Fatalf("ParseStat: %v", err)
+	}
+	if st.PID != 1234 || st.Comm != "tricky ) name" || st.State != 'S' || st.PPID != 1 || st.StartTicks != 5678 {
+		t.Fatalf("ParseStat = %+v", st)
+	}
+
+	if _, err := ParseStat([]byte("garbage")); err == nil {
+		t.Fatal("ParseStat(garbage) should fail")
+	}
+}
+
+func TestFakeProcTree(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("stat", "cpu  1 2 3\nbtime 1700000000\nprocesses 10\n")
+	write("42/status", "Name:\tsshd\nUid:\t1000\t0\t0\t0\nGid:\t100\t101\t101\t101\n")
+	write("42/cmdline", "/usr/sbin/sshd\x00-D\x00")
+	write("self-not-a-pid/stat", "")
+	if err := os.Symlink("/usr/sbin/sshd (deleted)", filepath.Join(root, "42", "exe")); err != nil {
+		t.Fatal(err)
+	}
+
+	pids, err := ListPIDs(root)
+	if err != nil || len(pids) != 1 || pids[0] != 42 {
+		t.Fatalf("ListPIDs = %v, %v", pids, err)
+	}
+
+	boot, err := BootTime(root)
+	if err != nil || !boot.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("BootTime = %v, %v", boot, err)
+	}
+	if got := StartTime(boot, 250); !got.Equal(boot.Add(2500 * time.Millisecond)) {
+		t.Fatalf("StartTime = %v", got)
+	}
+
+	st, err := ReadStatus(root, 42)
+	if err != nil || st != (Status{UID: 1000, EUID: 0, GID: 100, EGID: 101}) {
+		t.Fatalf("ReadStatus = %+v, %v", st, err)
+	}
+
+	write("43/status", "Name:\tgone\n")
+	if _, err := ReadStatus(root, 43); err == nil {
+		t.Fatal("ReadStatus without Uid and Gid should fail")
+	}
+
+	args, err := ReadCmdline(root, 42)
+	if err != nil || len(args) != 2 || args[0] != "/usr/sbin/sshd" || args[1] != "-D" {
+		t.Fatalf("ReadCmdline = %q, %v", args, err)
+	}
+
+	exe, deleted, err := ReadExe(root, 42)
+	if err != nil || exe != "/usr/sbin/sshd" || !deleted {
+		t.Fatalf("ReadExe = %q, %v, %v", exe, deleted, err)
+	}
+}
@@ -0,0 +1,81 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package agent
+
+import (
+	"context"
+	"net"
+	"os"
+	"runtime"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/internal/agent/controller"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+)
+
+// registerTimeout bounds the wait for the controller to acknowledge a register message.
+const registerTimeout = 30 * time.Second
+
+// announce sends the agent's register message to the controller on every new stream.
+func announce(ctrl *controller.ControllerClient, cfg *config.Configuration) {
+	ctrl.OnConnect(func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, registerTimeout)
+		defer cancel()
+
+		if _, err := ctrl.Request(ctx, registerInfo(cfg)); err != nil && ctx.Err() == nil {
+			logger.Warnf("controller register: %v", err)
+		}
+	})
+}
+
+// registerInfo describes this agent and host for the controller's inventory.
+func registerInfo(cfg *config.Configuration) *proto.RegisterInfo {
+	hostname, _ := os.Hostname()
+
+	plugins := make([]string, 0, len(cfg.Harvester.Plugins))
+	for _, p := range cfg.Harvester.Plugins {
+		plugins = append(plugins, p.Name)
+	}
+
+	return &proto.RegisterInfo{
+		Hostname:     hostname,
+		Ips:          hostIPs(),
+		Os:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		AgentName:    cfg.Name,
+		AgentVersion: cfg.Version,
+		Plugins:      plugins,
+	}
+}
+
+// hostIPs returns the global unicast addresses of the host.
+func hostIPs() []string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+
+	var ips []string
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.IsGlobalUnicast() {
+			ips = append(ips, ipNet.IP.String())
+		}
+	}
+	return ips
+}
@@ -0,0 +1,214 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/apm"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+)
+
+const (
+	// defaultMaxInflight is the number of batches that may wait for an ack at once.
+	defaultMaxInflight = 1024
+
+	// defaultAckTimeout is how long a batch waits for an ack before it is sent again.
+	defaultAckTimeout = 30 * time.Second
+
+	// retransmitInterval is how often unacked batches are checked.
+	retransmitInterval = time.Second
+
+	// closeAckTimeout bounds how long Close waits for outstanding acks.
+	closeAckTimeout = 2 * time.Second
+)
+
+var errInflightFull = errors.New("too many unacknowledged batches")
+
+// inflightBatch is a batch sent on an acknowledged stream and not acked yet.
+type inflightBatch struct {
+	msgs [][]byte
+	req  *proto.DatabusRequest
+
+	// slot is the pool slot the batch was last sent on, or -1 when it must be sent again.
+	slot   int
+	sentAt time.Time
+	reason string
+
+	// done, when set, is closed once the batch no longer waits for an ack.
+	done chan struct{}
+}
+
+// inflightTable tracks the batches waiting for an ack, keyed by sequence number.
+type inflightTable struct {
+	mu      sync.Mutex
+	name    string
+	max     int
+	batches map[uint64]*inflightBatch
+}
+
+func newInflightTable(name string, max int) *inflightTable {
+	return &inflightTable{
+		name:    name,
+		max:     max,
+		batches: make(map[uint64]*inflightBatch),
+	}
+}
+
+// add tracks req before it is sent. It returns errInflightFull when the table is full.
+// done, when not nil, is closed once the batch is acked or no longer tracked.
+func (t *inflightTable) add(msgs [][]byte, req *proto.DatabusRequest, done chan struct{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.max > 0 && len(t.batches) >= t.max {
+		return errInflightFull
+	}
+	t.batches[req.GetSeq()] = &inflightBatch{msgs: msgs, req: req, slot: -1, done: done}
+	t.updateMetrics()
+	return nil
+}
+
+// sent records that seq went out on slot. It is a no-op if seq was acked meanwhile.
+func (t *inflightTable) sent(seq uint64, slot int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if b, ok := t.batches[seq]; ok {
+		b.slot = slot
+		b.sentAt = time.Now()
+		b.reason = ""
+	}
+}
+
+// remove stops tracking seq.
+func (t *inflightTable) remove(seq uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.delete(seq)
+}
+
+// ack applies an ack from the databus. Successful and non-retryable acks end tracking;
+// any other status schedules the batch to be sent again.
+func (t *inflightTable) ack(a *proto.DatabusAck) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.batches[a.GetSeq()]
+	if !ok {
+		return
+	}
+
+	switch gerrors.Code(a.GetStatus()) {
+	case gerrors.Success:
+		t.delete(a.GetSeq())
+	case gerrors.InvalidParameter:
+		logger.Warnf("%s reporter: databus rejected batch seq=%d of %d messages: %s",
+			t.name, a.GetSeq(), len(b.msgs), a.GetErrmsg())
+		t.delete(a.GetSeq())
+	default:
+		logger.Debugf("%s reporter: databus failed batch seq=%d: %s", t.name, a.GetSeq(), a.GetErrmsg())
+		b.slot = -1
+		b.reason = "nack"
+	}
+}
+
+// requeueSlot schedules every batch last sent on slot to be sent again.
+func (t *inflightTable) requeueSlot(slot int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, b := range t.batches {
+		if b.slot == slot {
+			b.slot = -1
+			b.reason = "disconnect"
+		}
+	}
+}
+
+// due returns, oldest first, the batches that must be sent again: those whose slot went
+// away or was nacked, and those not acked within timeout.
+func (t *inflightTable) due(timeout time.Duration) []*inflightBatch {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	var out []*inflightBatch
+	for _, b := range t.batches {
+		switch {
+		case b.slot < 0:
+			if b.reason == "" {
+				// Not sent yet; the sender will record it shortly.
+				continue
+			}
+		case now.Sub(b.sentAt) >= timeout:
+			b.reason = "timeout"
+		default:
+			continue
+		}
+		out = append(out, b)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].req.GetSeq() < out[j].req.GetSeq() })
+	return out
+}
+
+// drain stops tracking all batches and returns them, oldest first. Their done channels
+// are left open: the batches were not acked.
+func (t *inflightTable) drain() []*inflightBatch {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]*inflightBatch, 0, len(t.batches))
+	for _, b := range t.batches {
+		out = append(out, b)
+	}
+	t.batches = make(map[uint64]*inflightBatch)
+	t.updateMetrics()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].req.GetSeq() < out[j].req.GetSeq() })
+	return out
+}
+
+// len returns the number of batches waiting for an ack.
+func (t *inflightTable) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.batches)
+}
+
+// delete stops tracking seq and closes its done channel. Called with t.mu held.
+func (t *inflightTable) delete(seq uint64) {
+	if b, ok := t.batches[seq]; ok {
+		delete(t.batches, seq)
+		if b.done != nil {
+			close(b.done)
+		}
+		t.updateMetrics()
+	}
+}
+
+// updateMetrics must be called with t.mu held.
+func (t *inflightTable) updateMetrics() {
+	apm.InflightBatches.WithLabelValues(t.name).Set(float64(len(t.batches)))
+}
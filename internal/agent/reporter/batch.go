@@ -0,0 +1,131 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"os-artificer/saber/pkg/sbcodec"
+)
+
+const (
+	defaultBatchMaxMessages = 512
+	defaultBatchMaxBytes    = 1 << 20
+	defaultBatchLinger      = 200 * time.Millisecond
+	defaultBatchCompression = sbcodec.EncodingSnappy
+)
+
+var errBatcherClosed = errors.New("batcher is closed")
+
+// BatchOptions configures how messages are grouped into one request.
+type BatchOptions struct {
+	// MaxMessages and MaxBytes flush a batch once it holds that many messages or bytes.
+	MaxMessages int
+	MaxBytes    int
+	// Linger flushes a batch that has not filled up this long after its first message.
+	Linger time.Duration
+	// Compression is the content encoding of batch payloads (see sbcodec).
+	Compression string
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.MaxMessages <= 0 {
+		o.MaxMessages = defaultBatchMaxMessages
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = defaultBatchMaxBytes
+	}
+	if o.Linger <= 0 {
+		o.Linger = defaultBatchLinger
+	}
+	if o.Compression == "" {
+		o.Compression = defaultBatchCompression
+	}
+	return o
+}
+
+// batcher accumulates messages and hands them to flush in batches, in arrival order.
+// A full batch is flushed by the goroutine that filled it, which applies backpressure to
+// the senders; a lingering one is flushed from a timer.
+type batcher struct {
+	opts  BatchOptions
+	flush func(msgs [][]byte)
+
+	flushMu sync.Mutex // serializes flushes so that batches keep their order
+
+	mu     sync.Mutex
+	msgs   [][]byte
+	size   int
+	timer  *time.Timer
+	closed bool
+}
+
+func newBatcher(opts BatchOptions, flush func(msgs [][]byte)) *batcher {
+	return &batcher{opts: opts.withDefaults(), flush: flush}
+}
+
+// add queues msg for the next batch.
+func (b *batcher) add(msg []byte) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return errBatcherClosed
+	}
+
+	b.msgs = append(b.msgs, msg)
+	b.size += len(msg)
+	if len(b.msgs) == 1 {
+		b.timer = time.AfterFunc(b.opts.Linger, b.flushNow)
+	}
+	full := len(b.msgs) >= b.opts.MaxMessages || b.size >= b.opts.MaxBytes
+	b.mu.Unlock()
+
+	if full {
+		b.flushNow()
+	}
+	return nil
+}
+
+// flushNow flushes the pending messages, if any.
+func (b *batcher) flushNow() {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	msgs := b.msgs
+	b.msgs, b.size = nil, 0
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(msgs) > 0 {
+		b.flush(msgs)
+	}
+}
+
+// close flushes the pending messages; later adds fail.
+func (b *batcher) close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	b.flushNow()
+}
@@ -0,0 +1,85 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (r *batchRecorder) flush(msgs [][]byte) {
+	batch := make([]string, len(msgs))
+	for i, m := range msgs {
+		batch[i] = string(m)
+	}
+
+	r.mu.Lock()
+	r.batches = append(r.batches, batch)
+	r.mu.Unlock()
+}
+
+func (r *batchRecorder) get() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string(nil), r.batches...)
+}
+
+func TestBatcherFlushesWhenFull(t *testing.T) {
+	var rec batchRecorder
+	b := newBatcher(BatchOptions{MaxMessages: 3, MaxBytes: 10, Linger: time.Hour}, rec.flush)
+
+	for _, m := range []string{"a", "b", "c", "d", "0123456789", "e"} {
+		if err := b.add([]byte(m)); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if got := fmt.Sprint(rec.get()); got != "[[a b c] [d 0123456789]]" {
+		t.Fatalf("batches = %s", got)
+	}
+
+	b.close()
+	if got := fmt.Sprint(rec.get()); got != "[[a b c] [d 0123456789] [e]]" {
+		t.Fatalf("batches after close = %s", got)
+	}
+	if err := b.add([]byte("f")); err != errBatcherClosed {
+		t.Fatalf("add after close = %v", err)
+	}
+}
+
+func TestBatcherLinger(t *testing.T) {
+	var rec batchRecorder
+	b := newBatcher(BatchOptions{MaxMessages: 100, Linger: 20 * time.Millisecond}, rec.flush)
+	defer b.close()
+
+	_ = b.add([]byte("a"))
+	_ = b.add([]byte("b"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(rec.get()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := fmt.Sprint(rec.get()); got != "[[a b]]" {
+		t.Fatalf("batches = %s", got)
+	}
+}
@@ -19,26 +19,36 @@ package reporter
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"os-artificer/saber/internal/agent/apm"
 	"os-artificer/saber/internal/agent/config"
 	"os-artificer/saber/pkg/constant"
 	"os-artificer/saber/pkg/logger"
 	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbcodec"
 	"os-artificer/saber/pkg/sbnet"
 	"os-artificer/saber/pkg/tools"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
 var _ Reporter = (*TransferReporter)(nil)
 
+// defaultDatabusSpoolDir is where the databus reporter spools messages it cannot send.
+const defaultDatabusSpoolDir = "./data/spool/databus"
+
 func init() {
 	RegisterReporter("databus", newTransferReporterFromOpts)
 }
@@ -50,7 +60,7 @@ func newTransferReporterFromOpts(ctx context.Context, opts any) (Reporter, error
 		return nil, fmt.Errorf("databus reporter expects *config.ReporterOpts, got %T", opts)
 	}
 	endpoints, _ := o.Config["endpoints"].(string)
-	if endpoints == "" {
+	if endpoints == "" && o.Databus == nil {
 		return nil, fmt.Errorf("databus reporter config missing or invalid endpoints")
 	}
 
@@ -67,12 +77,69 @@ func newTransferReporterFromOpts(ctx context.Context, opts any) (Reporter, error
 		}
 	}
 
-	clientID, err := tools.MachineID("saber-agent")
+	clientID, err := reporterClientID(o)
+	if err != nil {
+		return nil, err
+	}
+
+	rep, err := NewTransferReporter(ctx, endpoints, clientID, poolSize)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate machine-id: %v", err)
+		return nil, err
+	}
+	if o.Databus != nil {
+		rep.UseResolver(o.Databus)
+	}
+	if o.Credentials != nil {
+		rep.UseCredentials(o.Credentials)
+	}
+
+	batchCfg, _ := o.Config["batch"].(map[string]any)
+	if enabled, ok := batchCfg["enabled"].(bool); !ok || enabled {
+		batchOpts := BatchOptions{}
+		if n, ok := toInt(batchCfg["max_messages"]); ok {
+			batchOpts.MaxMessages = n
+		}
+		if n, ok := toInt(batchCfg["max_bytes"]); ok {
+			batchOpts.MaxBytes = n
+		}
+		if d, ok := toDuration(batchCfg["linger"]); ok {
+			batchOpts.Linger = d
+		}
+		if enc, ok := batchCfg["compression"].(string); ok {
+			batchOpts.Compression = enc
+		}
+		if !sbcodec.ValidEncoding(batchOpts.Compression) {
+			return nil, fmt.Errorf("databus reporter: unsupported batch compression %q", batchOpts.Compression)
+		}
+		rep.EnableBatching(batchOpts)
 	}
 
-	return NewTransferReporter(ctx, endpoints, clientID, poolSize)
+	spoolCfg, _ := o.Config["spool"].(map[string]any)
+	if enabled, ok := spoolCfg["enabled"].(bool); ok && !enabled {
+		return rep, nil
+	}
+
+	spoolOpts := SpoolOptions{Dir: defaultDatabusSpoolDir}
+	if dir, ok := spoolCfg["dir"].(string); ok && dir != "" {
+		spoolOpts.Dir = dir
+	}
+	if n, ok := toInt(spoolCfg["segment_bytes"]); ok {
+		spoolOpts.SegmentBytes = int64(n)
+	}
+	if n, ok := toInt(spoolCfg["max_bytes"]); ok {
+		spoolOpts.MaxBytes = int64(n)
+	}
+	if d, ok := toDuration(spoolCfg["max_age"]); ok {
+		spoolOpts.MaxAge = d
+	}
+
+	spool, err := OpenSpool("databus", spoolOpts)
+	if err != nil {
+		return nil, err
+	}
+	rep.spool = spool
+
+	return rep, nil
 }
 
 func toInt(v any) (int, bool) {
@@ -88,19 +155,41 @@ func toInt(v any) (int, bool) {
 	}
 }
 
-// poolEntry holds one gRPC connection and its PushData stream for the pool.
+func toDuration(v any) (time.Duration, bool) {
+	switch x := v.(type) {
+	case time.Duration:
+		return x, true
+	case string:
+		d, err := time.ParseDuration(x)
+		return d, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// dataStream is the sending side shared by PushData and StreamData streams.
+type dataStream interface {
+	Send(*proto.DatabusRequest) error
+}
+
+// poolEntry holds one gRPC connection and its stream for the pool.
 type poolEntry struct {
 	mu                sync.RWMutex
+	addr              string
+	gaveUp            bool
 	conn              *grpc.ClientConn
 	client            proto.DatabusServiceClient
-	stream            proto.DatabusService_PushDataClient
+	stream            dataStream
+	acked             bool
 	reconnecting      bool
 	reconnectAttempts int
 }
 
 // TransferReporter is the reporter for transfer using a pool of gRPC connections.
+// The pool slots are spread over the databus endpoints.
 type TransferReporter struct {
-	serverAddr           string
+	staticAddrs          []string
+	slotOffset           int
 	poolSize             int
 	pool                 []*poolEntry
 	nextIndex            atomic.Uint32
@@ -111,9 +200,27 @@ type TransferReporter struct {
 	mu                   sync.RWMutex
 	reconnectInterval    time.Duration
 	maxReconnectAttempts int
+	creds                credentials.TransportCredentials
+
+	// batch, when set, groups messages into compressed batches of encoding.
+	batch    *batcher
+	encoding string
+
+	// spool, when set, keeps the batches that cannot be sent and replays them in order
+	// once a stream is available again.
+	spool *Spool
+
+	// seq numbers the batches sent on StreamData streams; inflight holds those not
+	// acked yet. legacy is set once the databus turns out not to support StreamData.
+	seq        atomic.Uint64
+	inflight   *inflightTable
+	ackTimeout time.Duration
+	legacy     atomic.Bool
 }
 
 // NewTransferReporter creates a new transfer reporter with a connection pool.
+// serverAddr is a comma-separated list of databus endpoints; it may be empty when the
+// endpoints come from a resolver (see UseResolver).
 func NewTransferReporter(ctx context.Context, serverAddr string, clientId string, poolSize int) (*TransferReporter, error) {
 	if poolSize <= 0 {
 		poolSize = constant.DefaultTransferPoolSize
@@ -125,8 +232,19 @@ func NewTransferReporter(ctx context.Context, serverAddr string, clientId string
 		pool[i] = &poolEntry{}
 	}
 
-	return &TransferReporter{
-		serverAddr:           serverAddr,
+	var staticAddrs []string
+	for _, addr := range strings.Split(serverAddr, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			staticAddrs = append(staticAddrs, addr)
+		}
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(clientId))
+
+	c := &TransferReporter{
+		staticAddrs:          staticAddrs,
+		slotOffset:           int(h.Sum32() % uint32(poolSize)),
 		poolSize:             poolSize,
 		pool:                 pool,
 		ctx:                  ctxCancel,
@@ -134,13 +252,110 @@ func NewTransferReporter(ctx context.Context, serverAddr string, clientId string
 		clientId:             clientId,
 		reconnectInterval:    constant.DefaultClientReconnectInterval,
 		maxReconnectAttempts: constant.DefaultClientMaxReconnectAttempts,
-	}, nil
+		creds:                insecure.NewCredentials(),
+		inflight:             newInflightTable("databus", defaultMaxInflight),
+		ackTimeout:           defaultAckTimeout,
+	}
+
+	// Start from the clock so that sequence numbers are not reused across restarts while
+	// the databus still remembers them.
+	c.seq.Store(uint64(time.Now().UnixNano()))
+	c.setEndpoints(staticAddrs)
+	return c, nil
 }
 
-func (c *TransferReporter) newConn() (*grpc.ClientConn, error) {
-	ep, err := sbnet.NewEndpointFromString(c.serverAddr)
+// UseResolver makes the pool follow the databus instances known to r. The static
+// endpoints are used while r knows none. Call it before Run.
+func (c *TransferReporter) UseResolver(r config.EndpointResolver) {
+	r.Subscribe(c.setEndpoints)
+	if eps := r.Endpoints(); len(eps) > 0 {
+		c.setEndpoints(eps)
+	}
+}
+
+// UseCredentials secures the databus connections with creds. Call it before Run.
+func (c *TransferReporter) UseCredentials(creds credentials.TransportCredentials) {
+	c.creds = creds
+}
+
+// setEndpoints spreads the pool slots over endpoints, starting at an offset derived
+// from the client ID so that agents with small pools do not all pick the same instance.
+// Connected slots whose endpoint changed are reconnected.
+func (c *TransferReporter) setEndpoints(endpoints []string) {
+	if len(endpoints) == 0 {
+		endpoints = c.staticAddrs
+	}
+	if len(endpoints) == 0 {
+		logger.Warnf("transfer reporter: no databus endpoints known, keeping current connections")
+		return
+	}
+
+	for i, entry := range c.pool {
+		addr := endpoints[(i+c.slotOffset)%len(endpoints)]
+
+		entry.mu.Lock()
+		changed := entry.addr != "" && entry.addr != addr
+		entry.addr = addr
+		entry.mu.Unlock()
+
+		if changed {
+			logger.Infof("transfer pool slot %d: moving to %s", i, addr)
+			go c.resetSlot(i)
+		}
+	}
+}
+
+// resetSlot reconnects slot i to its current endpoint. Slots that are reconnecting or
+// still in their initial connect pick the endpoint up on their next attempt.
+func (c *TransferReporter) resetSlot(i int) {
+	entry := c.pool[i]
+	entry.mu.Lock()
+	if c.isClosed() || entry.reconnecting || (entry.conn == nil && !entry.gaveUp) {
+		entry.mu.Unlock()
+		return
+	}
+	oldConn := entry.conn
+	entry.conn = nil
+	entry.client = nil
+	entry.stream = nil
+	entry.acked = false
+	entry.gaveUp = false
+	entry.reconnectAttempts = 0
+	entry.mu.Unlock()
+
+	if oldConn != nil {
+		_ = oldConn.Close()
+	}
+	c.inflight.requeueSlot(i)
+
+	if err := c.connectSlot(i); err != nil {
+		logger.Warnf("transfer pool slot %d: connect failed: %v", i, err)
+		go c.handleDisconnect(i)
+	}
+}
+
+func (c *TransferReporter) isClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
+
+// EnableBatching makes SendMessage group messages into batches, each sent as one
+// DatabusRequest with a compressed payload. Call it before Run.
+func (c *TransferReporter) EnableBatching(opts BatchOptions) {
+	opts = opts.withDefaults()
+	c.encoding = opts.Compression
+	c.batch = newBatcher(opts, func(msgs [][]byte) {
+		if err := c.deliver(msgs); err != nil {
+			logger.Warnf("transfer reporter: dropped batch of %d messages: %v", len(msgs), err)
+		}
+	})
+}
+
+func (c *TransferReporter) newConn(addr string) (*grpc.ClientConn, error) {
+	ep, err := sbnet.NewEndpointFromString(addr)
 	if err != nil {
-		return nil, fmt.Errorf("parse endpoint %q: %w", c.serverAddr, err)
+		return nil, fmt.Errorf("parse endpoint %q: %w", addr, err)
 	}
 	dialAddr := ep.HostPort()
 
@@ -152,7 +367,7 @@ func (c *TransferReporter) newConn() (*grpc.ClientConn, error) {
 
 	return grpc.NewClient(
 		dialAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(c.creds),
 		grpc.WithKeepaliveParams(kacp),
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(constant.DefaultMaxReceiveMessageSize),
@@ -170,28 +385,50 @@ func (c *TransferReporter) connectSlot(i int) error {
 	}
 	c.mu.RUnlock()
 
-	conn, err := c.newConn()
+	entry := c.pool[i]
+	entry.mu.RLock()
+	addr := entry.addr
+	entry.mu.RUnlock()
+	if addr == "" {
+		return fmt.Errorf("no databus endpoint known")
+	}
+
+	conn, err := c.newConn(addr)
 	if err != nil {
 		return err
 	}
 
 	client := proto.NewDatabusServiceClient(conn)
-	stream, err := client.PushData(c.ctx)
+
+	var (
+		stream    dataStream
+		ackStream proto.DatabusService_StreamDataClient
+	)
+	if c.legacy.Load() {
+		stream, err = client.PushData(c.ctx)
+	} else {
+		ackStream, err = client.StreamData(c.ctx)
+		stream = ackStream
+	}
 	if err != nil {
 		_ = conn.Close()
 		return err
 	}
 
-	entry := c.pool[i]
 	entry.mu.Lock()
 	entry.conn = conn
 	entry.client = client
 	entry.stream = stream
+	entry.acked = ackStream != nil
+	entry.gaveUp = false
 	entry.reconnectAttempts = 0
 	entry.mu.Unlock()
 
+	if ackStream != nil {
+		go c.receiveAcks(i, ackStream)
+	}
 	go c.sendConnectionEstablished(i)
-	go c.monitorConnection(i)
+	go c.monitorConnection(i, conn)
 
 	return nil
 }
@@ -212,6 +449,7 @@ func (c *TransferReporter) handleDisconnect(i int) {
 	entry.conn = nil
 	entry.client = nil
 	entry.stream = nil
+	entry.acked = false
 	entry.reconnectAttempts++
 	attempts := entry.reconnectAttempts
 	maxAttempts := c.maxReconnectAttempts
@@ -226,9 +464,13 @@ func (c *TransferReporter) handleDisconnect(i int) {
 	if oldConn != nil {
 		_ = oldConn.Close()
 	}
+	c.inflight.requeueSlot(i)
 
 	if maxAttempts > 0 && attempts > maxAttempts {
 		logger.Warnf("transfer pool slot %d: max reconnect attempts (%d) reached", i, maxAttempts)
+		entry.mu.Lock()
+		entry.gaveUp = true
+		entry.mu.Unlock()
 		return
 	}
 
@@ -255,7 +497,8 @@ func (c *TransferReporter) handleDisconnect(i int) {
 	}
 }
 
-func (c *TransferReporter) monitorConnection(i int) {
+// monitorConnection watches conn while it is slot i's connection.
+func (c *TransferReporter) monitorConnection(i int, conn *grpc.ClientConn) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -271,9 +514,9 @@ func (c *TransferReporter) monitorConnection(i int) {
 
 			entry := c.pool[i]
 			entry.mu.RLock()
-			conn := entry.conn
+			current := entry.conn
 			entry.mu.RUnlock()
-			if conn == nil {
+			if current != conn {
 				return
 			}
 			state := conn.GetState()
@@ -327,11 +570,227 @@ func (c *TransferReporter) Run() error {
 		})
 	}
 
+	if c.spool != nil {
+		tools.Go(c.replaySpool)
+	}
+	tools.Go(c.retransmitLoop)
+
 	<-c.ctx.Done()
 	return c.ctx.Err()
 }
 
+// receiveAcks reads the acks of slot i's StreamData stream until it fails. A databus
+// without StreamData answers with Unimplemented; the reporter then falls back to PushData,
+// which has no acks.
+func (c *TransferReporter) receiveAcks(i int, stream proto.DatabusService_StreamDataClient) {
+	for {
+		ack, err := stream.Recv()
+		if err == nil {
+			c.inflight.ack(ack)
+			continue
+		}
+
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		if status.Code(err) == codes.Unimplemented && !c.legacy.Swap(true) {
+			logger.Warnf("transfer reporter: databus does not support StreamData, falling back to PushData without acknowledgements")
+		}
+
+		entry := c.pool[i]
+		entry.mu.RLock()
+		current := entry.stream == dataStream(stream)
+		entry.mu.RUnlock()
+
+		if current {
+			logger.Warnf("transfer pool slot %d: ack stream closed: %v", i, err)
+			go c.handleDisconnect(i)
+		}
+		return
+	}
+}
+
+// retransmitLoop sends again the batches that were not acked in time or whose stream
+// went away. They keep their sequence numbers so the databus can drop duplicates; a
+// batch retransmitted to another databus instance than the one that wrote it is not
+// recognized and is written twice.
+func (c *TransferReporter) retransmitLoop() {
+	ticker := time.NewTicker(retransmitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.ctx.Done():
+			return
+		}
+
+		for _, b := range c.inflight.due(c.ackTimeout) {
+			idx, acked, err := c.send(b.req)
+			if err != nil {
+				break
+			}
+
+			apm.RetransmitsTotal.WithLabelValues("databus", b.reason).Inc()
+			if acked {
+				c.inflight.sent(b.req.GetSeq(), idx)
+			} else {
+				c.inflight.remove(b.req.GetSeq())
+			}
+		}
+	}
+}
+
+// replaySpool sends the spooled batches in order, waiting for a healthy stream when a
+// send fails. A record is committed only once the databus acked its batch, so the spool
+// cursor stays at the oldest unacked record and a crash replays it again; the databus
+// drops the duplicates by sequence number. Records are replayed one at a time; a record
+// not acked within the ack timeout is replayed again after the reconnect interval.
+func (c *TransferReporter) replaySpool() {
+	for {
+		record, err := c.spool.Peek()
+		if err != nil {
+			if c.ctx.Err() != nil {
+				return
+			}
+			logger.Errorf("transfer reporter: failed to read spool: %v", err)
+		} else if record == nil {
+			select {
+			case <-c.spool.Notify():
+				continue
+			case <-c.ctx.Done():
+				return
+			}
+		} else {
+			msgs, derr := sbcodec.DecodeBatch(record)
+			if derr != nil {
+				logger.Errorf("transfer reporter: dropping unreadable spool record: %v", derr)
+				c.spool.Commit()
+				continue
+			}
+
+			var (
+				seq   uint64
+				acked <-chan struct{}
+			)
+			if seq, acked, err = c.trackBatch(msgs, true); err == nil {
+				if c.awaitAck(seq, acked) {
+					c.spool.Commit()
+					continue
+				}
+			}
+		}
+
+		select {
+		case <-time.After(c.reconnectInterval):
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// awaitAck waits up to the ack timeout for the batch seq to be acked and reports whether
+// it was. A batch not acked in time is no longer tracked. A nil acked channel means the
+// batch went out without acks.
+func (c *TransferReporter) awaitAck(seq uint64, acked <-chan struct{}) bool {
+	if acked == nil {
+		return true
+	}
+
+	timer := time.NewTimer(c.ackTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-acked:
+		return true
+	case <-timer.C:
+		logger.Warnf("transfer reporter: spooled batch seq=%d not acknowledged within %s", seq, c.ackTimeout)
+	case <-c.ctx.Done():
+	}
+	c.inflight.remove(seq)
+	return false
+}
+
+// SendMessage sends content on a healthy stream, or queues it for the next batch when
+// batching is enabled.
 func (c *TransferReporter) SendMessage(ctx context.Context, content []byte) error {
+	if c.batch != nil {
+		return c.batch.add(content)
+	}
+	return c.deliver([][]byte{content})
+}
+
+// deliver sends msgs as one request. With a spool, batches that cannot be sent are
+// spooled instead, and new batches queue behind the spooled ones to keep order.
+func (c *TransferReporter) deliver(msgs [][]byte) error {
+	if c.spool == nil {
+		return c.sendBatch(msgs)
+	}
+
+	if c.spool.Len() > 0 {
+		return c.spool.Append(sbcodec.EncodeBatch(msgs))
+	}
+
+	err := c.sendBatch(msgs)
+	if err == nil {
+		return nil
+	}
+	if serr := c.spool.Append(sbcodec.EncodeBatch(msgs)); serr != nil {
+		return fmt.Errorf("%w; spool: %v", err, serr)
+	}
+	return nil
+}
+
+// sendBatch sends msgs as one request. A single message without compression is sent as
+// a plain request, which databus versions without batch support understand.
+// On an acknowledged stream the batch is tracked until the databus acks it.
+func (c *TransferReporter) sendBatch(msgs [][]byte) error {
+	_, _, err := c.trackBatch(msgs, false)
+	return err
+}
+
+// trackBatch sends msgs like sendBatch and returns the batch sequence number. With wait
+// set, it also returns a channel closed once the databus acked the batch, or nil when the
+// stream has no acks.
+func (c *TransferReporter) trackBatch(msgs [][]byte, wait bool) (uint64, <-chan struct{}, error) {
+	var req *proto.DatabusRequest
+	if len(msgs) == 1 && (c.encoding == "" || c.encoding == sbcodec.EncodingIdentity) {
+		req = &proto.DatabusRequest{Payload: msgs[0]}
+	} else {
+		payload, headers, err := sbcodec.Pack(msgs, c.encoding)
+		if err != nil {
+			return 0, nil, err
+		}
+		req = &proto.DatabusRequest{Headers: headers, Payload: payload}
+	}
+
+	if c.legacy.Load() {
+		_, _, err := c.send(req)
+		return 0, nil, err
+	}
+
+	var done chan struct{}
+	if wait {
+		done = make(chan struct{})
+	}
+	req.Seq = c.seq.Add(1)
+	if err := c.inflight.add(msgs, req, done); err != nil {
+		return 0, nil, err
+	}
+
+	idx, acked, err := c.send(req)
+	if err != nil || !acked {
+		c.inflight.remove(req.GetSeq())
+		return 0, nil, err
+	}
+	c.inflight.sent(req.GetSeq(), idx)
+	return req.GetSeq(), done, nil
+}
+
+// send sends msg on the next healthy pool stream and returns the slot it went out on,
+// and whether that slot's stream acknowledges requests.
+func (c *TransferReporter) send(msg *proto.DatabusRequest) (int, bool, error) {
 	c.mu.RLock()
 	closed := c.closed
 	clientId := c.clientId
@@ -339,16 +798,13 @@ func (c *TransferReporter) SendMessage(ctx context.Context, content []byte) erro
 	c.mu.RUnlock()
 
 	if closed {
-		return fmt.Errorf("client is closed")
+		return -1, false, fmt.Errorf("client is closed")
 	}
 	if poolSize == 0 {
-		return fmt.Errorf("no pool slots")
+		return -1, false, fmt.Errorf("no pool slots")
 	}
 
-	msg := &proto.DatabusRequest{
-		ClientID: clientId,
-		Payload:  content,
-	}
+	msg.ClientID = clientId
 
 	for try := 0; try < poolSize; try++ {
 		idx := int(c.nextIndex.Add(1) % uint32(poolSize))
@@ -356,6 +812,7 @@ func (c *TransferReporter) SendMessage(ctx context.Context, content []byte) erro
 
 		entry.mu.RLock()
 		stream := entry.stream
+		acked := entry.acked
 		entry.mu.RUnlock()
 
 		if stream == nil {
@@ -367,13 +824,19 @@ func (c *TransferReporter) SendMessage(ctx context.Context, content []byte) erro
 			go c.handleDisconnect(idx)
 			continue
 		}
-		return nil
+		return idx, acked, nil
 	}
 
-	return fmt.Errorf("no healthy stream: all %d slots unavailable or send failed", poolSize)
+	return -1, false, fmt.Errorf("no healthy stream: all %d slots unavailable or send failed", poolSize)
 }
 
 func (c *TransferReporter) Close() error {
+	// Flush the pending batch while the streams are still up; it is spooled otherwise.
+	if c.batch != nil {
+		c.batch.close()
+	}
+	c.waitInflight(closeAckTimeout)
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -386,7 +849,25 @@ func (c *TransferReporter) Close() error {
 		c.cancel()
 	}
 
+	// Whatever is still unacked may not have reached a sink; keep it for the next run.
+	// Replayed batches are still in the spool, uncommitted.
 	var firstErr error
+	unacked := c.inflight.drain()
+	if c.spool != nil {
+		for _, b := range unacked {
+			if b.done != nil {
+				continue
+			}
+			if err := c.spool.Append(sbcodec.EncodeBatch(b.msgs)); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if err := c.spool.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	} else if len(unacked) > 0 {
+		logger.Warnf("transfer reporter: %d batches were not acknowledged before close", len(unacked))
+	}
 	for _, entry := range c.pool {
 		entry.mu.Lock()
 		conn := entry.conn
@@ -403,6 +884,14 @@ func (c *TransferReporter) Close() error {
 	return firstErr
 }
 
+// waitInflight waits up to timeout for the outstanding batches to be acked.
+func (c *TransferReporter) waitInflight(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for c.inflight.len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 // GetConnectionState returns the state of the first non-nil connection in the pool.
 func (c *TransferReporter) GetConnectionState() connectivity.State {
 	c.mu.RLock()
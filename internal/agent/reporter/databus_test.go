@@ -0,0 +1,343 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbcodec"
+
+	"google.golang.org/grpc"
+)
+
+// fakeDatabus records the sequence numbers it receives. When dropFirst is set it closes
+// the first stream carrying data without acking it; when hold is set it acks only once
+// hold is closed.
+type fakeDatabus struct {
+	proto.UnimplementedDatabusServiceServer
+
+	legacy    bool
+	dropFirst bool
+	hold      chan struct{}
+
+	mu      sync.Mutex
+	seqs    []uint64
+	dropped bool
+	pushed  [][]byte
+}
+
+func (f *fakeDatabus) PushData(stream proto.DatabusService_PushDataServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(req.GetPayload()) == 0 {
+			continue
+		}
+		f.mu.Lock()
+		f.pushed = append(f.pushed, req.GetPayload())
+		f.mu.Unlock()
+	}
+}
+
+func (f *fakeDatabus) StreamData(stream proto.DatabusService_StreamDataServer) error {
+	if f.legacy {
+		return f.UnimplementedDatabusServiceServer.StreamData(stream)
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetSeq() == 0 {
+			continue
+		}
+
+		f.mu.Lock()
+		f.seqs = append(f.seqs, req.GetSeq())
+		drop := f.dropFirst && !f.dropped
+		f.dropped = f.dropped || drop
+		f.mu.Unlock()
+
+		if drop {
+			return gerrors.New(gerrors.ComponentFailure, "sink unavailable")
+		}
+		if f.hold != nil {
+			select {
+			case <-f.hold:
+			case <-stream.Context().Done():
+				return stream.Context().Err()
+			}
+		}
+
+		ack := &proto.DatabusAck{Seq: req.GetSeq(), Status: int64(gerrors.Success)}
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+func (f *fakeDatabus) received() ([]uint64, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]uint64(nil), f.seqs...), len(f.pushed)
+}
+
+func startFakeDatabus(t *testing.T, f *fakeDatabus) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svr := grpc.NewServer()
+	proto.RegisterDatabusServiceServer(svr, f)
+	go func() { _ = svr.Serve(lis) }()
+	t.Cleanup(svr.Stop)
+
+	return "tcp://" + lis.Addr().String()
+}
+
+func startTransferReporter(t *testing.T, addr string) *TransferReporter {
+	t.Helper()
+
+	rep, err := NewTransferReporter(context.Background(), addr, "agent-1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.reconnectInterval = 20 * time.Millisecond
+	go func() { _ = rep.Run() }()
+	t.Cleanup(func() { _ = rep.Close() })
+
+	return rep
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func sendWhenReady(t *testing.T, rep *TransferReporter, msg string) {
+	t.Helper()
+	waitFor(t, 5*time.Second, func() bool {
+		return rep.SendMessage(context.Background(), []byte(msg)) == nil
+	})
+}
+
+func TestTransferReporterAcks(t *testing.T) {
+	f := &fakeDatabus{}
+	rep := startTransferReporter(t, startFakeDatabus(t, f))
+
+	sendWhenReady(t, rep, "m1")
+	waitFor(t, 5*time.Second, func() bool {
+		seqs, _ := f.received()
+		return len(seqs) == 1 && rep.inflight.len() == 0
+	})
+}
+
+func TestTransferReporterRetransmitsAfterDisconnect(t *testing.T) {
+	f := &fakeDatabus{dropFirst: true}
+	rep := startTransferReporter(t, startFakeDatabus(t, f))
+
+	sendWhenReady(t, rep, "m1")
+	waitFor(t, 10*time.Second, func() bool {
+		return rep.inflight.len() == 0
+	})
+
+	seqs, _ := f.received()
+	if len(seqs) != 2 || seqs[0] != seqs[1] {
+		t.Fatalf("got seqs %v, want the same seq twice", seqs)
+	}
+}
+
+func TestTransferReporterCommitsSpoolOnAck(t *testing.T) {
+	f := &fakeDatabus{hold: make(chan struct{})}
+	addr := startFakeDatabus(t, f)
+
+	spool, err := OpenSpool("test", SpoolOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spool.Append(sbcodec.EncodeBatch([][]byte{[]byte("m1")})); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := NewTransferReporter(context.Background(), addr, "agent-1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.reconnectInterval = 20 * time.Millisecond
+	rep.spool = spool
+	go func() { _ = rep.Run() }()
+	t.Cleanup(func() { _ = rep.Close() })
+
+	waitFor(t, 5*time.Second, func() bool {
+		seqs, _ := f.received()
+		return len(seqs) == 1
+	})
+	time.Sleep(50 * time.Millisecond)
+	if n := spool.Len(); n != 1 {
+		t.Fatalf("spool len = %d before the ack, want the record kept", n)
+	}
+
+	close(f.hold)
+	waitFor(t, 5*time.Second, func() bool {
+		return spool.Len() == 0 && rep.inflight.len() == 0
+	})
+}
+
+func TestTransferReporterReplaysSpoolAfterAckTimeout(t *testing.T) {
+	f := &fakeDatabus{hold: make(chan struct{})}
+	addr := startFakeDatabus(t, f)
+
+	spool, err := OpenSpool("test", SpoolOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spool.Append(sbcodec.EncodeBatch([][]byte{[]byte("m1")})); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := NewTransferReporter(context.Background(), addr, "agent-1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.reconnectInterval = 20 * time.Millisecond
+	rep.ackTimeout = 100 * time.Millisecond
+	rep.spool = spool
+	go func() { _ = rep.Run() }()
+	t.Cleanup(func() { _ = rep.Close() })
+
+	waitFor(t, 5*time.Second, func() bool {
+		seqs, _ := f.received()
+		return len(seqs) == 1
+	})
+	time.Sleep(300 * time.Millisecond)
+	if n := spool.Len(); n != 1 {
+		t.Fatalf("spool len = %d without an ack, want the record kept", n)
+	}
+
+	close(f.hold)
+	waitFor(t, 5*time.Second, func() bool {
+		return spool.Len() == 0
+	})
+
+	seqs, _ := f.received()
+	distinct := make(map[uint64]bool)
+	for _, seq := range seqs {
+		distinct[seq] = true
+	}
+	if len(distinct) < 2 {
+		t.Fatalf("got seqs %v, want the record replayed under a new seq", seqs)
+	}
+}
+
+func TestTransferReporterFallsBackToPushData(t *testing.T) {
+	f := &fakeDatabus{legacy: true}
+	rep := startTransferReporter(t, startFakeDatabus(t, f))
+
+	waitFor(t, 5*time.Second, func() bool {
+		return rep.legacy.Load()
+	})
+	waitFor(t, 10*time.Second, func() bool {
+		_ = rep.SendMessage(context.Background(), []byte("m1"))
+		_, pushed := f.received()
+		return pushed > 0
+	})
+	if n := rep.inflight.len(); n != 0 {
+		t.Fatalf("inflight = %d after fallback, want 0", n)
+	}
+}
+
+// staticResolver is a config.EndpointResolver with fixed endpoints.
+type staticResolver struct {
+	endpoints   []string
+	subscribers []func([]string)
+}
+
+func (r *staticResolver) Endpoints() []string { return r.endpoints }
+
+func (r *staticResolver) Subscribe(fn func([]string)) { r.subscribers = append(r.subscribers, fn) }
+
+func (r *staticResolver) set(endpoints ...string) {
+	r.endpoints = endpoints
+	for _, fn := range r.subscribers {
+		fn(endpoints)
+	}
+}
+
+func slotAddrs(rep *TransferReporter) map[string]int {
+	counts := make(map[string]int)
+	for _, entry := range rep.pool {
+		entry.mu.RLock()
+		counts[entry.addr]++
+		entry.mu.RUnlock()
+	}
+	return counts
+}
+
+func TestTransferReporterSpreadsSlotsOverEndpoints(t *testing.T) {
+	rep, err := NewTransferReporter(context.Background(), "tcp://static:1", "agent-1", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rep.Close()
+
+	if got := slotAddrs(rep); got["tcp://static:1"] != 4 {
+		t.Fatalf("slots before discovery: %v", got)
+	}
+
+	r := &staticResolver{endpoints: []string{"tcp://a:1", "tcp://b:1"}}
+	rep.UseResolver(r)
+	if got := slotAddrs(rep); got["tcp://a:1"] != 2 || got["tcp://b:1"] != 2 {
+		t.Fatalf("slots over two endpoints: %v", got)
+	}
+
+	r.set("tcp://a:1", "tcp://b:1", "tcp://c:1", "tcp://d:1")
+	if got := slotAddrs(rep); len(got) != 4 {
+		t.Fatalf("slots over four endpoints: %v", got)
+	}
+
+	// With every instance gone, the pool falls back to the static endpoint.
+	r.set()
+	if got := slotAddrs(rep); got["tcp://static:1"] != 4 {
+		t.Fatalf("slots after instances left: %v", got)
+	}
+}
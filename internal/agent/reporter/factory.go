@@ -0,0 +1,89 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"context"
+	"fmt"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/pkg/tools"
+)
+
+// NewReporterFromConfig builds the reporters of entries, each with base options plus the
+// entry's config. A single reporter without routes is returned as it is; otherwise they
+// are combined into a MultiReporter. Disabled entries are skipped. On error, the
+// reporters already built are closed.
+func NewReporterFromConfig(ctx context.Context, entries []config.ReporterEntry,
+	base config.ReporterOpts) (Reporter, error) {
+
+	var routes []Route
+	closeAll := func() {
+		for _, r := range routes {
+			_ = r.Reporter.Close()
+		}
+	}
+
+	for i, e := range entries {
+		if e.Enabled != nil && !*e.Enabled {
+			continue
+		}
+
+		name := e.Name
+		if name == "" {
+			name = fmt.Sprintf("%s#%d", e.Type, i)
+		}
+
+		opts := base
+		opts.Config = e.Config
+
+		rep, err := CreateReporter(ctx, e.Type, &opts)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("reporter[%d] %q: %w", i, name, err)
+		}
+
+		rules := make([]RouteRule, 0, len(e.Routes))
+		for _, r := range e.Routes {
+			rules = append(rules, RouteRule{Plugins: r.Plugins, Events: r.Events})
+		}
+		routes = append(routes, Route{Name: name, Reporter: rep, Rules: rules})
+	}
+
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no reporters enabled")
+	}
+
+	if len(routes) == 1 && len(routes[0].Rules) == 0 {
+		return routes[0].Reporter, nil
+	}
+	return NewMultiReporter(routes), nil
+}
+
+// reporterClientID returns the identity a reporter sends with its messages: the
+// configured client ID, or the machine ID when there is none.
+func reporterClientID(o *config.ReporterOpts) (string, error) {
+	if o.ClientID != "" {
+		return o.ClientID, nil
+	}
+
+	id, err := tools.MachineID("saber-agent")
+	if err != nil {
+		return "", fmt.Errorf("failed to generate machine-id: %v", err)
+	}
+	return id, nil
+}
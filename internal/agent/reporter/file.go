@@ -0,0 +1,219 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"os-artificer/saber/internal/agent/config"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+var (
+	_ Reporter = (*FileReporter)(nil)
+	_ Reporter = (*StdoutReporter)(nil)
+)
+
+const (
+	defaultFileReporterPath = "./data/reporter/events.ndjson"
+	defaultFileMaxSizeMB    = 100
+	defaultFileMaxBackups   = 10
+	defaultFileMaxAgeDays   = 7
+)
+
+func init() {
+	RegisterReporter("file", newFileReporterFromOpts)
+	RegisterReporter("stdout", newStdoutReporterFromOpts)
+}
+
+// FileOptions configures a FileReporter. Rotation follows pkg/logger.Config: files are
+// rotated at MaxSizeMB, and rotated files are removed after MaxBackups files or
+// MaxAge days. Compress gzips rotated files.
+type FileOptions struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAge     int
+	Compress   bool
+}
+
+// lineWriter writes each message as one NDJSON line. Messages are harvester events
+// encoded as JSON, so they never contain a newline.
+type lineWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closed bool
+}
+
+func (l *lineWriter) writeLine(content []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return fmt.Errorf("client is closed")
+	}
+
+	line := make([]byte, 0, len(content)+1)
+	line = append(line, bytes.TrimRight(content, "\n")...)
+	line = append(line, '\n')
+	_, err := l.w.Write(line)
+	return err
+}
+
+// close marks the writer closed and returns true the first time.
+func (l *lineWriter) close() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return false
+	}
+	l.closed = true
+	return true
+}
+
+// FileReporter writes messages as NDJSON to a rotated local file, for hosts whose data is
+// collected and shipped offline.
+type FileReporter struct {
+	lineWriter
+	ctx     context.Context
+	cancel  context.CancelFunc
+	rotator *lumberjack.Logger
+}
+
+// NewFileReporter returns a Reporter that appends messages to opts.Path. Creates the
+// parent directories if they do not exist.
+func NewFileReporter(ctx context.Context, opts FileOptions) (*FileReporter, error) {
+	if opts.Path == "" {
+		opts.Path = defaultFileReporterPath
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0755); err != nil {
+		return nil, fmt.Errorf("file reporter: %w", err)
+	}
+
+	rotator := &lumberjack.Logger{
+		Filename:   opts.Path,
+		MaxSize:    opts.MaxSizeMB,
+		MaxBackups: opts.MaxBackups,
+		MaxAge:     opts.MaxAge,
+		Compress:   opts.Compress,
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r := &FileReporter{ctx: ctx, cancel: cancel, rotator: rotator}
+	r.w = rotator
+	return r, nil
+}
+
+// newFileReporterFromOpts builds a FileReporter from config.ReporterOpts (used by RegisterReporter).
+func newFileReporterFromOpts(ctx context.Context, opts any) (Reporter, error) {
+	o, ok := opts.(*config.ReporterOpts)
+	if !ok {
+		return nil, fmt.Errorf("file reporter expects *config.ReporterOpts, got %T", opts)
+	}
+
+	fopts := FileOptions{
+		Path:       defaultFileReporterPath,
+		MaxSizeMB:  defaultFileMaxSizeMB,
+		MaxBackups: defaultFileMaxBackups,
+		MaxAge:     defaultFileMaxAgeDays,
+	}
+	if path, ok := o.Config["path"].(string); ok && path != "" {
+		fopts.Path = path
+	}
+	if n, ok := toInt(o.Config["max_size_mb"]); ok {
+		fopts.MaxSizeMB = n
+	}
+	if n, ok := toInt(o.Config["max_backups"]); ok {
+		fopts.MaxBackups = n
+	}
+	if n, ok := toInt(o.Config["max_age"]); ok {
+		fopts.MaxAge = n
+	}
+	if v, ok := o.Config["compress"].(bool); ok {
+		fopts.Compress = v
+	}
+
+	return NewFileReporter(ctx, fopts)
+}
+
+// SendMessage appends content as one line.
+func (r *FileReporter) SendMessage(ctx context.Context, content []byte) error {
+	return r.writeLine(content)
+}
+
+// Run blocks until the reporter is closed.
+func (r *FileReporter) Run() error {
+	<-r.ctx.Done()
+	return r.ctx.Err()
+}
+
+// Close closes the current file.
+func (r *FileReporter) Close() error {
+	if !r.close() {
+		return nil
+	}
+	r.cancel()
+	return r.rotator.Close()
+}
+
+// StdoutReporter writes messages as NDJSON to standard output, to inspect what plugins
+// produce without a databus.
+type StdoutReporter struct {
+	lineWriter
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewStdoutReporter returns a Reporter that writes messages to os.Stdout.
+func NewStdoutReporter(ctx context.Context) *StdoutReporter {
+	ctx, cancel := context.WithCancel(ctx)
+	r := &StdoutReporter{ctx: ctx, cancel: cancel}
+	r.w = os.Stdout
+	return r
+}
+
+// newStdoutReporterFromOpts builds a StdoutReporter (used by RegisterReporter).
+func newStdoutReporterFromOpts(ctx context.Context, opts any) (Reporter, error) {
+	return NewStdoutReporter(ctx), nil
+}
+
+// SendMessage writes content as one line.
+func (r *StdoutReporter) SendMessage(ctx context.Context, content []byte) error {
+	return r.writeLine(content)
+}
+
+// Run blocks until the reporter is closed.
+func (r *StdoutReporter) Run() error {
+	<-r.ctx.Done()
+	return r.ctx.Err()
+}
+
+// Close stops the reporter; standard output stays open.
+func (r *StdoutReporter) Close() error {
+	if r.close() {
+		r.cancel()
+	}
+	return nil
+}
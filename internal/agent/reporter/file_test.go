@@ -0,0 +1,81 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"os-artificer/saber/internal/agent/config"
+)
+
+func TestFileReporterWritesNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "events.ndjson")
+	rep, err := CreateReporter(context.Background(), "file", &config.ReporterOpts{
+		Config: map[string]any{"path": path, "max_size_mb": 1, "compress": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{`{"PluginName":"host"}`, `{"PluginName":"auth"}` + "\n"} {
+		if err := rep.SendMessage(context.Background(), []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rep.SendMessage(context.Background(), []byte(`{}`)); err == nil {
+		t.Fatal("SendMessage succeeded after Close")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), data)
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("line is not JSON: %q", line)
+		}
+	}
+}
+
+func TestStdoutReporterWritesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	rep := NewStdoutReporter(context.Background())
+	rep.w = &buf
+
+	if err := rep.SendMessage(context.Background(), []byte(`{"a":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "{\"a\":1}\n" {
+		t.Fatalf("got %q", got)
+	}
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
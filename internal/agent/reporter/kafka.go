@@ -0,0 +1,398 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/pkg/logger"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+var _ Reporter = (*KafkaReporter)(nil)
+
+const (
+	// defaultKafkaTopic is the topic template used when none is configured.
+	defaultKafkaTopic = "saber.{plugin}"
+
+	// kafkaPluginPlaceholder is replaced by the plugin name in topic templates.
+	kafkaPluginPlaceholder = "{plugin}"
+
+	// kafkaUnknownPlugin names the plugin of messages sent without WithEvent.
+	kafkaUnknownPlugin = "agent"
+
+	// Kafka message headers carrying the origin of a message.
+	kafkaHeaderPlugin = "saber-plugin"
+	kafkaHeaderEvent  = "saber-event"
+)
+
+func init() {
+	RegisterReporter("kafka", newKafkaReporterFromOpts)
+}
+
+// KafkaOptions configures a KafkaReporter.
+type KafkaOptions struct {
+	Brokers []string
+
+	// Topic is the topic template; "{plugin}" is replaced by the plugin name.
+	// Topics maps plugin names to topics and takes precedence over Topic.
+	Topic  string
+	Topics map[string]string
+
+	RequiredAcks kafkago.RequiredAcks
+
+	// Async makes SendMessage return once the message is queued; delivery errors are
+	// logged. Otherwise SendMessage waits until the batch holding the message is written.
+	Async        bool
+	BatchSize    int
+	BatchBytes   int64
+	BatchTimeout time.Duration
+	Compression  kafkago.Compression
+
+	SASL sasl.Mechanism
+	TLS  *tls.Config
+
+	// Transport, when set, replaces the transport built from SASL and TLS.
+	Transport kafkago.RoundTripper
+}
+
+// KafkaReporter writes messages straight to Kafka, one topic per plugin.
+type KafkaReporter struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	clientID string
+	topic    string
+	topics   map[string]string
+	writer   *kafkago.Writer
+}
+
+// NewKafkaReporter returns a Reporter that writes to the brokers in opts.
+// clientID is used as the message key so that an agent's messages stay ordered.
+func NewKafkaReporter(ctx context.Context, clientID string, opts KafkaOptions) (*KafkaReporter, error) {
+	if len(opts.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka reporter: no brokers")
+	}
+	if opts.Topic == "" {
+		opts.Topic = defaultKafkaTopic
+	}
+
+	transport := opts.Transport
+	if transport == nil {
+		transport = &kafkago.Transport{
+			ClientID: "saber-agent",
+			SASL:     opts.SASL,
+			TLS:      opts.TLS,
+		}
+	}
+
+	writer := &kafkago.Writer{
+		Addr:         kafkago.TCP(opts.Brokers...),
+		Balancer:     &kafkago.Hash{},
+		RequiredAcks: opts.RequiredAcks,
+		Async:        opts.Async,
+		BatchSize:    opts.BatchSize,
+		BatchBytes:   opts.BatchBytes,
+		BatchTimeout: opts.BatchTimeout,
+		Compression:  opts.Compression,
+		Transport:    transport,
+	}
+	if opts.Async {
+		writer.Completion = func(messages []kafkago.Message, err error) {
+			if err != nil {
+				logger.Warnf("kafka reporter: failed to write %d messages: %v", len(messages), err)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &KafkaReporter{
+		ctx:      ctx,
+		cancel:   cancel,
+		clientID: clientID,
+		topic:    opts.Topic,
+		topics:   opts.Topics,
+		writer:   writer,
+	}, nil
+}
+
+// newKafkaReporterFromOpts builds a KafkaReporter from config.ReporterOpts (used by RegisterReporter).
+func newKafkaReporterFromOpts(ctx context.Context, opts any) (Reporter, error) {
+	o, ok := opts.(*config.ReporterOpts)
+	if !ok {
+		return nil, fmt.Errorf("kafka reporter expects *config.ReporterOpts, got %T", opts)
+	}
+
+	kopts, err := kafkaOptionsFromConfig(o.Config)
+	if err != nil {
+		return nil, fmt.Errorf("kafka reporter: %w", err)
+	}
+
+	clientID, err := reporterClientID(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKafkaReporter(ctx, clientID, kopts)
+}
+
+// kafkaOptionsFromConfig parses the config map of a kafka reporter entry.
+func kafkaOptionsFromConfig(cfg map[string]any) (KafkaOptions, error) {
+	opts := KafkaOptions{
+		RequiredAcks: kafkago.RequireAll,
+		Async:        true,
+	}
+
+	brokers, err := toStringSlice(cfg["brokers"])
+	if err != nil || len(brokers) == 0 {
+		return opts, fmt.Errorf("config missing or invalid brokers")
+	}
+	opts.Brokers = brokers
+
+	opts.Topic, _ = cfg["topic"].(string)
+	if topics, ok := cfg["topics"].(map[string]any); ok {
+		opts.Topics = make(map[string]string, len(topics))
+		for plugin, v := range topics {
+			topic, ok := v.(string)
+			if !ok || topic == "" {
+				return opts, fmt.Errorf("topics.%s must be a non-empty string", plugin)
+			}
+			opts.Topics[plugin] = topic
+		}
+	}
+
+	if v, ok := cfg["required_acks"]; ok {
+		if opts.RequiredAcks, err = parseRequiredAcks(v); err != nil {
+			return opts, err
+		}
+	}
+	if v, ok := cfg["async"].(bool); ok {
+		opts.Async = v
+	}
+
+	batchCfg, _ := cfg["batch"].(map[string]any)
+	if n, ok := toInt(batchCfg["max_messages"]); ok {
+		opts.BatchSize = n
+	}
+	if n, ok := toInt(batchCfg["max_bytes"]); ok {
+		opts.BatchBytes = int64(n)
+	}
+	if d, ok := toDuration(batchCfg["linger"]); ok {
+		opts.BatchTimeout = d
+	}
+	if v, ok := batchCfg["compression"].(string); ok {
+		if opts.Compression, err = parseKafkaCompression(v); err != nil {
+			return opts, err
+		}
+	}
+
+	if saslCfg, ok := cfg["sasl"].(map[string]any); ok {
+		if opts.SASL, err = buildKafkaSASL(saslCfg); err != nil {
+			return opts, err
+		}
+	}
+	if tlsCfg, ok := cfg["tls"].(map[string]any); ok {
+		if opts.TLS, err = buildKafkaTLS(tlsCfg); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// toStringSlice accepts a comma-separated string or a list of strings. Items are trimmed
+// and empty ones dropped.
+func toStringSlice(v any) ([]string, error) {
+	var items []string
+	switch x := v.(type) {
+	case string:
+		items = strings.Split(x, ",")
+	case []string:
+		items = x
+	case []any:
+		items = make([]string, 0, len(x))
+		for i, item := range x {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("item %d must be a string", i)
+			}
+			items = append(items, s)
+		}
+	default:
+		return nil, fmt.Errorf("must be a string slice")
+	}
+
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func parseRequiredAcks(v any) (kafkago.RequiredAcks, error) {
+	if n, ok := toInt(v); ok {
+		v = fmt.Sprint(n)
+	}
+
+	switch s, _ := v.(string); strings.ToLower(s) {
+	case "all", "-1":
+		return kafkago.RequireAll, nil
+	case "one", "leader", "1":
+		return kafkago.RequireOne, nil
+	case "none", "0":
+		return kafkago.RequireNone, nil
+	default:
+		return 0, fmt.Errorf("unsupported required_acks %v", v)
+	}
+}
+
+func parseKafkaCompression(s string) (kafkago.Compression, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafkago.Gzip, nil
+	case "snappy":
+		return kafkago.Snappy, nil
+	case "lz4":
+		return kafkago.Lz4, nil
+	case "zstd":
+		return kafkago.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported compression %q", s)
+	}
+}
+
+// buildKafkaSASL builds the SASL mechanism named by cfg["mechanism"].
+func buildKafkaSASL(cfg map[string]any) (sasl.Mechanism, error) {
+	mechanism, _ := cfg["mechanism"].(string)
+	username, _ := cfg["username"].(string)
+	password, _ := cfg["password"].(string)
+
+	switch strings.ToUpper(mechanism) {
+	case "":
+		return nil, nil
+	case "PLAIN":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unsupported sasl mechanism %q", mechanism)
+	}
+}
+
+// buildKafkaTLS builds *tls.Config from the tls section. Returns (nil, nil) when TLS is
+// not enabled.
+func buildKafkaTLS(cfg map[string]any) (*tls.Config, error) {
+	if enabled, ok := cfg["enabled"].(bool); !ok || !enabled {
+		return nil, nil
+	}
+
+	insecureSkip, _ := cfg["insecure_skip_verify"].(bool)
+	serverName, _ := cfg["server_name"].(string)
+	tlsCfg := &tls.Config{
+		InsecureSkipVerify: insecureSkip,
+		ServerName:         serverName,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if caFile, _ := cfg["ca_file"].(string); caFile != "" {
+		b, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no valid CA certs in %s", caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	certFile, _ := cfg["cert_file"].(string)
+	keyFile, _ := cfg["key_file"].(string)
+	if certFile != "" && keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+// topicFor returns the topic for messages of plugin.
+func (k *KafkaReporter) topicFor(plugin string) string {
+	if plugin == "" {
+		plugin = kafkaUnknownPlugin
+	}
+	if topic, ok := k.topics[plugin]; ok {
+		return topic
+	}
+	return strings.ReplaceAll(k.topic, kafkaPluginPlaceholder, plugin)
+}
+
+// SendMessage writes content to the topic of the plugin set on ctx by WithEvent.
+func (k *KafkaReporter) SendMessage(ctx context.Context, content []byte) error {
+	if k.ctx.Err() != nil {
+		return fmt.Errorf("client is closed")
+	}
+
+	plugin, event := EventFromContext(ctx)
+	msg := kafkago.Message{
+		Topic: k.topicFor(plugin),
+		Key:   []byte(k.clientID),
+		Value: content,
+		Headers: []kafkago.Header{
+			{Key: kafkaHeaderPlugin, Value: []byte(plugin)},
+			{Key: kafkaHeaderEvent, Value: []byte(event)},
+		},
+	}
+
+	if err := k.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("write to kafka: %w", err)
+	}
+	return nil
+}
+
+// Run blocks until the reporter is closed; the writer connects on demand.
+func (k *KafkaReporter) Run() error {
+	<-k.ctx.Done()
+	return k.ctx.Err()
+}
+
+// Close flushes the pending messages and closes the writer.
+func (k *KafkaReporter) Close() error {
+	k.cancel()
+	if err := k.writer.Close(); err != nil {
+		logger.Warnf("close kafka writer failed: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	metadataAPI "github.com/segmentio/kafka-go/protocol/metadata"
+	produceAPI "github.com/segmentio/kafka-go/protocol/produce"
+)
+
+// fakeBroker is an in-process Kafka broker answering metadata and produce requests.
+// Every topic exists with a single partition.
+type fakeBroker struct {
+	mu       sync.Mutex
+	messages map[string][]string
+	acks     []int16
+}
+
+func (b *fakeBroker) RoundTrip(ctx context.Context, addr net.Addr, req protocol.Message) (protocol.Message, error) {
+	switch r := req.(type) {
+	case *metadataAPI.Request:
+		res := &metadataAPI.Response{
+			Brokers: []metadataAPI.ResponseBroker{{NodeID: 0, Host: "127.0.0.1", Port: 9092}},
+		}
+		for _, name := range r.TopicNames {
+			res.Topics = append(res.Topics, metadataAPI.ResponseTopic{
+				Name:       name,
+				Partitions: []metadataAPI.ResponsePartition{{PartitionIndex: 0, LeaderID: 0}},
+			})
+		}
+		return res, nil
+
+	case *produceAPI.Request:
+		res := &produceAPI.Response{}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.acks = append(b.acks, r.Acks)
+		for _, t := range r.Topics {
+			rt := produceAPI.ResponseTopic{Topic: t.Topic}
+			for _, p := range t.Partitions {
+				for {
+					rec, err := p.RecordSet.Records.ReadRecord()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						return nil, err
+					}
+					value, err := protocol.ReadAll(rec.Value)
+					if err != nil {
+						return nil, err
+					}
+					b.messages[t.Topic] = append(b.messages[t.Topic], string(value))
+				}
+				rt.Partitions = append(rt.Partitions, produceAPI.ResponsePartition{Partition: p.Partition})
+			}
+			res.Topics = append(res.Topics, rt)
+		}
+		return res, nil
+
+	default:
+		return nil, errors.New("fake broker: unsupported request")
+	}
+}
+
+func (b *fakeBroker) topic(name string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.messages[name]...)
+}
+
+func TestKafkaReporterTopicPerPlugin(t *testing.T) {
+	broker := &fakeBroker{messages: make(map[string][]string)}
+	rep, err := NewKafkaReporter(context.Background(), "agent-1", KafkaOptions{
+		Brokers:      []string{"127.0.0.1:9092"},
+		Topics:       map[string]string{"auth": "security.auth"},
+		RequiredAcks: kafkago.RequireOne,
+		BatchTimeout: 10 * time.Millisecond,
+		Transport:    broker,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := rep.SendMessage(WithEvent(ctx, "host", "stats"), []byte("h1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rep.SendMessage(WithEvent(ctx, "auth", "login"), []byte("a1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rep.SendMessage(ctx, []byte("x1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for topic, want := range map[string]string{
+		"saber.host":    "h1",
+		"security.auth": "a1",
+		"saber.agent":   "x1",
+	} {
+		got := broker.topic(topic)
+		if len(got) != 1 || got[0] != want {
+			t.Fatalf("topic %s got %v, want [%s]", topic, got, want)
+		}
+	}
+
+	for _, acks := range broker.acks {
+		if acks != int16(kafkago.RequireOne) {
+			t.Fatalf("produce request acks = %d, want %d", acks, kafkago.RequireOne)
+		}
+	}
+
+	if err := rep.SendMessage(ctx, []byte("late")); err == nil {
+		t.Fatal("SendMessage succeeded after Close")
+	}
+}
+
+func TestKafkaOptionsFromConfig(t *testing.T) {
+	opts, err := kafkaOptionsFromConfig(map[string]any{
+		"brokers":       []any{"k1:9092", "k2:9092"},
+		"topic":         "edge.{plugin}",
+		"topics":        map[string]any{"auth": "edge.security"},
+		"required_acks": "one",
+		"async":         false,
+		"batch":         map[string]any{"max_messages": 100, "linger": "50ms", "compression": "zstd"},
+		"sasl":          map[string]any{"mechanism": "SCRAM-SHA-512", "username": "u", "password": "p"},
+		"tls":           map[string]any{"enabled": true, "server_name": "kafka"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(opts.Brokers) != 2 || opts.Topic != "edge.{plugin}" || opts.Topics["auth"] != "edge.security" {
+		t.Fatalf("unexpected brokers/topics: %+v", opts)
+	}
+	if opts.RequiredAcks != kafkago.RequireOne || opts.Async {
+		t.Fatalf("unexpected acks/async: %v/%v", opts.RequiredAcks, opts.Async)
+	}
+	if opts.BatchSize != 100 || opts.BatchTimeout != 50*time.Millisecond || opts.Compression != kafkago.Zstd {
+		t.Fatalf("unexpected batch options: %+v", opts)
+	}
+	if opts.SASL == nil || opts.SASL.Name() != "SCRAM-SHA-512" {
+		t.Fatalf("unexpected sasl: %v", opts.SASL)
+	}
+	if opts.TLS == nil || opts.TLS.ServerName != "kafka" {
+		t.Fatalf("unexpected tls: %v", opts.TLS)
+	}
+
+	opts, err = kafkaOptionsFromConfig(map[string]any{"brokers": " k1:9092, k2:9092 ,"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Brokers) != 2 || opts.Brokers[0] != "k1:9092" || opts.Brokers[1] != "k2:9092" {
+		t.Fatalf("brokers = %q, want trimmed and without empty items", opts.Brokers)
+	}
+	if _, err := kafkaOptionsFromConfig(map[string]any{"brokers": " , "}); err == nil {
+		t.Fatal("blank brokers accepted")
+	}
+
+	if _, err := kafkaOptionsFromConfig(map[string]any{"brokers": "k1:9092", "required_acks": "some"}); err == nil {
+		t.Fatal("invalid required_acks accepted")
+	}
+	if _, err := kafkaOptionsFromConfig(map[string]any{}); err == nil {
+		t.Fatal("missing brokers accepted")
+	}
+}
+
+func TestKafkaReporterKeyedByClientID(t *testing.T) {
+	rep, err := newKafkaReporterFromOpts(context.Background(), &config.ReporterOpts{
+		Config:   map[string]any{"brokers": []any{"127.0.0.1:9092"}},
+		ClientID: "agent-7",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rep.Close()
+
+	if id := rep.(*KafkaReporter).clientID; id != "agent-7" {
+		t.Fatalf("message key = %q, want the configured client ID", id)
+	}
+}
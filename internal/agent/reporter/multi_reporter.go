@@ -0,0 +1,161 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"os-artificer/saber/pkg/logger"
+)
+
+var _ Reporter = (*MultiReporter)(nil)
+
+type eventKey struct{}
+
+type eventInfo struct {
+	plugin string
+	event  string
+}
+
+// WithEvent returns a context that tells reporters which plugin and event a message
+// comes from, so that a MultiReporter can route it.
+func WithEvent(ctx context.Context, plugin, event string) context.Context {
+	return context.WithValue(ctx, eventKey{}, eventInfo{plugin: plugin, event: event})
+}
+
+// EventFromContext returns the plugin and event names set by WithEvent.
+func EventFromContext(ctx context.Context) (plugin, event string) {
+	info, _ := ctx.Value(eventKey{}).(eventInfo)
+	return info.plugin, info.event
+}
+
+// RouteRule matches messages by plugin and event name. An empty list matches any name.
+type RouteRule struct {
+	Plugins []string
+	Events  []string
+}
+
+// Match reports whether the rule selects a message from plugin with the given event name.
+func (r RouteRule) Match(plugin, event string) bool {
+	if len(r.Plugins) > 0 && !slices.Contains(r.Plugins, plugin) {
+		return false
+	}
+	if len(r.Events) > 0 && !slices.Contains(r.Events, event) {
+		return false
+	}
+	return true
+}
+
+// Route is one reporter of a MultiReporter with the rules selecting its messages.
+// A route without rules receives every message.
+type Route struct {
+	Name     string
+	Reporter Reporter
+	Rules    []RouteRule
+}
+
+// Match reports whether the route selects a message from plugin with the given event name.
+func (r *Route) Match(plugin, event string) bool {
+	if len(r.Rules) == 0 {
+		return true
+	}
+	for _, rule := range r.Rules {
+		if rule.Match(plugin, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// MultiReporter sends each message to every reporter whose route matches it.
+// A failing reporter does not keep the message from the others. Run and Close
+// apply to all reporters.
+type MultiReporter struct {
+	routes []Route
+}
+
+// NewMultiReporter returns a Reporter that fans messages out to routes.
+func NewMultiReporter(routes []Route) *MultiReporter {
+	return &MultiReporter{routes: routes}
+}
+
+// SendMessage implements Reporter. It fails only when every matching reporter failed.
+func (m *MultiReporter) SendMessage(ctx context.Context, content []byte) error {
+	plugin, event := EventFromContext(ctx)
+
+	var (
+		matched  int
+		firstErr error
+		failed   int
+	)
+	for i := range m.routes {
+		r := &m.routes[i]
+		if !r.Match(plugin, event) {
+			continue
+		}
+
+		matched++
+		if err := r.Reporter.SendMessage(ctx, content); err != nil {
+			logger.Warnf("multi-reporter send to %s failed: %v", r.Name, err)
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if matched > 0 && failed == matched {
+		return fmt.Errorf("all %d reporters failed: %w", matched, firstErr)
+	}
+	return nil
+}
+
+// Run implements Reporter. It runs all reporters and returns when they have all returned.
+func (m *MultiReporter) Run() error {
+	var wg sync.WaitGroup
+	for i := range m.routes {
+		r := &m.routes[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.Reporter.Run(); err != nil {
+				logger.Warnf("multi-reporter %s exited: %v", r.Name, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// Close implements Reporter. It closes all reporters and returns the first error.
+func (m *MultiReporter) Close() error {
+	var firstErr error
+	for i := range m.routes {
+		r := &m.routes[i]
+		if err := r.Reporter.Close(); err != nil {
+			logger.Warnf("multi-reporter close %s failed: %v", r.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
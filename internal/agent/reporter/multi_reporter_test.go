@@ -0,0 +1,110 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeReporter struct {
+	err    error
+	msgs   []string
+	closed bool
+}
+
+func (f *fakeReporter) SendMessage(ctx context.Context, content []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.msgs = append(f.msgs, string(content))
+	return nil
+}
+
+func (f *fakeReporter) Run() error { return nil }
+
+func (f *fakeReporter) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestMultiReporterRoutes(t *testing.T) {
+	all := &fakeReporter{}
+	host := &fakeReporter{}
+	auth := &fakeReporter{}
+
+	m := NewMultiReporter([]Route{
+		{Name: "all", Reporter: all},
+		{Name: "host", Reporter: host, Rules: []RouteRule{{Plugins: []string{"host"}}}},
+		{Name: "auth", Reporter: auth, Rules: []RouteRule{{Plugins: []string{"auth"}, Events: []string{"login"}}}},
+	})
+
+	ctx := context.Background()
+	for _, ev := range []struct{ plugin, event, msg string }{
+		{"host", "", "h1"},
+		{"auth", "login", "a1"},
+		{"auth", "logout", "a2"},
+	} {
+		if err := m.SendMessage(WithEvent(ctx, ev.plugin, ev.event), []byte(ev.msg)); err != nil {
+			t.Fatalf("SendMessage(%s): %v", ev.msg, err)
+		}
+	}
+
+	check := func(name string, f *fakeReporter, want ...string) {
+		t.Helper()
+		if len(f.msgs) != len(want) {
+			t.Fatalf("%s got %v, want %v", name, f.msgs, want)
+		}
+		for i := range want {
+			if f.msgs[i] != want[i] {
+				t.Fatalf("%s got %v, want %v", name, f.msgs, want)
+			}
+		}
+	}
+	check("all", all, "h1", "a1", "a2")
+	check("host", host, "h1")
+	check("auth", auth, "a1")
+}
+
+func TestMultiReporterIndependentFailures(t *testing.T) {
+	bad := &fakeReporter{err: errors.New("down")}
+	good := &fakeReporter{}
+
+	m := NewMultiReporter([]Route{
+		{Name: "bad", Reporter: bad},
+		{Name: "good", Reporter: good},
+	})
+	if err := m.SendMessage(context.Background(), []byte("m")); err != nil {
+		t.Fatalf("SendMessage with one healthy reporter: %v", err)
+	}
+	if len(good.msgs) != 1 {
+		t.Fatalf("healthy reporter got %v", good.msgs)
+	}
+
+	good.err = errors.New("down too")
+	if err := m.SendMessage(context.Background(), []byte("m")); err == nil {
+		t.Fatal("SendMessage succeeded with every reporter failing")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bad.closed || !good.closed {
+		t.Fatal("Close did not close every reporter")
+	}
+}
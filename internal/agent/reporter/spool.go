@@ -0,0 +1,572 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/agent/apm"
+	"os-artificer/saber/pkg/logger"
+)
+
+const (
+	defaultSpoolSegmentBytes = 16 << 20
+	defaultSpoolMaxBytes     = 1 << 30
+	defaultSpoolMaxAge       = 7 * 24 * time.Hour
+
+	spoolSegmentExt       = ".seg"
+	spoolCursorFile       = "cursor.json"
+	spoolRecordHeaderSize = 8 // payload length and CRC-32, both big-endian uint32
+	spoolCursorSaveEvery  = time.Second
+)
+
+var errSpoolClosed = errors.New("spool is closed")
+
+// SpoolOptions configures a Spool.
+type SpoolOptions struct {
+	// Dir holds the segment files and the replay cursor.
+	Dir string
+	// SegmentBytes is the size at which the segment being written is closed and a new
+	// one started.
+	SegmentBytes int64
+	// MaxBytes caps the spool size; the oldest segments are dropped to stay below it.
+	MaxBytes int64
+	// MaxAge drops segments whose last write is older than this.
+	MaxAge time.Duration
+}
+
+func (o SpoolOptions) withDefaults() SpoolOptions {
+	if o.SegmentBytes <= 0 {
+		o.SegmentBytes = defaultSpoolSegmentBytes
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = defaultSpoolMaxBytes
+	}
+	if o.MaxBytes < 2*o.SegmentBytes {
+		o.MaxBytes = 2 * o.SegmentBytes
+	}
+	if o.MaxAge <= 0 {
+		o.MaxAge = defaultSpoolMaxAge
+	}
+	return o
+}
+
+// spoolSegment is one append-only segment file.
+type spoolSegment struct {
+	id      uint64
+	size    int64 // bytes of complete records
+	records int   // number of complete records
+	modTime time.Time
+}
+
+// spoolCursor is the persisted replay position: the next record to replay.
+type spoolCursor struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// Spool is a disk-backed FIFO of messages a reporter could not send. Messages are
+// appended to segment files of length-prefixed, checksummed records and replayed in
+// order with Peek and Commit. The replay position is persisted, so messages survive an
+// agent restart; a crash may replay the messages committed in the last second again.
+type Spool struct {
+	name string
+	opts SpoolOptions
+
+	mu          sync.Mutex
+	closed      bool
+	segments    []*spoolSegment // oldest first; records are appended to the last one
+	writer      *os.File
+	reader      *os.File // open on segments[0], nil until needed
+	readOff     int64    // offset of the next record in segments[0]
+	readRecords int      // records of segments[0] already replayed
+	peeked      int64    // size of the record returned by Peek, 0 when none
+	depth       int
+	bytes       int64
+	cursorSaved time.Time
+	notify      chan struct{}
+}
+
+// OpenSpool opens or creates the spool in opts.Dir. name labels the spool metrics.
+func OpenSpool(name string, opts SpoolOptions) (*Spool, error) {
+	opts = opts.withDefaults()
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("spool %s: dir is required", name)
+	}
+	if err := os.MkdirAll(opts.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("spool %s: %w", name, err)
+	}
+
+	s := &Spool{name: name, opts: opts, notify: make(chan struct{}, 1)}
+	if err := s.load(); err != nil {
+		s.closeFiles()
+		return nil, fmt.Errorf("spool %s: %w", name, err)
+	}
+	s.updateMetrics()
+
+	if s.depth > 0 {
+		logger.Infof("spool %s: %d messages (%d bytes) waiting for replay", name, s.depth, s.bytes)
+	}
+	return s, nil
+}
+
+// load scans the segment files, drops those already replayed and opens the last one for
+// appending. A torn record at the end of the last segment is truncated away.
+func (s *Spool) load() error {
+	ids, err := s.segmentIDs()
+	if err != nil {
+		return err
+	}
+	cursor := s.loadCursor()
+
+	for i, id := range ids {
+		path := s.segmentPath(id)
+		if id < cursor.Segment {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		upTo := int64(0)
+		if id == cursor.Segment {
+			upTo = cursor.Offset
+		}
+		scan, err := scanSpoolSegment(path, upTo)
+		if err != nil {
+			return err
+		}
+
+		if i == len(ids)-1 && scan.torn {
+			logger.Warnf("spool %s: truncating torn record at %s:%d", s.name, path, scan.size)
+			if err := os.Truncate(path, scan.size); err != nil {
+				return err
+			}
+		}
+
+		if len(s.segments) == 0 {
+			s.readOff, s.readRecords = scan.cursorOff, scan.cursorRecords
+		}
+		s.segments = append(s.segments, &spoolSegment{id: id, size: scan.size, records: scan.records, modTime: scan.modTime})
+		s.depth += scan.records
+		s.bytes += scan.size
+	}
+	s.depth -= s.readRecords
+
+	if len(s.segments) == 0 {
+		next := cursor.Segment
+		if next == 0 {
+			next = 1
+		}
+		return s.startSegment(next)
+	}
+	return s.openWriter()
+}
+
+func (s *Spool) segmentIDs() ([]uint64, error) {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentExt), 16, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *Spool) segmentPath(id uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%016x%s", id, spoolSegmentExt))
+}
+
+func (s *Spool) loadCursor() spoolCursor {
+	var c spoolCursor
+	data, err := os.ReadFile(filepath.Join(s.opts.Dir, spoolCursorFile))
+	if err != nil {
+		return c
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		logger.Warnf("spool %s: ignoring unreadable cursor: %v", s.name, err)
+		return spoolCursor{}
+	}
+	return c
+}
+
+// saveCursor persists the replay position. Callers hold s.mu.
+func (s *Spool) saveCursor() {
+	s.cursorSaved = time.Now()
+	if len(s.segments) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(spoolCursor{Segment: s.segments[0].id, Offset: s.readOff})
+	if err != nil {
+		return
+	}
+
+	path := filepath.Join(s.opts.Dir, spoolCursorFile)
+	tmp := path + ".tmp"
+	if err := writeSynced(tmp, data); err != nil {
+		logger.Warnf("spool %s: failed to save cursor: %v", s.name, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		logger.Warnf("spool %s: failed to save cursor: %v", s.name, err)
+	}
+}
+
+// writeSynced writes data to path and flushes it to disk, so that a rename over the
+// previous version never leaves an empty file behind after a crash.
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *Spool) openWriter() error {
+	seg := s.segments[len(s.segments)-1]
+	f, err := os.OpenFile(s.segmentPath(seg.id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.writer = f
+	return nil
+}
+
+// startSegment flushes and closes the segment being written and starts segment id.
+// Callers hold s.mu.
+func (s *Spool) startSegment(id uint64) error {
+	if s.writer != nil {
+		if err := s.writer.Sync(); err != nil {
+			logger.Warnf("spool %s: failed to sync segment: %v", s.name, err)
+		}
+		if err := s.writer.Close(); err != nil {
+			logger.Warnf("spool %s: failed to close segment: %v", s.name, err)
+		}
+		s.writer = nil
+	}
+
+	s.segments = append(s.segments, &spoolSegment{id: id, modTime: time.Now()})
+	return s.openWriter()
+}
+
+// Append adds data to the end of the spool, dropping the oldest segments when the spool
+// would exceed its size cap.
+func (s *Spool) Append(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errSpoolClosed
+	}
+
+	n := int64(spoolRecordHeaderSize + len(data))
+	if n > s.opts.SegmentBytes {
+		return fmt.Errorf("spool %s: message of %d bytes exceeds segment size %d", s.name, len(data), s.opts.SegmentBytes)
+	}
+
+	active := s.segments[len(s.segments)-1]
+	if active.size > 0 && active.size+n > s.opts.SegmentBytes {
+		if err := s.startSegment(active.id + 1); err != nil {
+			return fmt.Errorf("spool %s: %w", s.name, err)
+		}
+		active = s.segments[len(s.segments)-1]
+	}
+
+	for s.bytes+n > s.opts.MaxBytes && len(s.segments) > 1 {
+		s.dropOldest("max_bytes")
+	}
+
+	record := make([]byte, n)
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(data))
+	copy(record[spoolRecordHeaderSize:], data)
+
+	if _, err := s.writer.Write(record); err != nil {
+		// Drop the partial record so that later appends stay readable.
+		_ = s.writer.Truncate(active.size)
+		return fmt.Errorf("spool %s: %w", s.name, err)
+	}
+
+	active.size += n
+	active.records++
+	active.modTime = time.Now()
+	s.depth++
+	s.bytes += n
+	s.updateMetrics()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Peek returns the oldest message without removing it, or nil when the spool is empty.
+// Call Commit once the message has been delivered.
+func (s *Spool) Peek() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, errSpoolClosed
+	}
+	s.trimAge()
+
+	for s.depth > 0 {
+		seg := s.segments[0]
+		if s.readOff >= seg.size {
+			if len(s.segments) == 1 {
+				return nil, nil
+			}
+			s.dropOldest("")
+			continue
+		}
+
+		if s.reader == nil {
+			f, err := os.Open(s.segmentPath(seg.id))
+			if err != nil {
+				return nil, fmt.Errorf("spool %s: %w", s.name, err)
+			}
+			s.reader = f
+		}
+
+		data, err := readSpoolRecord(s.reader, s.readOff, seg.size)
+		if err != nil {
+			dropped := seg.records - s.readRecords
+			logger.Errorf("spool %s: dropping %d messages of corrupt segment %016x: %v", s.name, dropped, seg.id, err)
+			apm.SpoolDroppedTotal.WithLabelValues(s.name, "corrupt").Add(float64(dropped))
+			s.depth -= dropped
+			s.readOff, s.readRecords = seg.size, seg.records
+			s.updateMetrics()
+			continue
+		}
+
+		s.peeked = int64(spoolRecordHeaderSize + len(data))
+		return data, nil
+	}
+
+	return nil, nil
+}
+
+// Commit removes the message returned by the last Peek.
+func (s *Spool) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.peeked == 0 {
+		return
+	}
+
+	s.readOff += s.peeked
+	s.readRecords++
+	s.peeked = 0
+	s.depth--
+
+	if s.readOff >= s.segments[0].size && len(s.segments) > 1 {
+		s.dropOldest("")
+	} else if time.Since(s.cursorSaved) >= spoolCursorSaveEvery {
+		s.saveCursor()
+	}
+	s.updateMetrics()
+}
+
+// dropOldest removes segments[0]. reason labels the dropped-messages metric; an empty
+// reason means the segment was fully replayed. Callers hold s.mu and ensure that
+// segments[0] is not the segment being written.
+func (s *Spool) dropOldest(reason string) {
+	seg := s.segments[0]
+	dropped := seg.records - s.readRecords
+
+	if s.reader != nil {
+		_ = s.reader.Close()
+		s.reader = nil
+	}
+	if err := os.Remove(s.segmentPath(seg.id)); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("spool %s: failed to remove segment: %v", s.name, err)
+	}
+
+	s.segments = s.segments[1:]
+	s.readOff, s.readRecords, s.peeked = 0, 0, 0
+	s.depth -= dropped
+	s.bytes -= seg.size
+
+	if reason != "" && dropped > 0 {
+		logger.Warnf("spool %s: dropped %d messages (%s)", s.name, dropped, reason)
+		apm.SpoolDroppedTotal.WithLabelValues(s.name, reason).Add(float64(dropped))
+	}
+	s.saveCursor()
+	s.updateMetrics()
+}
+
+// trimAge drops the segments last written before MaxAge. Callers hold s.mu.
+func (s *Spool) trimAge() {
+	cutoff := time.Now().Add(-s.opts.MaxAge)
+	for len(s.segments) > 1 && s.segments[0].modTime.Before(cutoff) {
+		s.dropOldest("max_age")
+	}
+}
+
+// Len returns the number of messages waiting for replay.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.depth
+}
+
+// Notify returns a channel that receives after an Append.
+func (s *Spool) Notify() <-chan struct{} {
+	return s.notify
+}
+
+// Close saves the replay position, flushes the segment being written and closes the
+// segment files.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.saveCursor()
+
+	var firstErr error
+	if s.writer != nil {
+		firstErr = s.writer.Sync()
+	}
+	if err := s.closeFiles(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+func (s *Spool) closeFiles() error {
+	var firstErr error
+	for _, f := range []*os.File{s.reader, s.writer} {
+		if f == nil {
+			continue
+		}
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.reader, s.writer = nil, nil
+	return firstErr
+}
+
+func (s *Spool) updateMetrics() {
+	apm.SpoolDepth.WithLabelValues(s.name).Set(float64(s.depth))
+	apm.SpoolBytes.WithLabelValues(s.name).Set(float64(s.bytes))
+}
+
+// readSpoolRecord reads the record at off, which must end before limit.
+func readSpoolRecord(r io.ReaderAt, off, limit int64) ([]byte, error) {
+	var header [spoolRecordHeaderSize]byte
+	if _, err := r.ReadAt(header[:], off); err != nil {
+		return nil, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[0:4]))
+	if off+spoolRecordHeaderSize+size > limit {
+		return nil, fmt.Errorf("record at %d overruns segment", off)
+	}
+
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, off+spoolRecordHeaderSize); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, fmt.Errorf("checksum mismatch at %d", off)
+	}
+	return data, nil
+}
+
+// spoolScan is the result of scanning a segment file.
+type spoolScan struct {
+	size          int64 // end of the last valid record
+	records       int
+	torn          bool // bytes follow the last valid record
+	cursorOff     int64
+	cursorRecords int // records before cursorOff
+	modTime       time.Time
+}
+
+// scanSpoolSegment validates the records of a segment and locates the record boundary at
+// or before upTo.
+func scanSpoolSegment(path string, upTo int64) (spoolScan, error) {
+	var scan spoolScan
+
+	f, err := os.Open(path)
+	if err != nil {
+		return scan, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return scan, err
+	}
+	scan.modTime = info.ModTime()
+
+	for scan.size < info.Size() {
+		data, err := readSpoolRecord(f, scan.size, info.Size())
+		if err != nil {
+			scan.torn = true
+			break
+		}
+
+		scan.size += int64(spoolRecordHeaderSize + len(data))
+		scan.records++
+		if scan.size <= upTo {
+			scan.cursorOff, scan.cursorRecords = scan.size, scan.records
+		}
+	}
+	return scan, nil
+}
@@ -0,0 +1,168 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package reporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func drainSpool(t *testing.T, s *Spool, n int) []string {
+	t.Helper()
+
+	var out []string
+	for i := 0; i < n; i++ {
+		data, err := s.Peek()
+		if err != nil {
+			t.Fatalf("Peek: %v", err)
+		}
+		if data == nil {
+			break
+		}
+		out = append(out, string(data))
+		s.Commit()
+	}
+	return out
+}
+
+func TestSpoolOrderAcrossSegments(t *testing.T) {
+	s, err := OpenSpool("test", SpoolOptions{Dir: t.TempDir(), SegmentBytes: 64})
+	if err != nil {
+		t.Fatalf("OpenSpool: %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := s.Append([]byte(fmt.Sprintf("message-%02d", i))); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if s.Len() != 10 || len(s.segments) < 3 {
+		t.Fatalf("len = %d, segments = %d", s.Len(), len(s.segments))
+	}
+
+	// Peek without Commit returns the same message.
+	first, _ := s.Peek()
+	again, _ := s.Peek()
+	if string(first) != "message-00" || string(again) != "message-00" {
+		t.Fatalf("Peek = %q, %q", first, again)
+	}
+
+	got := drainSpool(t, s, 20)
+	if len(got) != 10 || got[0] != "message-00" || got[9] != "message-09" {
+		t.Fatalf("replayed %v", got)
+	}
+	if s.Len() != 0 || len(s.segments) != 1 {
+		t.Fatalf("after drain len = %d, segments = %d", s.Len(), len(s.segments))
+	}
+}
+
+func TestSpoolReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenSpool("test", SpoolOptions{Dir: dir, SegmentBytes: 64})
+	if err != nil {
+		t.Fatalf("OpenSpool: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		if err := s.Append([]byte(fmt.Sprintf("m%d", i))); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if got := drainSpool(t, s, 2); len(got) != 2 {
+		t.Fatalf("replayed %v", got)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := s.Append([]byte("late")); err != errSpoolClosed {
+		t.Fatalf("Append after Close = %v", err)
+	}
+
+	// Simulate a crash in the middle of the next append.
+	ids, _ := s.segmentIDs()
+	last := s.segmentPath(ids[len(ids)-1])
+	f, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{0, 0, 0, 9, 1, 2})
+	_ = f.Close()
+
+	s, err = OpenSpool("test", SpoolOptions{Dir: dir, SegmentBytes: 64})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+
+	if s.Len() != 4 {
+		t.Fatalf("reopened len = %d, want 4", s.Len())
+	}
+	if err := s.Append([]byte("m6")); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	got := drainSpool(t, s, 10)
+	want := []string{"m2", "m3", "m4", "m5", "m6"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+}
+
+func TestSpoolCaps(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenSpool("test", SpoolOptions{Dir: dir, SegmentBytes: 32, MaxBytes: 64})
+	if err != nil {
+		t.Fatalf("OpenSpool: %v", err)
+	}
+	defer s.Close()
+
+	// Each record is 8 bytes of header plus 8 of payload: two per segment.
+	for i := 0; i < 8; i++ {
+		if err := s.Append([]byte(fmt.Sprintf("record-%d", i))); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if s.bytes > 64 {
+		t.Fatalf("spool holds %d bytes, cap is 64", s.bytes)
+	}
+	got := drainSpool(t, s, 10)
+	if want := "[record-4 record-5 record-6 record-7]"; fmt.Sprint(got) != want {
+		t.Fatalf("replayed %v, want %s", got, want)
+	}
+
+	if err := s.Append(make([]byte, 64)); err == nil {
+		t.Fatal("message larger than a segment should be rejected")
+	}
+
+	// Segments not written for MaxAge are dropped on replay.
+	for i := 0; i < 3; i++ {
+		_ = s.Append([]byte(fmt.Sprintf("record-%d", i)))
+	}
+	s.opts.MaxAge = time.Hour
+	s.segments[0].modTime = time.Now().Add(-2 * time.Hour)
+	got = drainSpool(t, s, 10)
+	if len(got) != 1 || got[0] != "record-2" {
+		t.Fatalf("replayed after age trim %v", got)
+	}
+
+	if entries, _ := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt)); len(entries) != 1 {
+		t.Fatalf("segment files left: %v", entries)
+	}
+}
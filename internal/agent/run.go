@@ -24,11 +24,21 @@ import (
 
 	"os-artificer/saber/internal/agent/config"
 	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbnet"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// agentUnmarshalOpt composes default viper hooks with string->Endpoint so
+// apm.endpoint (string) unmarshals into sbnet.Endpoint.
+var agentUnmarshalOpt = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToSliceHookFunc(","),
+	sbnet.StringToEndpointHookFunc(),
+))
+
 func setupGracefulShutdown(svr *Service) {
 	sigC := make(chan os.Signal, 1)
 	signal.Notify(sigC, syscall.SIGINT, syscall.SIGTERM)
@@ -54,7 +64,7 @@ func loadAgentConfig() {
 		return
 	}
 
-	if err := viper.Unmarshal(&config.Cfg); err != nil {
+	if err := viper.Unmarshal(&config.Cfg, agentUnmarshalOpt); err != nil {
 		return
 	}
 
@@ -109,6 +119,10 @@ func Run(cmd *cobra.Command, args []string) error {
 				logger.Warnf("reload config: init logger failed: %v", err)
 				continue
 			}
+			if err := svr.ReloadTLS(); err != nil {
+				logger.Warnf("reload config: reload tls failed: %v", err)
+				continue
+			}
 			logger.Infof("config reloaded")
 		}
 	}()
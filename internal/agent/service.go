@@ -21,13 +21,18 @@ import (
 	"fmt"
 	"sync"
 
+	"os-artificer/saber/internal/agent/access"
+	"os-artificer/saber/internal/agent/apm"
 	"os-artificer/saber/internal/agent/config"
 	"os-artificer/saber/internal/agent/controller"
 	"os-artificer/saber/internal/agent/harvester"
 	"os-artificer/saber/internal/agent/harvester/plugin"
 	"os-artificer/saber/internal/agent/reporter"
+	"os-artificer/saber/internal/agent/task"
 	"os-artificer/saber/pkg/logger"
 	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+	"os-artificer/saber/pkg/sbnet"
 	"os-artificer/saber/pkg/tools"
 )
 
@@ -37,6 +42,16 @@ type Service struct {
 	reporter  reporter.Reporter
 	harvester *harvester.Harvester
 	ctrl      *controller.ControllerClient
+	apm       *apm.APM
+
+	// resolver, when set, discovers controller and databus instances from the access servers.
+	resolver *access.Resolver
+
+	// tls, when set, holds the certificates of the controller and databus channels.
+	tls *sbnet.CertReloader
+
+	// enrolled, when set, renews the enrolled client certificate held by tls.
+	enrolled *enroller
 }
 
 // NewService builds a service from a reporter, harvester, and optional controller client (used by CreateService).
@@ -50,10 +65,57 @@ func NewService(
 	return &Service{ctx: ctx, cancel: cancel, reporter: rep, harvester: h, ctrl: ctrl}
 }
 
+// InitAPM creates the APM service from config.Cfg.APM and sets s.apm. Business metrics are in internal/agent/apm/metrics.go.
+func (s *Service) InitAPM() error {
+	cfg := &config.Cfg.APM
+	s.apm = apm.NewAPM(cfg.Enabled, cfg.Endpoint)
+
+	if s.apm.IsEnabled() {
+		go func() {
+			if err := s.apm.Run(); err != nil {
+				logger.Warnf("APM server exited: %v", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// ReloadTLS re-reads the TLS certificates and CA bundle; new connections use them (for SIGHUP).
+func (s *Service) ReloadTLS() error {
+	if s.tls == nil {
+		return nil
+	}
+	if err := s.tls.Reload(); err != nil {
+		return err
+	}
+	logger.Infof("tls certificates reloaded")
+	return nil
+}
+
 // Run starts reporter, harvester, and optional controller client, then blocks until context is cancelled.
 func (s *Service) Run() error {
 	var runWg sync.WaitGroup
 
+	if err := s.InitAPM(); err != nil {
+		return err
+	}
+
+	if s.resolver != nil {
+		runWg.Add(1)
+		tools.Go(func() {
+			defer runWg.Done()
+			_ = s.resolver.Run(s.ctx)
+		})
+	}
+
+	if s.enrolled != nil {
+		runWg.Add(1)
+		tools.Go(func() {
+			defer runWg.Done()
+			s.enrolled.run(s.ctx, s.ReloadTLS)
+		})
+	}
+
 	if s.ctrl != nil {
 		runWg.Add(1)
 		tools.Go(func() {
@@ -98,6 +160,10 @@ func (s *Service) Run() error {
 		logger.Warnf("harvester close: %v", err)
 	}
 
+	if s.apm != nil {
+		_ = s.apm.Close()
+	}
+
 	runWg.Wait()
 	return s.ctx.Err()
 }
@@ -125,6 +191,10 @@ func (s *Service) Close() error {
 		s.reporter = nil
 	}
 
+	if s.apm != nil {
+		_ = s.apm.Close()
+	}
+
 	if s.cancel != nil {
 		s.cancel()
 		s.cancel = nil
@@ -138,15 +208,51 @@ func CreateService(ctx context.Context, cfg *config.Configuration) (*Service, er
 	if len(cfg.Reporters) == 0 {
 		return nil, fmt.Errorf("no reporters configured")
 	}
+	if err := verifyTasksTLS(cfg); err != nil {
+		return nil, err
+	}
+
+	resolver, err := access.NewResolver(&cfg.AccessServers)
+	if err != nil {
+		return nil, fmt.Errorf("access servers: %w", err)
+	}
 
-	entry := cfg.Reporters[0]
-	opts := &config.ReporterOpts{
-		Config:       entry.Config,
+	enrolled, err := enroll(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("enrollment: %w", err)
+	}
+
+	certs, err := sbnet.NewCertReloader(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	// With a client certificate, the servers only accept the identity it carries.
+	clientID := certs.Identity()
+
+	base := config.ReporterOpts{
 		AgentName:    cfg.Name,
 		AgentVersion: cfg.Version,
+		ClientID:     clientID,
+	}
+	if certs != nil {
+		base.Credentials = certs.ClientCredentials()
+	}
+	var ctrlResolver config.EndpointResolver
+	if resolver != nil {
+		databusWatcher, err := resolver.Watch(access.ServiceDatabus)
+		if err != nil {
+			return nil, err
+		}
+		ctrlWatcher, err := resolver.Watch(access.ServiceController)
+		if err != nil {
+			return nil, err
+		}
+		base.Databus = databusWatcher
+		ctrlResolver = ctrlWatcher
 	}
 
-	rep, err := reporter.CreateReporter(ctx, entry.Type, opts)
+	rep, err := reporter.NewReporterFromConfig(ctx, cfg.Reporters, base)
 	if err != nil {
 		return nil, err
 	}
@@ -168,13 +274,27 @@ func CreateService(ctx context.Context, cfg *config.Configuration) (*Service, er
 	h := harvester.NewHarvester(rep, plugins)
 
 	var ctrl *controller.ControllerClient
-	if cfg.Controller.Endpoints != "" {
-		clientID, err := tools.MachineID("saber-agent")
-		if err != nil {
-			_ = rep.Close()
-			return nil, fmt.Errorf("controller client requires machine-id: %w", err)
+	if cfg.Controller.Endpoints != "" || ctrlResolver != nil {
+		if clientID == "" {
+			clientID, err = tools.MachineID("saber-agent")
+			if err != nil {
+				_ = rep.Close()
+				return nil, fmt.Errorf("controller client requires machine-id: %w", err)
+			}
 		}
 		ctrl = controller.NewControllerClient(ctx, cfg.Controller.Endpoints, clientID)
+		if ctrlResolver != nil {
+			ctrl.UseResolver(ctrlResolver)
+		}
+		if certs != nil {
+			ctrl.UseCredentials(certs.ClientCredentials())
+		}
+		ctrl.UseHeartbeat(cfg.Controller.HeartbeatInterval)
+		announce(ctrl, cfg)
+		if cfg.Tasks.Enabled {
+			runner := task.NewRunner(cfg.Tasks, ctrl.Reply)
+			ctrl.Handle(sbmsg.KindTaskDispatch, runner.Handle)
+		}
 		ctrl.OnResponse(func(resp *proto.AgentResponse) {
 			if resp == nil {
 				return
@@ -189,5 +309,22 @@ func CreateService(ctx context.Context, cfg *config.Configuration) (*Service, er
 		})
 	}
 
-	return NewService(ctx, rep, h, ctrl), nil
+	svc := NewService(ctx, rep, h, ctrl)
+	svc.resolver = resolver
+	svc.tls = certs
+	svc.enrolled = enrolled
+	return svc, nil
+}
+
+// verifyTasksTLS refuses to run tasks unless the controller is authenticated: whoever
+// reaches the agent as its controller can run commands on the host, and the controller
+// endpoints may come from etcd.
+func verifyTasksTLS(cfg *config.Configuration) error {
+	if !cfg.Tasks.Enabled {
+		return nil
+	}
+	if !cfg.TLS.Enabled || cfg.TLS.CAFile == "" || cfg.TLS.InsecureSkipVerify {
+		return fmt.Errorf("tasks require tls enabled with caFile and without insecureSkipVerify")
+	}
+	return nil
 }
@@ -0,0 +1,51 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+package agent
+
+import (
+	"testing"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/pkg/sbnet"
+)
+
+func TestVerifyTasksTLS(t *testing.T) {
+	verified := sbnet.TLSConfig{Enabled: true, CAFile: "/etc/saber/ca.pem"}
+	skipVerify := verified
+	skipVerify.InsecureSkipVerify = true
+
+	cases := []struct {
+		name    string
+		tasks   bool
+		tls     sbnet.TLSConfig
+		wantErr bool
+	}{
+		{"tasks disabled", false, sbnet.TLSConfig{}, false},
+		{"plaintext", true, sbnet.TLSConfig{}, true},
+		{"no caFile", true, sbnet.TLSConfig{Enabled: true}, true},
+		{"insecureSkipVerify", true, skipVerify, true},
+		{"verified", true, verified, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Configuration{TLS: tc.tls}
+			cfg.Tasks.Enabled = tc.tasks
+			if err := verifyTasksTLS(cfg); (err != nil) != tc.wantErr {
+				t.Fatalf("verifyTasksTLS = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
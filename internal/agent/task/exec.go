@@ -0,0 +1,104 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package task
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/sbmsg"
+	"os-artificer/saber/pkg/sbproc"
+)
+
+// prepareExec allows args[0] when it is one of the configured commands, given by path or,
+// without a slash, by base name.
+func (r *Runner) prepareExec(args []string) (runFunc, error) {
+	if len(r.cfg.Commands) == 0 {
+		return nil, gerrors.New(gerrors.Unimplemented, "exec tasks are disabled")
+	}
+	if len(args) == 0 || args[0] == "" {
+		return nil, gerrors.New(gerrors.InvalidParameter, "exec task without command")
+	}
+
+	cmd := r.command(args[0])
+	if cmd == "" {
+		return nil, gerrors.Newf(gerrors.InvalidParameter, "command %q is not allowed", args[0])
+	}
+	return func(ctx context.Context, out *results) (int32, error) {
+		return r.exec(ctx, out, cmd, args[1:])
+	}, nil
+}
+
+// command returns the allowed command named name, or "".
+func (r *Runner) command(name string) string {
+	for _, cmd := range r.cfg.Commands {
+		if cmd == name || (!strings.Contains(name, "/") && filepath.Base(cmd) == name) {
+			return cmd
+		}
+	}
+	return ""
+}
+
+// exec runs cmd and streams its output until MaxOutputBytes have been sent; the rest is
+// read and dropped so the command does not block on a full pipe.
+func (r *Runner) exec(ctx context.Context, out *results, cmd string, args []string) (int32, error) {
+	child, err := sbproc.StartWithStreams(ctx, cmd, args...)
+	if err != nil {
+		return 0, gerrors.NewE(gerrors.Failure, err)
+	}
+
+	remaining := r.cfg.MaxOutputBytes
+	stdout, stderr := child.Stdout(), child.Stderr()
+	for stdout != nil || stderr != nil {
+		var (
+			stream string
+			p      []byte
+			ok     bool
+		)
+		select {
+		case p, ok = <-stdout:
+			if !ok {
+				stdout = nil
+				continue
+			}
+			stream = sbmsg.StreamStdout
+
+		case p, ok = <-stderr:
+			if !ok {
+				stderr = nil
+				continue
+			}
+			stream = sbmsg.StreamStderr
+		}
+
+		if int64(len(p)) > remaining {
+			p = p[:remaining]
+			out.truncated("output truncated at %d bytes", r.cfg.MaxOutputBytes)
+		}
+		remaining -= int64(len(p))
+		// A failed write cancels ctx, which kills the command.
+		_ = out.write(stream, p)
+	}
+
+	exitCode, err := child.Wait()
+	if err != nil {
+		return int32(exitCode), gerrors.NewE(gerrors.Failure, err)
+	}
+	return int32(exitCode), nil
+}
@@ -0,0 +1,266 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/sbmsg"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxDirEntries bounds the entries returned by a TaskListDir task.
+const maxDirEntries = 10000
+
+// prepareCollectFile allows collecting the regular file at path, up to MaxFileBytes.
+func (r *Runner) prepareCollectFile(path string) (runFunc, error) {
+	real, root, err := r.allowedPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(real)
+	if err != nil {
+		return nil, pathError(path, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, gerrors.Newf(gerrors.InvalidParameter, "%s is not a regular file", path)
+	}
+	if fi.Size() > r.cfg.MaxFileBytes {
+		return nil, gerrors.Newf(gerrors.InvalidParameter, "%s has %d bytes, over the limit of %d",
+			path, fi.Size(), r.cfg.MaxFileBytes)
+	}
+
+	return func(ctx context.Context, out *results) (int32, error) {
+		return 0, r.collectFile(ctx, out, root, real)
+	}, nil
+}
+
+// collectFile streams the file at path under root. A file that grew past MaxFileBytes since
+// it was checked is truncated.
+func (r *Runner) collectFile(ctx context.Context, out *results, root, path string) error {
+	f, err := openBeneath(root, path, unix.O_RDONLY)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return gerrors.NewE(gerrors.Failure, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return gerrors.Newf(gerrors.InvalidParameter, "%s is not a regular file", path)
+	}
+
+	buf := make([]byte, chunkSize)
+	remaining := r.cfg.MaxFileBytes
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := f.Read(buf[:min(int64(len(buf)), remaining+1)])
+		if int64(n) > remaining {
+			n = int(remaining)
+			out.truncated("file truncated at %d bytes", r.cfg.MaxFileBytes)
+			err = io.EOF
+		}
+		remaining -= int64(n)
+		if werr := out.write(sbmsg.StreamFile, buf[:n]); werr != nil {
+			return werr
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return gerrors.NewE(gerrors.Failure, err)
+		}
+	}
+}
+
+// prepareListDir allows listing the directory at path.
+func (r *Runner) prepareListDir(path string) (runFunc, error) {
+	real, root, err := r.allowedPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, out *results) (int32, error) {
+		return 0, r.listDir(out, root, real)
+	}, nil
+}
+
+// listDir writes the first maxDirEntries entries of the directory at path under root as JSON.
+func (r *Runner) listDir(out *results, root, path string) error {
+	dir, err := openBeneath(root, path, unix.O_RDONLY|unix.O_DIRECTORY)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	dirents, err := dir.ReadDir(-1)
+	if err != nil {
+		return pathError(path, err)
+	}
+	slices.SortFunc(dirents, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	if len(dirents) > maxDirEntries {
+		out.truncated("listing truncated at %d of %d entries", maxDirEntries, len(dirents))
+		dirents = dirents[:maxDirEntries]
+	}
+
+	entries := make([]sbmsg.DirEntry, 0, len(dirents))
+	for _, d := range dirents {
+		fi, err := d.Info()
+		if err != nil {
+			// Removed since it was read.
+			continue
+		}
+		entries = append(entries, sbmsg.DirEntry{
+			Name:    d.Name(),
+			Size:    fi.Size(),
+			Mode:    fi.Mode().String(),
+			ModTime: fi.ModTime(),
+			IsDir:   d.IsDir(),
+		})
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return gerrors.NewE(gerrors.Failure, err)
+	}
+	return out.write(sbmsg.StreamEntries, data)
+}
+
+// allowedPath resolves the symbolic links of path and returns the result together with the
+// configured path it lies under.
+func (r *Runner) allowedPath(path string) (string, string, error) {
+	if len(r.cfg.Paths) == 0 {
+		return "", "", gerrors.New(gerrors.Unimplemented, "file tasks are disabled")
+	}
+	if !filepath.IsAbs(path) {
+		return "", "", gerrors.Newf(gerrors.InvalidParameter, "path %q is not absolute", path)
+	}
+
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", "", pathError(path, err)
+	}
+
+	for _, root := range r.cfg.Paths {
+		root, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			continue
+		}
+		if beneath(real, root) {
+			return real, root, nil
+		}
+	}
+	return "", "", gerrors.Newf(gerrors.InvalidParameter, "path %s is not allowed", path)
+}
+
+// openBeneath opens path, which allowedPath resolved under root, without following any
+// symbolic link below root: a link swapped in since the check fails the open instead of
+// leading outside root. The opened file is checked once more to lie under root.
+//
+// The file is named after its /proc/self/fd entry, so that paths derived from it, such as
+// those of directory entries, go through the opened file rather than path.
+func openBeneath(root, path string, flags int) (*os.File, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil, gerrors.Newf(gerrors.InvalidParameter, "path %s is not allowed", path)
+	}
+
+	rootFd, err := unix.Open(root, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, pathError(path, err)
+	}
+	defer unix.Close(rootFd)
+
+	fd, err := unix.Openat2(rootFd, rel, &unix.OpenHow{
+		Flags:   uint64(flags | unix.O_NOFOLLOW | unix.O_CLOEXEC),
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_SYMLINKS,
+	})
+	if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM) {
+		// Kernels before 5.6, or seccomp profiles that refuse openat2.
+		fd, err = openNoFollow(rootFd, rel, flags)
+	}
+	if errors.Is(err, unix.ELOOP) || errors.Is(err, unix.EXDEV) {
+		return nil, gerrors.Newf(gerrors.InvalidParameter, "path %s is not allowed", path)
+	}
+	if err != nil {
+		return nil, pathError(path, err)
+	}
+
+	name := "/proc/self/fd/" + strconv.Itoa(fd)
+	f := os.NewFile(uintptr(fd), name)
+	opened, err := os.Readlink(name)
+	if err != nil {
+		f.Close()
+		return nil, gerrors.NewE(gerrors.Failure, err)
+	}
+	if !beneath(opened, root) {
+		f.Close()
+		return nil, gerrors.Newf(gerrors.InvalidParameter, "path %s is not allowed", path)
+	}
+	return f, nil
+}
+
+// openNoFollow opens rel below dirFd one component at a time, refusing symbolic links.
+func openNoFollow(dirFd int, rel string, flags int) (int, error) {
+	parts := strings.Split(rel, "/")
+	fd := dirFd
+	for i, part := range parts {
+		partFlags := unix.O_RDONLY | unix.O_DIRECTORY
+		if i == len(parts)-1 {
+			partFlags = flags
+		}
+		next, err := unix.Openat(fd, part, partFlags|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		if fd != dirFd {
+			unix.Close(fd)
+		}
+		if err != nil {
+			return -1, err
+		}
+		fd = next
+	}
+	return fd, nil
+}
+
+// beneath reports whether path is root or lies below it.
+func beneath(path, root string) bool {
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, "/")+"/")
+}
+
+func pathError(path string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return gerrors.Newf(gerrors.NotFound, "%s not found", path)
+	}
+	return gerrors.NewE(gerrors.Failure, err)
+}
@@ -0,0 +1,79 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package task
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"os-artificer/saber/pkg/gerrors"
+)
+
+// signals are the signals a TaskKillProcess task may send.
+var signals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+	"INT":  syscall.SIGINT,
+	"HUP":  syscall.SIGHUP,
+	"QUIT": syscall.SIGQUIT,
+}
+
+// prepareKill allows sending signal, TERM by default, to the process pid. The agent and
+// init cannot be killed.
+func (r *Runner) prepareKill(pid, signal string) (runFunc, error) {
+	if !r.cfg.AllowKill {
+		return nil, gerrors.New(gerrors.Unimplemented, "kill tasks are disabled")
+	}
+
+	n, err := strconv.Atoi(pid)
+	if err != nil || n <= 1 {
+		return nil, gerrors.Newf(gerrors.InvalidParameter, "invalid pid %q", pid)
+	}
+	if n == os.Getpid() {
+		return nil, gerrors.New(gerrors.InvalidParameter, "the agent cannot kill itself")
+	}
+
+	if signal == "" {
+		signal = "TERM"
+	}
+	sig, ok := signals[strings.TrimPrefix(strings.ToUpper(signal), "SIG")]
+	if !ok {
+		return nil, gerrors.Newf(gerrors.InvalidParameter, "unsupported signal %q", signal)
+	}
+
+	return func(ctx context.Context, out *results) (int32, error) {
+		return 0, kill(n, sig)
+	}, nil
+}
+
+func kill(pid int, sig syscall.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if errors.Is(err, os.ErrProcessDone) || errors.Is(err, syscall.ESRCH) {
+		return gerrors.Newf(gerrors.NotFound, "process %d not found", pid)
+	}
+	if err != nil {
+		return gerrors.NewE(gerrors.Failure, err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+// Package task runs the tasks the controller dispatches to the agent and streams their
+// results back on the Connect stream.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// chunkSize bounds the output carried by one TaskResult.
+const chunkSize = 64 << 10
+
+// ReplyFunc sends body to the controller as the reply to env (ControllerClient.Reply).
+// body must not be used after it returns.
+type ReplyFunc func(ctx context.Context, env *proto.Envelope, body protoreflect.ProtoMessage) error
+
+// runFunc runs a validated task, writing its output to out. It returns the exit code of a
+// command and an error when the task failed.
+type runFunc func(ctx context.Context, out *results) (int32, error)
+
+// Runner runs the tasks allowed by its configuration, up to MaxConcurrent at a time.
+type Runner struct {
+	cfg   config.TasksConfig
+	reply ReplyFunc
+	slots chan struct{}
+}
+
+// NewRunner returns a Runner replying the results of tasks with reply.
+func NewRunner(cfg config.TasksConfig, reply ReplyFunc) *Runner {
+	return &Runner{cfg: cfg, reply: reply, slots: make(chan struct{}, max(cfg.MaxConcurrent, 1))}
+}
+
+// Handle is the handler of TaskDispatch messages. It checks that the task is allowed and
+// starts it; the results are replied to env as they come, the last one with done set.
+// A task that cannot be started is refused with the returned error.
+func (r *Runner) Handle(ctx context.Context, env *proto.Envelope) error {
+	d := env.GetTaskDispatch()
+	if d.GetTaskID() == "" {
+		return gerrors.New(gerrors.InvalidParameter, "task without taskID")
+	}
+
+	run, err := r.prepare(d)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+	default:
+		return gerrors.Newf(gerrors.QueueFull, "%d tasks already running", cap(r.slots))
+	}
+
+	go func() {
+		defer func() { <-r.slots }()
+		r.run(ctx, env, run)
+	}()
+	return nil
+}
+
+// prepare validates d and returns the function running it.
+func (r *Runner) prepare(d *proto.TaskDispatch) (runFunc, error) {
+	params := d.GetParams()
+	switch d.GetType() {
+	case sbmsg.TaskExec:
+		return r.prepareExec(d.GetArgs())
+	case sbmsg.TaskCollectFile:
+		return r.prepareCollectFile(params[sbmsg.ParamPath])
+	case sbmsg.TaskListDir:
+		return r.prepareListDir(params[sbmsg.ParamPath])
+	case sbmsg.TaskKillProcess:
+		return r.prepareKill(params[sbmsg.ParamPID], params[sbmsg.ParamSignal])
+	default:
+		return nil, gerrors.Newf(gerrors.Unimplemented, "unknown task type %q", d.GetType())
+	}
+}
+
+// timeout returns how long d may run: its own timeout or the default one, capped at MaxTimeout.
+// Zero means no limit.
+func (r *Runner) timeout(d *proto.TaskDispatch) time.Duration {
+	timeout := time.Duration(d.GetTimeoutMs()) * time.Millisecond
+	if timeout <= 0 {
+		timeout = r.cfg.DefaultTimeout
+	}
+	if r.cfg.MaxTimeout > 0 && (timeout <= 0 || timeout > r.cfg.MaxTimeout) {
+		timeout = r.cfg.MaxTimeout
+	}
+	return max(timeout, 0)
+}
+
+func (r *Runner) run(ctx context.Context, env *proto.Envelope, run runFunc) {
+	d := env.GetTaskDispatch()
+	timeout := r.timeout(d)
+
+	var taskCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		taskCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		taskCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	logger.Infof("task %s (%s) started", d.GetTaskID(), d.GetType())
+	out := &results{ctx: ctx, cancel: cancel, env: env, reply: r.reply}
+	exitCode, err := run(taskCtx, out)
+	if errors.Is(taskCtx.Err(), context.DeadlineExceeded) {
+		err = gerrors.Newf(gerrors.Timeout, "task timed out after %s", timeout)
+	}
+	out.done(exitCode, err)
+
+	if out.err != nil {
+		logger.Warnf("task %s (%s): results not delivered: %v", d.GetTaskID(), d.GetType(), out.err)
+		return
+	}
+	logger.Infof("task %s (%s) finished: exitCode=%d, err: %v", d.GetTaskID(), d.GetType(), exitCode, err)
+}
+
+// results replies the results of one task in order. A failed reply cancels the task, since
+// the controller would miss the rest of its output.
+type results struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	env    *proto.Envelope
+	reply  ReplyFunc
+
+	seq  int64
+	note string
+	err  error
+}
+
+// write sends p on stream, in chunks of at most chunkSize.
+func (w *results) write(stream string, p []byte) error {
+	for len(p) > 0 {
+		n := min(len(p), chunkSize)
+		if err := w.send(&proto.TaskResult{Stream: stream, Output: p[:n]}); err != nil {
+			return err
+		}
+		p = p[n:]
+	}
+	return nil
+}
+
+// truncated records that output was dropped; the final result reports it when the task succeeds.
+func (w *results) truncated(format string, args ...any) {
+	w.note = fmt.Sprintf(format, args...)
+}
+
+// done sends the final result.
+func (w *results) done(exitCode int32, err error) {
+	res := &proto.TaskResult{Done: true, ExitCode: exitCode, Errmsg: w.note}
+	if err != nil {
+		res.Code, res.Errmsg = int32(gerrors.Failure), err.Error()
+		var ge *gerrors.Error
+		if gerrors.As(err, &ge) {
+			res.Code, res.Errmsg = int32(ge.Code()), ge.Message()
+		}
+	}
+	_ = w.send(res)
+}
+
+func (w *results) send(res *proto.TaskResult) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.seq++
+	res.TaskID, res.Seq = w.env.GetTaskDispatch().GetTaskID(), w.seq
+	if err := w.reply(w.ctx, w.env, res); err != nil {
+		w.err = err
+		w.cancel()
+	}
+	return w.err
+}
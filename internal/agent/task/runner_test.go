@@ -0,0 +1,367 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+	"os-artificer/saber/pkg/sbproc"
+
+	"golang.org/x/sys/unix"
+	gproto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// run dispatches d to a runner configured with cfg and returns the results it replies.
+func run(t *testing.T, cfg config.TasksConfig, d *proto.TaskDispatch) ([]*proto.TaskResult, error) {
+	t.Helper()
+
+	ch := make(chan *proto.TaskResult, 1024)
+	r := NewRunner(cfg, func(ctx context.Context, env *proto.Envelope, body protoreflect.ProtoMessage) error {
+		ch <- gproto.Clone(body).(*proto.TaskResult)
+		return nil
+	})
+
+	env, err := sbmsg.New(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Handle(context.Background(), env); err != nil {
+		return nil, err
+	}
+
+	var results []*proto.TaskResult
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case res := <-ch:
+			if res.TaskID != d.TaskID || res.Seq != int64(len(results)+1) {
+				t.Fatalf("result %d: taskID=%s seq=%d", len(results), res.TaskID, res.Seq)
+			}
+			results = append(results, res)
+			if res.Done {
+				return results, nil
+			}
+		case <-timeout:
+			t.Fatal("task did not finish")
+		}
+	}
+}
+
+// output returns the output of results on stream and the final result.
+func output(results []*proto.TaskResult, stream string) ([]byte, *proto.TaskResult) {
+	var buf bytes.Buffer
+	for _, res := range results {
+		if res.Stream == stream {
+			buf.Write(res.Output)
+		}
+	}
+	return buf.Bytes(), results[len(results)-1]
+}
+
+func execConfig() config.TasksConfig {
+	return config.TasksConfig{
+		Commands:       []string{"/bin/sh", "/bin/sleep"},
+		MaxOutputBytes: 1 << 20,
+		DefaultTimeout: 10 * time.Second,
+	}
+}
+
+func TestExec(t *testing.T) {
+	results, err := run(t, execConfig(), &proto.TaskDispatch{
+		TaskID: "t1",
+		Type:   sbmsg.TaskExec,
+		Args:   []string{"sh", "-c", "echo out; echo err >&2; exit 3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, last := output(results, sbmsg.StreamStdout)
+	stderr, _ := output(results, sbmsg.StreamStderr)
+	if string(stdout) != "out\n" || string(stderr) != "err\n" {
+		t.Errorf("stdout=%q stderr=%q", stdout, stderr)
+	}
+	if last.Code != int32(gerrors.Success) || last.ExitCode != 3 {
+		t.Errorf("final result code=%d exitCode=%d errmsg=%s", last.Code, last.ExitCode, last.Errmsg)
+	}
+}
+
+func TestExecRefused(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.TasksConfig
+		args []string
+		code gerrors.Code
+	}{
+		{"disabled", config.TasksConfig{}, []string{"sh"}, gerrors.Unimplemented},
+		{"no command", execConfig(), nil, gerrors.InvalidParameter},
+		{"not allowed", execConfig(), []string{"rm", "-rf", "/"}, gerrors.InvalidParameter},
+		{"other path", execConfig(), []string{"/tmp/sh"}, gerrors.InvalidParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, tt.cfg, &proto.TaskDispatch{TaskID: "t1", Type: sbmsg.TaskExec, Args: tt.args})
+			if !gerrors.Is(err, gerrors.New(tt.code, "")) {
+				t.Fatalf("error = %v, want code %d", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestExecOutputCap(t *testing.T) {
+	cfg := execConfig()
+	cfg.MaxOutputBytes = 1000
+
+	results, err := run(t, cfg, &proto.TaskDispatch{
+		TaskID: "t1",
+		Type:   sbmsg.TaskExec,
+		Args:   []string{"sh", "-c", "head -c 100000 /dev/zero"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, last := output(results, sbmsg.StreamStdout)
+	if len(stdout) != 1000 {
+		t.Errorf("stdout has %d bytes, want 1000", len(stdout))
+	}
+	if last.Code != int32(gerrors.Success) || last.Errmsg == "" {
+		t.Errorf("final result code=%d errmsg=%q, want a truncation note", last.Code, last.Errmsg)
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	start := time.Now()
+	results, err := run(t, execConfig(), &proto.TaskDispatch{
+		TaskID:    "t1",
+		Type:      sbmsg.TaskExec,
+		Args:      []string{"sleep", "10"},
+		TimeoutMs: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last := results[len(results)-1]; last.Code != int32(gerrors.Timeout) {
+		t.Errorf("final result code=%d errmsg=%s, want Timeout", last.Code, last.Errmsg)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("task took %s", elapsed)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	cfg := execConfig()
+	cfg.MaxConcurrent = 1
+	r := NewRunner(cfg, func(ctx context.Context, env *proto.Envelope, body protoreflect.ProtoMessage) error {
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i, want := range []error{nil, gerrors.New(gerrors.QueueFull, "")} {
+		env, _ := sbmsg.New(&proto.TaskDispatch{TaskID: strconv.Itoa(i), Type: sbmsg.TaskExec, Args: []string{"sleep", "10"}})
+		err := r.Handle(ctx, env)
+		if (want == nil && err != nil) || (want != nil && !gerrors.Is(err, want)) {
+			t.Fatalf("task %d: error = %v, want %v", i, err, want)
+		}
+	}
+}
+
+func TestCollectFile(t *testing.T) {
+	root := t.TempDir()
+	data := bytes.Repeat([]byte("0123456789"), 20000)
+	if err := os.WriteFile(filepath.Join(root, "log"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.TasksConfig{Paths: []string{root}, MaxFileBytes: 1 << 20}
+
+	results, err := run(t, cfg, &proto.TaskDispatch{
+		TaskID: "t1",
+		Type:   sbmsg.TaskCollectFile,
+		Params: map[string]string{sbmsg.ParamPath: filepath.Join(root, "log")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, last := output(results, sbmsg.StreamFile)
+	if !bytes.Equal(got, data) || last.Code != int32(gerrors.Success) {
+		t.Errorf("collected %d bytes, code=%d errmsg=%s", len(got), last.Code, last.Errmsg)
+	}
+	if len(results) < 3 {
+		t.Errorf("%d results, want the file in chunks", len(results))
+	}
+}
+
+func TestCollectFileRefused(t *testing.T) {
+	root, other := t.TempDir(), t.TempDir()
+	for _, p := range []string{filepath.Join(root, "big"), filepath.Join(other, "secret")} {
+		if err := os.WriteFile(p, make([]byte, 100), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(other, "secret"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.TasksConfig{Paths: []string{root}, MaxFileBytes: 10}
+
+	tests := []struct {
+		path string
+		code gerrors.Code
+	}{
+		{filepath.Join(other, "secret"), gerrors.InvalidParameter},
+		{filepath.Join(root, "link"), gerrors.InvalidParameter},
+		{filepath.Join(root, "..", filepath.Base(other), "secret"), gerrors.InvalidParameter},
+		{filepath.Join(root, "big"), gerrors.InvalidParameter},
+		{filepath.Join(root, "missing"), gerrors.NotFound},
+		{"big", gerrors.InvalidParameter},
+	}
+	for _, tt := range tests {
+		_, err := run(t, cfg, &proto.TaskDispatch{
+			TaskID: "t1",
+			Type:   sbmsg.TaskCollectFile,
+			Params: map[string]string{sbmsg.ParamPath: tt.path},
+		})
+		if !gerrors.Is(err, gerrors.New(tt.code, "")) {
+			t.Errorf("collect %s: error = %v, want code %d", tt.path, err, tt.code)
+		}
+	}
+}
+
+func TestOpenBeneathSwapped(t *testing.T) {
+	root, other := t.TempDir(), t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "dir"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{filepath.Join(root, "dir", "log"), filepath.Join(other, "log")} {
+		if err := os.WriteFile(p, []byte("data"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewRunner(config.TasksConfig{Paths: []string{root}}, nil)
+	real, allowed, err := r.allowedPath(filepath.Join(root, "dir", "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Swap the checked directory for a link leading outside root.
+	if err := os.Rename(filepath.Join(root, "dir"), filepath.Join(root, "moved")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(other, filepath.Join(root, "dir")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := openBeneath(allowed, real, unix.O_RDONLY); !gerrors.Is(err, gerrors.New(gerrors.InvalidParameter, "")) {
+		t.Errorf("openBeneath: error = %v, want InvalidParameter", err)
+	}
+
+	rootFd, err := unix.Open(allowed, unix.O_RDONLY|unix.O_DIRECTORY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(rootFd)
+	if _, err := openNoFollow(rootFd, "dir/log", unix.O_RDONLY); err == nil {
+		t.Error("openNoFollow followed the swapped link")
+	}
+	fd, err := openNoFollow(rootFd, "moved/log", unix.O_RDONLY)
+	if err != nil {
+		t.Fatalf("openNoFollow: %v", err)
+	}
+	unix.Close(fd)
+}
+
+func TestListDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "file"), []byte("abc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := run(t, config.TasksConfig{Paths: []string{root}}, &proto.TaskDispatch{
+		TaskID: "t1",
+		Type:   sbmsg.TaskListDir,
+		Params: map[string]string{sbmsg.ParamPath: root},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := output(results, sbmsg.StreamEntries)
+	var entries []sbmsg.DirEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name != "file" || entries[0].Size != 3 ||
+		entries[1].Name != "sub" || !entries[1].IsDir {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestKillProcess(t *testing.T) {
+	child, err := sbproc.Start(context.Background(), "sleep", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := strconv.Itoa(child.PID())
+
+	d := &proto.TaskDispatch{
+		TaskID: "t1",
+		Type:   sbmsg.TaskKillProcess,
+		Params: map[string]string{sbmsg.ParamPID: pid, sbmsg.ParamSignal: "SIGKILL"},
+	}
+	if _, err := run(t, config.TasksConfig{}, d); !gerrors.Is(err, gerrors.New(gerrors.Unimplemented, "")) {
+		t.Fatalf("kill without allowKill: error = %v", err)
+	}
+
+	results, err := run(t, config.TasksConfig{AllowKill: true}, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := results[len(results)-1]; last.Code != int32(gerrors.Success) {
+		t.Fatalf("kill: code=%d errmsg=%s", last.Code, last.Errmsg)
+	}
+	if code, _ := child.Wait(); code != -1 {
+		t.Errorf("child exit code = %d, want killed", code)
+	}
+
+	for _, params := range []map[string]string{
+		{sbmsg.ParamPID: "1"},
+		{sbmsg.ParamPID: strconv.Itoa(os.Getpid())},
+		{sbmsg.ParamPID: pid, sbmsg.ParamSignal: "STOP"},
+	} {
+		d := &proto.TaskDispatch{TaskID: "t1", Type: sbmsg.TaskKillProcess, Params: params}
+		if _, err := run(t, config.TasksConfig{AllowKill: true}, d); !gerrors.Is(err, gerrors.New(gerrors.InvalidParameter, "")) {
+			t.Errorf("kill %v: error = %v, want InvalidParameter", params, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/inventory"
+	"os-artificer/saber/pkg/sbnet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Agents is the agent inventory served by the API (an *inventory.Registry).
+type Agents interface {
+	Get(ctx context.Context, clientID string) (*inventory.Agent, error)
+	List(ctx context.Context) ([]*inventory.Agent, error)
+}
+
+// AgentRoutes returns the inventory routes:
+//
+//	GET /api/v1/agents[?state=online|offline]
+//	GET /api/v1/agents/:clientID
+func AgentRoutes(agents Agents) []sbnet.Route {
+	return []sbnet.Route{
+		sbnet.Get("/api/v1/agents", listAgents(agents)),
+		sbnet.Get("/api/v1/agents/:clientID", getAgent(agents)),
+	}
+}
+
+func listAgents(agents Agents) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := inventory.State(c.Query("state"))
+		if state != "" && state != inventory.StateOnline && state != inventory.StateOffline {
+			writeError(c, gerrors.Newf(gerrors.InvalidParameter, "unknown state %q", state))
+			return
+		}
+
+		list, err := agents.List(c.Request.Context())
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		filtered := make([]*inventory.Agent, 0, len(list))
+		for _, a := range list {
+			if state == "" || a.State == state {
+				filtered = append(filtered, a)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"agents": filtered})
+	}
+}
+
+func getAgent(agents Agents) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		a, err := agents.Get(c.Request.Context(), c.Param("clientID"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, a)
+	}
+}
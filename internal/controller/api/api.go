@@ -0,0 +1,130 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+// Package api serves the controller's HTTP API.
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbnet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API is the controller's HTTP API server.
+type API struct {
+	enabled  bool
+	endpoint sbnet.Endpoint
+	certs    *sbnet.CertReloader
+	routes   []sbnet.Route
+
+	mu       sync.Mutex
+	listener net.Listener
+}
+
+// NewAPI returns an API server for routes on endpoint, over TLS with certs unless it is
+// nil. Routes under an auth prefix are served only to callers with a verified client
+// certificate.
+func NewAPI(enabled bool, endpoint sbnet.Endpoint, certs *sbnet.CertReloader, routes ...sbnet.Route) *API {
+	return &API{enabled: enabled, endpoint: endpoint, certs: certs, routes: routes}
+}
+
+// newServer returns the HTTP server of routes.
+func newServer(routes ...sbnet.Route) *sbnet.Server {
+	return sbnet.NewServer(sbnet.WithAuthMiddleware(authenticate), sbnet.WithRoutes(routes...))
+}
+
+// Run serves the API and blocks until Close() is called.
+// Call it in a goroutine so the main process is not blocked.
+func (a *API) Run() error {
+	if !a.enabled {
+		return nil
+	}
+
+	srv := newServer(a.routes...)
+	lis, err := net.Listen("tcp", a.endpoint.HostPort())
+	if err != nil {
+		return err
+	}
+	if a.certs != nil {
+		lis = tls.NewListener(lis, a.certs.HTTPServerConfig())
+	}
+
+	a.mu.Lock()
+	a.listener = lis
+	a.mu.Unlock()
+
+	logger.Infof("API server listening at %s", a.endpoint.String())
+	err = srv.Engine().RunListener(lis)
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// Close stops the API server.
+func (a *API) Close() error {
+	a.mu.Lock()
+	lis := a.listener
+	a.listener = nil
+	a.mu.Unlock()
+	if lis != nil {
+		return lis.Close()
+	}
+	return nil
+}
+
+// IsEnabled returns true if the API server is enabled
+func (a *API) IsEnabled() bool {
+	return a.enabled
+}
+
+// writeError reports err with the HTTP status matching its gerrors code.
+func writeError(c *gin.Context, err error) {
+	code, msg := gerrors.Failure, err.Error()
+	var ge *gerrors.Error
+	if errors.As(err, &ge) {
+		code, msg = ge.Code(), ge.Message()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		code = gerrors.Timeout
+	}
+
+	status := http.StatusInternalServerError
+	switch code {
+	case gerrors.InvalidParameter:
+		status = http.StatusBadRequest
+	case gerrors.NotFound:
+		status = http.StatusNotFound
+	case gerrors.AlreadyExists:
+		status = http.StatusConflict
+	case gerrors.Timeout:
+		status = http.StatusGatewayTimeout
+	case gerrors.Unimplemented:
+		status = http.StatusNotImplemented
+	case gerrors.ComponentFailure:
+		status = http.StatusBadGateway
+	}
+	c.JSON(status, gin.H{"code": int(code), "errmsg": msg})
+}
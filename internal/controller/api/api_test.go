@@ -0,0 +1,64 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"os-artificer/saber/pkg/gerrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{gerrors.New(gerrors.InvalidParameter, "bad"), http.StatusBadRequest},
+		{gerrors.New(gerrors.NotFound, "missing"), http.StatusNotFound},
+		{gerrors.New(gerrors.AlreadyExists, "exists"), http.StatusConflict},
+		{gerrors.New(gerrors.Timeout, "slow"), http.StatusGatewayTimeout},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{gerrors.New(gerrors.Unimplemented, "no"), http.StatusNotImplemented},
+		{gerrors.New(gerrors.ComponentFailure, "etcd down"), http.StatusBadGateway},
+		{fmt.Errorf("wrapped: %w", gerrors.New(gerrors.NotFound, "missing")), http.StatusNotFound},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		writeError(c, tt.err)
+
+		if rec.Code != tt.status {
+			t.Errorf("writeError(%v): status %d, want %d", tt.err, rec.Code, tt.status)
+		}
+		var body struct {
+			Code   int    `json:"code"`
+			Errmsg string `json:"errmsg"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Errmsg == "" {
+			t.Errorf("writeError(%v): body %s", tt.err, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package api
+
+import (
+	"net/http"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/sbnet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authPrefix is the prefix of the routes served only to authenticated callers.
+const authPrefix = "/api/v1"
+
+// callerKey is the context key holding the identity of the authenticated caller.
+const callerKey = "caller"
+
+// authenticate admits the requests made with a verified client certificate; the first
+// identity of the certificate becomes the caller of the request.
+func authenticate(c *gin.Context) {
+	if state := c.Request.TLS; state != nil && len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		if ids := sbnet.CertIdentities(state.VerifiedChains[0][0]); len(ids) > 0 {
+			c.Set(callerKey, ids[0])
+			c.Next()
+			return
+		}
+	}
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"code":   int(gerrors.InvalidParameter),
+		"errmsg": "client certificate required",
+	})
+}
+
+// caller returns the identity of the caller admitted by authenticate.
+func caller(c *gin.Context) string {
+	return c.GetString(callerKey)
+}
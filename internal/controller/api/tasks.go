@@ -0,0 +1,126 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"os-artificer/saber/internal/controller/task"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbnet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tasks dispatches tasks to the connected agents (a *task.Manager).
+type Tasks interface {
+	Dispatch(ctx context.Context, clientIDs []string, spec task.Spec) ([]*task.Task, error)
+	Get(id string) (*task.Task, error)
+	List(batchID string) []*task.Task
+	Output(id, stream string) ([]byte, error)
+}
+
+// dispatchRequest is the body of POST /api/v1/tasks. Timeout is a duration such as "30s".
+type dispatchRequest struct {
+	Agents  []string          `json:"agents"`
+	Type    string            `json:"type"`
+	Args    []string          `json:"args"`
+	Params  map[string]string `json:"params"`
+	Timeout string            `json:"timeout"`
+}
+
+// TaskRoutes returns the task routes, served only to authenticated callers:
+//
+//	POST /api/v1/tasks {"agents": [...], "type": "exec", "args": [...], "params": {...}, "timeout": "30s"}
+//	GET  /api/v1/tasks[?batch=batchID]
+//	GET  /api/v1/tasks/:taskID
+//	GET  /api/v1/tasks/:taskID/output/:stream
+func TaskRoutes(tasks Tasks) []sbnet.Route {
+	return []sbnet.Route{
+		sbnet.AuthPost(authPrefix, "/tasks", dispatchTask(tasks)),
+		sbnet.AuthGet(authPrefix, "/tasks", listTasks(tasks)),
+		sbnet.AuthGet(authPrefix, "/tasks/:taskID", getTask(tasks)),
+		sbnet.AuthGet(authPrefix, "/tasks/:taskID/output/:stream", getTaskOutput(tasks)),
+	}
+}
+
+func dispatchTask(tasks Tasks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dispatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			writeError(c, gerrors.NewE(gerrors.InvalidParameter, err))
+			return
+		}
+		logger.Infof("task dispatch requested by %s from %s: type=%s agents=%v args=%q params=%v timeout=%s",
+			caller(c), c.ClientIP(), req.Type, req.Agents, req.Args, req.Params, req.Timeout)
+
+		var timeout time.Duration
+		if req.Timeout != "" {
+			d, err := time.ParseDuration(req.Timeout)
+			if err != nil || d < 0 {
+				writeError(c, gerrors.Newf(gerrors.InvalidParameter, "invalid timeout %q", req.Timeout))
+				return
+			}
+			timeout = d
+		}
+
+		list, err := tasks.Dispatch(c.Request.Context(), req.Agents, task.Spec{
+			Type:    req.Type,
+			Args:    req.Args,
+			Params:  req.Params,
+			Timeout: timeout,
+			Caller:  caller(c),
+		})
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"batchID": list[0].BatchID, "tasks": list})
+	}
+}
+
+func listTasks(tasks Tasks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"tasks": tasks.List(c.Query("batch"))})
+	}
+}
+
+func getTask(tasks Tasks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, err := tasks.Get(c.Param("taskID"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, t)
+	}
+}
+
+// getTaskOutput serves the output of a task on one stream as received so far.
+func getTaskOutput(tasks Tasks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		out, err := tasks.Output(c.Param("taskID"), c.Param("stream"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/octet-stream", out)
+	}
+}
@@ -0,0 +1,162 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/controller/config"
+	"os-artificer/saber/internal/controller/task"
+	"os-artificer/saber/pkg/inventory"
+	"os-artificer/saber/pkg/proto"
+)
+
+// fakeSender records the clients tasks are sent to.
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (f *fakeSender) SendToClient(ctx context.Context, clientID string, resp *proto.AgentResponse) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, clientID)
+	return nil
+}
+
+// fakeAgents is an empty inventory.
+type fakeAgents struct{}
+
+func (fakeAgents) Get(ctx context.Context, clientID string) (*inventory.Agent, error) {
+	return nil, inventory.ErrAgentNotFound
+}
+
+func (fakeAgents) List(ctx context.Context) ([]*inventory.Agent, error) {
+	return nil, nil
+}
+
+func newTestHandler(t *testing.T) (http.Handler, *fakeSender) {
+	t.Helper()
+
+	sender := &fakeSender{}
+	tasks := task.NewManager(sender, config.TasksConfig{
+		DefaultTimeout: time.Minute,
+		MaxOutputBytes: 1 << 20,
+		Retention:      time.Hour,
+	})
+	routes := append(AgentRoutes(fakeAgents{}), TaskRoutes(tasks)...)
+	return newServer(routes...).Engine(), sender
+}
+
+// do serves a request on h, made with a client certificate for caller unless it is empty.
+func do(h http.Handler, method, path, body, caller string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if caller != "" {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: caller}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTaskRoutes_requireClientCert(t *testing.T) {
+	h, sender := newTestHandler(t)
+
+	body := `{"agents": ["agent-1"], "type": "exec", "args": ["id"]}`
+	if rec := do(h, http.MethodPost, "/api/v1/tasks", body, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("dispatch without certificate: status %d, want 401", rec.Code)
+	}
+	if rec := do(h, http.MethodGet, "/api/v1/tasks", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("list without certificate: status %d, want 401", rec.Code)
+	}
+	if len(sender.sent) != 0 {
+		t.Fatalf("tasks sent to %v without authentication", sender.sent)
+	}
+}
+
+func TestDispatchTask(t *testing.T) {
+	h, sender := newTestHandler(t)
+
+	body := `{"agents": ["agent-1"], "type": "exec", "args": ["id"], "timeout": "30s"}`
+	rec := do(h, http.MethodPost, "/api/v1/tasks", body, "alice")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("dispatch: status %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp struct {
+		BatchID string       `json:"batchID"`
+		Tasks   []*task.Task `json:"tasks"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.BatchID == "" || len(resp.Tasks) != 1 || resp.Tasks[0].Caller != "alice" {
+		t.Fatalf("dispatch response = %s", rec.Body)
+	}
+	if len(sender.sent) != 1 || sender.sent[0] != "agent-1" {
+		t.Fatalf("tasks sent to %v, want agent-1", sender.sent)
+	}
+
+	if rec := do(h, http.MethodGet, "/api/v1/tasks/"+resp.Tasks[0].ID, "", "alice"); rec.Code != http.StatusOK {
+		t.Fatalf("get task: status %d", rec.Code)
+	}
+}
+
+func TestDispatchTask_invalid(t *testing.T) {
+	h, sender := newTestHandler(t)
+
+	for _, body := range []string{
+		`not json`,
+		`{"agents": ["agent-1"], "type": "exec", "timeout": "soon"}`,
+		`{"agents": ["agent-1"], "type": "exec", "timeout": "-1s"}`,
+		`{"agents": [], "type": "exec"}`,
+		`{"type": "exec"}`,
+		`{"agents": ["agent-1"]}`,
+	} {
+		if rec := do(h, http.MethodPost, "/api/v1/tasks", body, "alice"); rec.Code != http.StatusBadRequest {
+			t.Errorf("dispatch %s: status %d, want 400", body, rec.Code)
+		}
+	}
+	if len(sender.sent) != 0 {
+		t.Fatalf("invalid tasks sent to %v", sender.sent)
+	}
+}
+
+func TestRoutes_notFound(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	for _, path := range []string{
+		"/api/v1/tasks/unknown",
+		"/api/v1/tasks/unknown/output/stdout",
+		"/api/v1/agents/unknown",
+	} {
+		if rec := do(h, http.MethodGet, path, "", "alice"); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, rec.Code)
+		}
+	}
+}
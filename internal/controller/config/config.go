@@ -54,6 +54,28 @@ var Cfg = Configuration{
 			Host:     "127.0.0.1",
 			Port:     26689,
 		},
+		Enrollment: EnrollmentConfig{
+			CertTTL: 90 * 24 * time.Hour,
+		},
+		Inventory: InventoryConfig{
+			OfflineAfter: 90 * time.Second,
+		},
+
+		Tasks: TasksConfig{
+			Enabled:        false,
+			DefaultTimeout: 60 * time.Second,
+			MaxOutputBytes: 32 << 20,
+			Retention:      time.Hour,
+		},
+	},
+
+	API: APIConfig{
+		Enabled: false,
+		Endpoint: sbnet.Endpoint{
+			Protocol: "http",
+			Host:     "127.0.0.1",
+			Port:     26691,
+		},
 	},
 
 	Log: LogConfig{
@@ -90,9 +112,55 @@ type APMConfig struct {
 	Endpoint sbnet.Endpoint `yaml:"endpoint"`
 }
 
-// ServiceConfig service local config
+// ServiceConfig service local config. TLS secures the agent channel; with
+// requireClientCert, agents must present a certificate matching their clientID.
+// AdvertiseAddress is the address registered for discovery, by default ListenAddress
+// with a wildcard host replaced by the pod or host IP (sbnet.AdvertisedEndpoint).
 type ServiceConfig struct {
-	ListenAddress sbnet.Endpoint `yaml:"listenAddress"`
+	ListenAddress    sbnet.Endpoint   `yaml:"listenAddress"`
+	AdvertiseAddress string           `yaml:"advertiseAddress"`
+	TLS              sbnet.TLSConfig  `yaml:"tls"`
+	Enrollment       EnrollmentConfig `yaml:"enrollment"`
+	Inventory        InventoryConfig  `yaml:"inventory"`
+	Tasks            TasksConfig      `yaml:"tasks"`
+}
+
+// EnrollmentConfig lets agents exchange a bootstrap token for a client certificate signed
+// by the internal CA. Once enabled, Connect only accepts enrolled certificates that have
+// not been revoked. Tokens and revocations are kept in etcd under the discovery
+// registryRootKeyPrefix, where the admin token and cert commands manage them.
+type EnrollmentConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	CACertFile string        `yaml:"caCertFile"`
+	CAKeyFile  string        `yaml:"caKeyFile"`
+	CertTTL    time.Duration `yaml:"certTTL"`
+}
+
+// InventoryConfig configures the agent registry. An agent whose connection carries no
+// message for OfflineAfter is disconnected and recorded offline; agents send heartbeats
+// well within it. With discovery configured, the registry is kept in etcd under the
+// registryRootKeyPrefix and shared by all controller instances.
+type InventoryConfig struct {
+	OfflineAfter time.Duration `yaml:"offlineAfter"`
+}
+
+// TasksConfig lets the API dispatch tasks to the connected agents, which run only the tasks
+// their own configuration allows. A task without a timeout gets DefaultTimeout. Up to
+// MaxOutputBytes of the output of a task is kept, for Retention after it finishes.
+type TasksConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	DefaultTimeout time.Duration `yaml:"defaultTimeout"`
+	MaxOutputBytes int64         `yaml:"maxOutputBytes"`
+	Retention      time.Duration `yaml:"retention"`
+}
+
+// APIConfig is the HTTP API serving the agent inventory and tasks. The task routes need
+// TLS with requireClientCert: callers authenticate with a client certificate verified
+// against tls.caFile, and every dispatch is logged with its identity.
+type APIConfig struct {
+	Enabled  bool            `yaml:"enabled"`
+	Endpoint sbnet.Endpoint  `yaml:"endpoint"`
+	TLS      sbnet.TLSConfig `yaml:"tls"`
 }
 
 // LogConfig log config
@@ -110,6 +178,7 @@ type Configuration struct {
 	Version   string          `yaml:"version"`
 	Discovery DiscoveryConfig `yaml:"discovery"`
 	APM       APMConfig       `yaml:"apm"`
+	API       APIConfig       `yaml:"api"`
 	Service   ServiceConfig   `yaml:"service"`
 	Log       LogConfig       `yaml:"log"`
 }
@@ -18,6 +18,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"os-artificer/saber/pkg/logger"
 	"os-artificer/saber/pkg/sbnet"
@@ -44,6 +45,15 @@ func TestDefaultCfg(t *testing.T) {
 	if Cfg.Discovery.RegistryTTL != 60 {
 		t.Errorf("Cfg.Discovery.RegistryTTL = %d, want 60", Cfg.Discovery.RegistryTTL)
 	}
+	if Cfg.Service.Inventory.OfflineAfter != 90*time.Second {
+		t.Errorf("Cfg.Service.Inventory.OfflineAfter = %s, want 90s", Cfg.Service.Inventory.OfflineAfter)
+	}
+	if Cfg.Service.Tasks.Enabled {
+		t.Errorf("Cfg.Service.Tasks.Enabled = true, want false")
+	}
+	if Cfg.API.Enabled {
+		t.Errorf("Cfg.API.Enabled = true, want false")
+	}
 	if Cfg.Log.FileName != "./logs/controller.log" {
 		t.Errorf("Cfg.Log.FileName = %q, want %q", Cfg.Log.FileName, "./logs/controller.log")
 	}
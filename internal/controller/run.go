@@ -44,7 +44,11 @@ func Run(cmd *cobra.Command, args []string) error {
 
 	loadControllerConfig()
 
-	svr := CreateService(ctx, config.Cfg.Service.ListenAddress, "")
+	svr, err := CreateService(ctx, config.Cfg.Service.ListenAddress, "")
+	if err != nil {
+		logger.Errorf("Failed to create controller service: %v", err)
+		return err
+	}
 
 	setupGracefulShutdown(svr)
 
@@ -24,13 +24,19 @@ import (
 	"sync"
 	"time"
 
+	"os-artificer/saber/internal/controller/task"
 	"os-artificer/saber/pkg/constant"
+	"os-artificer/saber/pkg/inventory"
 	"os-artificer/saber/pkg/logger"
 	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
 	"os-artificer/saber/pkg/sbnet"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
 )
 
 var ErrConnectionNotFound = errors.New("connection not found")
@@ -39,9 +45,12 @@ var ErrConnectionNotFound = errors.New("connection not found")
 func New(ctx context.Context, address sbnet.Endpoint, serviceID string) *AgentServer {
 	_ = serviceID
 	return &AgentServer{
-		ctx:     ctx,
-		address: address,
-		manager: NewConnectionManager(),
+		ctx:      ctx,
+		address:  address,
+		manager:  NewConnectionManager(),
+		creds:    insecure.NewCredentials(),
+		handlers: make(map[sbmsg.Kind]MessageHandler),
+		pending:  sbmsg.NewPending(),
 	}
 }
 
@@ -52,6 +61,27 @@ type AgentServer struct {
 	address sbnet.Endpoint
 	manager *ConnectionManager
 	grpcSvr *grpc.Server
+	creds   credentials.TransportCredentials
+
+	// enroller, when set, issues certificates and gates Connect on them.
+	enroller *Enroller
+
+	// registry, when set, records the agents and their online state.
+	registry     *inventory.Registry
+	offlineAfter time.Duration
+
+	// tasks, when set, collects the results of the tasks dispatched to the agents.
+	tasks *task.Manager
+
+	handlersMu sync.RWMutex
+	handlers   map[sbmsg.Kind]MessageHandler
+	pending    *sbmsg.Pending
+}
+
+// UseCredentials makes the server authenticate with creds. With mutual TLS, the clientID
+// of an agent's first message must match its certificate. Call it before Run.
+func (s *AgentServer) UseCredentials(creds credentials.TransportCredentials) {
+	s.creds = creds
 }
 
 // extractClientInfo is unused; clientID is read from first AgentRequest in Connect.
@@ -77,6 +107,13 @@ func (s *AgentServer) SendToClient(ctx context.Context, clientID string, resp *p
 }
 
 func (s *AgentServer) Connect(stream proto.ControllerService_ConnectServer) error {
+	if s.enroller != nil {
+		if err := s.enroller.verifyEnrolled(stream.Context()); err != nil {
+			logger.Warnf("connection rejected: %v", err)
+			return err
+		}
+	}
+
 	// Read first message to get clientID; same clientID reconnecting will close previous session.
 	req, err := stream.Recv()
 	if err != nil {
@@ -85,6 +122,15 @@ func (s *AgentServer) Connect(stream proto.ControllerService_ConnectServer) erro
 	clientID := req.GetClientID()
 	if clientID == "" {
 		clientID = fmt.Sprintf("client-%d", time.Now().UnixNano())
+		if cert := sbnet.PeerCertificate(stream.Context()); cert != nil {
+			if ids := sbnet.CertIdentities(cert); len(ids) > 0 {
+				clientID = ids[0]
+			}
+		}
+	}
+	if err := sbnet.VerifyClientID(stream.Context(), clientID); err != nil {
+		logger.Warnf("connection rejected: %v", err)
+		return err
 	}
 	metadata := make(map[string]string)
 	if h := req.GetHeaders(); h != nil {
@@ -100,29 +146,43 @@ func (s *AgentServer) Connect(stream proto.ControllerService_ConnectServer) erro
 		LastActive: time.Now(),
 		Metadata:   metadata,
 		FirstReq:   req,
+		dispatch:   s.dispatch,
 	}
 
 	s.manager.Register(clientID, conn) // closes any existing connection with same clientID
-	defer s.manager.Unregister(clientID)
-
-	wg := &sync.WaitGroup{}
-
-	wg.Add(2)
-
-	go func() {
-		defer wg.Done()
-		conn.sendMessages(s.ctx)
-	}()
-
-	go func() {
-		defer wg.Done()
-		conn.receiveMessages(s.ctx)
-	}()
+	defer s.release(conn)
+	if s.registry != nil {
+		remoteAddr := ""
+		if p, ok := peer.FromContext(stream.Context()); ok {
+			remoteAddr = p.Addr.String()
+		}
+		s.registry.Connected(clientID, remoteAddr, conn.LastActive)
+	}
 
-	wg.Wait()
+	// The receive loop closes the connection when the stream fails, and the liveness check
+	// or a revocation closes it from outside; either way sendMessages returns. Returning ends the stream, which
+	// also stops a receive loop still blocked in Recv.
+	go conn.receiveMessages(s.ctx)
+	conn.sendMessages(s.ctx)
 	return nil
 }
 
+// release unregisters conn and, unless the agent has already reconnected, fails its running
+// tasks and records it offline.
+func (s *AgentServer) release(conn *Connection) {
+	if !s.manager.Release(conn) {
+		return
+	}
+	if s.tasks != nil {
+		s.tasks.Disconnected(conn.ClientID, time.Now())
+	}
+	if s.registry == nil {
+		return
+	}
+	s.registry.Seen(conn.ClientID, conn.lastActive())
+	s.registry.Disconnected(conn.ClientID, time.Now())
+}
+
 func (s *AgentServer) Run() error {
 
 	kasp := keepalive.ServerParameters{
@@ -136,12 +196,17 @@ func (s *AgentServer) Run() error {
 	}
 
 	svr := grpc.NewServer(
+		grpc.Creds(s.creds),
 		grpc.KeepaliveParams(kasp),
 		grpc.KeepaliveEnforcementPolicy(kacp),
 		grpc.MaxRecvMsgSize(constant.DefaultMaxReceiveMessageSize),
 		grpc.MaxSendMsgSize(constant.DefaultMaxSendMessageSize),
 	)
 
+	if s.registry != nil {
+		go s.watchLiveness(s.ctx)
+	}
+
 	proto.RegisterControllerServiceServer(svr, s)
 	s.grpcSvr = svr
 	lis, err := net.Listen(s.address.Protocol, s.address.HostPort())
@@ -24,6 +24,9 @@ import (
 
 	"os-artificer/saber/pkg/logger"
 	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 var (
@@ -40,6 +43,9 @@ type Connection struct {
 	FirstReq   *proto.AgentRequest // first message already read in Connect to get clientID
 	mu         sync.RWMutex
 	closed     bool
+
+	// dispatch, when set, handles the envelopes received from the client.
+	dispatch func(ctx context.Context, c *Connection, env *proto.Envelope)
 }
 
 func (c *Connection) sendMessages(ctx context.Context) {
@@ -70,8 +76,7 @@ func (c *Connection) receiveMessages(ctx context.Context) {
 	c.FirstReq = nil
 	c.mu.Unlock()
 	if firstReq != nil {
-		c.updateLastActive()
-		logger.Debugf("Received from %s: %v", c.ClientID, firstReq.GetPayload())
+		c.handle(ctx, firstReq)
 	}
 
 	for {
@@ -90,19 +95,34 @@ func (c *Connection) receiveMessages(ctx context.Context) {
 				return
 			}
 
-			c.updateLastActive()
-
-			logger.Debugf("Received from %s: %v", c.ClientID, msg.GetPayload())
+			c.handle(ctx, msg)
 		}
 	}
 }
 
+// handle dispatches the envelope of msg; requests without one carry an opaque payload.
+func (c *Connection) handle(ctx context.Context, msg *proto.AgentRequest) {
+	c.updateLastActive()
+
+	if env := msg.GetEnvelope(); env != nil && c.dispatch != nil {
+		c.dispatch(ctx, c, env)
+		return
+	}
+	logger.Debugf("Received from %s: %v", c.ClientID, msg.GetPayload())
+}
+
 func (c *Connection) updateLastActive() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.LastActive = time.Now()
 }
 
+func (c *Connection) lastActive() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.LastActive
+}
+
 func (c *Connection) isClosed() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -133,3 +153,17 @@ func (c *Connection) TrySend(msg *proto.AgentResponse) error {
 		return ErrSendChanFull
 	}
 }
+
+// SendMessage enqueues env for sending to the client, as TrySend does.
+func (c *Connection) SendMessage(env *proto.Envelope) error {
+	return c.TrySend(&proto.AgentResponse{Envelope: env})
+}
+
+// Reply enqueues body for sending to the client as the reply to env.
+func (c *Connection) Reply(env *proto.Envelope, body protoreflect.ProtoMessage) error {
+	resp, err := sbmsg.Reply(env, body)
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(resp)
+}
@@ -54,6 +54,32 @@ func (m *ConnectionManager) Unregister(clientID string) {
 	}
 }
 
+// Release removes conn and closes it if it is still the connection registered for its
+// clientID; it reports whether it was. A session replaced by a reconnect is not released twice.
+func (m *ConnectionManager) Release(conn *Connection) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conn.close()
+	if m.connections[conn.ClientID] != conn {
+		return false
+	}
+	delete(m.connections, conn.ClientID)
+	return true
+}
+
+// Connections returns the registered connections.
+func (m *ConnectionManager) Connections() []*Connection {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	conns := make([]*Connection, 0, len(m.connections))
+	for _, conn := range m.connections {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
 // Get returns the connection for clientID, or (nil, false) if not found.
 func (m *ConnectionManager) Get(clientID string) (*Connection, bool) {
 	m.mu.RLock()
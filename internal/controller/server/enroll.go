@@ -0,0 +1,162 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package server
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"os-artificer/saber/pkg/enrollment"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbnet"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Enroller issues agent certificates in exchange for bootstrap tokens and tells which
+// certificates have been revoked.
+type Enroller struct {
+	ca      *enrollment.CA
+	store   *enrollment.Store
+	revoked *enrollment.RevocationList
+	certTTL time.Duration
+}
+
+// NewEnroller returns an Enroller signing with ca certificates valid for certTTL.
+func NewEnroller(ca *enrollment.CA, store *enrollment.Store, revoked *enrollment.RevocationList,
+	certTTL time.Duration) *Enroller {
+
+	return &Enroller{ca: ca, store: store, revoked: revoked, certTTL: certTTL}
+}
+
+// UseEnrollment serves Enroll with e and makes Connect require an enrolled client
+// certificate that has not been revoked. Revoked agents are disconnected. Call it before Run.
+func (s *AgentServer) UseEnrollment(e *Enroller) {
+	s.enroller = e
+	e.revoked.Subscribe(s.revoke)
+}
+
+// revoke disconnects the agent of c if it is connected with the revoked certificate. An
+// agent that has connected again with a renewed certificate stays connected.
+func (s *AgentServer) revoke(c *enrollment.CertInfo) {
+	conn, ok := s.manager.Get(c.ClientID)
+	if !ok {
+		return
+	}
+	if cert := sbnet.PeerCertificate(conn.Stream.Context()); cert == nil || enrollment.SerialString(cert) != c.Serial {
+		return
+	}
+	logger.Infof("disconnecting agent with revoked certificate: clientID=%s serial=%s", c.ClientID, c.Serial)
+	s.release(conn)
+}
+
+// Enroll validates the bootstrap token of req and signs its CSR for req.ClientID. A client
+// that already holds a live certificate is refused until an operator revokes it. Without
+// a token, Enroll renews the client certificate the peer authenticates with.
+// Failures are reported in the response code, like AgentResponse.
+func (s *AgentServer) Enroll(ctx context.Context, req *proto.EnrollRequest) (*proto.EnrollResponse, error) {
+	e := s.enroller
+	if e == nil {
+		return nil, status.Error(codes.Unimplemented, "enrollment is disabled")
+	}
+
+	clientID := req.GetClientID()
+	if clientID == "" || strings.ContainsAny(clientID, "/") {
+		return enrollError(gerrors.New(gerrors.InvalidParameter, "invalid clientID")), nil
+	}
+
+	csr, err := enrollment.ParseCSR(req.GetCsr())
+	if err != nil {
+		return enrollError(err), nil
+	}
+
+	// The token is checked last: a malformed request must not use up a one-time token. It
+	// is consumed together with recording the certificate, so that a refused enrollment
+	// leaves it in place.
+	renewal := req.GetToken() == ""
+	if renewal {
+		if err := e.verifyRenewal(ctx, clientID); err != nil {
+			logger.Warnf("enrollment rejected: clientID=%s, renewal=%t, err: %v", clientID, renewal, err)
+			return enrollError(err), nil
+		}
+	}
+
+	cert, certPEM, err := e.ca.Sign(csr, clientID, e.certTTL)
+	if err != nil {
+		return enrollError(err), nil
+	}
+	if renewal {
+		err = e.store.RecordRenewal(ctx, clientID, cert)
+	} else {
+		_, err = e.store.RecordCertificate(ctx, req.GetToken(), clientID, cert)
+	}
+	if err != nil {
+		logger.Warnf("enrollment rejected: clientID=%s, renewal=%t, err: %v", clientID, renewal, err)
+		return enrollError(err), nil
+	}
+
+	logger.Infof("agent enrolled: clientID=%s serial=%s notAfter=%s renewal=%t",
+		clientID, enrollment.SerialString(cert), cert.NotAfter.Format(time.RFC3339), renewal)
+	return &proto.EnrollResponse{
+		Code:          int32(gerrors.Success),
+		Certificate:   certPEM,
+		CaCertificate: e.ca.CertificatePEM(),
+	}, nil
+}
+
+// verifyRenewal checks that the peer of ctx may renew the certificate of clientID: it
+// presents a certificate for clientID that has not expired or been revoked.
+func (e *Enroller) verifyRenewal(ctx context.Context, clientID string) error {
+	cert := sbnet.PeerCertificate(ctx)
+	if cert == nil {
+		return enrollment.ErrInvalidToken
+	}
+	if e.revoked.IsRevoked(cert) {
+		return gerrors.Newf(gerrors.InvalidParameter, "client certificate %s has been revoked",
+			enrollment.SerialString(cert))
+	}
+	if !slices.Contains(sbnet.CertIdentities(cert), clientID) {
+		return gerrors.Newf(gerrors.InvalidParameter, "client certificate is not issued to %s", clientID)
+	}
+	return nil
+}
+
+// verifyEnrolled checks that the peer of ctx presents a certificate that has not been revoked.
+func (e *Enroller) verifyEnrolled(ctx context.Context) error {
+	cert := sbnet.PeerCertificate(ctx)
+	if cert == nil {
+		return status.Error(codes.Unauthenticated, "client certificate required, enroll the agent first")
+	}
+	if e.revoked.IsRevoked(cert) {
+		return status.Errorf(codes.PermissionDenied, "client certificate %s has been revoked",
+			enrollment.SerialString(cert))
+	}
+	return nil
+}
+
+func enrollError(err error) *proto.EnrollResponse {
+	var ge *gerrors.Error
+	if gerrors.As(err, &ge) {
+		return &proto.EnrollResponse{Code: int32(ge.Code()), Errmsg: ge.Message()}
+	}
+	return &proto.EnrollResponse{Code: int32(gerrors.ComponentFailure), Errmsg: err.Error()}
+}
@@ -0,0 +1,71 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"testing"
+
+	"os-artificer/saber/pkg/enrollment"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbnet"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// certStream is a Connect stream authenticated with a client certificate.
+type certStream struct {
+	grpc.BidiStreamingServer[proto.AgentRequest, proto.AgentResponse]
+	ctx context.Context
+}
+
+func (s *certStream) Context() context.Context { return s.ctx }
+
+func newCertStream(serial int64) proto.ControllerService_ConnectServer {
+	cert := &x509.Certificate{SerialNumber: big.NewInt(serial)}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+		},
+	})
+	return &certStream{ctx: ctx}
+}
+
+func TestAgentServer_revoke(t *testing.T) {
+	s := New(context.Background(), sbnet.Endpoint{}, "")
+	conn := &Connection{
+		ClientID: "agent-1",
+		Stream:   newCertStream(2),
+		SendChan: make(chan *proto.AgentResponse, 1),
+	}
+	s.manager.Register(conn.ClientID, conn)
+
+	// The agent connected with its renewed certificate; revoking the old one keeps it.
+	s.revoke(&enrollment.CertInfo{ClientID: "agent-1", Serial: "1"})
+	if _, ok := s.manager.Get("agent-1"); !ok || conn.isClosed() {
+		t.Fatal("connection with the renewed certificate closed")
+	}
+
+	s.revoke(&enrollment.CertInfo{ClientID: "agent-1", Serial: "2"})
+	if _, ok := s.manager.Get("agent-1"); ok || !conn.isClosed() {
+		t.Fatal("connection with the revoked certificate still open")
+	}
+}
@@ -0,0 +1,90 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package server
+
+import (
+	"context"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MessageHandler handles an envelope received from the agent on conn. Handlers run on the
+// connection's receive loop, so long-running work belongs in its own goroutine. A returned
+// error is reported to the agent in an Ack.
+type MessageHandler func(ctx context.Context, conn *Connection, env *proto.Envelope) error
+
+// Handle registers h for the envelopes of the given kind, replacing any previous handler.
+func (s *AgentServer) Handle(kind sbmsg.Kind, h MessageHandler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.handlers[kind] = h
+}
+
+// SendMessage sends env to the connected client identified by clientID, as SendToClient does.
+func (s *AgentServer) SendMessage(ctx context.Context, clientID string, env *proto.Envelope) error {
+	return s.SendToClient(ctx, clientID, &proto.AgentResponse{Envelope: env})
+}
+
+// Request sends body to the client identified by clientID and waits for the reply, or for
+// ctx to be done. An Ack with a non-zero code is returned as an error.
+func (s *AgentServer) Request(
+	ctx context.Context,
+	clientID string,
+	body protoreflect.ProtoMessage) (*proto.Envelope, error) {
+	req, err := sbmsg.New(body)
+	if err != nil {
+		return nil, err
+	}
+	return s.pending.Call(ctx, clientID, req, func(env *proto.Envelope) error {
+		return s.SendMessage(ctx, clientID, env)
+	})
+}
+
+// dispatch hands env to the request waiting for it, or to the handler of its kind. Only the
+// client a request was sent to can reply to it.
+func (s *AgentServer) dispatch(ctx context.Context, conn *Connection, env *proto.Envelope) {
+	if s.pending.Resolve(conn.ClientID, env) {
+		return
+	}
+
+	kind := sbmsg.KindOf(env)
+	s.handlersMu.RLock()
+	h := s.handlers[kind]
+	s.handlersMu.RUnlock()
+
+	var err error
+	if h == nil {
+		err = gerrors.Newf(gerrors.Unimplemented, "no handler for %q messages", kind)
+	} else {
+		err = h(ctx, conn, env)
+	}
+	if err == nil {
+		return
+	}
+
+	logger.Debugf("message %s (%s) from %s: %v", env.GetId(), kind, conn.ClientID, err)
+	if sbmsg.NeedsAck(env) {
+		if err := conn.SendMessage(sbmsg.NewAck(env, err)); err != nil {
+			logger.Warnf("ack message %s to %s: %v", env.GetId(), conn.ClientID, err)
+		}
+	}
+}
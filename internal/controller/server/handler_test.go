@@ -0,0 +1,134 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/controller"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+	"os-artificer/saber/pkg/sbnet"
+)
+
+// startServer runs a server configured by setup on a free port and returns its address.
+func startServer(t *testing.T, setup func(s *AgentServer)) (*AgentServer, string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := "tcp://" + lis.Addr().String()
+	_ = lis.Close()
+
+	ep, err := sbnet.NewEndpointFromString(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := New(ctx, *ep, "")
+	if setup != nil {
+		setup(s)
+	}
+	go func() { _ = s.Run() }()
+	t.Cleanup(func() {
+		cancel()
+		_ = s.Close()
+	})
+	return s, addr
+}
+
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestAgentServer_messages(t *testing.T) {
+	s, addr := startServer(t, nil)
+
+	s.Handle(sbmsg.KindHeartbeat, func(ctx context.Context, conn *Connection, env *proto.Envelope) error {
+		return conn.Reply(env, &proto.Heartbeat{Uptime: env.GetHeartbeat().GetUptime() + 1})
+	})
+	s.Handle(sbmsg.KindRegister, func(ctx context.Context, conn *Connection, env *proto.Envelope) error {
+		return gerrors.New(gerrors.InvalidParameter, "hostname is required")
+	})
+
+	client := controller.NewControllerClient(context.Background(), addr, "agent-1")
+	client.Handle(sbmsg.KindTaskDispatch, func(ctx context.Context, env *proto.Envelope) error {
+		task := env.GetTaskDispatch()
+		return client.Reply(ctx, env, &proto.TaskResult{TaskID: task.GetTaskID(), Done: true})
+	})
+	go func() { _ = client.Run() }()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Agent to controller, once the stream is up.
+	var resp *proto.Envelope
+	eventually(t, func() bool {
+		reqCtx, reqCancel := context.WithTimeout(ctx, time.Second)
+		defer reqCancel()
+
+		var err error
+		resp, err = client.Request(reqCtx, &proto.Heartbeat{Uptime: 41})
+		return err == nil
+	})
+	if got := resp.GetHeartbeat().GetUptime(); got != 42 {
+		t.Fatalf("heartbeat reply uptime = %d, want 42", got)
+	}
+
+	_, err := client.Request(ctx, &proto.RegisterInfo{})
+	if !gerrors.Is(err, gerrors.New(gerrors.InvalidParameter, "")) {
+		t.Fatalf("register error = %v, want InvalidParameter", err)
+	}
+	_, err = client.Request(ctx, &proto.ConfigPush{})
+	if !gerrors.Is(err, gerrors.New(gerrors.Unimplemented, "")) {
+		t.Fatalf("unhandled message error = %v, want Unimplemented", err)
+	}
+
+	// Controller to agent.
+	resp, err = s.Request(ctx, "agent-1", &proto.TaskDispatch{TaskID: "task-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := resp.GetTaskResult(); result.GetTaskID() != "task-1" || !result.GetDone() {
+		t.Fatalf("task reply = %v", resp)
+	}
+
+	_, err = s.Request(ctx, "agent-1", &proto.ConfigPush{Name: "plugins"})
+	if !gerrors.Is(err, gerrors.New(gerrors.Unimplemented, "")) {
+		t.Fatalf("unhandled message error = %v, want Unimplemented", err)
+	}
+
+	if _, err := s.Request(ctx, "agent-2", &proto.Heartbeat{}); err != ErrConnectionNotFound {
+		t.Fatalf("request to unknown agent error = %v, want ErrConnectionNotFound", err)
+	}
+}
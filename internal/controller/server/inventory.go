@@ -0,0 +1,70 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"os-artificer/saber/pkg/inventory"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+)
+
+// UseRegistry records the connected agents in r: what they report in register messages,
+// their activity, and their online state. A connection without any message for
+// offlineAfter is closed and its agent goes offline. Call it before Run.
+func (s *AgentServer) UseRegistry(r *inventory.Registry, offlineAfter time.Duration) {
+	s.registry = r
+	s.offlineAfter = offlineAfter
+
+	s.Handle(sbmsg.KindRegister, func(ctx context.Context, conn *Connection, env *proto.Envelope) error {
+		r.Register(conn.ClientID, env.GetRegister(), time.Now())
+		return conn.SendMessage(sbmsg.NewAck(env, nil))
+	})
+	s.Handle(sbmsg.KindHeartbeat, func(ctx context.Context, conn *Connection, env *proto.Envelope) error {
+		r.Seen(conn.ClientID, time.Now())
+		return nil
+	})
+}
+
+// watchLiveness copies the activity of the connections to the registry and closes the
+// connections that have been silent for offlineAfter.
+func (s *AgentServer) watchLiveness(ctx context.Context) {
+	ticker := time.NewTicker(max(s.offlineAfter/3, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-ticker.C:
+			for _, conn := range s.manager.Connections() {
+				lastActive := conn.lastActive()
+				s.registry.Seen(conn.ClientID, lastActive)
+
+				if now.Sub(lastActive) > s.offlineAfter {
+					logger.Warnf("agent %s silent since %s, closing its connection",
+						conn.ClientID, lastActive.Format(time.RFC3339))
+					s.release(conn)
+				}
+			}
+		}
+	}
+}
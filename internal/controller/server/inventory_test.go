@@ -0,0 +1,77 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/controller"
+	"os-artificer/saber/pkg/inventory"
+	"os-artificer/saber/pkg/proto"
+)
+
+func TestAgentServer_inventory(t *testing.T) {
+	registry := inventory.NewRegistry(nil, "c1", time.Minute)
+
+	var (
+		mu     sync.Mutex
+		events []inventory.State
+	)
+	registry.Subscribe(func(ev inventory.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev.State)
+	})
+	states := func() []inventory.State {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]inventory.State(nil), events...)
+	}
+
+	_, addr := startServer(t, func(s *AgentServer) {
+		s.UseRegistry(registry, time.Second)
+	})
+
+	// The client announces itself but sends no heartbeat, so it falls silent.
+	client := controller.NewControllerClient(context.Background(), addr, "agent-1")
+	client.OnConnect(func(ctx context.Context) {
+		_, _ = client.Request(ctx, &proto.RegisterInfo{Hostname: "web-1", AgentVersion: "v1.0"})
+	})
+	go func() { _ = client.Run() }()
+	defer client.Close()
+
+	eventually(t, func() bool {
+		a, err := registry.Get(context.Background(), "agent-1")
+		return err == nil && a.Hostname == "web-1" && a.State == inventory.StateOnline
+	})
+
+	eventually(t, func() bool {
+		s := states()
+		return len(s) == 2 && s[0] == inventory.StateOnline && s[1] == inventory.StateOffline
+	})
+
+	a, err := registry.Get(context.Background(), "agent-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.State != inventory.StateOffline || a.AgentVersion != "v1.0" || a.RemoteAddr == "" {
+		t.Fatalf("agent after timeout = %+v", a)
+	}
+}
@@ -0,0 +1,43 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"os-artificer/saber/internal/controller/task"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+)
+
+// UseTasks collects in m the results of the tasks it dispatches: the results the agents
+// stream back, their refusals, and the loss of their connection. Call it before Run.
+func (s *AgentServer) UseTasks(m *task.Manager) {
+	s.tasks = m
+
+	s.Handle(sbmsg.KindTaskResult, func(ctx context.Context, conn *Connection, env *proto.Envelope) error {
+		return m.Result(conn.ClientID, env.GetTaskResult(), time.Now())
+	})
+	s.Handle(sbmsg.KindAck, func(ctx context.Context, conn *Connection, env *proto.Envelope) error {
+		if !m.Ack(conn.ClientID, env, time.Now()) {
+			logger.Debugf("unexpected ack of message %s from %s", env.GetReplyTo(), conn.ClientID)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/agent/config"
+	"os-artificer/saber/internal/agent/controller"
+	agenttask "os-artificer/saber/internal/agent/task"
+	controllerconfig "os-artificer/saber/internal/controller/config"
+	"os-artificer/saber/internal/controller/task"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/sbmsg"
+)
+
+func TestAgentServer_tasks(t *testing.T) {
+	var m *task.Manager
+	s, addr := startServer(t, func(s *AgentServer) {
+		m = task.NewManager(s, controllerconfig.TasksConfig{DefaultTimeout: 10 * time.Second, MaxOutputBytes: 1 << 20})
+		s.UseTasks(m)
+	})
+
+	client := controller.NewControllerClient(context.Background(), addr, "agent-1")
+	runner := agenttask.NewRunner(config.TasksConfig{
+		Commands:       []string{"/bin/sh", "/bin/sleep"},
+		MaxConcurrent:  4,
+		MaxOutputBytes: 1 << 20,
+	}, client.Reply)
+	client.Handle(sbmsg.KindTaskDispatch, runner.Handle)
+	go func() { _ = client.Run() }()
+	defer client.Close()
+
+	eventually(t, func() bool {
+		_, ok := s.manager.Get("agent-1")
+		return ok
+	})
+
+	ctx := context.Background()
+	tasks, err := m.Dispatch(ctx, []string{"agent-1", "agent-2"}, task.Spec{
+		Type: sbmsg.TaskExec,
+		Args: []string{"sh", "-c", "echo hello; exit 2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].ClientID != "agent-1" || tasks[0].State != task.StateRunning ||
+		tasks[1].State != task.StateFailed {
+		t.Fatalf("dispatched tasks = %+v", tasks)
+	}
+
+	eventually(t, func() bool {
+		got, err := m.Get(tasks[0].ID)
+		return err == nil && got.State == task.StateDone && got.ExitCode == 2
+	})
+	if out, _ := m.Output(tasks[0].ID, sbmsg.StreamStdout); string(out) != "hello\n" {
+		t.Errorf("stdout = %q", out)
+	}
+
+	// Refused by the agent.
+	refused, err := m.Dispatch(ctx, []string{"agent-1"}, task.Spec{Type: sbmsg.TaskExec, Args: []string{"rm"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool {
+		got, err := m.Get(refused[0].ID)
+		return err == nil && got.State == task.StateFailed && got.Code == int32(gerrors.InvalidParameter)
+	})
+
+	// Still running when the agent goes away.
+	running, err := m.Dispatch(ctx, []string{"agent-1"}, task.Spec{Type: sbmsg.TaskExec, Args: []string{"sleep", "10"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, _ := s.manager.Get("agent-1")
+	s.release(conn)
+	got, err := m.Get(running[0].ID)
+	if err != nil || got.State != task.StateFailed || got.Code != int32(gerrors.ComponentFailure) {
+		t.Fatalf("task after disconnect = %+v, err: %v", got, err)
+	}
+}
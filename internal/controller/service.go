@@ -23,17 +23,24 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
+	"os-artificer/saber/internal/controller/api"
 	"os-artificer/saber/internal/controller/apm"
 	"os-artificer/saber/internal/controller/config"
 	"os-artificer/saber/internal/controller/server"
+	"os-artificer/saber/internal/controller/task"
 	"os-artificer/saber/pkg/discovery"
+	"os-artificer/saber/pkg/enrollment"
+	"os-artificer/saber/pkg/inventory"
 	"os-artificer/saber/pkg/logger"
 	"os-artificer/saber/pkg/sbnet"
 
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 // controllerUnmarshalOpt composes default viper hooks with string->Endpoint so
@@ -50,16 +57,54 @@ type Service struct {
 	apm             *apm.APM
 	discoveryClient *discovery.Client
 	registry        *discovery.Registry
+	tls             *sbnet.CertReloader
+	api             *api.API
+	apiTLS          *sbnet.CertReloader
+	serviceID       string
+	agents          *inventory.Registry
+	tasks           *task.Manager
+
+	// etcdCli serves enrollment and the agent registry; bgCancel stops their background
+	// work on Close, which waits for it on bgWg before closing etcdCli.
+	etcdCli  *clientv3.Client
+	bgCtx    context.Context
+	bgCancel context.CancelFunc
+	bgWg     sync.WaitGroup
 }
 
 // CreateService creates a new controller service. APM is initialized later in Run() via InitAPM().
-func CreateService(ctx context.Context, address sbnet.Endpoint, serviceID string) *Service {
+// The agent channel is secured with config.Cfg.Service.TLS when enabled.
+func CreateService(ctx context.Context, address sbnet.Endpoint, serviceID string) (*Service, error) {
+	tlsCfg := config.Cfg.Service.TLS
+	if enroll := &config.Cfg.Service.Enrollment; enroll.Enabled {
+		// Agents call Enroll before they have a certificate, so the handshake must not
+		// require one; Connect requires it instead.
+		if !tlsCfg.Enabled || tlsCfg.RequireClientCert {
+			return nil, fmt.Errorf("enrollment requires service.tls enabled without requireClientCert")
+		}
+		if tlsCfg.CAFile == "" {
+			tlsCfg.CAFile = enroll.CACertFile
+		}
+	}
+
+	certs, err := sbnet.NewCertReloader(tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+
 	svr := server.New(ctx, address, serviceID)
+	if certs != nil {
+		svr.UseCredentials(certs.ServerCredentials())
+	}
+	bgCtx, bgCancel := context.WithCancel(context.Background())
 	return &Service{
 		svr:      svr,
 		apm:      nil,
 		registry: nil,
-	}
+		tls:      certs,
+		bgCtx:    bgCtx,
+		bgCancel: bgCancel,
+	}, nil
 }
 
 // InitLogger initializes the global logger from config.Cfg.Log (pkg/logger).
@@ -84,7 +129,8 @@ func (s *Service) InitAPM() error {
 	return nil
 }
 
-// ReloadConfig re-reads config from ConfigFilePath and re-inits logger (for SIGHUP).
+// ReloadConfig re-reads config from ConfigFilePath, re-inits logger and reloads the TLS
+// certificates (for SIGHUP).
 func (s *Service) ReloadConfig() error {
 	if ConfigFilePath == "" {
 		return nil
@@ -100,6 +146,12 @@ func (s *Service) ReloadConfig() error {
 	if err := s.InitLogger(); err != nil {
 		return err
 	}
+	if err := s.tls.Reload(); err != nil {
+		return err
+	}
+	if err := s.apiTLS.Reload(); err != nil {
+		return err
+	}
 	logger.Infof("config reloaded")
 	return nil
 }
@@ -135,6 +187,147 @@ func buildDiscoveryTLS(cfg *config.DiscoveryConfig) (*tls.Config, error) {
 	return tlsCfg, nil
 }
 
+// InitEnrollment loads the internal CA and starts following the certificate revocations in
+// etcd when enrollment is enabled. It needs the discovery client of RegisterSelf.
+func (s *Service) InitEnrollment() error {
+	cfg := &config.Cfg.Service.Enrollment
+	if !cfg.Enabled {
+		return nil
+	}
+	if s.discoveryClient == nil {
+		return fmt.Errorf("enrollment requires discovery etcdEndpoint")
+	}
+
+	ca, err := enrollment.LoadCA(cfg.CACertFile, cfg.CAKeyFile)
+	if err != nil {
+		return err
+	}
+
+	cli, err := s.etcdClient()
+	if err != nil {
+		return err
+	}
+
+	store := enrollment.NewStore(cli, config.Cfg.Discovery.RegistryRootKeyPrefix)
+	revoked := enrollment.NewRevocationList(store)
+
+	// Load the revocations before serving so that no revoked agent slips in at startup.
+	syncCtx, syncCancel := context.WithTimeout(context.Background(), config.Cfg.Discovery.DialTimeout)
+	_, err = revoked.Sync(syncCtx)
+	syncCancel()
+	if err != nil {
+		return fmt.Errorf("load certificate revocations: %w", err)
+	}
+
+	s.bgWg.Add(1)
+	go func() {
+		defer s.bgWg.Done()
+		_ = revoked.Run(s.bgCtx)
+	}()
+
+	s.svr.UseEnrollment(server.NewEnroller(ca, store, revoked, cfg.CertTTL))
+	logger.Infof("agent enrollment enabled, certTTL=%s", cfg.CertTTL)
+	return nil
+}
+
+// InitInventory sets up the agent registry. The registry is kept in etcd when discovery is
+// configured, and in memory otherwise.
+func (s *Service) InitInventory() error {
+	var store *inventory.Store
+	if s.discoveryClient != nil {
+		cli, err := s.etcdClient()
+		if err != nil {
+			return err
+		}
+		store = inventory.NewStore(cli, config.Cfg.Discovery.RegistryRootKeyPrefix)
+	}
+
+	leaseTTL := time.Duration(config.Cfg.Discovery.RegistryTTL) * time.Second
+	registry := inventory.NewRegistry(store, s.serviceID, leaseTTL)
+	registry.Subscribe(func(ev inventory.Event) {
+		if ev.State == inventory.StateOnline {
+			apm.AgentConnectionsActive.WithLabelValues("connected").Inc()
+		} else {
+			apm.AgentConnectionsActive.WithLabelValues("connected").Dec()
+		}
+	})
+	s.bgWg.Add(1)
+	go func() {
+		defer s.bgWg.Done()
+		_ = registry.Run(s.bgCtx)
+	}()
+
+	s.svr.UseRegistry(registry, config.Cfg.Service.Inventory.OfflineAfter)
+	s.agents = registry
+	return nil
+}
+
+// InitTasks lets the API dispatch tasks to the connected agents, when enabled. Tasks are
+// only sent to authenticated agents: the agent channel must require client certificates,
+// either directly or through enrollment.
+func (s *Service) InitTasks() error {
+	cfg := config.Cfg.Service.Tasks
+	if !cfg.Enabled {
+		return nil
+	}
+	if s.tls == nil || !(config.Cfg.Service.TLS.RequireClientCert || config.Cfg.Service.Enrollment.Enabled) {
+		return fmt.Errorf("service.tasks requires service.tls enabled with requireClientCert or enrollment")
+	}
+
+	s.tasks = task.NewManager(s.svr, cfg)
+	s.bgWg.Add(1)
+	go func() {
+		defer s.bgWg.Done()
+		_ = s.tasks.Run(s.bgCtx)
+	}()
+
+	s.svr.UseTasks(s.tasks)
+	return nil
+}
+
+// InitAPI starts the API serving the agent inventory and, when enabled, the tasks. Since
+// tasks run on the agents, their routes are only served over TLS to callers with a client
+// certificate.
+func (s *Service) InitAPI() error {
+	cfg := &config.Cfg.API
+	certs, err := sbnet.NewCertReloader(cfg.TLS)
+	if err != nil {
+		return fmt.Errorf("api: %w", err)
+	}
+
+	routes := api.AgentRoutes(s.agents)
+	if s.tasks != nil && cfg.Enabled {
+		if certs == nil || !cfg.TLS.RequireClientCert {
+			return fmt.Errorf("service.tasks requires api.tls enabled with requireClientCert")
+		}
+		routes = append(routes, api.TaskRoutes(s.tasks)...)
+	}
+
+	s.apiTLS = certs
+	s.api = api.NewAPI(cfg.Enabled, cfg.Endpoint, certs, routes...)
+	if s.api.IsEnabled() {
+		go func() {
+			if err := s.api.Run(); err != nil {
+				logger.Errorf("API server exited: %v", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// etcdClient returns the etcd client shared by enrollment and the agent registry.
+func (s *Service) etcdClient() (*clientv3.Client, error) {
+	if s.etcdCli != nil {
+		return s.etcdCli, nil
+	}
+	cli, err := s.discoveryClient.OriginClient()
+	if err != nil {
+		return nil, err
+	}
+	s.etcdCli = cli
+	return cli, nil
+}
+
 // RegisterSelf registers the controller service with the discovery service (etcd).
 func (s *Service) RegisterSelf() error {
 	cfg := &config.Cfg.Discovery
@@ -152,6 +345,11 @@ func (s *Service) RegisterSelf() error {
 		return err
 	}
 
+	advertised, err := sbnet.AdvertisedEndpoint(config.Cfg.Service.ListenAddress, config.Cfg.Service.AdvertiseAddress)
+	if err != nil {
+		return err
+	}
+
 	serviceID := uuid.New().String()
 	opts := []discovery.Option{
 		discovery.OptionEndpoints(endpoints),
@@ -177,8 +375,9 @@ func (s *Service) RegisterSelf() error {
 	}
 
 	s.discoveryClient = cli
+	s.serviceID = serviceID
 	s.registry = cli.CreateRegistry()
-	listenAddr := config.Cfg.Service.ListenAddress.String()
+	listenAddr := advertised.String()
 	// Use longer timeout for first-time connect + auth + grant + put (2x DialTimeout).
 	registerTimeout := max(2*cfg.DialTimeout, cfg.DialTimeout)
 	ctx, cancel := context.WithTimeout(context.Background(), registerTimeout)
@@ -190,7 +389,7 @@ func (s *Service) RegisterSelf() error {
 		return err
 	}
 
-	logger.Infof("controller registered to discovery, serviceID=%s, listenAddress=%s", serviceID, listenAddr)
+	logger.Infof("controller registered to discovery, serviceID=%s, advertiseAddress=%s", serviceID, listenAddr)
 	return nil
 }
 
@@ -214,11 +413,40 @@ func (s *Service) Run() error {
 		return err
 	}
 
+	if err := s.InitEnrollment(); err != nil {
+		return err
+	}
+
+	if err := s.InitInventory(); err != nil {
+		return err
+	}
+
+	if err := s.InitTasks(); err != nil {
+		return err
+	}
+
+	if err := s.InitAPI(); err != nil {
+		return err
+	}
+
 	return s.svr.Run()
 }
 
-// Close stops the controller service (registry, APM, then the gRPC server).
+// Close stops the controller service (API, enrollment, agent registry and tasks, discovery
+// registry, APM, then the gRPC server).
 func (s *Service) Close() error {
+	if s.api != nil {
+		_ = s.api.Close()
+	}
+	if s.bgCancel != nil {
+		s.bgCancel()
+		s.bgCancel = nil
+		s.bgWg.Wait()
+	}
+	if s.etcdCli != nil {
+		_ = s.etcdCli.Close()
+		s.etcdCli = nil
+	}
 	if s.registry != nil {
 		s.registry.Close()
 		s.registry = nil
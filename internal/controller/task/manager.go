@@ -0,0 +1,349 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+// Package task dispatches tasks to the connected agents and collects the results they
+// stream back.
+package task
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/internal/controller/config"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// resultGrace is how long after its timeout a task may still report its final result.
+	resultGrace = 30 * time.Second
+
+	// expireInterval is how often Run fails overdue tasks and drops old ones.
+	expireInterval = 10 * time.Second
+)
+
+// ErrTaskNotFound is returned for an unknown or expired task.
+var ErrTaskNotFound = gerrors.New(gerrors.NotFound, "task not found")
+
+// Sender delivers messages to the connected agents (an *server.AgentServer).
+type Sender interface {
+	SendToClient(ctx context.Context, clientID string, resp *proto.AgentResponse) error
+}
+
+// State is the state of a task.
+type State string
+
+// Task states.
+const (
+	StateRunning State = "running"
+	StateDone    State = "done"
+	StateFailed  State = "failed"
+)
+
+// Spec describes a task to run on agents; see the task types in package sbmsg. Caller is
+// the authenticated identity that requested it.
+type Spec struct {
+	Type    string
+	Args    []string
+	Params  map[string]string
+	Timeout time.Duration
+	Caller  string
+}
+
+// Task is a task dispatched to one agent. A task is done when it ran to completion,
+// whatever the exit code of its command, and failed when it could not. Output holds the
+// number of bytes received per output stream.
+type Task struct {
+	ID        string            `json:"id"`
+	BatchID   string            `json:"batchID"`
+	ClientID  string            `json:"clientID"`
+	Type      string            `json:"type"`
+	Args      []string          `json:"args,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	Caller    string            `json:"caller"`
+	State     State             `json:"state"`
+	Code      int32             `json:"code"`
+	Errmsg    string            `json:"errmsg,omitempty"`
+	ExitCode  int32             `json:"exitCode"`
+	Output    map[string]int64  `json:"output"`
+	Truncated bool              `json:"truncated,omitempty"`
+	Created   time.Time         `json:"created"`
+	Finished  time.Time         `json:"finished,omitzero"`
+}
+
+type record struct {
+	task     Task
+	envID    string // id of the dispatch envelope, answered by an Ack when the agent refuses
+	deadline time.Time
+	seq      int64
+	size     int64
+	output   map[string][]byte
+}
+
+// Manager dispatches tasks and keeps their results in memory, so the results of a task are
+// only known to the controller instance its agent is connected to. It is safe for concurrent use.
+type Manager struct {
+	sender Sender
+	cfg    config.TasksConfig
+
+	mu    sync.Mutex
+	tasks map[string]*record
+	byEnv map[string]*record
+}
+
+// NewManager returns a Manager sending tasks with sender.
+func NewManager(sender Sender, cfg config.TasksConfig) *Manager {
+	return &Manager{
+		sender: sender,
+		cfg:    cfg,
+		tasks:  make(map[string]*record),
+		byEnv:  make(map[string]*record),
+	}
+}
+
+// Dispatch sends a task built from spec to each of clientIDs, all in one batch. A task
+// that cannot be sent is returned failed.
+func (m *Manager) Dispatch(ctx context.Context, clientIDs []string, spec Spec) ([]*Task, error) {
+	if spec.Type == "" {
+		return nil, gerrors.New(gerrors.InvalidParameter, "task without type")
+	}
+	if len(clientIDs) == 0 {
+		return nil, gerrors.New(gerrors.InvalidParameter, "task without agents")
+	}
+
+	timeout := spec.Timeout
+	if timeout <= 0 {
+		timeout = m.cfg.DefaultTimeout
+	}
+
+	batchID := uuid.NewString()
+	tasks := make([]*Task, 0, len(clientIDs))
+	for _, clientID := range slices.Compact(slices.Sorted(slices.Values(clientIDs))) {
+		now := time.Now()
+		r := &record{
+			task: Task{
+				ID:       uuid.NewString(),
+				BatchID:  batchID,
+				ClientID: clientID,
+				Type:     spec.Type,
+				Args:     spec.Args,
+				Params:   spec.Params,
+				Caller:   spec.Caller,
+				State:    StateRunning,
+				Output:   make(map[string]int64),
+				Created:  now,
+			},
+			deadline: now.Add(timeout + resultGrace),
+			output:   make(map[string][]byte),
+		}
+
+		env, err := sbmsg.New(&proto.TaskDispatch{
+			TaskID:    r.task.ID,
+			Type:      spec.Type,
+			Args:      spec.Args,
+			Params:    spec.Params,
+			TimeoutMs: timeout.Milliseconds(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		r.envID = env.GetId()
+
+		// Recorded first: the results may come back before SendToClient returns.
+		m.mu.Lock()
+		m.tasks[r.task.ID] = r
+		m.byEnv[r.envID] = r
+		m.mu.Unlock()
+
+		err = m.sender.SendToClient(ctx, clientID, &proto.AgentResponse{Envelope: env})
+		if err != nil {
+			logger.Warnf("task %s (%s) not sent to %s: %v", r.task.ID, spec.Type, clientID, err)
+		} else {
+			logger.Infof("task %s (%s) sent to %s: batch=%s, caller=%s", r.task.ID, spec.Type, clientID, batchID, spec.Caller)
+		}
+
+		m.mu.Lock()
+		if err != nil {
+			m.finish(r, gerrors.Failure, err.Error(), time.Now())
+		}
+		tasks = append(tasks, r.snapshot())
+		m.mu.Unlock()
+	}
+	return tasks, nil
+}
+
+// Result records a result that clientID reported for one of its tasks.
+func (m *Manager) Result(clientID string, res *proto.TaskResult, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.tasks[res.GetTaskID()]
+	if !ok || r.task.ClientID != clientID {
+		return ErrTaskNotFound
+	}
+	if r.task.State != StateRunning || res.GetSeq() <= r.seq {
+		// Late or repeated.
+		return nil
+	}
+	r.seq = res.GetSeq()
+
+	if out := res.GetOutput(); len(out) > 0 {
+		stream := res.GetStream()
+		r.task.Output[stream] += int64(len(out))
+		if keep := min(int64(len(out)), m.cfg.MaxOutputBytes-r.size); keep < int64(len(out)) {
+			out = out[:max(keep, 0)]
+			r.task.Truncated = true
+		}
+		r.size += int64(len(out))
+		r.output[stream] = append(r.output[stream], out...)
+	}
+
+	if res.GetDone() {
+		r.task.ExitCode = res.GetExitCode()
+		m.finish(r, gerrors.Code(res.GetCode()), res.GetErrmsg(), now)
+	}
+	return nil
+}
+
+// Ack records an Ack from clientID and reports whether it answered a task dispatch: the
+// agent acknowledges a dispatch only to refuse the task.
+func (m *Manager) Ack(clientID string, env *proto.Envelope, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.byEnv[env.GetReplyTo()]
+	if !ok || r.task.ClientID != clientID {
+		return false
+	}
+	if ack := env.GetAck(); ack.GetCode() != int32(gerrors.Success) && r.task.State == StateRunning {
+		m.finish(r, gerrors.Code(ack.GetCode()), ack.GetErrmsg(), now)
+	}
+	return true
+}
+
+// Disconnected fails the running tasks of clientID, whose results can no longer arrive.
+func (m *Manager) Disconnected(clientID string, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range m.tasks {
+		if r.task.ClientID == clientID && r.task.State == StateRunning {
+			m.finish(r, gerrors.ComponentFailure, "agent disconnected", now)
+		}
+	}
+}
+
+// Get returns the task id.
+func (m *Manager) Get(id string) (*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.tasks[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return r.snapshot(), nil
+}
+
+// List returns the tasks of batchID, or all tasks when it is empty, oldest first.
+func (m *Manager) List(batchID string) []*Task {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks := make([]*Task, 0, len(m.tasks))
+	for _, r := range m.tasks {
+		if batchID == "" || r.task.BatchID == batchID {
+			tasks = append(tasks, r.snapshot())
+		}
+	}
+	slices.SortFunc(tasks, func(a, b *Task) int {
+		if c := a.Created.Compare(b.Created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ClientID, b.ClientID)
+	})
+	return tasks
+}
+
+// Output returns the output of task id on stream received so far.
+func (m *Manager) Output(id, stream string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.tasks[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return slices.Clone(r.output[stream]), nil
+}
+
+// Run fails the tasks whose results are overdue and drops the tasks finished for longer
+// than the retention, until ctx is done.
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(expireInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			m.expire(now)
+		}
+	}
+}
+
+func (m *Manager) expire(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, r := range m.tasks {
+		switch {
+		case r.task.State == StateRunning && now.After(r.deadline):
+			m.finish(r, gerrors.Timeout, "no result from the agent", now)
+		case r.task.State != StateRunning && now.Sub(r.task.Finished) > m.cfg.Retention:
+			delete(m.tasks, id)
+		}
+	}
+}
+
+// finish ends the running task of r. Called with m.mu held.
+func (m *Manager) finish(r *record, code gerrors.Code, errmsg string, now time.Time) {
+	r.task.State = StateDone
+	if code != gerrors.Success {
+		r.task.State = StateFailed
+	}
+	r.task.Code, r.task.Errmsg, r.task.Finished = int32(code), errmsg, now
+	delete(m.byEnv, r.envID)
+
+	logger.Infof("task %s on %s %s: exitCode=%d, errmsg=%s",
+		r.task.ID, r.task.ClientID, r.task.State, r.task.ExitCode, errmsg)
+}
+
+// snapshot returns a copy of the task. Called with m.mu held.
+func (r *record) snapshot() *Task {
+	t := r.task
+	t.Output = maps.Clone(r.task.Output)
+	return &t
+}
@@ -0,0 +1,168 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"os-artificer/saber/internal/controller/config"
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbmsg"
+)
+
+// sender records the dispatched tasks and fails for clients it does not know.
+type sender struct {
+	clients map[string][]*proto.Envelope
+}
+
+func (s *sender) SendToClient(ctx context.Context, clientID string, resp *proto.AgentResponse) error {
+	if _, ok := s.clients[clientID]; !ok {
+		return gerrors.New(gerrors.NotFound, "connection not found")
+	}
+	s.clients[clientID] = append(s.clients[clientID], resp.GetEnvelope())
+	return nil
+}
+
+func newManager(maxOutput int64) (*Manager, *sender) {
+	s := &sender{clients: map[string][]*proto.Envelope{"a1": nil, "a2": nil}}
+	return NewManager(s, config.TasksConfig{
+		DefaultTimeout: time.Minute,
+		MaxOutputBytes: maxOutput,
+		Retention:      time.Hour,
+	}), s
+}
+
+func TestManager_Dispatch(t *testing.T) {
+	m, s := newManager(1 << 20)
+
+	tasks, err := m.Dispatch(context.Background(), []string{"a2", "a1", "a2", "gone"}, Spec{
+		Type:   sbmsg.TaskListDir,
+		Params: map[string]string{sbmsg.ParamPath: "/var/log"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("%d tasks, want one per agent", len(tasks))
+	}
+	for i, want := range []string{"a1", "a2", "gone"} {
+		if tasks[i].ClientID != want || tasks[i].BatchID != tasks[0].BatchID {
+			t.Errorf("task %d = %+v", i, tasks[i])
+		}
+	}
+	if tasks[2].State != StateFailed || tasks[2].Errmsg == "" {
+		t.Errorf("task for an unknown agent = %+v", tasks[2])
+	}
+
+	d := s.clients["a1"][0].GetTaskDispatch()
+	if d.GetTaskID() != tasks[0].ID || d.GetParams()[sbmsg.ParamPath] != "/var/log" || d.GetTimeoutMs() != 60000 {
+		t.Errorf("dispatch = %v", d)
+	}
+	if got := m.List(tasks[0].BatchID); len(got) != 3 {
+		t.Errorf("List(batch) returned %d tasks", len(got))
+	}
+
+	invalid := gerrors.New(gerrors.InvalidParameter, "")
+	if _, err := m.Dispatch(context.Background(), []string{"a1"}, Spec{}); !gerrors.Is(err, invalid) {
+		t.Errorf("Dispatch without type: error = %v, want InvalidParameter", err)
+	}
+	if _, err := m.Dispatch(context.Background(), nil, Spec{Type: sbmsg.TaskExec}); !gerrors.Is(err, invalid) {
+		t.Errorf("Dispatch without agents: error = %v, want InvalidParameter", err)
+	}
+}
+
+func TestManager_Result(t *testing.T) {
+	m, _ := newManager(8)
+	tasks, _ := m.Dispatch(context.Background(), []string{"a1"}, Spec{Type: sbmsg.TaskExec, Args: []string{"ps"}})
+	id := tasks[0].ID
+	now := time.Now()
+
+	if err := m.Result("a2", &proto.TaskResult{TaskID: id, Seq: 1}, now); err != ErrTaskNotFound {
+		t.Fatalf("result from another agent: error = %v", err)
+	}
+
+	results := []*proto.TaskResult{
+		{Seq: 1, Stream: sbmsg.StreamStdout, Output: []byte("hello ")},
+		{Seq: 1, Stream: sbmsg.StreamStdout, Output: []byte("hello ")},
+		{Seq: 2, Stream: sbmsg.StreamStderr, Output: []byte("oops")},
+		{Seq: 3, Done: true, ExitCode: 1},
+		{Seq: 4, Stream: sbmsg.StreamStdout, Output: []byte("late")},
+	}
+	for _, res := range results {
+		res.TaskID = id
+		if err := m.Result("a1", res, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != StateDone || got.ExitCode != 1 || !got.Truncated ||
+		got.Output[sbmsg.StreamStdout] != 6 || got.Output[sbmsg.StreamStderr] != 4 {
+		t.Errorf("task = %+v", got)
+	}
+	stdout, _ := m.Output(id, sbmsg.StreamStdout)
+	stderr, _ := m.Output(id, sbmsg.StreamStderr)
+	if string(stdout) != "hello " || string(stderr) != "oo" {
+		t.Errorf("stdout=%q stderr=%q", stdout, stderr)
+	}
+}
+
+func TestManager_Ack(t *testing.T) {
+	m, s := newManager(1 << 20)
+	tasks, _ := m.Dispatch(context.Background(), []string{"a1"}, Spec{Type: sbmsg.TaskKillProcess})
+
+	ack := sbmsg.NewAck(s.clients["a1"][0], gerrors.New(gerrors.Unimplemented, "kill tasks are disabled"))
+	if m.Ack("a2", ack, time.Now()) {
+		t.Fatal("ack from another agent accepted")
+	}
+	if !m.Ack("a1", ack, time.Now()) {
+		t.Fatal("ack not matched to its task")
+	}
+
+	got, _ := m.Get(tasks[0].ID)
+	if got.State != StateFailed || got.Code != int32(gerrors.Unimplemented) || got.Errmsg != "kill tasks are disabled" {
+		t.Errorf("task = %+v", got)
+	}
+}
+
+func TestManager_expire(t *testing.T) {
+	m, _ := newManager(1 << 20)
+	tasks, _ := m.Dispatch(context.Background(), []string{"a1", "a2"}, Spec{Type: sbmsg.TaskExec, Timeout: time.Second})
+	now := time.Now()
+	_ = m.Result("a2", &proto.TaskResult{TaskID: tasks[1].ID, Seq: 1, Done: true}, now)
+
+	m.expire(now.Add(time.Second))
+	if got, _ := m.Get(tasks[0].ID); got.State != StateRunning {
+		t.Fatalf("task expired within its timeout: %+v", got)
+	}
+
+	m.expire(now.Add(time.Second + resultGrace + time.Millisecond))
+	if got, _ := m.Get(tasks[0].ID); got.State != StateFailed || got.Code != int32(gerrors.Timeout) {
+		t.Fatalf("overdue task = %+v", got)
+	}
+
+	m.expire(now.Add(2 * time.Hour))
+	if _, err := m.Get(tasks[1].ID); err != ErrTaskNotFound {
+		t.Fatalf("Get(old task) error = %v, want ErrTaskNotFound", err)
+	}
+}
@@ -111,6 +111,13 @@ type APMConfig struct {
 	Endpoint sbnet.Endpoint `yaml:"endpoint"`
 }
 
+// RevocationConfig makes the agent source refuse enrolled agent certificates that have
+// been revoked, and close the streams already open with them. Revocations are followed in
+// etcd under the discovery registryRootKeyPrefix.
+type RevocationConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
 // LogConfig log config
 type LogConfig struct {
 	FileName       string       `yaml:"fileName"`
@@ -133,13 +140,16 @@ type SinkConfig struct {
 	Config  map[string]any `yaml:"config"`
 }
 
-// Configuration databus's configuration
+// Configuration databus's configuration. TLS secures the agent source; with
+// requireClientCert, agents must present a certificate matching their clientID.
 type Configuration struct {
-	Name      string          `yaml:"name"`
-	Version   string          `yaml:"version"`
-	Discovery DiscoveryConfig `yaml:"discovery"`
-	APM       APMConfig       `yaml:"apm"`
-	Source    []SourceConfig  `yaml:"source"`
-	Sink      []SinkConfig    `yaml:"sink"`
-	Log       LogConfig       `yaml:"log"`
+	Name       string           `yaml:"name"`
+	Version    string           `yaml:"version"`
+	Discovery  DiscoveryConfig  `yaml:"discovery"`
+	APM        APMConfig        `yaml:"apm"`
+	TLS        sbnet.TLSConfig  `yaml:"tls"`
+	Revocation RevocationConfig `yaml:"revocation"`
+	Source     []SourceConfig   `yaml:"source"`
+	Sink       []SinkConfig     `yaml:"sink"`
+	Log        LogConfig        `yaml:"log"`
 }
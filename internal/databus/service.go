@@ -23,12 +23,14 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"os-artificer/saber/internal/databus/apm"
 	"os-artificer/saber/internal/databus/config"
 	"os-artificer/saber/internal/databus/sink"
 	"os-artificer/saber/internal/databus/source"
 	"os-artificer/saber/pkg/discovery"
+	"os-artificer/saber/pkg/enrollment"
 	"os-artificer/saber/pkg/logger"
 	"os-artificer/saber/pkg/sbnet"
 
@@ -36,6 +38,9 @@ import (
 	"golang.org/x/sync/errgroup"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // databusUnmarshalOpt composes default viper hooks with string->Endpoint so
@@ -54,6 +59,10 @@ type Service struct {
 	apm             *apm.APM
 	discoveryClient *discovery.Client
 	registry        *discovery.Registry
+	tls             *sbnet.CertReloader
+	revoked         atomic.Pointer[enrollment.RevocationList]
+	streams         *source.PeerStreams
+	revocationCli   *clientv3.Client
 	runCtx          context.Context
 	runCancel       context.CancelFunc
 }
@@ -72,14 +81,26 @@ func CreateService(ctx context.Context, serviceID string) (*Service, error) {
 		return nil, fmt.Errorf("no source configured")
 	}
 
-	sources, err := source.NewSourcesFromConfig(config.Cfg.Source)
+	certs, err := sbnet.NewCertReloader(config.Cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{}
+	streams := source.NewPeerStreams()
+	opts := source.ServerOptions{VerifyPeer: s.verifyPeer, Streams: streams}
+	if certs != nil {
+		opts.Credentials = certs.ServerCredentials()
+	}
+
+	sources, err := source.NewSourcesFromConfig(config.Cfg.Source, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	runCtx, runCancel := context.WithCancel(context.Background())
 
-	return &Service{
+	*s = Service{
 		sources:         sources,
 		handler:         handler,
 		sink:            snk,
@@ -87,9 +108,12 @@ func CreateService(ctx context.Context, serviceID string) (*Service, error) {
 		apm:             nil,
 		discoveryClient: nil,
 		registry:        nil,
+		tls:             certs,
+		streams:         streams,
 		runCtx:          runCtx,
 		runCancel:      runCancel,
-	}, nil
+	}
+	return s, nil
 }
 
 // InitLogger initializes the global logger from config.Cfg.Log (pkg/logger).
@@ -120,7 +144,8 @@ func (s *Service) InitAPM() error {
 	return nil
 }
 
-// ReloadConfig re-reads config from ConfigFilePath and re-inits logger (for SIGHUP).
+// ReloadConfig re-reads config from ConfigFilePath, re-inits logger and reloads the TLS
+// certificates (for SIGHUP).
 func (s *Service) ReloadConfig() error {
 	if ConfigFilePath == "" {
 		return nil
@@ -136,10 +161,67 @@ func (s *Service) ReloadConfig() error {
 	if err := s.InitLogger(); err != nil {
 		return err
 	}
+	if err := s.tls.Reload(); err != nil {
+		return err
+	}
 	logger.Infof("config reloaded")
 	return nil
 }
 
+// InitRevocation starts following the revoked agent certificates in etcd when
+// config.Cfg.Revocation is enabled, and closes the open streams of a certificate once it
+// is revoked. It needs the discovery client of RegisterSelf.
+func (s *Service) InitRevocation() error {
+	if !config.Cfg.Revocation.Enabled {
+		return nil
+	}
+	if s.discoveryClient == nil {
+		return fmt.Errorf("revocation requires discovery etcdEndpoint")
+	}
+
+	cli, err := s.discoveryClient.OriginClient()
+	if err != nil {
+		return err
+	}
+
+	store := enrollment.NewStore(cli, config.Cfg.Discovery.RegistryRootKeyPrefix)
+	revoked := enrollment.NewRevocationList(store)
+	ctx, cancel := context.WithTimeout(s.runCtx, config.Cfg.Discovery.DialTimeout)
+	_, err = revoked.Sync(ctx)
+	cancel()
+	if err != nil {
+		_ = cli.Close()
+		return fmt.Errorf("load certificate revocations: %w", err)
+	}
+
+	revoked.Subscribe(func(c *enrollment.CertInfo) {
+		err := status.Errorf(codes.PermissionDenied, "client certificate %s has been revoked", c.Serial)
+		if n := s.streams.Close(c.Serial, err); n > 0 {
+			logger.Infof("closed %d stream(s) of revoked certificate: clientID=%s serial=%s", n, c.ClientID, c.Serial)
+		}
+	})
+	go func() {
+		_ = revoked.Run(s.runCtx)
+	}()
+
+	s.revocationCli = cli
+	s.revoked.Store(revoked)
+	return nil
+}
+
+// verifyPeer rejects agents whose client certificate has been revoked.
+func (s *Service) verifyPeer(ctx context.Context) error {
+	revoked := s.revoked.Load()
+	if revoked == nil {
+		return nil
+	}
+	if cert := sbnet.PeerCertificate(ctx); cert != nil && revoked.IsRevoked(cert) {
+		return status.Errorf(codes.PermissionDenied, "client certificate %s has been revoked",
+			enrollment.SerialString(cert))
+	}
+	return nil
+}
+
 // RegisterSelf registers the databus service with the discovery service (etcd).
 func (s *Service) RegisterSelf() error {
 	cfg := &config.Cfg.Discovery
@@ -157,6 +239,11 @@ func (s *Service) RegisterSelf() error {
 		return err
 	}
 
+	listenAddr, err := getDatabusAdvertiseAddr()
+	if err != nil {
+		return err
+	}
+
 	serviceID := uuid.New().String()
 	opts := []discovery.Option{
 		discovery.OptionEndpoints(endpoints),
@@ -182,7 +269,6 @@ func (s *Service) RegisterSelf() error {
 
 	s.discoveryClient = cli
 	s.registry = cli.CreateRegistry()
-	listenAddr := getDatabusListenAddr()
 	registerTimeout := max(2*cfg.DialTimeout, cfg.DialTimeout)
 	ctx, cancel := context.WithTimeout(context.Background(), registerTimeout)
 	defer cancel()
@@ -193,7 +279,7 @@ func (s *Service) RegisterSelf() error {
 		return err
 	}
 
-	logger.Infof("databus registered to discovery, serviceID=%s, listenAddress=%s", serviceID, listenAddr)
+	logger.Infof("databus registered to discovery, serviceID=%s, advertiseAddress=%s", serviceID, listenAddr)
 	return nil
 }
 
@@ -211,6 +297,10 @@ func (s *Service) Run() error {
 		return err
 	}
 
+	if err := s.InitRevocation(); err != nil {
+		return err
+	}
+
 	g, gCtx := errgroup.WithContext(s.runCtx)
 	for _, src := range s.sources {
 		src := src
@@ -231,6 +321,10 @@ func (s *Service) Close() error {
 		s.registry.Close()
 		s.registry = nil
 	}
+	if s.revocationCli != nil {
+		_ = s.revocationCli.Close()
+		s.revocationCli = nil
+	}
 	if s.apm != nil {
 		_ = s.apm.Close()
 	}
@@ -292,23 +386,23 @@ func buildDiscoveryTLS(cfg *config.DiscoveryConfig, endpoints []string) (*tls.Co
 	return tlsCfg, nil
 }
 
-// getDatabusListenAddr returns the first agent source endpoint from config as the listen address for discovery.
-func getDatabusListenAddr() string {
+// getDatabusAdvertiseAddr returns the advertised address of the first agent source from config for discovery.
+func getDatabusAdvertiseAddr() (string, error) {
 	for _, src := range config.Cfg.Source {
 		if src.Type != config.SourceTypeAgent {
 			continue
 		}
 		cfg, err := source.ConfigFromMap(src.Config)
 		if err != nil {
-			return ""
+			return "", err
 		}
-		ep, err := cfg.ListenAddress()
+		ep, err := cfg.AdvertisedAddress()
 		if err != nil {
-			return ""
+			return "", err
 		}
-		return ep.String()
+		return ep.String(), nil
 	}
-	return ""
+	return "", fmt.Errorf("no agent source to register")
 }
 
 // loadDatabusConfig reads config from ConfigFilePath into config.Cfg.
@@ -18,28 +18,44 @@ package source
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"sync"
 
 	"os-artificer/saber/pkg/constant"
+	"os-artificer/saber/pkg/gerrors"
 	"os-artificer/saber/pkg/logger"
 	"os-artificer/saber/pkg/proto"
+	"os-artificer/saber/pkg/sbcodec"
 	"os-artificer/saber/pkg/sbnet"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/peer"
 )
 
 var _ Source = (*AgentSource)(nil)
 
-// AgentSource implements Source by accepting gRPC PushData streams from agents,
+// ackBufferSize is the number of acks a StreamData stream can queue for sending.
+const ackBufferSize = 1024
+
+// AgentSource implements Source by accepting gRPC PushData and StreamData streams from agents,
 // tracking long-lived connections via ConnectionManager, and delivering each
 // DatabusRequest to the Handler.
 type AgentSource struct {
 	address sbnet.Endpoint
 	connMgr *ConnectionManager
+	creds   credentials.TransportCredentials
+
+	// verifyPeer, when set, rejects new streams whose peer it returns an error for.
+	verifyPeer func(ctx context.Context) error
+
+	// streams, when set, tracks the open streams by peer certificate.
+	streams *PeerStreams
 }
 
 // NewAgentSource returns a Source that listens on address and serves DatabusService PushData.
@@ -49,7 +65,13 @@ func NewAgentSource(address sbnet.Endpoint, connMgr *ConnectionManager) *AgentSo
 	if connMgr == nil {
 		connMgr = NewConnectionManager(0)
 	}
-	return &AgentSource{address: address, connMgr: connMgr}
+	return &AgentSource{address: address, connMgr: connMgr, creds: insecure.NewCredentials()}
+}
+
+// UseCredentials makes the server authenticate with creds. With mutual TLS, the clientID
+// agents send must match their certificate. Call it before Run.
+func (p *AgentSource) UseCredentials(creds credentials.TransportCredentials) {
+	p.creds = creds
 }
 
 // Run starts the gRPC server and blocks until ctx is done or server stops.
@@ -65,13 +87,20 @@ func (p *AgentSource) Run(ctx context.Context, h Handler) error {
 	}
 
 	svr := grpc.NewServer(
+		grpc.Creds(p.creds),
 		grpc.KeepaliveParams(kasp),
 		grpc.KeepaliveEnforcementPolicy(kacp),
 		grpc.MaxRecvMsgSize(constant.DefaultMaxReceiveMessageSize),
 		grpc.MaxSendMsgSize(constant.DefaultMaxSendMessageSize),
 	)
 
-	pushSrv := &pushDataServer{handler: h, connMgr: p.connMgr}
+	pushSrv := &pushDataServer{
+		handler:    h,
+		connMgr:    p.connMgr,
+		dedupe:     newSeqDedupe(dedupeWindow, dedupeIdleTTL),
+		verifyPeer: p.verifyPeer,
+		streams:    p.streams,
+	}
 	proto.RegisterDatabusServiceServer(svr, pushSrv)
 
 	lis, err := net.Listen(p.address.Protocol, p.address.HostPort())
@@ -92,40 +121,109 @@ func (p *AgentSource) Run(ctx context.Context, h Handler) error {
 // pushDataServer implements proto.DatabusServiceServer and forwards each request to Handler.
 type pushDataServer struct {
 	proto.UnimplementedDatabusServiceServer
-	handler Handler
-	connMgr *ConnectionManager
+	handler    Handler
+	connMgr    *ConnectionManager
+	dedupe     *seqDedupe
+	verifyPeer func(ctx context.Context) error
+	streams    *PeerStreams
 }
 
-func (s *pushDataServer) PushData(stream proto.DatabusService_PushDataServer) error {
-	ctx := stream.Context()
+// openConn registers a new stream connection and returns its id, and the context the
+// stream is served with until release is called. The context ends when the peer
+// certificate is closed by PeerStreams.
+func (s *pushDataServer) openConn(ctx context.Context) (connID string, _ context.Context, release func(), _ error) {
 	remoteAddr := ""
 	if p, ok := peer.FromContext(ctx); ok {
 		remoteAddr = p.Addr.String()
 	}
 
-	connID := fmt.Sprintf("%s#%d", remoteAddr, s.connMgr.NextID())
+	// The stream is tracked before its peer is verified, so that a certificate revoked in
+	// between still closes it.
+	ctx, release = s.streams.open(ctx)
+	if s.verifyPeer != nil {
+		if err := s.verifyPeer(ctx); err != nil {
+			release()
+			logger.Warnf("connection rejected: %s, err: %v", remoteAddr, err)
+			return "", nil, nil, err
+		}
+	}
+
+	connID = fmt.Sprintf("%s#%d", remoteAddr, s.connMgr.NextID())
 	meta := &ConnMeta{RemoteAddr: remoteAddr}
 	if err := s.connMgr.Register(connID, meta); err != nil {
+		release()
 		logger.Warnf("connection rejected: %s, err: %v", connID, err)
+		return "", nil, nil, err
+	}
+
+	logger.Infof("connection established: %s, active=%d", connID, s.connMgr.ActiveCount())
+	return connID, ctx, release, nil
+}
+
+// received is a request read from a stream, or the error that ended it.
+type received struct {
+	req *proto.DatabusRequest
+	err error
+}
+
+// receive reads the requests of a stream with recv until it fails or ctx is done, so that
+// the stream can be ended while a read is blocked.
+func receive(ctx context.Context, recv func() (*proto.DatabusRequest, error)) <-chan received {
+	ch := make(chan received)
+	go func() {
+		for {
+			req, err := recv()
+			select {
+			case ch <- received{req: req, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// streamEnded returns what a stream served with ctx returns once ctx is done: nil when
+// the stream itself ended, or the cause it was closed with.
+func streamEnded(ctx context.Context, connID string) error {
+	cause := context.Cause(ctx)
+	if errors.Is(cause, context.Canceled) || errors.Is(cause, context.DeadlineExceeded) {
+		logger.Infof("stream context done: %s", connID)
+		return nil
+	}
+	logger.Warnf("stream closed: %s, err: %v", connID, cause)
+	return cause
+}
+
+// closeConn unregisters a stream connection opened by openConn.
+func (s *pushDataServer) closeConn(connID, clientID string) {
+	s.connMgr.Unregister(connID)
+	logger.Infof("connection closed: %s (clientID=%s), active=%d", connID, clientID, s.connMgr.ActiveCount())
+}
+
+func (s *pushDataServer) PushData(stream proto.DatabusService_PushDataServer) error {
+	connID, ctx, release, err := s.openConn(stream.Context())
+	if err != nil {
 		return err
 	}
+	defer release()
 
 	var clientID string
 	defer func() {
-		s.connMgr.Unregister(connID)
-		logger.Infof("connection closed: %s (clientID=%s), active=%d", connID, clientID, s.connMgr.ActiveCount())
+		s.closeConn(connID, clientID)
 	}()
 
-	logger.Infof("connection established: %s, active=%d", connID, s.connMgr.ActiveCount())
-
+	reqs := receive(ctx, stream.Recv)
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Infof("stream context done: %s", connID)
-			return nil
+			return streamEnded(ctx, connID)
 
-		default:
-			req, err := stream.Recv()
+		case r := <-reqs:
+			req, err := r.req, r.err
 			if err == io.EOF {
 				return nil
 			}
@@ -135,18 +233,244 @@ func (s *pushDataServer) PushData(stream proto.DatabusService_PushDataServer) er
 				return err
 			}
 
-			if clientID == "" && req.GetClientID() != "" {
-				clientID = req.GetClientID()
-				s.connMgr.UpdateMeta(connID, &ConnMeta{ClientID: clientID})
+			if id := req.GetClientID(); id != "" && id != clientID {
+				if err := sbnet.VerifyClientID(ctx, id); err != nil {
+					logger.Warnf("stream rejected: %s, err: %v", connID, err)
+					return err
+				}
+				if clientID == "" {
+					clientID = id
+					s.connMgr.UpdateMeta(connID, &ConnMeta{ClientID: clientID})
+				}
 			}
 
 			if len(req.GetPayload()) == 0 && req.GetClientID() == "" {
 				continue
 			}
 
-			if err := s.handler.OnDatabusRequest(req); err != nil {
+			reqs, err := unpackRequest(req)
+			if err != nil {
+				logger.Warnf("dropping undecodable request: %s, err: %v", connID, err)
+				continue
+			}
+
+			for _, r := range reqs {
+				if err := s.handler.OnDatabusRequest(r); err != nil {
+					logger.Warnf("handler OnDatabusRequest failed: %s, err: %v", connID, err)
+				}
+			}
+		}
+	}
+}
+
+// StreamData is PushData with acknowledgements: every request carrying a non-zero Seq is
+// answered with a DatabusAck once all of its messages have been written to the sink, or
+// once writing them has failed. Requests the agent retransmits after a lost ack are
+// recognised by (client ID, Seq) and acknowledged without being written again.
+func (s *pushDataServer) StreamData(stream proto.DatabusService_StreamDataServer) error {
+	connID, ctx, release, err := s.openConn(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var clientID string
+	defer func() {
+		s.closeConn(connID, clientID)
+	}()
+
+	// Acks are produced by sink writes on other goroutines; a single sender serializes
+	// them onto the stream and stops before StreamData returns.
+	ackC := make(chan *proto.DatabusAck, ackBufferSize)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			case ack := <-ackC:
+				if err := stream.Send(ack); err != nil {
+					logger.Warnf("stream send ack failed: %s, err: %v", connID, err)
+					return
+				}
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	ack := func(seq uint64, err error) {
+		a := &proto.DatabusAck{Seq: seq, Status: int64(gerrors.Success)}
+		if err != nil {
+			a.Status = int64(ackCode(err))
+			a.Errmsg = err.Error()
+		}
+
+		select {
+		case ackC <- a:
+		case <-stop:
+		}
+	}
+
+	reqs := receive(ctx, stream.Recv)
+	for {
+		var r received
+		select {
+		case <-ctx.Done():
+			return streamEnded(ctx, connID)
+		case r = <-reqs:
+		}
+
+		req, err := r.req, r.err
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return streamEnded(ctx, connID)
+			}
+			logger.Warnf("stream recv error: %s, err: %v", connID, err)
+			return err
+		}
+
+		if id := req.GetClientID(); id != "" && id != clientID {
+			if err := sbnet.VerifyClientID(ctx, id); err != nil {
+				logger.Warnf("stream rejected: %s, err: %v", connID, err)
+				return err
+			}
+			if clientID == "" {
+				clientID = id
+				s.connMgr.UpdateMeta(connID, &ConnMeta{ClientID: clientID})
+			}
+		}
+
+		if len(req.GetPayload()) == 0 && req.GetClientID() == "" {
+			continue
+		}
+
+		seq := req.GetSeq()
+		switch s.dedupe.Begin(clientID, seq) {
+		case seqWritten:
+			logger.Debugf("duplicate request acknowledged: %s, seq=%d", connID, seq)
+			ack(seq, nil)
+			continue
+		case seqPending:
+			logger.Debugf("duplicate request dropped while being written: %s, seq=%d", connID, seq)
+			continue
+		}
+
+		reqs, err := unpackRequest(req)
+		if err != nil {
+			logger.Warnf("dropping undecodable request: %s, err: %v", connID, err)
+			err = gerrors.NewE(gerrors.InvalidParameter, err)
+			s.dedupe.Finish(clientID, seq, err)
+			if seq != 0 {
+				ack(seq, err)
+			}
+			continue
+		}
+
+		s.handleAcked(connID, clientID, seq, reqs, ack)
+	}
+}
+
+// handleAcked hands reqs to the handler and calls ack once for seq when all of them are
+// done. Requests with a zero seq are handled without an ack.
+func (s *pushDataServer) handleAcked(connID, clientID string, seq uint64,
+	reqs []*proto.DatabusRequest, ack func(seq uint64, err error)) {
+
+	ah, ok := s.handler.(AckHandler)
+	if !ok || seq == 0 || len(reqs) == 0 {
+		var firstErr error
+		for _, r := range reqs {
+			if err := s.handler.OnDatabusRequest(r); err != nil {
 				logger.Warnf("handler OnDatabusRequest failed: %s, err: %v", connID, err)
+				if firstErr == nil {
+					firstErr = err
+				}
 			}
 		}
+		if seq != 0 {
+			s.dedupe.Finish(clientID, seq, firstErr)
+			ack(seq, firstErr)
+		}
+		return
+	}
+
+	var (
+		mu      sync.Mutex
+		pending = len(reqs)
+		failed  error
+	)
+	done := func(err error) {
+		mu.Lock()
+		if err != nil && failed == nil {
+			failed = err
+		}
+		pending--
+		last := pending == 0
+		mu.Unlock()
+
+		if !last {
+			return
+		}
+		s.dedupe.Finish(clientID, seq, failed)
+		ack(seq, failed)
+	}
+
+	for _, r := range reqs {
+		if err := ah.OnDatabusRequestAck(r, done); err != nil {
+			logger.Warnf("handler OnDatabusRequestAck failed: %s, err: %v", connID, err)
+			done(err)
+		}
+	}
+}
+
+// ackCode maps a handling error to the status reported in a DatabusAck.
+// InvalidParameter tells the agent not to retry; anything else is retryable.
+func ackCode(err error) gerrors.Code {
+	var ge *gerrors.Error
+	if gerrors.As(err, &ge) && ge.Code() != gerrors.Success {
+		return ge.Code()
+	}
+	return gerrors.ComponentFailure
+}
+
+// unpackRequest splits a batched, possibly compressed request into one request per
+// message, so that handlers only ever see single messages. Plain requests are returned
+// as they are.
+func unpackRequest(req *proto.DatabusRequest) ([]*proto.DatabusRequest, error) {
+	headers := req.GetHeaders()
+	if !sbcodec.IsBatch(headers) && headers[sbcodec.HeaderContentEncoding] == "" {
+		return []*proto.DatabusRequest{req}, nil
+	}
+
+	msgs, err := sbcodec.Unpack(headers, req.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+
+	var rest map[string]string
+	for k, v := range headers {
+		switch k {
+		case sbcodec.HeaderContentType, sbcodec.HeaderContentEncoding, sbcodec.HeaderBatchCount:
+			continue
+		}
+		if rest == nil {
+			rest = make(map[string]string)
+		}
+		rest[k] = v
+	}
+
+	out := make([]*proto.DatabusRequest, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, &proto.DatabusRequest{ClientID: req.GetClientID(), Headers: rest, Payload: m})
 	}
+	return out, nil
 }
@@ -27,8 +27,11 @@ var (
 	ErrNoEndpoint = gerrors.New(gerrors.InvalidParameter, "no endpoint configured")
 )
 
+// Config configures the agent source. AdvertiseAddress is the address registered for
+// discovery, by default Endpoint with a wildcard host replaced by the pod or host IP.
 type Config struct {
-	Endpoint sbnet.Endpoint `yaml:"endpoint" mapstructure:"endpoint"`
+	Endpoint         sbnet.Endpoint `yaml:"endpoint" mapstructure:"endpoint"`
+	AdvertiseAddress string         `yaml:"advertiseAddress" mapstructure:"advertiseAddress"`
 }
 
 // ConfigFromMap decodes m into *Config using mapstructure with Endpoint hooks.
@@ -54,3 +57,12 @@ func (c *Config) ListenAddress() (sbnet.Endpoint, error) {
 	}
 	return c.Endpoint, nil
 }
+
+// AdvertisedAddress returns the endpoint agents reach the source at.
+func (c *Config) AdvertisedAddress() (sbnet.Endpoint, error) {
+	listen, err := c.ListenAddress()
+	if err != nil {
+		return sbnet.Endpoint{}, err
+	}
+	return sbnet.AdvertisedEndpoint(listen, c.AdvertiseAddress)
+}
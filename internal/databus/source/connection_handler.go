@@ -27,9 +27,15 @@ import (
 	"os-artificer/saber/pkg/proto"
 )
 
-var _ Handler = (*ConnectionHandler)(nil)
+var _ AckHandler = (*ConnectionHandler)(nil)
 
-type requestEventC chan *proto.DatabusRequest
+// requestEvent is a request waiting to be written; done, if set, receives the result.
+type requestEvent struct {
+	req  *proto.DatabusRequest
+	done func(err error)
+}
+
+type requestEventC chan requestEvent
 
 // ConnectionHandler buffers DatabusRequests and writes them to a Sink.
 // It implements Handler for use with Source (e.g. AgentSource).
@@ -54,7 +60,12 @@ func NewConnectionHandler(sink sink.Sink) *ConnectionHandler {
 
 // OnDatabusRequest implements Handler.
 func (c *ConnectionHandler) OnDatabusRequest(req *proto.DatabusRequest) error {
-	return c.postEvent(req)
+	return c.postEvent(requestEvent{req: req})
+}
+
+// OnDatabusRequestAck implements AckHandler.
+func (c *ConnectionHandler) OnDatabusRequestAck(req *proto.DatabusRequest, done func(err error)) error {
+	return c.postEvent(requestEvent{req: req, done: done})
 }
 
 func (c *ConnectionHandler) readEvent() {
@@ -63,19 +74,23 @@ func (c *ConnectionHandler) readEvent() {
 		case <-c.quit:
 			return
 
-		case msg := <-c.eventC:
-			logger.Debugf("connection handler received event: %v", msg)
+		case ev := <-c.eventC:
+			logger.Debugf("connection handler received event: %v", ev.req)
 
-			if msg != nil && c.sink != nil {
-				if err := c.sink.Write(context.Background(), msg); err != nil {
+			var err error
+			if ev.req != nil && c.sink != nil {
+				if err = c.sink.Write(context.Background(), ev.req); err != nil {
 					logger.Warnf("sink write failed: %v", err)
 				}
 			}
+			if ev.done != nil {
+				ev.done(err)
+			}
 		}
 	}
 }
 
-func (c *ConnectionHandler) postEvent(event *proto.DatabusRequest) error {
+func (c *ConnectionHandler) postEvent(event requestEvent) error {
 	select {
 	case c.eventC <- event:
 		return nil
@@ -87,7 +102,7 @@ func (c *ConnectionHandler) postEvent(event *proto.DatabusRequest) error {
 
 func (c *ConnectionHandler) run() {
 	if c.eventC == nil {
-		c.eventC = make(chan *proto.DatabusRequest, constant.DefaultMaxReceiveMessageSize)
+		c.eventC = make(requestEventC, constant.DefaultMaxReceiveMessageSize)
 	}
 	if c.quit == nil {
 		c.quit = make(chan struct{}, 1)
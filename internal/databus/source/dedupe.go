@@ -0,0 +1,147 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package source
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// dedupeWindow is the number of recently written sequence numbers kept per client.
+	dedupeWindow = 1024
+
+	// dedupeIdleTTL is how long a client's window is kept after its last request.
+	dedupeIdleTTL = 10 * time.Minute
+)
+
+// seqDedupe remembers the sequence numbers recently written for each client, so a batch
+// that an agent retransmits after losing its ack is acknowledged without being written
+// again. A batch retransmitted while its first copy is still being written is dropped
+// unacknowledged; the agent sends it again once its ack times out. It is best effort: a
+// sequence number that has left the window is written twice, and so is a batch
+// retransmitted to another databus instance, since each instance has its own windows.
+type seqDedupe struct {
+	mu        sync.Mutex
+	window    int
+	idleTTL   time.Duration
+	clients   map[string]*seqWindow
+	lastSweep time.Time
+}
+
+type seqWindow struct {
+	seqs     []uint64
+	next     int
+	pending  map[uint64]struct{}
+	lastSeen time.Time
+}
+
+// seqState is what Begin found about a sequence number.
+type seqState int
+
+const (
+	// seqNew has not been seen: it is now pending and the caller writes it.
+	seqNew seqState = iota
+
+	// seqPending is being written by an earlier copy of the batch.
+	seqPending
+
+	// seqWritten has already been written.
+	seqWritten
+)
+
+func newSeqDedupe(window int, idleTTL time.Duration) *seqDedupe {
+	return &seqDedupe{
+		window:  window,
+		idleTTL: idleTTL,
+		clients: make(map[string]*seqWindow),
+	}
+}
+
+// Begin reports what is known of seq for clientID and marks it pending when it is new.
+// A new seq must be passed to Finish once written. Seq 0 is always new and never tracked.
+func (d *seqDedupe) Begin(clientID string, seq uint64) seqState {
+	if seq == 0 {
+		return seqNew
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w := d.client(clientID)
+	if _, ok := w.pending[seq]; ok {
+		return seqPending
+	}
+	for _, s := range w.seqs {
+		if s == seq {
+			return seqWritten
+		}
+	}
+
+	w.pending[seq] = struct{}{}
+	return seqNew
+}
+
+// Finish ends the write of seq for clientID. A written seq is recorded, evicting the
+// oldest entry when the window is full; a failed one is forgotten so a retry is written.
+func (d *seqDedupe) Finish(clientID string, seq uint64, err error) {
+	if seq == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w := d.client(clientID)
+	delete(w.pending, seq)
+	if err != nil {
+		return
+	}
+
+	w.seqs[w.next] = seq
+	w.next = (w.next + 1) % len(w.seqs)
+}
+
+// client returns the window of clientID, creating it if needed. Must be called with
+// d.mu held.
+func (d *seqDedupe) client(clientID string) *seqWindow {
+	now := time.Now()
+	d.sweep(now)
+
+	w, ok := d.clients[clientID]
+	if !ok {
+		w = &seqWindow{seqs: make([]uint64, d.window), pending: make(map[uint64]struct{})}
+		d.clients[clientID] = w
+	}
+	w.lastSeen = now
+	return w
+}
+
+// sweep drops clients that have been idle for longer than idleTTL. Must be called
+// with d.mu held.
+func (d *seqDedupe) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < d.idleTTL {
+		return
+	}
+
+	d.lastSweep = now
+	for id, w := range d.clients {
+		if now.Sub(w.lastSeen) > d.idleTTL && len(w.pending) == 0 {
+			delete(d.clients, id)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package source
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSeqDedupe(t *testing.T) {
+	d := newSeqDedupe(3, time.Minute)
+
+	if got := d.Begin("a", 1); got != seqNew {
+		t.Fatalf("seq 1 = %d before it was written, want new", got)
+	}
+	if got := d.Begin("a", 1); got != seqPending {
+		t.Fatalf("seq 1 = %d while being written, want pending", got)
+	}
+	d.Finish("a", 1, nil)
+	if got := d.Begin("a", 1); got != seqWritten {
+		t.Fatalf("seq 1 = %d after it was written, want written", got)
+	}
+	if got := d.Begin("b", 1); got != seqNew {
+		t.Fatal("seq 1 of client a reported for client b")
+	}
+
+	for _, seq := range []uint64{2, 3, 4} {
+		d.Begin("a", seq)
+		d.Finish("a", seq, nil)
+	}
+	if got := d.Begin("a", 1); got != seqNew {
+		t.Fatal("seq 1 still written after leaving the window")
+	}
+	for _, seq := range []uint64{2, 3, 4} {
+		if got := d.Begin("a", seq); got != seqWritten {
+			t.Fatalf("seq %d = %d, want written", seq, got)
+		}
+	}
+
+	d.Begin("a", 0)
+	if got := d.Begin("a", 0); got != seqNew {
+		t.Fatal("seq 0 must never be deduplicated")
+	}
+}
+
+func TestSeqDedupeFailedWrite(t *testing.T) {
+	d := newSeqDedupe(3, time.Minute)
+
+	d.Begin("a", 1)
+	d.Finish("a", 1, errors.New("write failed"))
+	if got := d.Begin("a", 1); got != seqNew {
+		t.Fatalf("seq 1 = %d after a failed write, want new so the retry is written", got)
+	}
+}
+
+func TestSeqDedupeEvictsIdleClients(t *testing.T) {
+	d := newSeqDedupe(4, time.Minute)
+	d.Begin("a", 1)
+	d.Finish("a", 1, nil)
+	d.Begin("c", 1)
+
+	d.clients["a"].lastSeen = time.Now().Add(-2 * time.Minute)
+	d.clients["c"].lastSeen = time.Now().Add(-2 * time.Minute)
+	d.lastSweep = time.Time{}
+	d.Begin("b", 1)
+	d.Finish("b", 1, nil)
+
+	if _, ok := d.clients["a"]; ok {
+		t.Fatal("idle client a was not evicted")
+	}
+	if _, ok := d.clients["c"]; !ok {
+		t.Fatal("client c was evicted with a write pending")
+	}
+	if got := d.Begin("b", 1); got != seqWritten {
+		t.Fatal("client b lost")
+	}
+}
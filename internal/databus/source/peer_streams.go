@@ -0,0 +1,88 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+package source
+
+import (
+	"context"
+	"sync"
+
+	"os-artificer/saber/pkg/enrollment"
+	"os-artificer/saber/pkg/sbnet"
+)
+
+// PeerStreams tracks the open streams by the serial number of the client certificate they
+// were opened with, so that the streams of a revoked certificate can be closed. It is safe
+// for concurrent use; a nil *PeerStreams tracks nothing.
+type PeerStreams struct {
+	mu      sync.Mutex
+	streams map[string]map[*peerStream]struct{}
+}
+
+type peerStream struct {
+	cancel context.CancelCauseFunc
+}
+
+// NewPeerStreams returns an empty PeerStreams.
+func NewPeerStreams() *PeerStreams {
+	return &PeerStreams{streams: make(map[string]map[*peerStream]struct{})}
+}
+
+// open tracks the stream of ctx under the serial of its peer certificate. The returned
+// context is cancelled, with the cause passed to Close, when the certificate is closed;
+// release stops tracking the stream and must be called when it ends.
+func (p *PeerStreams) open(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	cert := sbnet.PeerCertificate(ctx)
+	if p == nil || cert == nil {
+		return ctx, func() { cancel(nil) }
+	}
+
+	serial := enrollment.SerialString(cert)
+	ps := &peerStream{cancel: cancel}
+	p.mu.Lock()
+	if p.streams[serial] == nil {
+		p.streams[serial] = make(map[*peerStream]struct{})
+	}
+	p.streams[serial][ps] = struct{}{}
+	p.mu.Unlock()
+
+	return ctx, func() {
+		p.mu.Lock()
+		delete(p.streams[serial], ps)
+		if len(p.streams[serial]) == 0 {
+			delete(p.streams, serial)
+		}
+		p.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// Close ends the open streams of the certificate with serial with cause, and returns how
+// many there were.
+func (p *PeerStreams) Close(serial string, cause error) int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	streams := p.streams[serial]
+	delete(p.streams, serial)
+	p.mu.Unlock()
+
+	for ps := range streams {
+		ps.cancel(cause)
+	}
+	return len(streams)
+}
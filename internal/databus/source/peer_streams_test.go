@@ -0,0 +1,103 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+package source
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// blockedStream is a StreamData stream whose reads block until it is closed.
+type blockedStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	closed chan struct{}
+}
+
+func (s *blockedStream) Context() context.Context { return s.ctx }
+
+func (s *blockedStream) Send(*proto.DatabusAck) error { return nil }
+
+func (s *blockedStream) Recv() (*proto.DatabusRequest, error) {
+	<-s.closed
+	return nil, context.Canceled
+}
+
+func TestPeerStreamsCloseRevokedStream(t *testing.T) {
+	cert := &x509.Certificate{SerialNumber: big.NewInt(0x2a)}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 50000},
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+		},
+	})
+	stream := &blockedStream{ctx: ctx, closed: make(chan struct{})}
+	defer close(stream.closed)
+
+	streams := NewPeerStreams()
+	srv := &pushDataServer{
+		connMgr: NewConnectionManager(0),
+		dedupe:  newSeqDedupe(dedupeWindow, dedupeIdleTTL),
+		streams: streams,
+	}
+	errC := make(chan error, 1)
+	go func() { errC <- srv.StreamData(stream) }()
+
+	deadline := time.Now().Add(time.Second)
+	for srv.connMgr.ActiveCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("stream not opened")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if n := streams.Close("2b", status.Error(codes.PermissionDenied, "revoked")); n != 0 {
+		t.Fatalf("Close of another serial closed %d streams", n)
+	}
+	select {
+	case err := <-errC:
+		t.Fatalf("stream of another certificate ended: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if n := streams.Close("2a", status.Error(codes.PermissionDenied, "revoked")); n != 1 {
+		t.Fatalf("Close closed %d streams, want 1", n)
+	}
+	select {
+	case err := <-errC:
+		if status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("StreamData = %v, want PermissionDenied", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream of the revoked certificate still open")
+	}
+	if n := srv.connMgr.ActiveCount(); n != 0 {
+		t.Fatalf("%d connections still registered", n)
+	}
+}
@@ -22,6 +22,8 @@ import (
 
 	"os-artificer/saber/internal/databus/config"
 	"os-artificer/saber/pkg/proto"
+
+	"google.golang.org/grpc/credentials"
 )
 
 var (
@@ -34,15 +36,37 @@ type Handler interface {
 	OnDatabusRequest(req *proto.DatabusRequest) error
 }
 
+// AckHandler is implemented by handlers that can report when a request has been written
+// to the sink. done is called once with the write result, unless
+// OnDatabusRequestAck returns an error. Sources use it to acknowledge requests to agents.
+type AckHandler interface {
+	Handler
+	OnDatabusRequestAck(req *proto.DatabusRequest, done func(err error)) error
+}
+
 // Source is a data source that runs and delivers DatabusRequests to the handler
 // until context is done. Implementations may start a gRPC server or other acceptor.
 type Source interface {
 	Run(ctx context.Context, h Handler) error
 }
 
-// NewSourceFromConfig creates a new source from a config.SourceConfig.
+// ServerOptions secure the network sources.
+type ServerOptions struct {
+	// Credentials authenticate the server; plaintext when nil.
+	Credentials credentials.TransportCredentials
+
+	// VerifyPeer, when set, is called for each new stream and rejects it on error.
+	VerifyPeer func(ctx context.Context) error
+
+	// Streams, when set, tracks the open streams by peer certificate so that they can be
+	// closed.
+	Streams *PeerStreams
+}
+
+// NewSourceFromConfig creates a new source from a config.SourceConfig. Network sources
+// are served with opts.
 // Supported source types: agent.
-func NewSourceFromConfig(cfg *config.SourceConfig) (Source, error) {
+func NewSourceFromConfig(cfg *config.SourceConfig, opts ServerOptions) (Source, error) {
 	switch cfg.Type {
 	case config.SourceTypeAgent:
 		cfg, err := ConfigFromMap(cfg.Config)
@@ -54,7 +78,13 @@ func NewSourceFromConfig(cfg *config.SourceConfig) (Source, error) {
 		if err != nil {
 			return nil, err
 		}
-		return NewAgentSource(address, nil), nil
+		src := NewAgentSource(address, nil)
+		if opts.Credentials != nil {
+			src.UseCredentials(opts.Credentials)
+		}
+		src.verifyPeer = opts.VerifyPeer
+		src.streams = opts.Streams
+		return src, nil
 
 	default:
 		return nil, ErrSourceTypeNotSupported
@@ -63,14 +93,14 @@ func NewSourceFromConfig(cfg *config.SourceConfig) (Source, error) {
 
 // NewSourcesFromConfig creates sources from a slice of config.SourceConfig.
 // Returns (nil, error) if any config fails to create a source.
-func NewSourcesFromConfig(cfgs []config.SourceConfig) ([]Source, error) {
+func NewSourcesFromConfig(cfgs []config.SourceConfig, opts ServerOptions) ([]Source, error) {
 	if len(cfgs) == 0 {
 		return []Source{}, nil
 	}
 
 	sources := make([]Source, 0, len(cfgs))
 	for i := range cfgs {
-		src, err := NewSourceFromConfig(&cfgs[i])
+		src, err := NewSourceFromConfig(&cfgs[i], opts)
 		if err != nil {
 			return nil, err
 		}
@@ -38,6 +38,7 @@ const (
 
 var (
 	ErrEmptyWatchedKey = gerrors.New(gerrors.InvalidParameter, "watched key is required but got empty string")
+	ErrNotConnected    = gerrors.New(gerrors.ComponentFailure, "discovery is not connected, watch a key first")
 )
 
 // WatchEvent This event will be generated
@@ -83,6 +84,10 @@ func (d *Discovery) Get(ctx context.Context, key string) ([]byte, error) {
 	d.cliMu.RLock()
 	defer d.cliMu.RUnlock()
 
+	if d.client == nil {
+		return nil, ErrNotConnected
+	}
+
 	resp, err := d.client.Get(ctx, key)
 	if err != nil {
 		return nil, gerrors.New(gerrors.ComponentFailure, err.Error())
@@ -102,6 +107,10 @@ func (d *Discovery) GetWithPrefix(ctx context.Context, key string) (map[string][
 	d.cliMu.RLock()
 	defer d.cliMu.RUnlock()
 
+	if d.client == nil {
+		return nil, ErrNotConnected
+	}
+
 	resp, err := d.client.Get(ctx, key, clientv3.WithPrefix())
 
 	if err != nil {
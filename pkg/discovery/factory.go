@@ -32,6 +32,7 @@ const (
 // Client etcd client
 type Client struct {
 	opts             options
+	rootKeyPrefix    string
 	createEtcdClient func() (*clientv3.Client, error)
 }
 
@@ -47,6 +48,7 @@ func NewClientWithOptions(opts ...Option) (*Client, error) {
 		}
 	}
 
+	cli.rootKeyPrefix = cli.opts.registryRootKeyPrefix
 	if cli.opts.serviceName != "" {
 		cli.opts.registryRootKeyPrefix += "/" + cli.opts.serviceName
 	}
@@ -81,6 +83,12 @@ func (c Client) GetSelfPrefix() string {
 	return c.opts.registryRootKeyPrefix + "/" + etcdKeySegmentSelf
 }
 
+// GetServicePrefix returns the etcd key prefix under which the instances of service
+// register, whatever the service name of this client. Keys are GetServicePrefix(service) + serviceID.
+func (c Client) GetServicePrefix(service string) string {
+	return c.rootKeyPrefix + "/" + service + "/" + etcdKeySegmentSelf + "/"
+}
+
 // GetElectionPrefix returns the etcd key prefix for leader election.
 //
 //	Full key for one election is GetElectionPrefix() + "/" + name.
@@ -0,0 +1,317 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/sbnet"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the gRPC resolver scheme of services registered with Registry.SetService,
+// e.g. grpc.NewClient("saber-etcd:///databus").
+const Scheme = "saber-etcd"
+
+// RoundRobinServiceConfig is a gRPC service config spreading calls over all instances.
+// Pass it with grpc.WithDefaultServiceConfig.
+const RoundRobinServiceConfig = `{"loadBalancingConfig":[{"round_robin":{}}]}`
+
+const (
+	resolverSyncInterval  = 30 * time.Second
+	resolverRetryInterval = time.Second
+	resolverMaxRetry      = 30 * time.Second
+	resolverGetTimeout    = 5 * time.Second
+)
+
+var (
+	// ErrNoInstances is reported to gRPC while a service has no registered instance.
+	ErrNoInstances = gerrors.New(gerrors.NotFound, "no registered instance")
+
+	errResolverWatchClosed = errors.New("watch channel closed")
+)
+
+// instanceIDKey is the address attribute holding the instance's service ID.
+type instanceIDKey struct{}
+
+// InstanceID returns the service ID of the instance behind a resolved address, for use
+// by custom balancers.
+func InstanceID(addr resolver.Address) string {
+	id, _ := addr.BalancerAttributes.Value(instanceIDKey{}).(string)
+	return id
+}
+
+// ParseServiceEndpoint parses the value an instance registered with Registry.SetService:
+// its listen address (tcp://host:port or host:port) or a JSON ServiceInfo.
+func ParseServiceEndpoint(value []byte) (*sbnet.Endpoint, error) {
+	raw := strings.TrimSpace(string(value))
+	if raw == "" {
+		return nil, gerrors.New(gerrors.InvalidParameter, "empty service value")
+	}
+
+	var ep *sbnet.Endpoint
+	if strings.HasPrefix(raw, "{") {
+		var info ServiceInfo
+		if err := json.Unmarshal(value, &info); err != nil {
+			return nil, gerrors.NewE(gerrors.InvalidParameter, err)
+		}
+		if info.ListenAddress == nil {
+			return nil, gerrors.New(gerrors.InvalidParameter, "service info without listen address")
+		}
+		ep = &sbnet.Endpoint{Protocol: "tcp", Host: info.ListenAddress.Host, Port: info.ListenAddress.Port}
+	} else {
+		if !strings.Contains(raw, "://") {
+			raw = "tcp://" + raw
+		}
+		var err error
+		if ep, err = sbnet.NewEndpointFromString(raw); err != nil {
+			return nil, gerrors.NewE(gerrors.InvalidParameter, err)
+		}
+	}
+
+	if ip := net.ParseIP(ep.Host); ep.Host == "" || (ip != nil && ip.IsUnspecified()) {
+		return nil, gerrors.Newf(gerrors.InvalidParameter, "unspecified host in %q", raw)
+	}
+	return ep, nil
+}
+
+// resolverBuilder implements resolver.Builder for Scheme.
+type resolverBuilder struct {
+	client *Client
+}
+
+// NewResolverBuilder returns a gRPC resolver.Builder for Scheme that resolves the
+// instances registered under c's registry root key prefix. Use it with
+// grpc.WithResolvers, or register it globally with resolver.Register.
+func NewResolverBuilder(c *Client) resolver.Builder {
+	return &resolverBuilder{client: c}
+}
+
+// Scheme implements resolver.Builder.
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+// Build implements resolver.Builder.
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn,
+	_ resolver.BuildOptions) (resolver.Resolver, error) {
+
+	service := strings.Trim(target.Endpoint(), "/")
+	if service == "" {
+		return nil, fmt.Errorf("%s: target %q has no service name", Scheme, target.URL.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &etcdResolver{
+		service:    service,
+		prefix:     b.client.GetServicePrefix(service),
+		cc:         cc,
+		newDisc:    b.client.CreateDiscovery,
+		ctx:        ctx,
+		cancel:     cancel,
+		resolveNow: make(chan struct{}, 1),
+		instances:  make(map[string]resolver.Address),
+	}
+
+	r.wg.Add(1)
+	go r.run()
+	return r, nil
+}
+
+// etcdResolver follows the instances of one service and pushes them to gRPC.
+type etcdResolver struct {
+	service    string
+	prefix     string
+	cc         resolver.ClientConn
+	newDisc    func() (*Discovery, error)
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	resolveNow chan struct{}
+
+	// instances is only accessed by the run goroutine.
+	instances map[string]resolver.Address
+	last      []string
+}
+
+// ResolveNow implements resolver.Resolver; it triggers a resync.
+func (r *etcdResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+// Close implements resolver.Resolver.
+func (r *etcdResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *etcdResolver) run() {
+	defer r.wg.Done()
+
+	retry := resolverRetryInterval
+	for {
+		err := r.watch()
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		logger.Warnf("%s resolver: watch of %s failed, retrying in %v: %v", Scheme, r.service, retry, err)
+		r.cc.ReportError(err)
+
+		select {
+		case <-time.After(retry):
+		case <-r.ctx.Done():
+			return
+		}
+		retry = min(2*retry, resolverMaxRetry)
+	}
+}
+
+// watch follows the service's instances until the watch fails or the resolver is closed.
+func (r *etcdResolver) watch() error {
+	disc, err := r.newDisc()
+	if err != nil {
+		return err
+	}
+	defer disc.Close()
+
+	// Watch first so that no change between the initial list and the watch is lost.
+	events, err := disc.WatchWithPrefix(r.ctx, r.prefix)
+	if err != nil {
+		return err
+	}
+	// Push the state again after a failed watch, which was reported as an error.
+	r.last = nil
+	if err := r.sync(disc); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(resolverSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return nil
+
+		case ev, ok := <-events:
+			if !ok {
+				return errResolverWatchClosed
+			}
+			r.apply(ev)
+			r.push()
+
+		case <-r.resolveNow:
+			if err := r.sync(disc); err != nil {
+				return err
+			}
+
+		case <-ticker.C:
+			if err := r.sync(disc); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sync replaces the known instances with those currently registered.
+func (r *etcdResolver) sync(disc *Discovery) error {
+	ctx, cancel := context.WithTimeout(r.ctx, resolverGetTimeout)
+	defer cancel()
+
+	kvs, err := disc.GetWithPrefix(ctx, r.prefix)
+	if err != nil {
+		return err
+	}
+
+	r.instances = make(map[string]resolver.Address, len(kvs))
+	for key, value := range kvs {
+		r.apply(&WatchEvent{EventType: WatchedEventPut, Key: key, Value: value})
+	}
+	r.push()
+	return nil
+}
+
+// apply applies one watch event to the known instances.
+func (r *etcdResolver) apply(ev *WatchEvent) {
+	if ev.EventType == WatchedEventDelete {
+		delete(r.instances, ev.Key)
+		return
+	}
+
+	ep, err := ParseServiceEndpoint(ev.Value)
+	if err != nil {
+		// Registry writes an empty value before SetService; skip it quietly.
+		if len(strings.TrimSpace(string(ev.Value))) > 0 {
+			logger.Warnf("%s resolver: ignoring %s instance %s: %v", Scheme, r.service, ev.Key, err)
+		}
+		delete(r.instances, ev.Key)
+		return
+	}
+
+	id := strings.TrimPrefix(ev.Key, r.prefix)
+	r.instances[ev.Key] = resolver.Address{
+		Addr:               ep.HostPort(),
+		BalancerAttributes: attributes.New(instanceIDKey{}, id),
+	}
+}
+
+// push sends the known addresses to gRPC when they changed.
+func (r *etcdResolver) push() {
+	keys := make([]string, 0, len(r.instances))
+	for key := range r.instances {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	current := make([]string, 0, len(keys))
+	addrs := make([]resolver.Address, 0, len(keys))
+	for _, key := range keys {
+		addr := r.instances[key]
+		current = append(current, key+"="+addr.Addr)
+		addrs = append(addrs, addr)
+	}
+
+	if r.last != nil && slices.Equal(current, r.last) {
+		return
+	}
+	r.last = current
+
+	if len(addrs) == 0 {
+		r.cc.ReportError(ErrNoInstances)
+		return
+	}
+	if err := r.cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		logger.Debugf("%s resolver: update state of %s: %v", Scheme, r.service, err)
+	}
+}
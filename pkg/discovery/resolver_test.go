@@ -0,0 +1,163 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package discovery_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/discovery"
+	"os-artificer/saber/pkg/discovery/etcdtest"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// backend is a gRPC server counting the health checks it answers.
+type backend struct {
+	addr  string
+	calls atomic.Int64
+}
+
+func startBackend(t *testing.T) *backend {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &backend{addr: lis.Addr().String()}
+	svr := grpc.NewServer(grpc.UnaryInterceptor(
+		func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			b.calls.Add(1)
+			return handler(ctx, req)
+		}))
+	healthpb.RegisterHealthServer(svr, health.NewServer())
+	go func() { _ = svr.Serve(lis) }()
+	t.Cleanup(svr.Stop)
+	return b
+}
+
+func register(t *testing.T, etcd, id, value string) *discovery.Registry {
+	t.Helper()
+
+	cli, err := discovery.NewClientWithOptions(
+		discovery.OptionEndpoints([]string{etcd}),
+		discovery.OptionServiceName("databus"),
+		discovery.OptionServiceID(id),
+		discovery.OptionTTL(10),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := cli.CreateRegistry()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := reg.SetService(ctx, value); err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func TestResolverBalancesOverRegisteredInstances(t *testing.T) {
+	etcd := etcdtest.Start(t)
+	b1, b2 := startBackend(t), startBackend(t)
+
+	reg1 := register(t, etcd, "instance-1", "tcp://"+b1.addr)
+	reg2 := register(t, etcd, "instance-2", b2.addr)
+	defer reg2.Close()
+
+	cli, err := discovery.NewClientWithOptions(discovery.OptionEndpoints([]string{etcd}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := grpc.NewClient(
+		fmt.Sprintf("%s:///databus", discovery.Scheme),
+		grpc.WithResolvers(discovery.NewResolverBuilder(cli)),
+		grpc.WithDefaultServiceConfig(discovery.RoundRobinServiceConfig),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	health := healthpb.NewHealthClient(conn)
+	check := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := health.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitUntil := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(20 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s (calls: %d, %d)", what, b1.calls.Load(), b2.calls.Load())
+			}
+			check()
+		}
+	}
+
+	waitUntil("both instances to be used", func() bool {
+		return b1.calls.Load() > 0 && b2.calls.Load() > 0
+	})
+
+	// Once instance-1 deregisters, every call goes to instance-2.
+	reg1.Close()
+	waitUntil("instance-1 to be dropped", func() bool {
+		before := b1.calls.Load()
+		for i := 0; i < 10; i++ {
+			check()
+		}
+		return b1.calls.Load() == before
+	})
+}
+
+func TestParseServiceEndpoint(t *testing.T) {
+	for value, want := range map[string]string{
+		"tcp://10.0.0.1:26689": "10.0.0.1:26689",
+		"10.0.0.1:26689":       "10.0.0.1:26689",
+		`{"listen_address":{"host":"10.0.0.2","port":26688}}`: "10.0.0.2:26688",
+	} {
+		ep, err := discovery.ParseServiceEndpoint([]byte(value))
+		if err != nil {
+			t.Fatalf("ParseServiceEndpoint(%q): %v", value, err)
+		}
+		if ep.HostPort() != want {
+			t.Fatalf("ParseServiceEndpoint(%q) = %s, want %s", value, ep.HostPort(), want)
+		}
+	}
+
+	for _, value := range []string{"", "tcp://0.0.0.0:26689", `{"name":"databus"}`, "not an endpoint"} {
+		if _, err := discovery.ParseServiceEndpoint([]byte(value)); err == nil {
+			t.Fatalf("ParseServiceEndpoint(%q) succeeded", value)
+		}
+	}
+}
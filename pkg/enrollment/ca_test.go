@@ -0,0 +1,96 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package enrollment
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func newTestCA(t *testing.T) *CA {
+	t.Helper()
+
+	certPEM, keyPEM, err := GenerateCA("saber test ca", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := ParseCA(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+func TestCA_Sign(t *testing.T) {
+	ca := newTestCA(t)
+
+	// The request asks for another identity; the certificate is issued for clientID anyway.
+	_, csrPEM, err := NewKeyAndCSR("someone-else")
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := ParseCSR(csrPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, certPEM, err := ca.Sign(csr, "agent-1", 365*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certPEM) == 0 {
+		t.Fatal("empty certificate PEM")
+	}
+	if cert.Subject.CommonName != "agent-1" {
+		t.Fatalf("CommonName = %q, want agent-1", cert.Subject.CommonName)
+	}
+	if cert.NotAfter.After(ca.cert.NotAfter) {
+		t.Fatal("certificate outlives its CA")
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca.CertificatePEM()) {
+		t.Fatal("bad CA PEM")
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Fatalf("issued certificate does not verify: %v", err)
+	}
+}
+
+func TestParseCSR_invalid(t *testing.T) {
+	_, csrPEM, err := NewKeyAndCSR("agent-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := append([]byte(nil), csrPEM...)
+	tampered[len(tampered)/2] ^= 0x01
+
+	for name, in := range map[string][]byte{
+		"empty":    nil,
+		"not pem":  []byte("hello"),
+		"tampered": tampered,
+	} {
+		if _, err := ParseCSR(in); err == nil {
+			t.Fatalf("%s: ParseCSR succeeded", name)
+		}
+	}
+}
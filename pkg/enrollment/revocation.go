@@ -0,0 +1,170 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package enrollment
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"path"
+	"sync"
+	"time"
+
+	"os-artificer/saber/pkg/logger"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// maxRetryInterval caps the backoff between failed watches.
+const maxRetryInterval = 30 * time.Second
+
+var errWatchClosed = errors.New("watch channel closed")
+
+// RevocationList follows the revoked certificates in a Store, so that servers can refuse
+// them without a round trip to etcd on every connection.
+type RevocationList struct {
+	store *Store
+
+	mu          sync.RWMutex
+	serials     map[string]*CertInfo
+	subscribers []func(*CertInfo)
+}
+
+// NewRevocationList returns an empty list for store; call Run to fill and follow it.
+func NewRevocationList(store *Store) *RevocationList {
+	return &RevocationList{store: store, serials: make(map[string]*CertInfo)}
+}
+
+// IsRevoked reports whether cert has been revoked.
+func (l *RevocationList) IsRevoked(cert *x509.Certificate) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, ok := l.serials[SerialString(cert)]
+	return ok
+}
+
+// Subscribe registers fn to be called for every certificate revoked after Run started.
+func (l *RevocationList) Subscribe(fn func(*CertInfo)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, fn)
+}
+
+// Run loads the revocations and follows them until ctx is done, reloading the full list
+// whenever the watch fails.
+func (l *RevocationList) Run(ctx context.Context) error {
+	retry := time.Second
+	for {
+		err := l.watch(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		logger.Warnf("revocation list: watch failed, retrying in %s: %v", retry, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retry):
+		}
+		retry = min(2*retry, maxRetryInterval)
+	}
+}
+
+// Sync replaces the list with the revocations in the store and returns the store revision
+// it reflects. Subscribers are called for the revocations that were not in the list.
+func (l *RevocationList) Sync(ctx context.Context) (int64, error) {
+	prefix := l.store.key(keySegmentRevoked)
+	resp, err := l.store.cli.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, err
+	}
+
+	serials := make(map[string]*CertInfo, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var info CertInfo
+		if err := json.Unmarshal(kv.Value, &info); err == nil {
+			serials[path.Base(string(kv.Key))] = &info
+		}
+	}
+
+	l.mu.Lock()
+	var added []*CertInfo
+	for serial, info := range serials {
+		if _, ok := l.serials[serial]; !ok {
+			added = append(added, info)
+		}
+	}
+	l.serials = serials
+	subscribers := l.subscribers
+	l.mu.Unlock()
+
+	// Revocations missed while the watch was down are announced now.
+	for _, info := range added {
+		for _, fn := range subscribers {
+			fn(info)
+		}
+	}
+	return resp.Header.Revision, nil
+}
+
+func (l *RevocationList) watch(ctx context.Context) error {
+	rev, err := l.Sync(ctx)
+	if err != nil {
+		return err
+	}
+
+	wctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	prefix := l.store.key(keySegmentRevoked)
+	for resp := range l.store.cli.Watch(wctx, prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1)) {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+		for _, ev := range resp.Events {
+			l.apply(ev)
+		}
+	}
+	return errWatchClosed
+}
+
+func (l *RevocationList) apply(ev *clientv3.Event) {
+	serial := path.Base(string(ev.Kv.Key))
+	if ev.Type == clientv3.EventTypeDelete {
+		l.mu.Lock()
+		delete(l.serials, serial)
+		l.mu.Unlock()
+		return
+	}
+
+	var info CertInfo
+	if err := json.Unmarshal(ev.Kv.Value, &info); err != nil {
+		logger.Warnf("revocation list: bad entry %s: %v", ev.Kv.Key, err)
+		return
+	}
+
+	l.mu.Lock()
+	l.serials[serial] = &info
+	subscribers := l.subscribers
+	l.mu.Unlock()
+
+	logger.Infof("certificate revoked: clientID=%s serial=%s", info.ClientID, serial)
+	for _, fn := range subscribers {
+		fn(&info)
+	}
+}
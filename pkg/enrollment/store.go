@@ -0,0 +1,424 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package enrollment
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"os-artificer/saber/pkg/gerrors"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	keySegmentTokens  = "enrollment/tokens"
+	keySegmentCerts   = "enrollment/certs"
+	keySegmentRevoked = "enrollment/revoked"
+)
+
+var (
+	ErrInvalidToken   = gerrors.New(gerrors.InvalidParameter, "invalid, used or expired bootstrap token")
+	ErrTokenNotFound  = gerrors.New(gerrors.NotFound, "bootstrap token not found")
+	ErrCertNotFound   = gerrors.New(gerrors.NotFound, "no certificate issued to this client")
+	ErrClientEnrolled = gerrors.New(gerrors.AlreadyExists,
+		"client already holds a live certificate, revoke it before enrolling again")
+)
+
+// TokenInfo describes a bootstrap token. Only a hash of the token's secret is stored.
+type TokenInfo struct {
+	ID        string    `json:"id"`
+	Hash      string    `json:"hash"`
+	OneTime   bool      `json:"oneTime"`
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+	CreatedAt time.Time `json:"createdAt"`
+	Comment   string    `json:"comment,omitempty"`
+}
+
+// Expired reports whether the token is past its expiry at now.
+func (t *TokenInfo) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+// CertInfo describes a certificate issued to an agent, or a revoked one.
+type CertInfo struct {
+	ClientID  string    `json:"clientID"`
+	Serial    string    `json:"serial"`
+	NotAfter  time.Time `json:"notAfter"`
+	IssuedAt  time.Time `json:"issuedAt,omitzero"`
+	RevokedAt time.Time `json:"revokedAt,omitzero"`
+}
+
+// Store keeps bootstrap tokens, issued certificates and revocations in etcd under
+// rootKeyPrefix, where the admin commands and every controller instance share them.
+// Keys expire with what they describe, so the store does not grow without bound.
+type Store struct {
+	cli    *clientv3.Client
+	prefix string
+}
+
+// NewStore returns a store using cli under rootKeyPrefix (the discovery root prefix).
+func NewStore(cli *clientv3.Client, rootKeyPrefix string) *Store {
+	return &Store{cli: cli, prefix: strings.TrimSuffix(rootKeyPrefix, "/")}
+}
+
+func (s *Store) key(segment string, parts ...string) string {
+	return s.prefix + "/" + segment + "/" + strings.Join(parts, "/")
+}
+
+// CreateToken mints a bootstrap token. A zero ttl never expires; a one-time token is
+// consumed by the first successful enrollment. The returned token is "<id>.<secret>" and
+// cannot be recovered later.
+func (s *Store) CreateToken(ctx context.Context, ttl time.Duration, oneTime bool,
+	comment string) (string, *TokenInfo, error) {
+
+	if ttl < 0 {
+		return "", nil, gerrors.New(gerrors.InvalidParameter, "token ttl must not be negative")
+	}
+
+	id, err := randomString(6)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomString(24)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now().UTC()
+	info := &TokenInfo{
+		ID:        id,
+		Hash:      hashSecret(secret),
+		OneTime:   oneTime,
+		CreatedAt: now,
+		Comment:   comment,
+	}
+	if ttl > 0 {
+		info.ExpiresAt = now.Add(ttl)
+	}
+
+	if err := s.put(ctx, s.key(keySegmentTokens, id), info, ttl); err != nil {
+		return "", nil, err
+	}
+	return id + "." + secret, info, nil
+}
+
+// ListTokens returns the tokens that have not expired, used or been revoked.
+func (s *Store) ListTokens(ctx context.Context) ([]*TokenInfo, error) {
+	resp, err := s.cli.Get(ctx, s.key(keySegmentTokens), clientv3.WithPrefix())
+	if err != nil {
+		return nil, gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+
+	now := time.Now()
+	tokens := make([]*TokenInfo, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var info TokenInfo
+		if err := json.Unmarshal(kv.Value, &info); err != nil || info.Expired(now) {
+			continue
+		}
+		tokens = append(tokens, &info)
+	}
+	return tokens, nil
+}
+
+// RevokeToken deletes the token with id, so that it can no longer be used to enroll.
+func (s *Store) RevokeToken(ctx context.Context, id string) error {
+	resp, err := s.cli.Delete(ctx, s.key(keySegmentTokens, id))
+	if err != nil {
+		return gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+	if resp.Deleted == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// ConsumeToken validates token and, for a one-time token, deletes it atomically so that
+// it cannot be used twice, even by concurrent enrollments on different controllers.
+func (s *Store) ConsumeToken(ctx context.Context, token string) (*TokenInfo, error) {
+	info, used, err := s.validToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.commit(ctx, used, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return info, nil
+}
+
+// RecordCertificate consumes token and remembers a certificate issued to clientID until it
+// expires, so that it can be revoked by client ID. Both happen in one transaction: it
+// fails with ErrClientEnrolled, leaving the token in place, when clientID already holds a
+// live certificate, even one recorded concurrently by another controller, so that a
+// bootstrap token cannot be used to take over the identity of an enrolled agent.
+func (s *Store) RecordCertificate(ctx context.Context, token, clientID string, cert *x509.Certificate) (*TokenInfo, error) {
+	info, used, err := s.validToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	live, rev, err := s.liveCertificates(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(live) > 0 {
+		return nil, ErrClientEnrolled
+	}
+
+	put, err := s.putOp(ctx, s.key(keySegmentCerts, clientID, SerialString(cert)), newCertInfo(clientID, cert),
+		max(time.Until(cert.NotAfter), time.Second))
+	if err != nil {
+		return nil, err
+	}
+	// No certificate of clientID may have been recorded since it was listed.
+	used.cmps = append(used.cmps,
+		clientv3.Compare(clientv3.ModRevision(s.key(keySegmentCerts, clientID, "")), "<", rev+1).WithPrefix())
+	ok, err := s.commit(ctx, used, &put)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return info, nil
+	}
+
+	// Tell a token consumed concurrently from a certificate recorded concurrently.
+	if _, _, err := s.validToken(ctx, token); err != nil {
+		return nil, err
+	}
+	return nil, ErrClientEnrolled
+}
+
+// tokenUse holds the conditions and operations that consume a validated token.
+type tokenUse struct {
+	cmps []clientv3.Cmp
+	ops  []clientv3.Op
+}
+
+// validToken checks token and returns how to consume it: a one-time token is deleted,
+// provided it has not changed since it was checked.
+func (s *Store) validToken(ctx context.Context, token string) (*TokenInfo, *tokenUse, error) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || id == "" || secret == "" || strings.Contains(id, "/") {
+		return nil, nil, ErrInvalidToken
+	}
+
+	key := s.key(keySegmentTokens, id)
+	resp, err := s.cli.Get(ctx, key)
+	if err != nil {
+		return nil, nil, gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	kv := resp.Kvs[0]
+	var info TokenInfo
+	if err := json.Unmarshal(kv.Value, &info); err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if subtle.ConstantTimeCompare([]byte(info.Hash), []byte(hashSecret(secret))) != 1 ||
+		info.Expired(time.Now()) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	used := &tokenUse{}
+	if info.OneTime {
+		used.cmps = append(used.cmps, clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision))
+		used.ops = append(used.ops, clientv3.OpDelete(key))
+	}
+	return &info, used, nil
+}
+
+// commit applies used, and op when not nil, in one transaction. It reports whether the
+// conditions held.
+func (s *Store) commit(ctx context.Context, used *tokenUse, op *clientv3.Op) (bool, error) {
+	ops := used.ops
+	if op != nil {
+		ops = append(ops, *op)
+	}
+	if len(ops) == 0 {
+		return true, nil
+	}
+	txn, err := s.cli.Txn(ctx).If(used.cmps...).Then(ops...).Commit()
+	if err != nil {
+		return false, gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+	return txn.Succeeded, nil
+}
+
+// RecordRenewal remembers a certificate issued to clientID in exchange for a live one,
+// which stays valid until it expires.
+func (s *Store) RecordRenewal(ctx context.Context, clientID string, cert *x509.Certificate) error {
+	return s.put(ctx, s.key(keySegmentCerts, clientID, SerialString(cert)), newCertInfo(clientID, cert),
+		max(time.Until(cert.NotAfter), time.Second))
+}
+
+// liveCertificates returns the certificates issued to clientID that have neither expired
+// nor been revoked, and the store revision they were listed at.
+func (s *Store) liveCertificates(ctx context.Context, clientID string) ([]*CertInfo, int64, error) {
+	resp, err := s.cli.Get(ctx, s.key(keySegmentCerts, clientID, ""), clientv3.WithPrefix())
+	if err != nil {
+		return nil, 0, gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+
+	now := time.Now()
+	var live []*CertInfo
+	for _, kv := range resp.Kvs {
+		var info CertInfo
+		if err := json.Unmarshal(kv.Value, &info); err != nil || !now.Before(info.NotAfter) {
+			continue
+		}
+
+		revoked, err := s.cli.Get(ctx, s.key(keySegmentRevoked, info.Serial), clientv3.WithCountOnly())
+		if err != nil {
+			return nil, 0, gerrors.NewE(gerrors.ComponentFailure, err)
+		}
+		if revoked.Count == 0 {
+			live = append(live, &info)
+		}
+	}
+	return live, resp.Header.GetRevision(), nil
+}
+
+// ListCertificates returns the unexpired certificates issued to clientID, or to every
+// client when clientID is empty.
+func (s *Store) ListCertificates(ctx context.Context, clientID string) ([]*CertInfo, error) {
+	prefix := s.key(keySegmentCerts)
+	if clientID != "" {
+		prefix = s.key(keySegmentCerts, clientID, "")
+	}
+	return s.listCerts(ctx, prefix)
+}
+
+// RevokeCertificates revokes the certificates issued to clientID: all of them when
+// serial is empty, otherwise only the one with serial. It returns the revoked certificates.
+func (s *Store) RevokeCertificates(ctx context.Context, clientID, serial string) ([]*CertInfo, error) {
+	certs, err := s.ListCertificates(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var revoked []*CertInfo
+	for _, c := range certs {
+		if serial != "" && !strings.EqualFold(c.Serial, serial) {
+			continue
+		}
+		c.RevokedAt = now
+		ttl := max(time.Until(c.NotAfter), time.Second)
+		if err := s.put(ctx, s.key(keySegmentRevoked, c.Serial), c, ttl); err != nil {
+			return revoked, err
+		}
+		revoked = append(revoked, c)
+	}
+
+	if len(revoked) == 0 {
+		return nil, ErrCertNotFound
+	}
+	return revoked, nil
+}
+
+// Revocations returns the revoked certificates that have not expired.
+func (s *Store) Revocations(ctx context.Context) ([]*CertInfo, error) {
+	return s.listCerts(ctx, s.key(keySegmentRevoked))
+}
+
+func (s *Store) listCerts(ctx context.Context, prefix string) ([]*CertInfo, error) {
+	resp, err := s.cli.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+
+	certs := make([]*CertInfo, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var info CertInfo
+		if err := json.Unmarshal(kv.Value, &info); err != nil {
+			continue
+		}
+		certs = append(certs, &info)
+	}
+	return certs, nil
+}
+
+// put stores v as JSON under key, attached to a lease of ttl when ttl is positive.
+func (s *Store) put(ctx context.Context, key string, v any, ttl time.Duration) error {
+	op, err := s.putOp(ctx, key, v, ttl)
+	if err != nil {
+		return err
+	}
+	if _, err := s.cli.Do(ctx, op); err != nil {
+		return gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+	return nil
+}
+
+// putOp returns the operation of put.
+func (s *Store) putOp(ctx context.Context, key string, v any, ttl time.Duration) (clientv3.Op, error) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return clientv3.Op{}, gerrors.NewE(gerrors.InvalidParameter, err)
+	}
+
+	var opts []clientv3.OpOption
+	if ttl > 0 {
+		lease, err := s.cli.Grant(ctx, int64((ttl+time.Second-1)/time.Second))
+		if err != nil {
+			return clientv3.Op{}, gerrors.NewE(gerrors.ComponentFailure, err)
+		}
+		opts = append(opts, clientv3.WithLease(lease.ID))
+	}
+	return clientv3.OpPut(key, string(value), opts...), nil
+}
+
+func newCertInfo(clientID string, cert *x509.Certificate) *CertInfo {
+	return &CertInfo{
+		ClientID: clientID,
+		Serial:   SerialString(cert),
+		NotAfter: cert.NotAfter,
+		IssuedAt: time.Now().UTC(),
+	}
+}
+
+// SerialString formats the serial number of cert as used in the store.
+func SerialString(cert *x509.Certificate) string {
+	return cert.SerialNumber.Text(16)
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,258 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package enrollment
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/discovery/etcdtest"
+	"os-artificer/saber/pkg/gerrors"
+)
+
+func newTestStore(t *testing.T) *Store {
+	return NewStore(etcdtest.NewClient(t), "/saber-test")
+}
+
+func TestStore_tokens(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	reusable, _, err := store.CreateToken(ctx, time.Hour, false, "rack 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneTime, oneTimeInfo, err := store.CreateToken(ctx, 0, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := store.ConsumeToken(ctx, reusable); err != nil {
+			t.Fatalf("reusable token, use %d: %v", i+1, err)
+		}
+	}
+
+	if _, err := store.ConsumeToken(ctx, oneTime); err != nil {
+		t.Fatalf("one-time token: %v", err)
+	}
+	if _, err := store.ConsumeToken(ctx, oneTime); !gerrors.Is(err, ErrInvalidToken) {
+		t.Fatalf("one-time token reused: got %v, want ErrInvalidToken", err)
+	}
+	if err := store.RevokeToken(ctx, oneTimeInfo.ID); !gerrors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("RevokeToken(used) = %v, want ErrTokenNotFound", err)
+	}
+
+	id, secret, _ := strings.Cut(reusable, ".")
+	for _, bad := range []string{"", "nodot", id + ".wrong" + secret, "unknown." + secret} {
+		if _, err := store.ConsumeToken(ctx, bad); !gerrors.Is(err, ErrInvalidToken) {
+			t.Fatalf("ConsumeToken(%q) = %v, want ErrInvalidToken", bad, err)
+		}
+	}
+
+	tokens, err := store.ListTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].ID != id || tokens[0].Comment != "rack 1" {
+		t.Fatalf("ListTokens() = %+v, want only %s", tokens, id)
+	}
+
+	if err := store.RevokeToken(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.ConsumeToken(ctx, reusable); !gerrors.Is(err, ErrInvalidToken) {
+		t.Fatalf("revoked token: got %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestStore_concurrentOneTimeToken(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	token, _, err := store.CreateToken(ctx, time.Minute, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 8
+	results := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.ConsumeToken(ctx, token)
+			results <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		if <-results == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d enrollments succeeded with a one-time token, want 1", succeeded)
+	}
+}
+
+// signCert signs a certificate for clientID with ca.
+func signCert(t *testing.T, ca *CA, clientID string) *x509.Certificate {
+	t.Helper()
+
+	_, csrPEM, err := NewKeyAndCSR(clientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := ParseCSR(csrPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _, err := ca.Sign(csr, clientID, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestStore_oneLiveCertificatePerClient(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	ca := newTestCA(t)
+
+	token, _, err := store.CreateToken(ctx, 0, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneTime, _, err := store.CreateToken(ctx, 0, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.RecordCertificate(ctx, token, "agent-1", signCert(t, ca, "agent-1")); err != nil {
+		t.Fatal(err)
+	}
+	second := signCert(t, ca, "agent-1")
+	if _, err := store.RecordCertificate(ctx, oneTime, "agent-1", second); !gerrors.Is(err, ErrClientEnrolled) {
+		t.Fatalf("second certificate of agent-1: got %v, want ErrClientEnrolled", err)
+	}
+
+	// The refused enrollment left the one-time token in place.
+	if _, err := store.RevokeCertificates(ctx, "agent-1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.RecordCertificate(ctx, oneTime, "agent-1", second); err != nil {
+		t.Fatalf("certificate of agent-1 after revocation: %v", err)
+	}
+	if _, err := store.RecordCertificate(ctx, oneTime, "agent-3", signCert(t, ca, "agent-3")); !gerrors.Is(err, ErrInvalidToken) {
+		t.Fatalf("reused one-time token: got %v, want ErrInvalidToken", err)
+	}
+
+	const n = 8
+	certs := make([]*x509.Certificate, n)
+	for i := range certs {
+		certs[i] = signCert(t, ca, "agent-2")
+	}
+	results := make(chan error, n)
+	for _, cert := range certs {
+		go func() {
+			_, err := store.RecordCertificate(ctx, token, "agent-2", cert)
+			results <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		if <-results == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d concurrent enrollments of agent-2 succeeded, want 1", succeeded)
+	}
+}
+
+func TestStore_revokeCertificates(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	ca := newTestCA(t)
+	token, _, err := store.CreateToken(ctx, 0, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := func(clientID string) *x509.Certificate {
+		cert := signCert(t, ca, clientID)
+		if _, err := store.RecordCertificate(ctx, token, clientID, cert); err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	first, other := issue("agent-1"), issue("agent-2")
+
+	revoked := NewRevocationList(store)
+	notified := make(chan *CertInfo, 4)
+	revoked.Subscribe(func(c *CertInfo) { notified <- c })
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if _, err := revoked.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = revoked.Run(runCtx) }()
+
+	if _, err := store.RevokeCertificates(ctx, "agent-1", SerialString(first)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-notified:
+		if c.ClientID != "agent-1" || c.Serial != SerialString(first) {
+			t.Fatalf("notified %+v, want agent-1 %s", c, SerialString(first))
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("revocation was not delivered")
+	}
+
+	// With its certificate revoked, agent-1 may enroll again.
+	second := issue("agent-1")
+	if !revoked.IsRevoked(first) || revoked.IsRevoked(second) || revoked.IsRevoked(other) {
+		t.Fatal("only the first certificate of agent-1 should be revoked")
+	}
+
+	certs, err := store.RevokeCertificates(ctx, "agent-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 {
+		t.Fatalf("revoked %d certificates of agent-1, want 2", len(certs))
+	}
+	if _, err := store.RevokeCertificates(ctx, "agent-3", ""); !gerrors.Is(err, ErrCertNotFound) {
+		t.Fatalf("RevokeCertificates(unknown) = %v, want ErrCertNotFound", err)
+	}
+
+	// A fresh list sees the revocations made before it started.
+	fresh := NewRevocationList(store)
+	if _, err := fresh.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !fresh.IsRevoked(first) || !fresh.IsRevoked(second) || fresh.IsRevoked(other) {
+		t.Fatal("synced revocations do not match the store")
+	}
+}
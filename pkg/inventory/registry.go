@@ -0,0 +1,444 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package inventory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/logger"
+	"os-artificer/saber/pkg/proto"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const (
+	// flushInterval is how often changed records are written to the store.
+	flushInterval = 5 * time.Second
+
+	// seenResolution is how stale the stored LastSeen of an online agent may get, so
+	// that activity alone does not write to the store on every message.
+	seenResolution = time.Minute
+)
+
+// Event reports that an agent went online or offline.
+type Event struct {
+	ClientID string
+	State    State
+	Time     time.Time
+	Agent    Agent // record at the time of the change
+}
+
+// Registry tracks the agents connected to one controller instance. Changes are published
+// to subscribers at once and written to the store, if any, in the background by Run.
+// It is safe for concurrent use.
+type Registry struct {
+	store        *Store
+	controllerID string
+	leaseTTL     time.Duration
+
+	mu          sync.Mutex
+	agents      map[string]*entry
+	dirty       map[string]struct{}
+	lease       clientv3.LeaseID
+	subscribers []func(Event)
+}
+
+type entry struct {
+	agent     Agent
+	loaded    bool      // FirstSeen merged with the stored record
+	savedSeen time.Time // LastSeen as last written to the store
+}
+
+// NewRegistry returns a registry for the controller instance controllerID. With a nil
+// store it only knows the agents seen by this instance. leaseTTL bounds how long the
+// agents stay online in the store after this instance dies.
+func NewRegistry(store *Store, controllerID string, leaseTTL time.Duration) *Registry {
+	return &Registry{
+		store:        store,
+		controllerID: controllerID,
+		leaseTTL:     leaseTTL,
+		agents:       make(map[string]*entry),
+		dirty:        make(map[string]struct{}),
+	}
+}
+
+// Subscribe registers fn to be called for every state change. fn runs on the goroutine
+// that reported the change and must not block.
+func (r *Registry) Subscribe(fn func(Event)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Connected records that clientID connected from remoteAddr at now.
+func (r *Registry) Connected(clientID, remoteAddr string, now time.Time) {
+	r.mu.Lock()
+	e := r.entry(clientID, now)
+	e.agent.RemoteAddr = remoteAddr
+	e.agent.Controller = r.controllerID
+	e.agent.LastSeen = now
+	ev := r.setState(e, StateOnline, now)
+	r.mu.Unlock()
+
+	r.publish(ev)
+}
+
+// Register records what clientID reported about itself.
+func (r *Registry) Register(clientID string, info *proto.RegisterInfo, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := r.entry(clientID, now)
+	e.agent.Hostname = info.GetHostname()
+	e.agent.IPs = info.GetIps()
+	e.agent.OS = info.GetOs()
+	e.agent.Arch = info.GetArch()
+	e.agent.AgentName = info.GetAgentName()
+	e.agent.AgentVersion = info.GetAgentVersion()
+	e.agent.Plugins = info.GetPlugins()
+	e.agent.Labels = info.GetLabels()
+	e.agent.LastSeen = later(e.agent.LastSeen, now)
+	r.markDirty(clientID)
+}
+
+// Seen records activity of clientID at at.
+func (r *Registry) Seen(clientID string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.agents[clientID]
+	if !ok {
+		return
+	}
+	e.agent.LastSeen = later(e.agent.LastSeen, at)
+	if e.agent.LastSeen.Sub(e.savedSeen) >= seenResolution {
+		r.markDirty(clientID)
+	}
+}
+
+// Disconnected records that clientID lost its connection at now.
+func (r *Registry) Disconnected(clientID string, now time.Time) {
+	r.mu.Lock()
+	e, ok := r.agents[clientID]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	ev := r.setState(e, StateOffline, now)
+	r.mu.Unlock()
+
+	r.publish(ev)
+}
+
+// Get returns the record of clientID, or ErrAgentNotFound.
+func (r *Registry) Get(ctx context.Context, clientID string) (*Agent, error) {
+	local, online := r.local(clientID)
+	if online || r.store == nil {
+		if local == nil {
+			return nil, ErrAgentNotFound
+		}
+		return local, nil
+	}
+
+	a, err := r.store.Get(ctx, clientID)
+	if gerrors.Is(err, ErrAgentNotFound) && local != nil {
+		return local, nil
+	}
+	return a, err
+}
+
+// List returns every known agent ordered by clientID: all the agents in the store, with
+// the records of the agents connected to this instance brought up to date.
+func (r *Registry) List(ctx context.Context) ([]*Agent, error) {
+	var stored []*Agent
+	if r.store != nil {
+		var err error
+		if stored, err = r.store.List(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	agents := make(map[string]*Agent, len(stored)+len(r.agents))
+	for _, a := range stored {
+		agents[a.ClientID] = a
+	}
+	for id, e := range r.agents {
+		if _, ok := agents[id]; !ok || e.agent.State == StateOnline {
+			agents[id] = e.snapshot()
+		}
+	}
+	r.mu.Unlock()
+
+	list := make([]*Agent, 0, len(agents))
+	for _, a := range agents {
+		list = append(list, a)
+	}
+	slices.SortFunc(list, func(a, b *Agent) int { return strings.Compare(a.ClientID, b.ClientID) })
+	return list, nil
+}
+
+// Run writes the changes to the store until ctx is done. The agents connected to this
+// instance are online under a lease kept alive by Run and revoked when it returns.
+func (r *Registry) Run(ctx context.Context) error {
+	if r.store == nil {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var session *concurrency.Session
+	defer func() {
+		if session == nil {
+			return
+		}
+		flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushInterval)
+		r.flush(flushCtx)
+		cancel()
+		// Revoking the lease takes the agents of this instance offline at once.
+		_ = session.Close()
+	}()
+
+	for {
+		if session == nil {
+			s, err := r.newSession(ctx)
+			if err != nil {
+				logger.Warnf("agent registry: create lease: %v", err)
+			} else {
+				session = s
+				r.setLease(s.Lease())
+			}
+		}
+		if session != nil {
+			r.flush(ctx)
+		}
+
+		var lost <-chan struct{}
+		if session != nil {
+			lost = session.Done()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-lost:
+			logger.Warnf("agent registry: lease %x lost, marking agents online again", session.Lease())
+			session = nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// newSession grants a lease and keeps it alive until the session is closed. The session
+// outlives ctx so that it can still revoke its lease when Run returns.
+func (r *Registry) newSession(ctx context.Context) (*concurrency.Session, error) {
+	ttl := max(int64(r.leaseTTL/time.Second), 1)
+
+	grantCtx, cancel := context.WithTimeout(ctx, flushInterval)
+	defer cancel()
+	lease, err := r.store.cli.Grant(grantCtx, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return concurrency.NewSession(r.store.cli,
+		concurrency.WithLease(lease.ID),
+		concurrency.WithTTL(int(ttl)),
+		concurrency.WithContext(context.WithoutCancel(ctx)))
+}
+
+// setLease switches to lease; the agents online under the previous one are written again.
+func (r *Registry) setLease(lease clientv3.LeaseID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lease = lease
+	for id, e := range r.agents {
+		if e.agent.State == StateOnline {
+			r.dirty[id] = struct{}{}
+		}
+	}
+}
+
+// flush writes the changed records to the store. Offline agents are forgotten once written.
+func (r *Registry) flush(ctx context.Context) {
+	r.mu.Lock()
+	lease := r.lease
+	ids := make([]string, 0, len(r.dirty))
+	var unloaded []string
+	for id := range r.dirty {
+		ids = append(ids, id)
+		if !r.agents[id].loaded {
+			unloaded = append(unloaded, id)
+		}
+	}
+	clear(r.dirty)
+	r.mu.Unlock()
+
+	// Agents first seen by this instance may have been recorded by another one before.
+	stored := make(map[string]*Agent, len(unloaded))
+	for _, id := range unloaded {
+		a, err := r.store.Get(ctx, id)
+		if err != nil && !gerrors.Is(err, ErrAgentNotFound) {
+			logger.Warnf("agent registry: load %s: %v", id, err)
+			continue
+		}
+		stored[id] = a
+	}
+
+	r.mu.Lock()
+	pending := make([]*Agent, 0, len(ids))
+	for _, id := range ids {
+		e, ok := r.agents[id]
+		if !ok {
+			continue
+		}
+		if !e.loaded {
+			a, ok := stored[id]
+			if !ok {
+				r.dirty[id] = struct{}{}
+				continue
+			}
+			if a != nil {
+				e.agent.FirstSeen = earlier(e.agent.FirstSeen, a.FirstSeen)
+			}
+			e.loaded = true
+		}
+		pending = append(pending, e.snapshot())
+	}
+	r.mu.Unlock()
+
+	for _, a := range pending {
+		var err error
+		if a.State == StateOnline {
+			err = r.store.SaveOnline(ctx, a, lease)
+		} else {
+			err = r.store.SaveOffline(ctx, a, lease)
+		}
+
+		r.mu.Lock()
+		e, ok := r.agents[a.ClientID]
+		switch {
+		case !ok:
+		case err != nil:
+			r.dirty[a.ClientID] = struct{}{}
+		case e.agent.State == StateOffline && a.State == StateOffline:
+			delete(r.agents, a.ClientID)
+		default:
+			e.savedSeen = a.LastSeen
+		}
+		r.mu.Unlock()
+
+		if err != nil {
+			logger.Warnf("agent registry: save %s: %v", a.ClientID, err)
+		}
+	}
+}
+
+func (r *Registry) local(clientID string) (*Agent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.agents[clientID]
+	if !ok {
+		return nil, false
+	}
+	return e.snapshot(), e.agent.State == StateOnline
+}
+
+// entry returns the entry of clientID, creating it; r.mu must be held.
+func (r *Registry) entry(clientID string, now time.Time) *entry {
+	e, ok := r.agents[clientID]
+	if !ok {
+		e = &entry{
+			agent:  Agent{ClientID: clientID, State: StateOffline, FirstSeen: now, LastSeen: now},
+			loaded: r.store == nil,
+		}
+		r.agents[clientID] = e
+	}
+	return e
+}
+
+// setState changes the state of e and returns the event to publish, if any; r.mu must be held.
+func (r *Registry) setState(e *entry, state State, now time.Time) *Event {
+	r.markDirty(e.agent.ClientID)
+	if e.agent.State == state {
+		return nil
+	}
+	e.agent.State = state
+
+	logger.Infof("agent %s is %s", e.agent.ClientID, state)
+	return &Event{ClientID: e.agent.ClientID, State: state, Time: now, Agent: *e.snapshot()}
+}
+
+// markDirty queues the record of clientID for the next flush; r.mu must be held.
+func (r *Registry) markDirty(clientID string) {
+	if r.store != nil {
+		r.dirty[clientID] = struct{}{}
+	}
+}
+
+func (r *Registry) publish(ev *Event) {
+	if ev == nil {
+		return
+	}
+
+	r.mu.Lock()
+	subscribers := r.subscribers
+	r.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(*ev)
+	}
+}
+
+// snapshot returns a copy of the record that does not share slices or maps with e.
+func (e *entry) snapshot() *Agent {
+	a := e.agent
+	a.IPs = slices.Clone(a.IPs)
+	a.Plugins = slices.Clone(a.Plugins)
+	if a.Labels != nil {
+		labels := make(map[string]string, len(a.Labels))
+		for k, v := range a.Labels {
+			labels[k] = v
+		}
+		a.Labels = labels
+	}
+	return &a
+}
+
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+func earlier(a, b time.Time) time.Time {
+	if !b.IsZero() && b.Before(a) {
+		return b
+	}
+	return a
+}
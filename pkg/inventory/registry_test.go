@@ -0,0 +1,130 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package inventory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/proto"
+)
+
+func TestRegistry_events(t *testing.T) {
+	r := NewRegistry(nil, "c1", time.Minute)
+	var events []Event
+	r.Subscribe(func(ev Event) { events = append(events, ev) })
+
+	start := time.Now()
+	r.Connected("agent-1", "10.0.0.1:40000", start)
+	r.Register("agent-1", &proto.RegisterInfo{
+		Hostname:     "web-1",
+		Ips:          []string{"10.0.0.1"},
+		AgentVersion: "v1.0",
+		Plugins:      []string{"host"},
+	}, start)
+	r.Connected("agent-1", "10.0.0.1:40001", start.Add(time.Second)) // reconnect, still online
+	r.Seen("agent-1", start.Add(time.Minute))
+	r.Disconnected("agent-1", start.Add(2*time.Minute))
+	r.Seen("agent-2", start) // unknown agents are ignored
+
+	if len(events) != 2 || events[0].State != StateOnline || events[1].State != StateOffline {
+		t.Fatalf("events = %+v, want online then offline", events)
+	}
+	if events[1].Agent.Hostname != "web-1" {
+		t.Fatalf("offline event carries %+v", events[1].Agent)
+	}
+
+	a, err := r.Get(context.Background(), "agent-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.State != StateOffline || a.RemoteAddr != "10.0.0.1:40001" || !a.FirstSeen.Equal(start) ||
+		!a.LastSeen.Equal(start.Add(time.Minute)) || a.Plugins[0] != "host" {
+		t.Fatalf("Get() = %+v", a)
+	}
+
+	// Records handed out do not alias the registry's.
+	a.Plugins[0] = "changed"
+	if b, _ := r.Get(context.Background(), "agent-1"); b.Plugins[0] != "host" {
+		t.Fatal("record shares its slices")
+	}
+
+	if _, err := r.Get(context.Background(), "agent-2"); err != ErrAgentNotFound {
+		t.Fatalf("Get(unknown) error = %v, want ErrAgentNotFound", err)
+	}
+}
+
+func TestRegistry_store(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	// Recorded earlier by another controller instance.
+	firstSeen := time.Now().Add(-24 * time.Hour).UTC().Truncate(time.Second)
+	err := store.SaveOffline(ctx, &Agent{ClientID: "agent-1", FirstSeen: firstSeen}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry(store, "c1", time.Minute)
+	r.Connected("agent-1", "10.0.0.1:40000", time.Now())
+	r.Register("agent-1", &proto.RegisterInfo{Hostname: "web-1"}, time.Now())
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- r.Run(runCtx) }()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		a, err := store.Get(ctx, "agent-1")
+		if err == nil && a.State == StateOnline {
+			if a.Hostname != "web-1" || a.Controller != "c1" || !a.FirstSeen.Equal(firstSeen) {
+				t.Fatalf("stored %+v", a)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("agent not stored online: %+v, %v", a, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// List merges the store with the agents connected here.
+	r.Connected("agent-2", "10.0.0.2:40000", time.Now())
+	agents, err := r.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agents) != 2 || agents[1].ClientID != "agent-2" || agents[1].State != StateOnline {
+		t.Fatalf("List() = %+v", agents)
+	}
+
+	// Stopping the registry revokes its lease: its agents go offline.
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"agent-1", "agent-2"} {
+		a, err := store.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.State != StateOffline {
+			t.Fatalf("%s is %s after shutdown", id, a.State)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+// Package inventory keeps the registry of agents known to the controllers: what each
+// agent reported about itself, when it was first and last seen, and whether it is online.
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"os-artificer/saber/pkg/gerrors"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	keySegmentAgents = "inventory/agents"
+	keySegmentOnline = "inventory/online"
+)
+
+var ErrAgentNotFound = gerrors.New(gerrors.NotFound, "agent not found")
+
+// State is whether an agent holds a connection to a controller.
+type State string
+
+const (
+	StateOnline  State = "online"
+	StateOffline State = "offline"
+)
+
+// Agent is the inventory record of an agent.
+type Agent struct {
+	ClientID     string            `json:"clientID"`
+	Hostname     string            `json:"hostname"`
+	IPs          []string          `json:"ips"`
+	OS           string            `json:"os"`
+	Arch         string            `json:"arch"`
+	AgentName    string            `json:"agentName"`
+	AgentVersion string            `json:"agentVersion"`
+	Plugins      []string          `json:"plugins"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	RemoteAddr   string            `json:"remoteAddr"`
+	Controller   string            `json:"controller"` // instance that holds, or last held, the connection
+	State        State             `json:"state"`
+	FirstSeen    time.Time         `json:"firstSeen"`
+	LastSeen     time.Time         `json:"lastSeen"`
+}
+
+// Store keeps agent records in etcd under rootKeyPrefix, where every controller instance
+// and the admin commands share them. An agent is online while its online key exists;
+// the key is bound to the lease of the controller holding the connection, so the agents
+// of a controller that dies go offline when its lease expires.
+type Store struct {
+	cli    *clientv3.Client
+	prefix string
+}
+
+// NewStore returns a store using cli under rootKeyPrefix (the discovery root prefix).
+func NewStore(cli *clientv3.Client, rootKeyPrefix string) *Store {
+	return &Store{cli: cli, prefix: strings.TrimSuffix(rootKeyPrefix, "/")}
+}
+
+func (s *Store) key(segment string, parts ...string) string {
+	return s.prefix + "/" + segment + "/" + strings.Join(parts, "/")
+}
+
+// SaveOnline writes a and marks the agent online under lease, the lease of the
+// controller instance that holds its connection.
+func (s *Store) SaveOnline(ctx context.Context, a *Agent, lease clientv3.LeaseID) error {
+	record, err := json.Marshal(a)
+	if err != nil {
+		return gerrors.NewE(gerrors.InvalidParameter, err)
+	}
+
+	_, err = s.cli.Txn(ctx).Then(
+		clientv3.OpPut(s.key(keySegmentAgents, a.ClientID), string(record)),
+		clientv3.OpPut(s.key(keySegmentOnline, a.ClientID), a.Controller, clientv3.WithLease(lease)),
+	).Commit()
+	if err != nil {
+		return gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+	return nil
+}
+
+// SaveOffline writes a and marks the agent offline, unless another controller instance
+// has marked it online since: an agent that moved keeps the record of its new controller.
+// lease is the lease the caller marked the agent online with.
+func (s *Store) SaveOffline(ctx context.Context, a *Agent, lease clientv3.LeaseID) error {
+	record, err := json.Marshal(a)
+	if err != nil {
+		return gerrors.NewE(gerrors.InvalidParameter, err)
+	}
+
+	recordKey, onlineKey := s.key(keySegmentAgents, a.ClientID), s.key(keySegmentOnline, a.ClientID)
+	resp, err := s.cli.Txn(ctx).
+		If(clientv3.Compare(clientv3.LeaseValue(onlineKey), "=", lease)).
+		Then(clientv3.OpPut(recordKey, string(record)), clientv3.OpDelete(onlineKey)).
+		Commit()
+	if err != nil {
+		return gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+	if resp.Succeeded {
+		return nil
+	}
+
+	// Our key is gone, e.g. with an expired lease: write the record if nobody else holds it.
+	_, err = s.cli.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(onlineKey), "=", 0)).
+		Then(clientv3.OpPut(recordKey, string(record))).
+		Commit()
+	if err != nil {
+		return gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+	return nil
+}
+
+// Get returns the record of clientID, or ErrAgentNotFound.
+func (s *Store) Get(ctx context.Context, clientID string) (*Agent, error) {
+	resp, err := s.cli.Txn(ctx).Then(
+		clientv3.OpGet(s.key(keySegmentAgents, clientID)),
+		clientv3.OpGet(s.key(keySegmentOnline, clientID)),
+	).Commit()
+	if err != nil {
+		return nil, gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+
+	records := resp.Responses[0].GetResponseRange().Kvs
+	if len(records) == 0 {
+		return nil, ErrAgentNotFound
+	}
+
+	var a Agent
+	if err := json.Unmarshal(records[0].Value, &a); err != nil {
+		return nil, gerrors.NewE(gerrors.Failure, err)
+	}
+	a.State = StateOffline
+	if online := resp.Responses[1].GetResponseRange().Kvs; len(online) > 0 {
+		a.State, a.Controller = StateOnline, string(online[0].Value)
+	}
+	return &a, nil
+}
+
+// List returns every agent record ordered by clientID.
+func (s *Store) List(ctx context.Context) ([]*Agent, error) {
+	resp, err := s.cli.Txn(ctx).Then(
+		clientv3.OpGet(s.key(keySegmentAgents), clientv3.WithPrefix()),
+		clientv3.OpGet(s.key(keySegmentOnline), clientv3.WithPrefix()),
+	).Commit()
+	if err != nil {
+		return nil, gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+
+	online := make(map[string]string)
+	onlinePrefix := s.key(keySegmentOnline)
+	for _, kv := range resp.Responses[1].GetResponseRange().Kvs {
+		online[strings.TrimPrefix(string(kv.Key), onlinePrefix)] = string(kv.Value)
+	}
+
+	records := resp.Responses[0].GetResponseRange().Kvs
+	agents := make([]*Agent, 0, len(records))
+	for _, kv := range records {
+		var a Agent
+		if err := json.Unmarshal(kv.Value, &a); err != nil {
+			continue
+		}
+		a.State = StateOffline
+		if controller, ok := online[a.ClientID]; ok {
+			a.State, a.Controller = StateOnline, controller
+		}
+		agents = append(agents, &a)
+	}
+
+	slices.SortFunc(agents, func(a, b *Agent) int { return strings.Compare(a.ClientID, b.ClientID) })
+	return agents, nil
+}
+
+// Delete removes the record of clientID, e.g. for a decommissioned host. An agent that
+// connects again is recorded anew.
+func (s *Store) Delete(ctx context.Context, clientID string) error {
+	resp, err := s.cli.Txn(ctx).Then(
+		clientv3.OpDelete(s.key(keySegmentAgents, clientID)),
+		clientv3.OpDelete(s.key(keySegmentOnline, clientID)),
+	).Commit()
+	if err != nil {
+		return gerrors.NewE(gerrors.ComponentFailure, err)
+	}
+	if resp.Responses[0].GetResponseDeleteRange().Deleted == 0 {
+		return ErrAgentNotFound
+	}
+	return nil
+}
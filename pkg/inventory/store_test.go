@@ -0,0 +1,116 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package inventory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/discovery/etcdtest"
+	"os-artificer/saber/pkg/gerrors"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func newTestStore(t *testing.T) *Store {
+	return NewStore(etcdtest.NewClient(t), "/saber-test")
+}
+
+func grant(t *testing.T, s *Store) clientv3.LeaseID {
+	t.Helper()
+
+	resp, err := s.cli.Grant(context.Background(), 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.ID
+}
+
+func wantAgent(t *testing.T, s *Store, clientID string, state State, controller, hostname string) {
+	t.Helper()
+
+	a, err := s.Get(context.Background(), clientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.State != state || a.Controller != controller || a.Hostname != hostname {
+		t.Fatalf("Get(%s) = %s on %q host %q, want %s on %q host %q",
+			clientID, a.State, a.Controller, a.Hostname, state, controller, hostname)
+	}
+}
+
+func TestStore_takeover(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	lease1, lease2 := grant(t, s), grant(t, s)
+
+	a := &Agent{ClientID: "agent-1", Hostname: "web-1", Controller: "c1", FirstSeen: time.Now()}
+	if err := s.SaveOnline(ctx, a, lease1); err != nil {
+		t.Fatal(err)
+	}
+	wantAgent(t, s, "agent-1", StateOnline, "c1", "web-1")
+
+	// The agent moves to c2 before c1 notices that its connection is gone.
+	moved := *a
+	moved.Controller, moved.Hostname = "c2", "web-1.example"
+	if err := s.SaveOnline(ctx, &moved, lease2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveOffline(ctx, a, lease1); err != nil {
+		t.Fatal(err)
+	}
+	wantAgent(t, s, "agent-1", StateOnline, "c2", "web-1.example")
+
+	if err := s.SaveOffline(ctx, &moved, lease2); err != nil {
+		t.Fatal(err)
+	}
+	wantAgent(t, s, "agent-1", StateOffline, "c2", "web-1.example")
+
+	// A controller whose lease expired still records its agents offline.
+	b := &Agent{ClientID: "agent-2", Hostname: "db-1", Controller: "c1"}
+	if err := s.SaveOnline(ctx, b, lease1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.cli.Revoke(ctx, lease1); err != nil {
+		t.Fatal(err)
+	}
+	wantAgent(t, s, "agent-2", StateOffline, "c1", "db-1")
+	b.Hostname = "db-1.example"
+	if err := s.SaveOffline(ctx, b, lease1); err != nil {
+		t.Fatal(err)
+	}
+	wantAgent(t, s, "agent-2", StateOffline, "c1", "db-1.example")
+
+	agents, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agents) != 2 || agents[0].ClientID != "agent-1" || agents[1].ClientID != "agent-2" {
+		t.Fatalf("List() = %v", agents)
+	}
+
+	if err := s.Delete(ctx, "agent-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "agent-1"); !gerrors.Is(err, ErrAgentNotFound) {
+		t.Fatalf("Get(deleted) error = %v, want ErrAgentNotFound", err)
+	}
+	if err := s.Delete(ctx, "agent-1"); !gerrors.Is(err, ErrAgentNotFound) {
+		t.Fatalf("Delete(deleted) error = %v, want ErrAgentNotFound", err)
+	}
+}
@@ -41,6 +41,7 @@ type AgentRequest struct {
 	ClientID      string                 `protobuf:"bytes,1,opt,name=clientID,proto3" json:"clientID,omitempty"`
 	Headers       map[string]string      `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	Payload       []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Envelope      *Envelope              `protobuf:"bytes,5,opt,name=envelope,proto3" json:"envelope,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -96,11 +97,19 @@ func (x *AgentRequest) GetPayload() []byte {
 	return nil
 }
 
+func (x *AgentRequest) GetEnvelope() *Envelope {
+	if x != nil {
+		return x.Envelope
+	}
+	return nil
+}
+
 type AgentResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
 	Errmsg        string                 `protobuf:"bytes,2,opt,name=errmsg,proto3" json:"errmsg,omitempty"`
 	Payload       []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	Envelope      *Envelope              `protobuf:"bytes,5,opt,name=envelope,proto3" json:"envelope,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -156,24 +165,844 @@ func (x *AgentResponse) GetPayload() []byte {
 	return nil
 }
 
+func (x *AgentResponse) GetEnvelope() *Envelope {
+	if x != nil {
+		return x.Envelope
+	}
+	return nil
+}
+
+// EnrollRequest exchanges a bootstrap token for a client certificate; without a token,
+// it renews the client certificate the agent authenticates with. csr is a PEM-encoded
+// certificate signing request; the certificate is issued for clientID.
+type EnrollRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientID      string                 `protobuf:"bytes,1,opt,name=clientID,proto3" json:"clientID,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Csr           []byte                 `protobuf:"bytes,3,opt,name=csr,proto3" json:"csr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrollRequest) Reset() {
+	*x = EnrollRequest{}
+	mi := &file_controller_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrollRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrollRequest) ProtoMessage() {}
+
+func (x *EnrollRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrollRequest.ProtoReflect.Descriptor instead.
+func (*EnrollRequest) Descriptor() ([]byte, []int) {
+	return file_controller_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *EnrollRequest) GetClientID() string {
+	if x != nil {
+		return x.ClientID
+	}
+	return ""
+}
+
+func (x *EnrollRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *EnrollRequest) GetCsr() []byte {
+	if x != nil {
+		return x.Csr
+	}
+	return nil
+}
+
+// EnrollResponse carries the PEM-encoded certificate and the CA that issued it.
+type EnrollResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Errmsg        string                 `protobuf:"bytes,2,opt,name=errmsg,proto3" json:"errmsg,omitempty"`
+	Certificate   []byte                 `protobuf:"bytes,3,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	CaCertificate []byte                 `protobuf:"bytes,4,opt,name=caCertificate,proto3" json:"caCertificate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrollResponse) Reset() {
+	*x = EnrollResponse{}
+	mi := &file_controller_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrollResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrollResponse) ProtoMessage() {}
+
+func (x *EnrollResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrollResponse.ProtoReflect.Descriptor instead.
+func (*EnrollResponse) Descriptor() ([]byte, []int) {
+	return file_controller_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EnrollResponse) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *EnrollResponse) GetErrmsg() string {
+	if x != nil {
+		return x.Errmsg
+	}
+	return ""
+}
+
+func (x *EnrollResponse) GetCertificate() []byte {
+	if x != nil {
+		return x.Certificate
+	}
+	return nil
+}
+
+func (x *EnrollResponse) GetCaCertificate() []byte {
+	if x != nil {
+		return x.CaCertificate
+	}
+	return nil
+}
+
+// Envelope is a typed message on the Connect stream. id identifies the message; a reply
+// carries the id of the message it answers in replyTo. timestamp is in unix milliseconds.
+type Envelope struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ReplyTo   string                 `protobuf:"bytes,2,opt,name=replyTo,proto3" json:"replyTo,omitempty"`
+	Timestamp int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Envelope_Heartbeat
+	//	*Envelope_Register
+	//	*Envelope_TaskDispatch
+	//	*Envelope_TaskResult
+	//	*Envelope_ConfigPush
+	//	*Envelope_Ack
+	Body          isEnvelope_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_controller_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_controller_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Envelope) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Envelope) GetReplyTo() string {
+	if x != nil {
+		return x.ReplyTo
+	}
+	return ""
+}
+
+func (x *Envelope) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Envelope) GetBody() isEnvelope_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Envelope) GetHeartbeat() *Heartbeat {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_Heartbeat); ok {
+			return x.Heartbeat
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetRegister() *RegisterInfo {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_Register); ok {
+			return x.Register
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetTaskDispatch() *TaskDispatch {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_TaskDispatch); ok {
+			return x.TaskDispatch
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetTaskResult() *TaskResult {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_TaskResult); ok {
+			return x.TaskResult
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetConfigPush() *ConfigPush {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_ConfigPush); ok {
+			return x.ConfigPush
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+type isEnvelope_Body interface {
+	isEnvelope_Body()
+}
+
+type Envelope_Heartbeat struct {
+	Heartbeat *Heartbeat `protobuf:"bytes,10,opt,name=heartbeat,proto3,oneof"`
+}
+
+type Envelope_Register struct {
+	Register *RegisterInfo `protobuf:"bytes,11,opt,name=register,proto3,oneof"`
+}
+
+type Envelope_TaskDispatch struct {
+	TaskDispatch *TaskDispatch `protobuf:"bytes,12,opt,name=taskDispatch,proto3,oneof"`
+}
+
+type Envelope_TaskResult struct {
+	TaskResult *TaskResult `protobuf:"bytes,13,opt,name=taskResult,proto3,oneof"`
+}
+
+type Envelope_ConfigPush struct {
+	ConfigPush *ConfigPush `protobuf:"bytes,14,opt,name=configPush,proto3,oneof"`
+}
+
+type Envelope_Ack struct {
+	Ack *Ack `protobuf:"bytes,15,opt,name=ack,proto3,oneof"`
+}
+
+func (*Envelope_Heartbeat) isEnvelope_Body() {}
+
+func (*Envelope_Register) isEnvelope_Body() {}
+
+func (*Envelope_TaskDispatch) isEnvelope_Body() {}
+
+func (*Envelope_TaskResult) isEnvelope_Body() {}
+
+func (*Envelope_ConfigPush) isEnvelope_Body() {}
+
+func (*Envelope_Ack) isEnvelope_Body() {}
+
+// Heartbeat is sent periodically by the agent. uptime is in seconds.
+type Heartbeat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uptime        int64                  `protobuf:"varint,1,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Heartbeat) Reset() {
+	*x = Heartbeat{}
+	mi := &file_controller_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Heartbeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Heartbeat) ProtoMessage() {}
+
+func (x *Heartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
+func (*Heartbeat) Descriptor() ([]byte, []int) {
+	return file_controller_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Heartbeat) GetUptime() int64 {
+	if x != nil {
+		return x.Uptime
+	}
+	return 0
+}
+
+// RegisterInfo describes the agent; it is sent once the stream is established.
+type RegisterInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Ips           []string               `protobuf:"bytes,2,rep,name=ips,proto3" json:"ips,omitempty"`
+	Os            string                 `protobuf:"bytes,3,opt,name=os,proto3" json:"os,omitempty"`
+	Arch          string                 `protobuf:"bytes,4,opt,name=arch,proto3" json:"arch,omitempty"`
+	AgentName     string                 `protobuf:"bytes,5,opt,name=agentName,proto3" json:"agentName,omitempty"`
+	AgentVersion  string                 `protobuf:"bytes,6,opt,name=agentVersion,proto3" json:"agentVersion,omitempty"`
+	Plugins       []string               `protobuf:"bytes,7,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterInfo) Reset() {
+	*x = RegisterInfo{}
+	mi := &file_controller_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterInfo) ProtoMessage() {}
+
+func (x *RegisterInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterInfo.ProtoReflect.Descriptor instead.
+func (*RegisterInfo) Descriptor() ([]byte, []int) {
+	return file_controller_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RegisterInfo) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *RegisterInfo) GetIps() []string {
+	if x != nil {
+		return x.Ips
+	}
+	return nil
+}
+
+func (x *RegisterInfo) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *RegisterInfo) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *RegisterInfo) GetAgentName() string {
+	if x != nil {
+		return x.AgentName
+	}
+	return ""
+}
+
+func (x *RegisterInfo) GetAgentVersion() string {
+	if x != nil {
+		return x.AgentVersion
+	}
+	return ""
+}
+
+func (x *RegisterInfo) GetPlugins() []string {
+	if x != nil {
+		return x.Plugins
+	}
+	return nil
+}
+
+func (x *RegisterInfo) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// TaskDispatch asks the agent to run a task of the given type.
+type TaskDispatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskID        string                 `protobuf:"bytes,1,opt,name=taskID,proto3" json:"taskID,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Args          []string               `protobuf:"bytes,3,rep,name=args,proto3" json:"args,omitempty"`
+	Params        map[string]string      `protobuf:"bytes,4,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	TimeoutMs     int64                  `protobuf:"varint,5,opt,name=timeoutMs,proto3" json:"timeoutMs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskDispatch) Reset() {
+	*x = TaskDispatch{}
+	mi := &file_controller_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskDispatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskDispatch) ProtoMessage() {}
+
+func (x *TaskDispatch) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskDispatch.ProtoReflect.Descriptor instead.
+func (*TaskDispatch) Descriptor() ([]byte, []int) {
+	return file_controller_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TaskDispatch) GetTaskID() string {
+	if x != nil {
+		return x.TaskID
+	}
+	return ""
+}
+
+func (x *TaskDispatch) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TaskDispatch) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *TaskDispatch) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *TaskDispatch) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+// TaskResult reports task output and completion. Results of a task are numbered by seq;
+// the last one has done set, with exitCode for commands that ran.
+type TaskResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskID        string                 `protobuf:"bytes,1,opt,name=taskID,proto3" json:"taskID,omitempty"`
+	Seq           int64                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Done          bool                   `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
+	Code          int32                  `protobuf:"varint,4,opt,name=code,proto3" json:"code,omitempty"`
+	Errmsg        string                 `protobuf:"bytes,5,opt,name=errmsg,proto3" json:"errmsg,omitempty"`
+	Stream        string                 `protobuf:"bytes,6,opt,name=stream,proto3" json:"stream,omitempty"`
+	Output        []byte                 `protobuf:"bytes,7,opt,name=output,proto3" json:"output,omitempty"`
+	ExitCode      int32                  `protobuf:"varint,8,opt,name=exitCode,proto3" json:"exitCode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskResult) Reset() {
+	*x = TaskResult{}
+	mi := &file_controller_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskResult) ProtoMessage() {}
+
+func (x *TaskResult) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskResult.ProtoReflect.Descriptor instead.
+func (*TaskResult) Descriptor() ([]byte, []int) {
+	return file_controller_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TaskResult) GetTaskID() string {
+	if x != nil {
+		return x.TaskID
+	}
+	return ""
+}
+
+func (x *TaskResult) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *TaskResult) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *TaskResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *TaskResult) GetErrmsg() string {
+	if x != nil {
+		return x.Errmsg
+	}
+	return ""
+}
+
+func (x *TaskResult) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *TaskResult) GetOutput() []byte {
+	if x != nil {
+		return x.Output
+	}
+	return nil
+}
+
+func (x *TaskResult) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+// ConfigPush delivers a named configuration document to the agent.
+type ConfigPush struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigPush) Reset() {
+	*x = ConfigPush{}
+	mi := &file_controller_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigPush) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigPush) ProtoMessage() {}
+
+func (x *ConfigPush) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigPush.ProtoReflect.Descriptor instead.
+func (*ConfigPush) Descriptor() ([]byte, []int) {
+	return file_controller_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ConfigPush) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ConfigPush) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ConfigPush) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+// Ack acknowledges a message; a non-zero code reports why it was not handled.
+type Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Errmsg        string                 `protobuf:"bytes,2,opt,name=errmsg,proto3" json:"errmsg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_controller_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_controller_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Ack) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Ack) GetErrmsg() string {
+	if x != nil {
+		return x.Errmsg
+	}
+	return ""
+}
+
 var File_controller_proto protoreflect.FileDescriptor
 
 const file_controller_proto_rawDesc = "" +
 	"\n" +
-	"\x10controller.proto\"\xb6\x01\n" +
+	"\x10controller.proto\"\xdd\x01\n" +
 	"\fAgentRequest\x12\x1a\n" +
 	"\bclientID\x18\x01 \x01(\tR\bclientID\x124\n" +
 	"\aheaders\x18\x02 \x03(\v2\x1a.AgentRequest.HeadersEntryR\aheaders\x12\x18\n" +
-	"\apayload\x18\x03 \x01(\fR\apayload\x1a:\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12%\n" +
+	"\benvelope\x18\x05 \x01(\v2\t.EnvelopeR\benvelope\x1a:\n" +
 	"\fHeadersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"U\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"|\n" +
 	"\rAgentResponse\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x16\n" +
 	"\x06errmsg\x18\x02 \x01(\tR\x06errmsg\x12\x18\n" +
-	"\apayload\x18\x04 \x01(\fR\apayload2C\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x12%\n" +
+	"\benvelope\x18\x05 \x01(\v2\t.EnvelopeR\benvelope\"S\n" +
+	"\rEnrollRequest\x12\x1a\n" +
+	"\bclientID\x18\x01 \x01(\tR\bclientID\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x10\n" +
+	"\x03csr\x18\x03 \x01(\fR\x03csr\"\x84\x01\n" +
+	"\x0eEnrollResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x16\n" +
+	"\x06errmsg\x18\x02 \x01(\tR\x06errmsg\x12 \n" +
+	"\vcertificate\x18\x03 \x01(\fR\vcertificate\x12$\n" +
+	"\rcaCertificate\x18\x04 \x01(\fR\rcaCertificate\"\xe0\x02\n" +
+	"\bEnvelope\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\areplyTo\x18\x02 \x01(\tR\areplyTo\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12*\n" +
+	"\theartbeat\x18\n" +
+	" \x01(\v2\n" +
+	".HeartbeatH\x00R\theartbeat\x12+\n" +
+	"\bregister\x18\v \x01(\v2\r.RegisterInfoH\x00R\bregister\x123\n" +
+	"\ftaskDispatch\x18\f \x01(\v2\r.TaskDispatchH\x00R\ftaskDispatch\x12-\n" +
+	"\n" +
+	"taskResult\x18\r \x01(\v2\v.TaskResultH\x00R\n" +
+	"taskResult\x12-\n" +
+	"\n" +
+	"configPush\x18\x0e \x01(\v2\v.ConfigPushH\x00R\n" +
+	"configPush\x12\x18\n" +
+	"\x03ack\x18\x0f \x01(\v2\x04.AckH\x00R\x03ackB\x06\n" +
+	"\x04body\"#\n" +
+	"\tHeartbeat\x12\x16\n" +
+	"\x06uptime\x18\x01 \x01(\x03R\x06uptime\"\xaa\x02\n" +
+	"\fRegisterInfo\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x10\n" +
+	"\x03ips\x18\x02 \x03(\tR\x03ips\x12\x0e\n" +
+	"\x02os\x18\x03 \x01(\tR\x02os\x12\x12\n" +
+	"\x04arch\x18\x04 \x01(\tR\x04arch\x12\x1c\n" +
+	"\tagentName\x18\x05 \x01(\tR\tagentName\x12\"\n" +
+	"\fagentVersion\x18\x06 \x01(\tR\fagentVersion\x12\x18\n" +
+	"\aplugins\x18\a \x03(\tR\aplugins\x121\n" +
+	"\x06labels\x18\b \x03(\v2\x19.RegisterInfo.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xda\x01\n" +
+	"\fTaskDispatch\x12\x16\n" +
+	"\x06taskID\x18\x01 \x01(\tR\x06taskID\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
+	"\x04args\x18\x03 \x03(\tR\x04args\x121\n" +
+	"\x06params\x18\x04 \x03(\v2\x19.TaskDispatch.ParamsEntryR\x06params\x12\x1c\n" +
+	"\ttimeoutMs\x18\x05 \x01(\x03R\ttimeoutMs\x1a9\n" +
+	"\vParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc2\x01\n" +
+	"\n" +
+	"TaskResult\x12\x16\n" +
+	"\x06taskID\x18\x01 \x01(\tR\x06taskID\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x03R\x03seq\x12\x12\n" +
+	"\x04done\x18\x03 \x01(\bR\x04done\x12\x12\n" +
+	"\x04code\x18\x04 \x01(\x05R\x04code\x12\x16\n" +
+	"\x06errmsg\x18\x05 \x01(\tR\x06errmsg\x12\x16\n" +
+	"\x06stream\x18\x06 \x01(\tR\x06stream\x12\x16\n" +
+	"\x06output\x18\a \x01(\fR\x06output\x12\x1a\n" +
+	"\bexitCode\x18\b \x01(\x05R\bexitCode\"T\n" +
+	"\n" +
+	"ConfigPush\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\"1\n" +
+	"\x03Ack\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x16\n" +
+	"\x06errmsg\x18\x02 \x01(\tR\x06errmsg2p\n" +
 	"\x11ControllerService\x12.\n" +
-	"\aConnect\x12\r.AgentRequest\x1a\x0e.AgentResponse\"\x00(\x010\x01B\tZ\a.;protob\x06proto3"
+	"\aConnect\x12\r.AgentRequest\x1a\x0e.AgentResponse\"\x00(\x010\x01\x12+\n" +
+	"\x06Enroll\x12\x0e.EnrollRequest\x1a\x0f.EnrollResponse\"\x00B\tZ\a.;protob\x06proto3"
 
 var (
 	file_controller_proto_rawDescOnce sync.Once
@@ -187,21 +1016,44 @@ func file_controller_proto_rawDescGZIP() []byte {
 	return file_controller_proto_rawDescData
 }
 
-var file_controller_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_controller_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_controller_proto_goTypes = []any{
-	(*AgentRequest)(nil),  // 0: AgentRequest
-	(*AgentResponse)(nil), // 1: AgentResponse
-	nil,                   // 2: AgentRequest.HeadersEntry
+	(*AgentRequest)(nil),   // 0: AgentRequest
+	(*AgentResponse)(nil),  // 1: AgentResponse
+	(*EnrollRequest)(nil),  // 2: EnrollRequest
+	(*EnrollResponse)(nil), // 3: EnrollResponse
+	(*Envelope)(nil),       // 4: Envelope
+	(*Heartbeat)(nil),      // 5: Heartbeat
+	(*RegisterInfo)(nil),   // 6: RegisterInfo
+	(*TaskDispatch)(nil),   // 7: TaskDispatch
+	(*TaskResult)(nil),     // 8: TaskResult
+	(*ConfigPush)(nil),     // 9: ConfigPush
+	(*Ack)(nil),            // 10: Ack
+	nil,                    // 11: AgentRequest.HeadersEntry
+	nil,                    // 12: RegisterInfo.LabelsEntry
+	nil,                    // 13: TaskDispatch.ParamsEntry
 }
 var file_controller_proto_depIdxs = []int32{
-	2, // 0: AgentRequest.headers:type_name -> AgentRequest.HeadersEntry
-	0, // 1: ControllerService.Connect:input_type -> AgentRequest
-	1, // 2: ControllerService.Connect:output_type -> AgentResponse
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	11, // 0: AgentRequest.headers:type_name -> AgentRequest.HeadersEntry
+	4,  // 1: AgentRequest.envelope:type_name -> Envelope
+	4,  // 2: AgentResponse.envelope:type_name -> Envelope
+	5,  // 3: Envelope.heartbeat:type_name -> Heartbeat
+	6,  // 4: Envelope.register:type_name -> RegisterInfo
+	7,  // 5: Envelope.taskDispatch:type_name -> TaskDispatch
+	8,  // 6: Envelope.taskResult:type_name -> TaskResult
+	9,  // 7: Envelope.configPush:type_name -> ConfigPush
+	10, // 8: Envelope.ack:type_name -> Ack
+	12, // 9: RegisterInfo.labels:type_name -> RegisterInfo.LabelsEntry
+	13, // 10: TaskDispatch.params:type_name -> TaskDispatch.ParamsEntry
+	0,  // 11: ControllerService.Connect:input_type -> AgentRequest
+	2,  // 12: ControllerService.Enroll:input_type -> EnrollRequest
+	1,  // 13: ControllerService.Connect:output_type -> AgentResponse
+	3,  // 14: ControllerService.Enroll:output_type -> EnrollResponse
+	13, // [13:15] is the sub-list for method output_type
+	11, // [11:13] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_controller_proto_init() }
@@ -209,13 +1061,21 @@ func file_controller_proto_init() {
 	if File_controller_proto != nil {
 		return
 	}
+	file_controller_proto_msgTypes[4].OneofWrappers = []any{
+		(*Envelope_Heartbeat)(nil),
+		(*Envelope_Register)(nil),
+		(*Envelope_TaskDispatch)(nil),
+		(*Envelope_TaskResult)(nil),
+		(*Envelope_ConfigPush)(nil),
+		(*Envelope_Ack)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_controller_proto_rawDesc), len(file_controller_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -41,6 +41,7 @@ type DatabusRequest struct {
 	ClientID      string                 `protobuf:"bytes,1,opt,name=clientID,proto3" json:"clientID,omitempty"`
 	Headers       map[string]string      `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	Payload       []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Seq           uint64                 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -96,6 +97,13 @@ func (x *DatabusRequest) GetPayload() []byte {
 	return nil
 }
 
+func (x *DatabusRequest) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
 type DatabusResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        int64                  `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
@@ -148,23 +156,91 @@ func (x *DatabusResponse) GetErrmsg() string {
 	return ""
 }
 
+type DatabusAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Status        int64                  `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	Errmsg        string                 `protobuf:"bytes,3,opt,name=errmsg,proto3" json:"errmsg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DatabusAck) Reset() {
+	*x = DatabusAck{}
+	mi := &file_databus_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DatabusAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DatabusAck) ProtoMessage() {}
+
+func (x *DatabusAck) ProtoReflect() protoreflect.Message {
+	mi := &file_databus_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DatabusAck.ProtoReflect.Descriptor instead.
+func (*DatabusAck) Descriptor() ([]byte, []int) {
+	return file_databus_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DatabusAck) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *DatabusAck) GetStatus() int64 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *DatabusAck) GetErrmsg() string {
+	if x != nil {
+		return x.Errmsg
+	}
+	return ""
+}
+
 var File_databus_proto protoreflect.FileDescriptor
 
 const file_databus_proto_rawDesc = "" +
 	"\n" +
-	"\rdatabus.proto\"\xba\x01\n" +
+	"\rdatabus.proto\"\xcc\x01\n" +
 	"\x0eDatabusRequest\x12\x1a\n" +
 	"\bclientID\x18\x01 \x01(\tR\bclientID\x126\n" +
 	"\aheaders\x18\x02 \x03(\v2\x1c.DatabusRequest.HeadersEntryR\aheaders\x12\x18\n" +
-	"\apayload\x18\x03 \x01(\fR\apayload\x1a:\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\x1a:\n" +
 	"\fHeadersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"A\n" +
 	"\x0fDatabusResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\x03R\x06status\x12\x16\n" +
-	"\x06errmsg\x18\x02 \x01(\tR\x06errmsg2C\n" +
+	"\x06errmsg\x18\x02 \x01(\tR\x06errmsg\"N\n" +
+	"\n" +
+	"DatabusAck\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\x03R\x06status\x12\x16\n" +
+	"\x06errmsg\x18\x03 \x01(\tR\x06errmsg2u\n" +
 	"\x0eDatabusService\x121\n" +
-	"\bPushData\x12\x0f.DatabusRequest\x1a\x10.DatabusResponse\"\x00(\x01B\tZ\a.;protob\x06proto3"
+	"\bPushData\x12\x0f.DatabusRequest\x1a\x10.DatabusResponse\"\x00(\x01\x120\n" +
+	"\n" +
+	"StreamData\x12\x0f.DatabusRequest\x1a\v.DatabusAck\"\x00(\x010\x01B\tZ\a.;protob\x06proto3"
 
 var (
 	file_databus_proto_rawDescOnce sync.Once
@@ -178,18 +254,21 @@ func file_databus_proto_rawDescGZIP() []byte {
 	return file_databus_proto_rawDescData
 }
 
-var file_databus_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_databus_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_databus_proto_goTypes = []any{
 	(*DatabusRequest)(nil),  // 0: DatabusRequest
 	(*DatabusResponse)(nil), // 1: DatabusResponse
-	nil,                     // 2: DatabusRequest.HeadersEntry
+	(*DatabusAck)(nil),      // 2: DatabusAck
+	nil,                     // 3: DatabusRequest.HeadersEntry
 }
 var file_databus_proto_depIdxs = []int32{
-	2, // 0: DatabusRequest.headers:type_name -> DatabusRequest.HeadersEntry
+	3, // 0: DatabusRequest.headers:type_name -> DatabusRequest.HeadersEntry
 	0, // 1: DatabusService.PushData:input_type -> DatabusRequest
-	1, // 2: DatabusService.PushData:output_type -> DatabusResponse
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
+	0, // 2: DatabusService.StreamData:input_type -> DatabusRequest
+	1, // 3: DatabusService.PushData:output_type -> DatabusResponse
+	2, // 4: DatabusService.StreamData:output_type -> DatabusAck
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
 	1, // [1:1] is the sub-list for extension type_name
 	1, // [1:1] is the sub-list for extension extendee
 	0, // [0:1] is the sub-list for field type_name
@@ -206,7 +285,7 @@ func file_databus_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_databus_proto_rawDesc), len(file_databus_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -34,7 +34,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	DatabusService_PushData_FullMethodName = "/DatabusService/PushData"
+	DatabusService_PushData_FullMethodName   = "/DatabusService/PushData"
+	DatabusService_StreamData_FullMethodName = "/DatabusService/StreamData"
 )
 
 // DatabusServiceClient is the client API for DatabusService service.
@@ -42,6 +43,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type DatabusServiceClient interface {
 	PushData(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[DatabusRequest, DatabusResponse], error)
+	StreamData(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[DatabusRequest, DatabusAck], error)
 }
 
 type databusServiceClient struct {
@@ -65,11 +67,25 @@ func (c *databusServiceClient) PushData(ctx context.Context, opts ...grpc.CallOp
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type DatabusService_PushDataClient = grpc.ClientStreamingClient[DatabusRequest, DatabusResponse]
 
+func (c *databusServiceClient) StreamData(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[DatabusRequest, DatabusAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DatabusService_ServiceDesc.Streams[1], DatabusService_StreamData_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DatabusRequest, DatabusAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DatabusService_StreamDataClient = grpc.BidiStreamingClient[DatabusRequest, DatabusAck]
+
 // DatabusServiceServer is the server API for DatabusService service.
 // All implementations must embed UnimplementedDatabusServiceServer
 // for forward compatibility.
 type DatabusServiceServer interface {
 	PushData(grpc.ClientStreamingServer[DatabusRequest, DatabusResponse]) error
+	StreamData(grpc.BidiStreamingServer[DatabusRequest, DatabusAck]) error
 	mustEmbedUnimplementedDatabusServiceServer()
 }
 
@@ -83,6 +99,9 @@ type UnimplementedDatabusServiceServer struct{}
 func (UnimplementedDatabusServiceServer) PushData(grpc.ClientStreamingServer[DatabusRequest, DatabusResponse]) error {
 	return status.Error(codes.Unimplemented, "method PushData not implemented")
 }
+func (UnimplementedDatabusServiceServer) StreamData(grpc.BidiStreamingServer[DatabusRequest, DatabusAck]) error {
+	return status.Error(codes.Unimplemented, "method StreamData not implemented")
+}
 func (UnimplementedDatabusServiceServer) mustEmbedUnimplementedDatabusServiceServer() {}
 func (UnimplementedDatabusServiceServer) testEmbeddedByValue()                        {}
 
@@ -111,6 +130,13 @@ func _DatabusService_PushData_Handler(srv interface{}, stream grpc.ServerStream)
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type DatabusService_PushDataServer = grpc.ClientStreamingServer[DatabusRequest, DatabusResponse]
 
+func _DatabusService_StreamData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DatabusServiceServer).StreamData(&grpc.GenericServerStream[DatabusRequest, DatabusAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DatabusService_StreamDataServer = grpc.BidiStreamingServer[DatabusRequest, DatabusAck]
+
 // DatabusService_ServiceDesc is the grpc.ServiceDesc for DatabusService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -124,6 +150,12 @@ var DatabusService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _DatabusService_PushData_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "StreamData",
+			Handler:       _DatabusService_StreamData_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "databus.proto",
 }
@@ -0,0 +1,223 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+// Package sbcodec packs several agent messages into one DatabusRequest payload and
+// compresses it. The encoding is announced in the request headers, so receivers can tell
+// batches from the single-message requests older agents send.
+package sbcodec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DatabusRequest header keys and values used by batched requests.
+const (
+	HeaderContentType     = "content-type"
+	HeaderContentEncoding = "content-encoding"
+	HeaderBatchCount      = "batch-count"
+
+	// ContentTypeBatch marks a payload made of length-prefixed messages.
+	ContentTypeBatch = "application/vnd.saber.batch"
+)
+
+// Content encodings.
+const (
+	EncodingIdentity = "identity"
+	EncodingGzip     = "gzip"
+	EncodingZstd     = "zstd"
+	EncodingSnappy   = "snappy"
+)
+
+// MaxDecodedSize bounds the size of a decompressed payload.
+const MaxDecodedSize = 64 << 20
+
+var (
+	// ErrUnsupportedEncoding is returned for an unknown content encoding.
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	// ErrTooLarge is returned when a payload decompresses to more than MaxDecodedSize.
+	ErrTooLarge = errors.New("decoded payload too large")
+)
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxDecodedSize))
+)
+
+// ValidEncoding reports whether encoding is supported. The empty string means identity.
+func ValidEncoding(encoding string) bool {
+	switch encoding {
+	case "", EncodingIdentity, EncodingGzip, EncodingZstd, EncodingSnappy:
+		return true
+	default:
+		return false
+	}
+}
+
+// Compress compresses data with encoding.
+func Compress(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "", EncodingIdentity:
+		return data, nil
+
+	case EncodingGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	case EncodingZstd:
+		return zstdEncoder.EncodeAll(data, nil), nil
+
+	case EncodingSnappy:
+		return snappy.Encode(nil, data), nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// Decompress reverses Compress, refusing payloads that decode to more than MaxDecodedSize.
+func Decompress(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "", EncodingIdentity:
+		return data, nil
+
+	case EncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		out, err := io.ReadAll(io.LimitReader(r, MaxDecodedSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(out) > MaxDecodedSize {
+			return nil, ErrTooLarge
+		}
+		return out, nil
+
+	case EncodingZstd:
+		out, err := zstdDecoder.DecodeAll(data, nil)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || len(out) > MaxDecodedSize {
+			return nil, ErrTooLarge
+		}
+		return out, err
+
+	case EncodingSnappy:
+		n, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if n > MaxDecodedSize {
+			return nil, ErrTooLarge
+		}
+		return snappy.Decode(nil, data)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// EncodeBatch frames msgs as a sequence of uvarint length-prefixed messages.
+func EncodeBatch(msgs [][]byte) []byte {
+	size := 0
+	for _, m := range msgs {
+		size += binary.MaxVarintLen64 + len(m)
+	}
+
+	out := make([]byte, 0, size)
+	for _, m := range msgs {
+		out = binary.AppendUvarint(out, uint64(len(m)))
+		out = append(out, m...)
+	}
+	return out
+}
+
+// DecodeBatch splits a payload framed by EncodeBatch.
+func DecodeBatch(data []byte) ([][]byte, error) {
+	var msgs [][]byte
+	for len(data) > 0 {
+		n, k := binary.Uvarint(data)
+		if k <= 0 || n > uint64(len(data)-k) {
+			return nil, fmt.Errorf("malformed batch at message %d", len(msgs))
+		}
+		data = data[k:]
+		msgs = append(msgs, data[:n:n])
+		data = data[n:]
+	}
+	return msgs, nil
+}
+
+// Pack frames and compresses msgs, returning the payload and the headers describing it.
+func Pack(msgs [][]byte, encoding string) ([]byte, map[string]string, error) {
+	if encoding == "" {
+		encoding = EncodingIdentity
+	}
+
+	payload, err := Compress(encoding, EncodeBatch(msgs))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headers := map[string]string{
+		HeaderContentType:     ContentTypeBatch,
+		HeaderContentEncoding: encoding,
+		HeaderBatchCount:      strconv.Itoa(len(msgs)),
+	}
+	return payload, headers, nil
+}
+
+// IsBatch reports whether headers describe a payload built by Pack.
+func IsBatch(headers map[string]string) bool {
+	return headers[HeaderContentType] == ContentTypeBatch
+}
+
+// Unpack returns the messages of a payload. A payload without batch headers is a single
+// message, possibly compressed.
+func Unpack(headers map[string]string, payload []byte) ([][]byte, error) {
+	data, err := Decompress(headers[HeaderContentEncoding], payload)
+	if err != nil {
+		return nil, err
+	}
+	if !IsBatch(headers) {
+		return [][]byte{data}, nil
+	}
+
+	msgs, err := DecodeBatch(data)
+	if err != nil {
+		return nil, err
+	}
+	if count, ok := headers[HeaderBatchCount]; ok && count != strconv.Itoa(len(msgs)) {
+		return nil, fmt.Errorf("batch holds %d messages, header says %s", len(msgs), count)
+	}
+	return msgs, nil
+}
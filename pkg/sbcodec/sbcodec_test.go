@@ -0,0 +1,86 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbcodec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPackUnpack(t *testing.T) {
+	msgs := [][]byte{[]byte(`{"PluginName":"host"}`), {}, bytes.Repeat([]byte("x"), 300)}
+
+	for _, enc := range []string{"", EncodingIdentity, EncodingGzip, EncodingZstd, EncodingSnappy} {
+		payload, headers, err := Pack(msgs, enc)
+		if err != nil {
+			t.Fatalf("Pack(%q): %v", enc, err)
+		}
+		if !IsBatch(headers) || headers[HeaderBatchCount] != "3" {
+			t.Fatalf("Pack(%q) headers = %v", enc, headers)
+		}
+
+		got, err := Unpack(headers, payload)
+		if err != nil {
+			t.Fatalf("Unpack(%q): %v", enc, err)
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", msgs) {
+			t.Fatalf("Unpack(%q) = %q", enc, got)
+		}
+	}
+}
+
+func TestUnpackSingle(t *testing.T) {
+	got, err := Unpack(nil, []byte("plain"))
+	if err != nil || len(got) != 1 || string(got[0]) != "plain" {
+		t.Fatalf("Unpack(plain) = %q, %v", got, err)
+	}
+
+	compressed, _ := Compress(EncodingGzip, []byte("zipped"))
+	got, err = Unpack(map[string]string{HeaderContentEncoding: EncodingGzip}, compressed)
+	if err != nil || len(got) != 1 || string(got[0]) != "zipped" {
+		t.Fatalf("Unpack(gzip) = %q, %v", got, err)
+	}
+}
+
+func TestUnpackErrors(t *testing.T) {
+	if _, err := Unpack(map[string]string{HeaderContentEncoding: "brotli"}, []byte("x")); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Fatalf("unknown encoding: %v", err)
+	}
+
+	payload, headers, _ := Pack([][]byte{[]byte("a"), []byte("b")}, EncodingIdentity)
+	headers[HeaderBatchCount] = "5"
+	if _, err := Unpack(headers, payload); err == nil {
+		t.Fatal("count mismatch should fail")
+	}
+
+	if _, err := DecodeBatch([]byte{10, 'a'}); err == nil {
+		t.Fatal("truncated batch should fail")
+	}
+
+	bomb := make([]byte, MaxDecodedSize+1)
+	for _, enc := range []string{EncodingGzip, EncodingZstd, EncodingSnappy} {
+		compressed, err := Compress(enc, bomb)
+		if err != nil {
+			t.Fatalf("Compress(%s): %v", enc, err)
+		}
+		if _, err := Decompress(enc, compressed); !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("Decompress(%s) of oversized payload = %v", enc, err)
+		}
+	}
+}
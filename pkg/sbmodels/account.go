@@ -0,0 +1,107 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+import "time"
+
+// Account event names emitted by the accounts harvester plugin.
+const (
+	AccountEventInventory            = "account_inventory"
+	AccountEventUserAdded            = "user_added"
+	AccountEventUserRemoved          = "user_removed"
+	AccountEventUserModified         = "user_modified"
+	AccountEventGroupAdded           = "group_added"
+	AccountEventGroupRemoved         = "group_removed"
+	AccountEventGroupModified        = "group_modified"
+	AccountEventSudoRuleAdded        = "sudo_rule_added"
+	AccountEventSudoRuleRemoved      = "sudo_rule_removed"
+	AccountEventAuthorizedKeyAdded   = "authorized_key_added"
+	AccountEventAuthorizedKeyRemoved = "authorized_key_removed"
+)
+
+// Password states derived from the shadow entry. The hash itself is never read out.
+const (
+	PasswordStateSet      = "set"
+	PasswordStateLocked   = "locked"   // hash prefixed with '!' or '*'
+	PasswordStateEmpty    = "empty"    // login without password
+	PasswordStateDisabled = "disabled" // "!!", "*" or "!" alone: no password was ever set
+	PasswordStateUnknown  = "unknown"  // no shadow entry or shadow unreadable
+)
+
+// UserAccount is one /etc/passwd entry with its /etc/shadow metadata.
+type UserAccount struct {
+	Name          string     `json:"name"`
+	UID           uint32     `json:"uid"`
+	GID           uint32     `json:"gid"`
+	Gecos         string     `json:"gecos,omitempty"`
+	Home          string     `json:"home"`
+	Shell         string     `json:"shell"`
+	Groups        []string   `json:"groups,omitempty"` // supplementary groups
+	PasswordState string     `json:"password_state"`
+	LastChange    *time.Time `json:"last_change,omitempty"`
+	MinDays       *int       `json:"min_days,omitempty"`
+	MaxDays       *int       `json:"max_days,omitempty"`
+	WarnDays      *int       `json:"warn_days,omitempty"`
+	InactiveDays  *int       `json:"inactive_days,omitempty"`
+	Expire        *time.Time `json:"expire,omitempty"`
+}
+
+// GroupInfo is one /etc/group entry.
+type GroupInfo struct {
+	Name    string   `json:"name"`
+	GID     uint32   `json:"gid"`
+	Members []string `json:"members,omitempty"`
+}
+
+// SudoRule is one effective line of the sudoers policy, with continuation lines joined.
+type SudoRule struct {
+	Source string `json:"source"` // file the rule was read from
+	Rule   string `json:"rule"`
+}
+
+// AuthorizedKey is one public key allowed to log in as User.
+type AuthorizedKey struct {
+	User        string   `json:"user"`
+	Path        string   `json:"path"`
+	Type        string   `json:"type"`
+	Fingerprint string   `json:"fingerprint"` // SHA256:<base64>, as printed by ssh-keygen -l
+	Comment     string   `json:"comment,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// AccountInventory is the full set of local accounts, groups, sudo rules and
+// authorized keys at one point in time.
+type AccountInventory struct {
+	Users          []UserAccount   `json:"users"`
+	Groups         []GroupInfo     `json:"groups"`
+	SudoRules      []SudoRule      `json:"sudo_rules"`
+	AuthorizedKeys []AuthorizedKey `json:"authorized_keys"`
+	Timestamp      time.Time       `json:"timestamp"`
+}
+
+// AccountEvent reports one change between two inventories. Exactly one of User, Group,
+// SudoRule or AuthorizedKey is set; Previous* holds the old value of a modified entry.
+type AccountEvent struct {
+	Action        string         `json:"action"`
+	User          *UserAccount   `json:"user,omitempty"`
+	PreviousUser  *UserAccount   `json:"previous_user,omitempty"`
+	Group         *GroupInfo     `json:"group,omitempty"`
+	PreviousGroup *GroupInfo     `json:"previous_group,omitempty"`
+	SudoRule      *SudoRule      `json:"sudo_rule,omitempty"`
+	AuthorizedKey *AuthorizedKey `json:"authorized_key,omitempty"`
+	Timestamp     time.Time      `json:"timestamp"`
+}
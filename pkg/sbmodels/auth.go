@@ -0,0 +1,47 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+import "time"
+
+// Authentication event names emitted by the auth harvester plugin.
+const (
+	AuthEventLoginSuccess        = "login_success"
+	AuthEventLoginFailure        = "login_failure"
+	AuthEventLogout              = "logout"
+	AuthEventPrivilegeEscalation = "privilege_escalation"
+)
+
+// AuthEvent is one login, logout or privilege change decoded from the login accounting
+// files (wtmp, btmp) or the system auth log.
+type AuthEvent struct {
+	Action     string    `json:"action"`
+	Success    bool      `json:"success"`
+	User       string    `json:"user,omitempty"`
+	TargetUser string    `json:"target_user,omitempty"` // sudo/su target account
+	SourceIP   string    `json:"source_ip,omitempty"`
+	SourcePort int       `json:"source_port,omitempty"`
+	Host       string    `json:"host,omitempty"` // remote host name when no IP is recorded
+	TTY        string    `json:"tty,omitempty"`
+	Method     string    `json:"method,omitempty"`  // e.g. password, publickey, sudo, su
+	Service    string    `json:"service,omitempty"` // e.g. sshd, sudo, su, login
+	Command    string    `json:"command,omitempty"`
+	PID        int32     `json:"pid,omitempty"`
+	Source     string    `json:"source"` // file the event was read from
+	Message    string    `json:"message,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
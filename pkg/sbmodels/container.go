@@ -0,0 +1,40 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+// Container runtimes recorded in ContainerInfo.Runtime.
+const (
+	ContainerRuntimeDocker     = "docker"
+	ContainerRuntimeContainerd = "containerd"
+	ContainerRuntimeCRIO       = "cri-o"
+	ContainerRuntimePodman     = "podman"
+)
+
+// ContainerInfo attributes an event to the container, and for Kubernetes workloads the
+// pod, it came from. Fields other than ID and CgroupPath are filled from the runtime's
+// local state files when they can be found.
+type ContainerInfo struct {
+	ID            string `json:"id,omitempty"`
+	Runtime       string `json:"runtime,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Image         string `json:"image,omitempty"`
+	CgroupPath    string `json:"cgroup_path,omitempty"`
+	PodName       string `json:"pod_name,omitempty"`
+	PodNamespace  string `json:"pod_namespace,omitempty"`
+	PodUID        string `json:"pod_uid,omitempty"`
+	ContainerName string `json:"container_name,omitempty"` // container name within the pod
+}
@@ -0,0 +1,61 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+import "time"
+
+// File integrity event names emitted by the fim harvester plugin.
+const (
+	FIMEventCreated           = "fim_created"
+	FIMEventModified          = "fim_modified"
+	FIMEventDeleted           = "fim_deleted"
+	FIMEventPermissionChanged = "fim_permission_changed"
+)
+
+// File types recorded in FileState.Type.
+const (
+	FileTypeRegular = "file"
+	FileTypeDir     = "dir"
+	FileTypeSymlink = "symlink"
+	FileTypeOther   = "other"
+)
+
+// FileState is the baseline record of one monitored path.
+type FileState struct {
+	Path       string    `json:"path"`
+	Type       string    `json:"type"`
+	Size       int64     `json:"size"`
+	Mode       string    `json:"mode"` // e.g. "-rwsr-xr-x"
+	UID        uint32    `json:"uid"`
+	GID        uint32    `json:"gid"`
+	Inode      uint64    `json:"inode"`
+	MTime      time.Time `json:"mtime"`
+	CTime      time.Time `json:"ctime"`
+	SHA256     string    `json:"sha256,omitempty"` // regular files within the hash size limit only
+	LinkTarget string    `json:"link_target,omitempty"`
+}
+
+// FIMEvent reports a difference between the baseline and the current state of a path.
+type FIMEvent struct {
+	Action    string         `json:"action"`
+	Path      string         `json:"path"`
+	Changes   []string       `json:"changes,omitempty"` // changed attributes, e.g. ["sha256", "size"]
+	Previous  *FileState     `json:"previous,omitempty"`
+	Current   *FileState     `json:"current,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+	Container *ContainerInfo `json:"container,omitempty"` // set for paths inside container state directories
+}
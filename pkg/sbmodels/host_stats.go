@@ -29,12 +29,69 @@ type NetworkStats struct {
 	TxErrors  uint64   `json:"tx_errors"`
 	RxFifo    uint64   `json:"rx_fifo"`
 	TxFifo    uint64   `json:"tx_fifo"`
+
+	// Per-second rates over the last collection interval; zero on the first collection.
+	RxBytesPerSec   float64 `json:"rx_bytes_per_sec"`
+	TxBytesPerSec   float64 `json:"tx_bytes_per_sec"`
+	RxPacketsPerSec float64 `json:"rx_packets_per_sec"`
+	TxPacketsPerSec float64 `json:"tx_packets_per_sec"`
 }
 
 // DiskStats holds mountpoint and used percent for one disk.
 type DiskStats struct {
 	Mountpoint  string  `json:"mountpoint"`
 	UsedPercent float64 `json:"used_percent"`
+
+	Device            string  `json:"device"`
+	FSType            string  `json:"fs_type"`
+	TotalBytes        uint64  `json:"total_bytes"`
+	UsedBytes         uint64  `json:"used_bytes"`
+	FreeBytes         uint64  `json:"free_bytes"`
+	InodesTotal       uint64  `json:"inodes_total"`
+	InodesUsed        uint64  `json:"inodes_used"`
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
+
+	// I/O rates of the underlying block device over the last collection interval; zero on
+	// the first collection and for filesystems without a block device.
+	ReadIOPS         float64 `json:"read_iops"`
+	WriteIOPS        float64 `json:"write_iops"`
+	ReadBytesPerSec  float64 `json:"read_bytes_per_sec"`
+	WriteBytesPerSec float64 `json:"write_bytes_per_sec"`
+}
+
+// CPUStats holds the share of CPU time (0-100) spent in each state over the last
+// collection interval, for one core or for all cores.
+type CPUStats struct {
+	Core        int     `json:"core"` // -1 for the aggregate of all cores
+	UsedPercent float64 `json:"used_percent"`
+	User        float64 `json:"user"`
+	System      float64 `json:"system"`
+	Nice        float64 `json:"nice"`
+	Idle        float64 `json:"idle"`
+	IOWait      float64 `json:"iowait"`
+	IRQ         float64 `json:"irq"`
+	SoftIRQ     float64 `json:"softirq"`
+	Steal       float64 `json:"steal"`
+}
+
+// LoadStats holds the 1, 5 and 15 minute load averages.
+type LoadStats struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// MemoryStats holds physical memory and swap usage in bytes.
+type MemoryStats struct {
+	TotalBytes         uint64  `json:"total_bytes"`
+	AvailableBytes     uint64  `json:"available_bytes"`
+	UsedBytes          uint64  `json:"used_bytes"`
+	SwapTotalBytes     uint64  `json:"swap_total_bytes"`
+	SwapUsedBytes      uint64  `json:"swap_used_bytes"`
+	SwapFreeBytes      uint64  `json:"swap_free_bytes"`
+	SwapUsedPercent    float64 `json:"swap_used_percent"`
+	SwapInBytesPerSec  float64 `json:"swap_in_bytes_per_sec"`
+	SwapOutBytesPerSec float64 `json:"swap_out_bytes_per_sec"`
 }
 
 // Stats is the stats for host metrics/info.
@@ -48,6 +105,11 @@ type Stats struct {
 	OS       string         `json:"os"`
 	Arch     string         `json:"arch"`
 	Kernel   string         `json:"kernel"`
+
+	CPUTotal     CPUStats    `json:"cpu_total"`
+	CPUCores     []CPUStats  `json:"cpu_cores"`
+	Load         LoadStats   `json:"load"`
+	MemoryDetail MemoryStats `json:"memory_detail"`
 }
 
 // NewHostStats returns a zero-valued Stats.
@@ -62,5 +124,7 @@ func NewHostStats() *Stats {
 		OS:       "",
 		Arch:     "",
 		Kernel:   "",
+		CPUTotal: CPUStats{Core: -1},
+		CPUCores: []CPUStats{},
 	}
 }
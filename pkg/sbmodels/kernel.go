@@ -0,0 +1,65 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+import "time"
+
+// Kernel event names emitted by the kernel harvester plugin.
+const (
+	KernelEventState          = "kernel_state"
+	KernelEventModuleLoaded   = "kernel_module_loaded"
+	KernelEventModuleUnloaded = "kernel_module_unloaded"
+	KernelEventSysctlChanged  = "kernel_sysctl_changed"
+	KernelEventTaintChanged   = "kernel_taint_changed"
+	KernelEventLSMChanged     = "kernel_lsm_changed"
+)
+
+// KernelModule is one loaded kernel module.
+type KernelModule struct {
+	Name     string   `json:"name"`
+	Size     uint64   `json:"size"`
+	RefCount int      `json:"ref_count"`
+	UsedBy   []string `json:"used_by,omitempty"`
+	State    string   `json:"state"`
+	Taint    string   `json:"taint,omitempty"` // e.g. "OE": out-of-tree, unsigned
+}
+
+// KernelState is the security-relevant state of the running kernel.
+type KernelState struct {
+	Release    string            `json:"release"`
+	Version    string            `json:"version"`
+	Tainted    uint64            `json:"tainted"`
+	TaintFlags []string          `json:"taint_flags,omitempty"` // letters of the set taint bits, e.g. ["P", "O"]
+	Modules    []KernelModule    `json:"modules"`
+	Sysctls    map[string]string `json:"sysctls"`
+	LSMs       []string          `json:"lsms,omitempty"`     // active Linux security modules, in stacking order
+	Lockdown   string            `json:"lockdown,omitempty"` // none, integrity or confidentiality
+	Timestamp  time.Time         `json:"timestamp"`
+}
+
+// KernelEvent reports one change of the kernel state between two scans. Module is set
+// for module events; Name, Previous and Current describe a changed sysctl, the taint
+// value or the LSM settings ("lsm" or "lockdown").
+type KernelEvent struct {
+	Action     string        `json:"action"`
+	Module     *KernelModule `json:"module,omitempty"`
+	Name       string        `json:"name,omitempty"`
+	Previous   string        `json:"previous,omitempty"`
+	Current    string        `json:"current,omitempty"`
+	TaintFlags []string      `json:"taint_flags,omitempty"` // for taint changes, the flags now set
+	Timestamp  time.Time     `json:"timestamp"`
+}
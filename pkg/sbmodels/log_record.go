@@ -0,0 +1,31 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+import "time"
+
+// LogRecord is one line (or one multiline record) read from a tailed log file.
+type LogRecord struct {
+	Path      string         `json:"path"`
+	Inode     uint64         `json:"inode"`
+	Offset    int64          `json:"offset"` // byte offset of the first line of the record
+	Lines     int            `json:"lines"`
+	Message   string         `json:"message"`
+	Truncated bool           `json:"truncated,omitempty"` // record was cut at the configured max size
+	Timestamp time.Time      `json:"timestamp"`
+	Container *ContainerInfo `json:"container,omitempty"` // set for container log files
+}
@@ -0,0 +1,56 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+import "time"
+
+// Package event names emitted by the packages harvester plugin.
+const (
+	PackageEventInventory = "package_inventory"
+	PackageEventInstalled = "package_installed"
+	PackageEventRemoved   = "package_removed"
+	PackageEventUpgraded  = "package_upgraded" // version changed; downgrades are reported too
+)
+
+// Package managers the packages plugin reads.
+const (
+	PackageManagerDpkg = "dpkg"
+	PackageManagerRPM  = "rpm"
+)
+
+// PackageInfo is one installed package.
+type PackageInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"` // full version as the manager compares it, e.g. 1:2.3-4 or 2:8.2-1.el9
+	Arch    string `json:"arch"`
+	Source  string `json:"source,omitempty"` // source package name, for matching distribution advisories
+	Manager string `json:"manager"`          // PackageManagerDpkg or PackageManagerRPM
+}
+
+// PackageInventory is the full set of installed packages at one point in time.
+type PackageInventory struct {
+	Packages  []PackageInfo `json:"packages"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// PackageEvent reports a package installed, removed or changed between two inventories.
+type PackageEvent struct {
+	Action          string      `json:"action"`
+	Package         PackageInfo `json:"package"`
+	PreviousVersion string      `json:"previous_version,omitempty"` // set for PackageEventUpgraded
+	Timestamp       time.Time   `json:"timestamp"`
+}
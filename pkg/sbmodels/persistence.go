@@ -0,0 +1,70 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+import "time"
+
+// Persistence event names emitted by the persistence harvester plugin.
+const (
+	PersistenceEventInventory = "persistence_inventory"
+	PersistenceEventAdded     = "persistence_added"
+	PersistenceEventModified  = "persistence_modified"
+	PersistenceEventRemoved   = "persistence_removed"
+)
+
+// Persistence mechanism kinds recorded in PersistenceEntry.Kind.
+const (
+	PersistenceKindCron           = "cron"
+	PersistenceKindSystemdUnit    = "systemd_unit"
+	PersistenceKindSystemdTimer   = "systemd_timer"
+	PersistenceKindRCScript       = "rc_script"
+	PersistenceKindShellProfile   = "shell_profile"
+	PersistenceKindLDPreload      = "ld_preload"
+	PersistenceKindModuleAutoload = "module_autoload"
+	PersistenceKindCustom         = "custom"
+)
+
+// PersistenceEntry is one file through which code can be started automatically.
+type PersistenceEntry struct {
+	Kind       string    `json:"kind"`
+	Path       string    `json:"path"`
+	User       string    `json:"user,omitempty"` // owner of a per-user crontab
+	SHA256     string    `json:"sha256,omitempty"`
+	Size       int64     `json:"size"`
+	Mode       string    `json:"mode"`
+	UID        uint32    `json:"uid"`
+	GID        uint32    `json:"gid"`
+	MTime      time.Time `json:"mtime"`
+	LinkTarget string    `json:"link_target,omitempty"`
+	// Directives are the effective lines of table-like files: crontab entries, Exec*= lines
+	// of systemd units, preloaded libraries and autoloaded modules.
+	Directives []string `json:"directives,omitempty"`
+}
+
+// PersistenceInventory is the full set of persistence entries at one point in time.
+type PersistenceInventory struct {
+	Entries   []PersistenceEntry `json:"entries"`
+	Timestamp time.Time          `json:"timestamp"`
+}
+
+// PersistenceEvent reports a persistence entry added, changed or removed between two scans.
+type PersistenceEvent struct {
+	Action    string            `json:"action"`
+	Entry     *PersistenceEntry `json:"entry"`
+	Previous  *PersistenceEntry `json:"previous,omitempty"` // set for PersistenceEventModified
+	Timestamp time.Time         `json:"timestamp"`
+}
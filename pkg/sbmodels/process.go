@@ -0,0 +1,57 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+import "time"
+
+// Process event names emitted by the process harvester plugin.
+const (
+	ProcessEventStart    = "process_start"
+	ProcessEventExit     = "process_exit"
+	ProcessEventSnapshot = "process_snapshot"
+)
+
+// ProcessInfo describes one running process as read from /proc/<pid>.
+type ProcessInfo struct {
+	PID        int32          `json:"pid"`
+	PPID       int32          `json:"ppid"`
+	Name       string         `json:"name"`
+	Exe        string         `json:"exe"`
+	ExeDeleted bool           `json:"exe_deleted,omitempty"` // the executable was removed from disk after start
+	ExeSHA256  string         `json:"exe_sha256,omitempty"`
+	Cmdline    []string       `json:"cmdline"`
+	Cwd        string         `json:"cwd"`
+	UID        uint32         `json:"uid"`
+	EUID       uint32         `json:"euid"`
+	GID        uint32         `json:"gid"`
+	EGID       uint32         `json:"egid"`
+	StartTime  time.Time      `json:"start_time"`
+	Container  *ContainerInfo `json:"container,omitempty"`
+}
+
+// ProcessEvent reports a process that started or exited between two scans.
+type ProcessEvent struct {
+	Action    string      `json:"action"` // ProcessEventStart or ProcessEventExit
+	Process   ProcessInfo `json:"process"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// ProcessSnapshot is the full process table at one point in time.
+type ProcessSnapshot struct {
+	Processes []ProcessInfo `json:"processes"`
+	Timestamp time.Time     `json:"timestamp"`
+}
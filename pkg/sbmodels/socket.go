@@ -0,0 +1,58 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmodels
+
+import "time"
+
+// Socket event names emitted by the netconn harvester plugin.
+const (
+	SocketEventListen   = "socket_listen"   // a new listening socket appeared
+	SocketEventUnlisten = "socket_unlisten" // a listening socket was closed
+	SocketEventConnect  = "socket_connect"  // a new outbound connection was established
+	SocketEventSnapshot = "socket_snapshot"
+)
+
+// SocketInfo describes one socket and, when resolvable, the process that owns it.
+type SocketInfo struct {
+	Protocol    string         `json:"protocol"` // tcp, tcp6, udp, udp6 or unix
+	State       string         `json:"state"`
+	LocalAddr   string         `json:"local_addr,omitempty"`
+	LocalPort   uint16         `json:"local_port,omitempty"`
+	RemoteAddr  string         `json:"remote_addr,omitempty"`
+	RemotePort  uint16         `json:"remote_port,omitempty"`
+	Path        string         `json:"path,omitempty"` // unix sockets only
+	Inode       uint64         `json:"inode"`
+	UID         uint32         `json:"uid"`
+	PID         int32          `json:"pid,omitempty"`
+	ProcessName string         `json:"process_name,omitempty"`
+	Exe         string         `json:"exe,omitempty"`
+	Container   *ContainerInfo `json:"container,omitempty"` // container of the owning process
+}
+
+// SocketEvent reports a socket that appeared or disappeared between two scans.
+type SocketEvent struct {
+	Action    string     `json:"action"`
+	Socket    SocketInfo `json:"socket"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// SocketSnapshot lists the listening sockets and outbound connections at one point in time.
+type SocketSnapshot struct {
+	Listening []SocketInfo `json:"listening"`
+	Outbound  []SocketInfo `json:"outbound"`
+	Timestamp time.Time    `json:"timestamp"`
+}
@@ -0,0 +1,213 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+// Package sbmsg builds the typed envelopes exchanged between the controller and agents on
+// the Connect stream, and matches replies to the requests waiting for them.
+package sbmsg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/proto"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Kind names the body carried by an envelope.
+type Kind string
+
+// Envelope kinds.
+const (
+	KindUnknown      Kind = ""
+	KindHeartbeat    Kind = "heartbeat"
+	KindRegister     Kind = "register"
+	KindTaskDispatch Kind = "taskDispatch"
+	KindTaskResult   Kind = "taskResult"
+	KindConfigPush   Kind = "configPush"
+	KindAck          Kind = "ack"
+)
+
+// ErrUnknownBody is returned for a body that does not fit in an envelope.
+var ErrUnknownBody = gerrors.New(gerrors.InvalidParameter, "unknown envelope body")
+
+// KindOf returns the kind of env's body.
+func KindOf(env *proto.Envelope) Kind {
+	switch env.GetBody().(type) {
+	case *proto.Envelope_Heartbeat:
+		return KindHeartbeat
+	case *proto.Envelope_Register:
+		return KindRegister
+	case *proto.Envelope_TaskDispatch:
+		return KindTaskDispatch
+	case *proto.Envelope_TaskResult:
+		return KindTaskResult
+	case *proto.Envelope_ConfigPush:
+		return KindConfigPush
+	case *proto.Envelope_Ack:
+		return KindAck
+	default:
+		return KindUnknown
+	}
+}
+
+// New wraps body, one of *proto.Heartbeat, *proto.RegisterInfo, *proto.TaskDispatch,
+// *proto.TaskResult, *proto.ConfigPush or *proto.Ack, in an envelope with a new id.
+func New(body protoreflect.ProtoMessage) (*proto.Envelope, error) {
+	env := &proto.Envelope{
+		Id:        uuid.NewString(),
+		Timestamp: time.Now().UnixMilli(),
+	}
+
+	switch b := body.(type) {
+	case *proto.Heartbeat:
+		env.Body = &proto.Envelope_Heartbeat{Heartbeat: b}
+	case *proto.RegisterInfo:
+		env.Body = &proto.Envelope_Register{Register: b}
+	case *proto.TaskDispatch:
+		env.Body = &proto.Envelope_TaskDispatch{TaskDispatch: b}
+	case *proto.TaskResult:
+		env.Body = &proto.Envelope_TaskResult{TaskResult: b}
+	case *proto.ConfigPush:
+		env.Body = &proto.Envelope_ConfigPush{ConfigPush: b}
+	case *proto.Ack:
+		env.Body = &proto.Envelope_Ack{Ack: b}
+	default:
+		return nil, ErrUnknownBody
+	}
+	return env, nil
+}
+
+// Reply wraps body in an envelope answering to.
+func Reply(to *proto.Envelope, body protoreflect.ProtoMessage) (*proto.Envelope, error) {
+	env, err := New(body)
+	if err != nil {
+		return nil, err
+	}
+	env.ReplyTo = to.GetId()
+	return env, nil
+}
+
+// NewAck acknowledges to. A nil err is acknowledged with gerrors.Success; otherwise the
+// code of a gerrors.Error, or gerrors.Failure, is reported with its message.
+func NewAck(to *proto.Envelope, err error) *proto.Envelope {
+	ack := &proto.Ack{Code: int32(gerrors.Success)}
+	if err != nil {
+		ack.Code, ack.Errmsg = int32(gerrors.Failure), err.Error()
+
+		var ge *gerrors.Error
+		if errors.As(err, &ge) {
+			ack.Code, ack.Errmsg = int32(ge.Code()), ge.Message()
+		}
+	}
+
+	env, _ := Reply(to, ack)
+	return env
+}
+
+// AckError returns the error reported by an Ack with a non-zero code, or nil.
+func AckError(env *proto.Envelope) error {
+	ack := env.GetAck()
+	if ack == nil || ack.GetCode() == int32(gerrors.Success) {
+		return nil
+	}
+	return gerrors.New(gerrors.Code(ack.GetCode()), ack.GetErrmsg())
+}
+
+// NeedsAck reports whether an unhandled env should be answered with an Ack. Replies and
+// acks are never answered, so two peers cannot acknowledge each other forever.
+func NeedsAck(env *proto.Envelope) bool {
+	return env.GetReplyTo() == "" && KindOf(env) != KindAck
+}
+
+// Pending matches replies to the requests waiting for them. Each request is bound to the
+// peer it was sent to, and only a reply from that peer resolves it. It is safe for
+// concurrent use.
+type Pending struct {
+	mu    sync.Mutex
+	calls map[string]*pendingCall
+}
+
+type pendingCall struct {
+	peer string
+	ch   chan *proto.Envelope
+}
+
+// NewPending returns an empty Pending.
+func NewPending() *Pending {
+	return &Pending{calls: make(map[string]*pendingCall)}
+}
+
+// Call sends req to peer with send and waits for its first reply or for ctx to be done.
+// A reply that is an Ack with a non-zero code is returned as an error.
+func (p *Pending) Call(
+	ctx context.Context,
+	peer string,
+	req *proto.Envelope,
+	send func(*proto.Envelope) error) (*proto.Envelope, error) {
+	call := &pendingCall{peer: peer, ch: make(chan *proto.Envelope, 1)}
+
+	p.mu.Lock()
+	p.calls[req.GetId()] = call
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.calls, req.GetId())
+		p.mu.Unlock()
+	}()
+
+	if err := send(req); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case resp := <-call.ch:
+		if err := AckError(resp); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+// Resolve delivers env, received from peer, to the call it replies to and reports whether
+// one was waiting. Replies from another peer than the request was sent to, and later
+// replies to the same request, are not delivered.
+func (p *Pending) Resolve(peer string, env *proto.Envelope) bool {
+	if env.GetReplyTo() == "" {
+		return false
+	}
+
+	p.mu.Lock()
+	call, ok := p.calls[env.GetReplyTo()]
+	ok = ok && call.peer == peer
+	if ok {
+		delete(p.calls, env.GetReplyTo())
+	}
+	p.mu.Unlock()
+
+	if ok {
+		call.ch <- env
+	}
+	return ok
+}
@@ -0,0 +1,142 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmsg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"os-artificer/saber/pkg/gerrors"
+	"os-artificer/saber/pkg/proto"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestNew(t *testing.T) {
+	for kind, body := range map[Kind]protoreflect.ProtoMessage{
+		KindHeartbeat:    &proto.Heartbeat{Uptime: 1},
+		KindRegister:     &proto.RegisterInfo{Hostname: "h"},
+		KindTaskDispatch: &proto.TaskDispatch{TaskID: "t"},
+		KindTaskResult:   &proto.TaskResult{TaskID: "t"},
+		KindConfigPush:   &proto.ConfigPush{Name: "n"},
+		KindAck:          &proto.Ack{},
+	} {
+		env, err := New(body)
+		if err != nil {
+			t.Fatalf("New(%s): %v", kind, err)
+		}
+		if got := KindOf(env); got != kind {
+			t.Fatalf("KindOf(New(%s)) = %q", kind, got)
+		}
+		if env.GetId() == "" || env.GetTimestamp() == 0 || env.GetReplyTo() != "" {
+			t.Fatalf("New(%s) = %v", kind, env)
+		}
+	}
+
+	if _, err := New(&proto.AgentRequest{}); !errors.Is(err, ErrUnknownBody) {
+		t.Fatalf("New(AgentRequest) error = %v, want ErrUnknownBody", err)
+	}
+	if kind := KindOf(&proto.Envelope{}); kind != KindUnknown {
+		t.Fatalf("KindOf(empty) = %q", kind)
+	}
+}
+
+func TestAck(t *testing.T) {
+	req, _ := New(&proto.TaskDispatch{TaskID: "t"})
+
+	ok := NewAck(req, nil)
+	if ok.GetReplyTo() != req.GetId() || AckError(ok) != nil {
+		t.Fatalf("NewAck(nil) = %v", ok)
+	}
+
+	failed := NewAck(req, gerrors.New(gerrors.NotFound, "no such task"))
+	err := AckError(failed)
+	if !gerrors.Is(err, gerrors.New(gerrors.NotFound, "")) {
+		t.Fatalf("AckError = %v, want NotFound", err)
+	}
+	var ge *gerrors.Error
+	if !errors.As(err, &ge) || ge.Message() != "no such task" {
+		t.Fatalf("AckError = %v, want the original message", err)
+	}
+
+	if AckError(NewAck(req, errors.New("boom"))) == nil {
+		t.Fatal("plain error was acknowledged as success")
+	}
+
+	if NeedsAck(failed) || NeedsAck(&proto.Envelope{Body: &proto.Envelope_Ack{Ack: &proto.Ack{}}}) || !NeedsAck(req) {
+		t.Fatal("only requests that are not acks need an ack")
+	}
+}
+
+func TestPending(t *testing.T) {
+	p := NewPending()
+	ctx := context.Background()
+
+	// Only the peer the request was sent to can answer it.
+	forged := make(chan bool, 1)
+	req, _ := New(&proto.Heartbeat{})
+	resp, err := p.Call(ctx, "agent-1", req, func(env *proto.Envelope) error {
+		go func() {
+			other, _ := Reply(env, &proto.Heartbeat{Uptime: 13})
+			forged <- p.Resolve("agent-2", other)
+
+			reply, _ := Reply(env, &proto.Heartbeat{Uptime: 7})
+			p.Resolve("agent-1", reply)
+		}()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetHeartbeat().GetUptime() != 7 {
+		t.Fatalf("Call() = %v", resp)
+	}
+	if <-forged {
+		t.Fatal("reply from another peer was delivered")
+	}
+
+	// A late reply finds nobody waiting.
+	late, _ := Reply(req, &proto.Ack{})
+	if p.Resolve("agent-1", late) {
+		t.Fatal("late reply was delivered")
+	}
+
+	req, _ = New(&proto.TaskDispatch{})
+	_, err = p.Call(ctx, "agent-1", req, func(env *proto.Envelope) error {
+		go p.Resolve("agent-1", NewAck(env, gerrors.New(gerrors.Unimplemented, "no handler")))
+		return nil
+	})
+	if !gerrors.Is(err, gerrors.New(gerrors.Unimplemented, "")) {
+		t.Fatalf("Call() error = %v, want Unimplemented", err)
+	}
+
+	sendErr := errors.New("not connected")
+	if _, err := p.Call(ctx, "agent-1", req, func(*proto.Envelope) error { return sendErr }); err != sendErr {
+		t.Fatalf("Call() error = %v, want the send error", err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Call(timeout, "agent-1", req, func(*proto.Envelope) error { return nil }); err != context.DeadlineExceeded {
+		t.Fatalf("Call() error = %v, want DeadlineExceeded", err)
+	}
+	if len(p.calls) != 0 {
+		t.Fatalf("%d calls left pending", len(p.calls))
+	}
+}
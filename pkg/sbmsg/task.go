@@ -0,0 +1,58 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbmsg
+
+import "time"
+
+// Task types carried in TaskDispatch.type.
+const (
+	// TaskExec runs args[0] with args[1:] and streams its stdout and stderr.
+	TaskExec = "exec"
+
+	// TaskCollectFile streams the content of the file at param path.
+	TaskCollectFile = "collectFile"
+
+	// TaskListDir returns the entries of the directory at param path as a JSON array of DirEntry.
+	TaskListDir = "listDir"
+
+	// TaskKillProcess sends param signal (TERM by default) to the process with param pid.
+	TaskKillProcess = "killProcess"
+)
+
+// TaskDispatch parameters.
+const (
+	ParamPath   = "path"
+	ParamPID    = "pid"
+	ParamSignal = "signal"
+)
+
+// TaskResult output streams.
+const (
+	StreamStdout  = "stdout"
+	StreamStderr  = "stderr"
+	StreamFile    = "file"
+	StreamEntries = "entries"
+)
+
+// DirEntry describes a file listed by a TaskListDir task.
+type DirEntry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	ModTime time.Time `json:"modTime"`
+	IsDir   bool      `json:"isDir"`
+}
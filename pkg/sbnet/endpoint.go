@@ -18,8 +18,10 @@ package sbnet
 
 import (
 	"encoding"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"strconv"
 	"strings"
 
@@ -103,6 +105,74 @@ func (e *Endpoint) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// PodIPEnv is the environment variable holding the address of the host or pod, e.g. set
+// from status.podIP through the Kubernetes downward API.
+const PodIPEnv = "POD_IP"
+
+// AdvertisedEndpoint returns the endpoint other hosts reach a server listening on listen
+// at: advertise when set (protocol://host:port, or host:port with the protocol of
+// listen), listen itself unless it has an unspecified host, and otherwise listen with the
+// host replaced by $POD_IP or, without it, the first global unicast address of this host.
+func AdvertisedEndpoint(listen Endpoint, advertise string) (Endpoint, error) {
+	if advertise = strings.TrimSpace(advertise); advertise != "" {
+		if !strings.Contains(advertise, "://") {
+			advertise = listen.Protocol + "://" + advertise
+		}
+		ep, err := NewEndpointFromString(advertise)
+		if err != nil {
+			return Endpoint{}, err
+		}
+		if isUnspecifiedHost(ep.Host) {
+			return Endpoint{}, fmt.Errorf("advertise address %s has an unspecified host", advertise)
+		}
+		return *ep, nil
+	}
+	if !isUnspecifiedHost(listen.Host) {
+		return listen, nil
+	}
+
+	host := strings.TrimSpace(os.Getenv(PodIPEnv))
+	if host == "" {
+		ip, err := hostIP()
+		if err != nil {
+			return Endpoint{}, fmt.Errorf("advertise address for %s: %w", listen.String(), err)
+		}
+		host = ip.String()
+	}
+	listen.Host = host
+	return listen, nil
+}
+
+func isUnspecifiedHost(host string) bool {
+	ip := net.ParseIP(host)
+	return host == "" || (ip != nil && ip.IsUnspecified())
+}
+
+// hostIP returns the first global unicast address of this host, preferring IPv4.
+func hostIP() (net.IP, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	var found net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+		if found == nil {
+			found = ipNet.IP
+		}
+	}
+	if found == nil {
+		return nil, errors.New("no global unicast address found")
+	}
+	return found, nil
+}
+
 // Ensure *Endpoint implements encoding.TextUnmarshaler at compile time.
 var _ encoding.TextUnmarshaler = (*Endpoint)(nil)
 
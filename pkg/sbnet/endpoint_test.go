@@ -277,3 +277,46 @@ func TestEndpoint_UnmarshalYAML(t *testing.T) {
 		})
 	}
 }
+
+func TestAdvertisedEndpoint(t *testing.T) {
+	t.Setenv(PodIPEnv, "10.0.0.4")
+
+	tests := []struct {
+		name      string
+		listen    Endpoint
+		advertise string
+		want      string
+		wantErr   bool
+	}{
+		{"specific host", Endpoint{"tcp", "192.168.1.2", 26689}, "", "tcp://192.168.1.2:26689", false},
+		{"wildcard ipv4", Endpoint{"tcp", "0.0.0.0", 26689}, "", "tcp://10.0.0.4:26689", false},
+		{"wildcard ipv6", Endpoint{"tcp", "::", 26688}, "", "tcp://10.0.0.4:26688", false},
+		{"advertise", Endpoint{"tcp", "0.0.0.0", 26689}, "tcp://controller.saber:26689", "tcp://controller.saber:26689", false},
+		{"advertise without protocol", Endpoint{"tcp", "0.0.0.0", 26689}, "10.1.1.1:36689", "tcp://10.1.1.1:36689", false},
+		{"advertise wildcard", Endpoint{"tcp", "0.0.0.0", 26689}, "0.0.0.0:26689", "", true},
+		{"advertise invalid", Endpoint{"tcp", "0.0.0.0", 26689}, "tcp://host", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AdvertisedEndpoint(tt.listen, tt.advertise)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AdvertisedEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Fatalf("AdvertisedEndpoint() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAdvertisedEndpoint_hostIP(t *testing.T) {
+	t.Setenv(PodIPEnv, "")
+
+	got, err := AdvertisedEndpoint(Endpoint{"tcp", "0.0.0.0", 26689}, "")
+	if err != nil {
+		t.Skipf("no global unicast address: %v", err)
+	}
+	if isUnspecifiedHost(got.Host) || got.Port != 26689 {
+		t.Fatalf("AdvertisedEndpoint() = %s, want a host address", got.String())
+	}
+}
@@ -0,0 +1,273 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbnet
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// TLSConfig configures TLS for a gRPC server or client.
+// On a server, CAFile is the bundle client certificates are verified against and
+// RequireClientCert turns on mutual TLS. On a client, CAFile is the bundle the server
+// certificate is verified against (system roots when empty), and CertFile/KeyFile is
+// the client certificate presented for mutual TLS.
+type TLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+	CAFile             string `yaml:"caFile"`
+	ServerName         string `yaml:"serverName"`
+	RequireClientCert  bool   `yaml:"requireClientCert"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"` // client only, for dev/test
+}
+
+// CertReloader holds the certificate and CA bundle of a TLSConfig and re-reads them from
+// disk on Reload, so that rotated certificates are used by new connections without a
+// restart. A nil *CertReloader stands for plaintext.
+type CertReloader struct {
+	cfg TLSConfig
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+// NewCertReloader loads the files of cfg. It returns (nil, nil) when TLS is disabled.
+func NewCertReloader(cfg TLSConfig) (*CertReloader, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, errors.New("tls: certFile and keyFile must be set together")
+	}
+	if cfg.RequireClientCert && cfg.CAFile == "" {
+		return nil, errors.New("tls: requireClientCert needs caFile")
+	}
+
+	r := &CertReloader{cfg: cfg}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads the certificate, key and CA bundle. On error the previous ones stay in use.
+func (r *CertReloader) Reload() error {
+	if r == nil {
+		return nil
+	}
+
+	var cert *tls.Certificate
+	if r.cfg.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("tls: load key pair: %w", err)
+		}
+		if c.Leaf == nil && len(c.Certificate) > 0 {
+			c.Leaf, _ = x509.ParseCertificate(c.Certificate[0])
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.CAFile != "" {
+		pem, err := os.ReadFile(r.cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("tls: read ca file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificate found in %s", r.cfg.CAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = cert
+	r.pool = pool
+	r.mu.Unlock()
+	return nil
+}
+
+// Identity returns the identity of the loaded certificate (see CertIdentities), or ""
+// when no certificate is configured.
+func (r *CertReloader) Identity() string {
+	if r == nil {
+		return ""
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cert == nil || r.cert.Leaf == nil {
+		return ""
+	}
+	if ids := CertIdentities(r.cert.Leaf); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+func (r *CertReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.pool
+}
+
+// ServerConfig returns a tls.Config for a gRPC server that picks up reloaded files on every handshake.
+func (r *CertReloader) ServerConfig() *tls.Config {
+	return r.serverConfig("h2")
+}
+
+// HTTPServerConfig returns a tls.Config for an HTTP/1.1 server, like ServerConfig.
+func (r *CertReloader) HTTPServerConfig() *tls.Config {
+	return r.serverConfig("http/1.1")
+}
+
+func (r *CertReloader) serverConfig(proto string) *tls.Config {
+	clientAuth := tls.NoClientCert
+	switch {
+	case r.cfg.RequireClientCert:
+		clientAuth = tls.RequireAndVerifyClientCert
+	case r.cfg.CAFile != "":
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			if cert == nil {
+				return nil, errors.New("tls: no server certificate configured")
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    pool,
+				ClientAuth:   clientAuth,
+				NextProtos:   []string{proto},
+			}, nil
+		},
+	}
+}
+
+// ClientConfig returns a tls.Config for a client that picks up reloaded files on every
+// handshake. The server certificate is verified in VerifyConnection against the current
+// CA bundle, since RootCAs cannot change once the config is in use.
+func (r *CertReloader) ClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         r.cfg.ServerName,
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert, _ := r.current(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if r.cfg.InsecureSkipVerify {
+				return nil
+			}
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("tls: server presented no certificate")
+			}
+
+			_, pool := r.current()
+			opts := x509.VerifyOptions{
+				DNSName:       cs.ServerName,
+				Roots:         pool,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, c := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(c)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+}
+
+// ServerCredentials returns the transport credentials for a gRPC server: insecure for a nil r.
+func (r *CertReloader) ServerCredentials() credentials.TransportCredentials {
+	if r == nil {
+		return insecure.NewCredentials()
+	}
+	return credentials.NewTLS(r.ServerConfig())
+}
+
+// ClientCredentials returns the transport credentials for a gRPC client: insecure for a nil r.
+func (r *CertReloader) ClientCredentials() credentials.TransportCredentials {
+	if r == nil {
+		return insecure.NewCredentials()
+	}
+	return credentials.NewTLS(r.ClientConfig())
+}
+
+// CertIdentities returns the identities a certificate vouches for: its common name,
+// then its DNS and URI subject alternative names.
+func CertIdentities(cert *x509.Certificate) []string {
+	var ids []string
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	ids = append(ids, cert.DNSNames...)
+	for _, u := range cert.URIs {
+		ids = append(ids, u.String())
+	}
+	return ids
+}
+
+// PeerCertificate returns the verified client certificate of the gRPC peer in ctx, or
+// nil when the peer did not authenticate with one.
+func PeerCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return info.State.VerifiedChains[0][0]
+}
+
+// VerifyClientID checks that clientID is one of the identities of the peer's client
+// certificate. Peers without a verified certificate are not checked. The returned error
+// is a gRPC PermissionDenied status.
+func VerifyClientID(ctx context.Context, clientID string) error {
+	cert := PeerCertificate(ctx)
+	if cert == nil {
+		return nil
+	}
+	if ids := CertIdentities(cert); !slices.Contains(ids, clientID) {
+		return status.Errorf(codes.PermissionDenied,
+			"clientID %q does not match client certificate %v", clientID, ids)
+	}
+	return nil
+}
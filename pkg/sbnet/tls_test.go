@@ -0,0 +1,285 @@
+/**
+ * Copyright 2025 Saber authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+**/
+
+package sbnet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// testCA issues certificates for the TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "saber test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// writeCA writes the CA certificate to path.
+func (ca *testCA) writeCA(t *testing.T, path string) {
+	t.Helper()
+	writePEM(t, path, "CERTIFICATE", ca.cert.Raw)
+}
+
+// issue writes a leaf certificate for cn to certPath and keyPath.
+func (ca *testCA) issue(t *testing.T, cn string, server bool, certPath, keyPath string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, certPath, "CERTIFICATE", der)
+	writePEM(t, keyPath, "EC PRIVATE KEY", keyDER)
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startTLSServer serves health checks with certs. Each check passes its service field
+// as the clientID to VerifyClientID.
+func startTLSServer(t *testing.T, certs *CertReloader) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svr := grpc.NewServer(
+		grpc.Creds(certs.ServerCredentials()),
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (any, error) {
+			if err := VerifyClientID(ctx, req.(*healthpb.HealthCheckRequest).GetService()); err != nil {
+				return nil, err
+			}
+			return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+		}),
+	)
+	healthpb.RegisterHealthServer(svr, health.NewServer())
+	go func() { _ = svr.Serve(lis) }()
+	t.Cleanup(svr.Stop)
+	return lis.Addr().String()
+}
+
+func check(addr string, certs *CertReloader, clientID string) error {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(certs.ClientCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: clientID})
+	return err
+}
+
+func TestCertReloader_mutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	ca := newTestCA(t)
+	ca.writeCA(t, path("ca.pem"))
+	ca.issue(t, "databus", true, path("server.pem"), path("server-key.pem"))
+	ca.issue(t, "agent-1", false, path("agent.pem"), path("agent-key.pem"))
+
+	server, err := NewCertReloader(TLSConfig{
+		Enabled:           true,
+		CertFile:          path("server.pem"),
+		KeyFile:           path("server-key.pem"),
+		CAFile:            path("ca.pem"),
+		RequireClientCert: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := startTLSServer(t, server)
+
+	client, err := NewCertReloader(TLSConfig{
+		Enabled:  true,
+		CertFile: path("agent.pem"),
+		KeyFile:  path("agent-key.pem"),
+		CAFile:   path("ca.pem"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := client.Identity(); got != "agent-1" {
+		t.Fatalf("Identity() = %q, want agent-1", got)
+	}
+
+	if err := check(addr, client, "agent-1"); err != nil {
+		t.Fatalf("matching clientID: %v", err)
+	}
+	if err := check(addr, client, "agent-2"); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("forged clientID: got %v, want PermissionDenied", err)
+	}
+
+	anonymous, err := NewCertReloader(TLSConfig{Enabled: true, CAFile: path("ca.pem")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := check(addr, anonymous, "agent-1"); err == nil {
+		t.Fatal("client without certificate was accepted")
+	}
+	if err := check(addr, nil, "agent-1"); err == nil {
+		t.Fatal("plaintext client was accepted")
+	}
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	oldCA, newCA := newTestCA(t), newTestCA(t)
+	oldCA.writeCA(t, path("ca.pem"))
+	oldCA.issue(t, "databus", true, path("server.pem"), path("server-key.pem"))
+
+	server, err := NewCertReloader(TLSConfig{
+		Enabled:  true,
+		CertFile: path("server.pem"),
+		KeyFile:  path("server-key.pem"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := startTLSServer(t, server)
+
+	client, err := NewCertReloader(TLSConfig{Enabled: true, CAFile: path("ca.pem")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := check(addr, client, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotate the server to a certificate of another CA: the client rejects it until it
+	// reloads the new CA bundle.
+	newCA.issue(t, "databus", true, path("server.pem"), path("server-key.pem"))
+	if err := server.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(addr, client, ""); err == nil {
+		t.Fatal("certificate of an untrusted CA was accepted")
+	}
+
+	newCA.writeCA(t, path("ca.pem"))
+	if err := client.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(addr, client, ""); err != nil {
+		t.Fatalf("after reload: %v", err)
+	}
+
+	// A broken file keeps the loaded certificate in use.
+	if err := os.WriteFile(path("ca.pem"), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Reload(); err == nil {
+		t.Fatal("Reload succeeded with a broken CA file")
+	}
+	if err := check(addr, client, ""); err != nil {
+		t.Fatalf("after failed reload: %v", err)
+	}
+}
+
+func TestNewCertReloader_disabled(t *testing.T) {
+	r, err := NewCertReloader(TLSConfig{CertFile: "missing.pem", KeyFile: "missing-key.pem"})
+	if err != nil || r != nil {
+		t.Fatalf("NewCertReloader(disabled) = %v, %v; want nil, nil", r, err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Identity() != "" {
+		t.Fatal("nil reloader has an identity")
+	}
+
+	if _, err := NewCertReloader(TLSConfig{Enabled: true, CertFile: "a.pem"}); err == nil {
+		t.Fatal("certFile without keyFile accepted")
+	}
+	if _, err := NewCertReloader(TLSConfig{Enabled: true, RequireClientCert: true}); err == nil {
+		t.Fatal("requireClientCert without caFile accepted")
+	}
+}